@@ -0,0 +1,49 @@
+package database
+
+import (
+	"api/utils"
+	"context"
+	"log"
+	"os"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// indexes são os índices que a API precisa para garantir unicidade no banco.
+var indexes = map[string][]mongo.IndexModel{
+	// Uma resposta por chat e envio; protege a contagem contra reentregas simultâneas do webhook
+	COLLECTION_SPACE_DESK_INTERACTIVE_RES: {
+		{Keys: bson.D{{Key: "send_id", Value: 1}, {Key: "chat_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	// SKU e id do Tiny identificam o produto; a checagem antes de gravar não cobre cadastros simultâneos
+	COLLECTION_PRODUCTS: {
+		{Keys: bson.D{{Key: "sku", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{
+			Keys:    bson.D{{Key: "tiny_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.D{{Key: "tiny_id", Value: bson.D{{Key: "$gt", Value: ""}}}}),
+		},
+	},
+}
+
+// EnsureIndexes cria os índices que ainda não existem. Falhas são apenas registradas no log para não impedir a subida
+// da API (ex.: dados duplicados antigos que precisam ser limpos antes).
+func EnsureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		log.Printf("[EnsureIndexes] Erro ao conectar no MongoDB: %v", err)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(GetDB())
+	for collection, models := range indexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			log.Printf("[EnsureIndexes] Erro ao criar os índices de %s: %v", collection, err)
+		}
+	}
+}
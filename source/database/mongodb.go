@@ -10,6 +10,11 @@ const (
 	MONGO_TIMEOUT                         = 20 * time.Second
 	COLLECTION_LEADS                      = "leads"
 	COLLECTION_LEADS_TIERS                = "leads_tiers"
+	COLLECTION_LEADS_MERGES               = "leads_merges"
+	COLLECTION_LEADS_HISTORY              = "leads_history"
+	COLLECTION_LEADS_SCORING              = "leads_scoring"
+	COLLECTION_LEADS_TIERS_HISTORY        = "leads_tiers_history"
+	COLLECTION_LEADS_IMPORTS              = "leads_imports"
 	COLLECTION_FUNNELS                    = "funnels"
 	COLLECTION_BUDGETS                    = "budgets"
 	COLLECTION_ORDERS                     = "orders"
@@ -23,6 +28,16 @@ const (
 	COLLECTION_SPACE_DESK_GROUPS          = "groups"
 	COLLECTION_SPACE_DESK_READY_MESSAGE   = "ready_chat_messages"
 	COLLECTION_COMMERCIAL_GOALS           = "commercial_goals"
+	COLLECTION_SPACE_DESK_INTERACTIVE     = "space_desk_interactive_sends"
+	COLLECTION_SPACE_DESK_INTERACTIVE_RES = "space_desk_interactive_responses"
+	COLLECTION_SPACE_DESK_SURVEYS         = "space_desk_surveys"
+	COLLECTION_PRODUCTS                   = "products"
+	COLLECTION_TINY_SYNC_LOGS             = "tiny_sync_logs"
+	COLLECTION_EXPORTS                    = "exports"
+
+	GRIDFS_BUCKET_BUDGETS_PDF   = "budgets_pdf"
+	GRIDFS_BUCKET_LEADS_IMPORTS = "leads_imports"
+	GRIDFS_BUCKET_EXPORTS       = "exports"
 )
 
 func GetDB() string {
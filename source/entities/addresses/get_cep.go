@@ -0,0 +1,34 @@
+package addresses
+
+import (
+	"api/integrations/cep"
+	"api/utils"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const CEP_LOOKUP_TIMEOUT = 10 * time.Second
+
+// GetCEP devolve logradouro, bairro, cidade e UF do CEP, para o preenchimento de endereços nas telas.
+func GetCEP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), CEP_LOOKUP_TIMEOUT)
+	defer cancel()
+
+	address, err := cep.Lookup(ctx, r.PathValue("cep"))
+	if errors.Is(err, cep.ErrInvalid) {
+		utils.SendResponse(w, http.StatusBadRequest, "CEP inválido", nil, utils.INVALID_CEP)
+		return
+	}
+	if errors.Is(err, cep.ErrNotFound) {
+		utils.SendResponse(w, http.StatusNotFound, "CEP não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "Não foi possível consultar o CEP", nil, 0)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", address, 0)
+}
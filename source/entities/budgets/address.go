@@ -0,0 +1,77 @@
+package budgets
+
+import (
+	"api/integrations/cep"
+	"api/schemas"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// normalizeAddress confere o CEP do endereço de entrega e completa logradouro, bairro, cidade e UF pela
+// consulta do CEP. CEP inválido ou inexistente é recusado; se a consulta falhar, o endereço segue só com o
+// CEP formatado para não travar o orçamento por indisponibilidade do provedor.
+func normalizeAddress(ctx context.Context, address *schemas.Address) error {
+	if strings.TrimSpace(address.CEP) == "" {
+		return nil
+	}
+
+	found, err := cep.Lookup(ctx, address.CEP)
+	if errors.Is(err, cep.ErrInvalid) {
+		return fmt.Errorf("CEP '%s' inválido", address.CEP)
+	}
+	if errors.Is(err, cep.ErrNotFound) {
+		return fmt.Errorf("CEP '%s' não encontrado", address.CEP)
+	}
+	if err != nil {
+		log.Printf("[CEP] Erro ao consultar o CEP %s: %v", address.CEP, err)
+		address.CEP = cep.Format(address.CEP)
+		return nil
+	}
+
+	address.CEP = found.CEP
+	address.Street = found.Street
+	address.Neighborhood = found.Neighborhood
+	address.City = found.City
+	address.State = found.State
+	if strings.TrimSpace(address.Details) == "" {
+		address.Details = formatAddress(*found)
+	}
+	return nil
+}
+
+// formatAddress monta o endereço do CEP em uma linha, no formato "logradouro - bairro - cidade/UF".
+func formatAddress(address cep.Address) string {
+	parts := []string{}
+	for _, part := range []string{address.Street, address.Neighborhood, address.City} {
+		if strings.TrimSpace(part) != "" {
+			parts = append(parts, part)
+		}
+	}
+	line := strings.Join(parts, " - ")
+	if address.State != "" {
+		line += "/" + address.State
+	}
+	return line
+}
+
+// validateQuoteCEPs recusa a cotação com CEP de origem ou destino inválido, ou com destino inexistente,
+// antes de chamar os provedores de frete.
+func validateQuoteCEPs(ctx context.Context, sellerCEP, recipientCEP string) error {
+	if _, err := cep.Normalize(sellerCEP); err != nil {
+		return errors.New("CEP de origem inválido")
+	}
+	_, err := cep.Lookup(ctx, recipientCEP)
+	if errors.Is(err, cep.ErrInvalid) {
+		return errors.New("CEP de destino inválido")
+	}
+	if errors.Is(err, cep.ErrNotFound) {
+		return errors.New("CEP de destino não encontrado")
+	}
+	if err != nil {
+		log.Printf("[CEP] Erro ao consultar o CEP %s: %v", recipientCEP, err)
+	}
+	return nil
+}
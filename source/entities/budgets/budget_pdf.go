@@ -0,0 +1,268 @@
+package budgets
+
+import (
+	"api/database"
+	spacedesk "api/entities/space_desk"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type SendBudgetPDFRequest struct {
+	ChatID  string `json:"chat_id"`
+	Caption string `json:"caption,omitempty"`
+}
+
+func budgetsPDFBucket(db *mongo.Database) *mongo.GridFSBucket {
+	return db.GridFSBucket(options.GridFSBucket().SetName(database.GRIDFS_BUCKET_BUDGETS_PDF))
+}
+
+// loadBudgetPDFData busca lead, cliente e vendedor do orçamento; os que não existirem ficam vazios no PDF.
+func loadBudgetPDFData(ctx context.Context, db *mongo.Database, budget schemas.Budget) budgetPDFData {
+	data := budgetPDFData{Budget: budget}
+	if !budget.RelatedLead.IsZero() {
+		_ = db.Collection(database.COLLECTION_LEADS).FindOne(ctx, bson.D{{Key: "_id", Value: budget.RelatedLead}}).Decode(&data.Lead)
+	}
+	clientID := budget.RelatedClient
+	if clientID.IsZero() {
+		clientID = data.Lead.RelatedClient
+	}
+	if !clientID.IsZero() {
+		_ = db.Collection(database.COLLECTION_CLIENTS).FindOne(ctx, bson.D{{Key: "_id", Value: clientID}}).Decode(&data.Client)
+	}
+	if !budget.Seller.IsZero() {
+		_ = db.Collection(database.COLLECTION_USERS).FindOne(ctx, bson.D{{Key: "_id", Value: budget.Seller}}).Decode(&data.Seller)
+	}
+	return data
+}
+
+// generateBudgetPDF renderiza o PDF, guarda no GridFS e substitui o PDF anterior do orçamento.
+func generateBudgetPDF(ctx context.Context, db *mongo.Database, budget schemas.Budget, generatedBy bson.ObjectID) (*schemas.BudgetPDF, []byte, error) {
+	content, err := renderBudgetPDF(loadBudgetPDFData(ctx, db, budget))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fileName := fmt.Sprintf("orcamento-%s.pdf", budgetNumber(budget))
+	bucket := budgetsPDFBucket(db)
+	fileID, err := bucket.UploadFromStream(ctx, fileName, bytes.NewReader(content),
+		options.GridFSUpload().SetMetadata(bson.D{{Key: "budget_id", Value: budget.ID}, {Key: "content_type", Value: "application/pdf"}}),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pdf := &schemas.BudgetPDF{
+		FileID:      fileID,
+		FileName:    fileName,
+		Size:        len(content),
+		GeneratedBy: generatedBy,
+		GeneratedAt: time.Now(),
+	}
+	_, err = db.Collection(database.COLLECTION_BUDGETS).UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: budget.ID}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "pdf", Value: pdf}}}},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if budget.PDF != nil && !budget.PDF.FileID.IsZero() {
+		if err := bucket.Delete(ctx, budget.PDF.FileID); err != nil {
+			log.Printf("[generateBudgetPDF] Erro ao remover PDF anterior %s: %v", budget.PDF.FileID.Hex(), err)
+		}
+	}
+
+	return pdf, content, nil
+}
+
+// currentBudgetPDF devolve o PDF guardado, gerando de novo quando não existe ou o orçamento mudou depois dele.
+func currentBudgetPDF(ctx context.Context, db *mongo.Database, budget schemas.Budget, userID bson.ObjectID) (*schemas.BudgetPDF, []byte, error) {
+	if budget.PDF != nil && !budget.PDF.FileID.IsZero() && !budget.UpdatedAt.After(budget.PDF.GeneratedAt) {
+		buffer := &bytes.Buffer{}
+		if _, err := budgetsPDFBucket(db).DownloadToStream(ctx, budget.PDF.FileID, buffer); err == nil {
+			return budget.PDF, buffer.Bytes(), nil
+		}
+	}
+	return generateBudgetPDF(ctx, db, budget, userID)
+}
+
+func findBudgetForPDF(ctx context.Context, db *mongo.Database, w http.ResponseWriter, r *http.Request) (schemas.Budget, bool) {
+	budget := schemas.Budget{}
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_BUDGET_ID_FORMAT)
+		return budget, false
+	}
+	err = db.Collection(database.COLLECTION_BUDGETS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&budget)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Orçamento não encontrado", nil, 0)
+		return budget, false
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_BUDGET_BY_ID_IN_MONGODB)
+		return budget, false
+	}
+	return budget, true
+}
+
+// CreateBudgetPDF gera (ou regera) o PDF do orçamento e retorna os dados do arquivo guardado.
+func CreateBudgetPDF(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	budget, ok := findBudgetForPDF(ctx, db, w, r)
+	if !ok {
+		return
+	}
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+
+	pdf, _, err := generateBudgetPDF(ctx, db, budget, user.ID)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "Erro ao gerar o PDF do orçamento", nil, utils.CANNOT_UPDATE_BUDGET_IN_MONGODB)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusCreated, "", pdf, 0)
+}
+
+// DownloadBudgetPDF retorna o arquivo PDF do orçamento.
+func DownloadBudgetPDF(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	budget, ok := findBudgetForPDF(ctx, db, w, r)
+	if !ok {
+		return
+	}
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+
+	pdf, content, err := currentBudgetPDF(ctx, db, budget, user.ID)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "Erro ao gerar o PDF do orçamento", nil, utils.CANNOT_UPDATE_BUDGET_IN_MONGODB)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, pdf.FileName))
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
+
+// SendBudgetPDF envia o PDF do orçamento como documento para o chat do WhatsApp informado.
+func SendBudgetPDF(w http.ResponseWriter, r *http.Request) {
+	req := SendBudgetPDFRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+	chatID, err := bson.ObjectIDFromHex(req.ChatID)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_CHAT_ID_FORMAT)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	budget, ok := findBudgetForPDF(ctx, db, w, r)
+	if !ok {
+		return
+	}
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+
+	pdf, content, err := currentBudgetPDF(ctx, db, budget, user.ID)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "Erro ao gerar o PDF do orçamento", nil, utils.CANNOT_UPDATE_BUDGET_IN_MONGODB)
+		return
+	}
+
+	caption := req.Caption
+	if caption == "" {
+		caption = "Segue o orçamento nº " + budgetNumber(budget)
+	}
+	userID := ""
+	if laravelUser, ok := r.Context().Value(middlewares.UserContextKey).(middlewares.LaravelUser); ok {
+		userID = strconv.Itoa(laravelUser.ID)
+	}
+
+	resp, err := spacedesk.SendDocumentToChat(ctx, mongoClient, spacedesk.OutgoingDocument{
+		ChatID:      chatID,
+		UserID:      userID,
+		FileName:    pdf.FileName,
+		ContentType: "application/pdf",
+		Caption:     caption,
+		Data:        content,
+		Extra:       bson.M{"budget_id": budget.ID},
+	})
+	switch {
+	case errors.Is(err, spacedesk.ErrChatNotFound):
+		utils.SendResponse(w, http.StatusNotFound, "Chat não encontrado", nil, 0)
+		return
+	case errors.Is(err, spacedesk.ErrApiKeyNotConfigured):
+		utils.SendResponse(w, http.StatusInternalServerError, "API key não configurada", nil, 0)
+		return
+	case errors.Is(err, spacedesk.ErrCannotReachSpaceDesk), errors.Is(err, spacedesk.ErrWamidNotReturned):
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.ERROR_TO_SEND_MESSAGE)
+		return
+	case err != nil:
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.ERROR_TO_INSERT_IN_MONGODB)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", map[string]any{"pdf": pdf, "message": resp}, 0)
+}
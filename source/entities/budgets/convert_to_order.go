@@ -0,0 +1,326 @@
+package budgets
+
+import (
+	"api/database"
+	"api/entities/funnels"
+	"api/entities/leads"
+	"api/entities/orders"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var errBudgetAlreadyConverted = errors.New("orçamento já convertido em pedido")
+
+var orderTypes = []schemas.OrderType{
+	schemas.TypePrazoNormal,
+	schemas.TypeAntecipacao,
+	schemas.TypeFaturado,
+	schemas.TypeMetadeMetade,
+	schemas.TypeAmostra,
+	schemas.TypeReposicao,
+}
+
+type ConvertToOrderRequest struct {
+	Type         schemas.OrderType `json:"type,omitempty"`
+	ExpectedDate time.Time         `json:"expected_date,omitzero"`
+	Notes        string            `json:"notes,omitempty"`
+}
+
+type ConvertToOrderResponse struct {
+	Order schemas.Order               `json:"order"`
+	Tier  *schemas.LeadTierAssignment `json:"tier"`
+}
+
+// orderTypeForBudget deduz o tipo do pedido pelas condições do orçamento: antecipação, duas parcelas
+// iguais (metade/metade), faturamento ou prazo normal. Pedidos sem valor são amostras.
+func orderTypeForBudget(budget schemas.Budget) schemas.OrderType {
+	if !budget.EarlyMode.Date.IsZero() || budget.EarlyMode.Tax > 0 {
+		return schemas.TypeAntecipacao
+	}
+	if installments := budget.Billing.Installments; len(installments) == 2 && installments[0].Value > 0 &&
+		math.Abs(installments[0].Value-installments[1].Value) < 0.01 {
+		return schemas.TypeMetadeMetade
+	}
+	if budget.Billing.Type != "" {
+		return schemas.TypeFaturado
+	}
+	if budget.Totals != nil && budget.Totals.Total == 0 {
+		return schemas.TypeAmostra
+	}
+	return schemas.TypePrazoNormal
+}
+
+// addBusinessDays soma dias úteis (segunda a sexta) à data informada.
+func addBusinessDays(from time.Time, days uint) time.Time {
+	date := from
+	for added := uint(0); added < days; {
+		date = date.AddDate(0, 0, 1)
+		if date.Weekday() != time.Saturday && date.Weekday() != time.Sunday {
+			added++
+		}
+	}
+	return date
+}
+
+// expectedDateForBudget usa a data antecipada quando existe, senão o prazo de produção em dias úteis a partir de hoje.
+// Sem prazo de produção, cai na previsão de entrega do orçamento.
+func expectedDateForBudget(budget schemas.Budget, now time.Time) time.Time {
+	if !budget.EarlyMode.Date.IsZero() {
+		return budget.EarlyMode.Date
+	}
+	if budget.ProductionDeadline > 0 {
+		return addBusinessDays(now, budget.ProductionDeadline)
+	}
+	return budget.DeliveryForecast
+}
+
+// ConvertToOrder cria o pedido de um orçamento aprovado. Numa única transação grava o pedido,
+// liga o pedido ao orçamento e ao lead e atualiza o cliente; depois recalcula o tier do lead.
+func ConvertToOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_BUDGET_ID_FORMAT)
+		return
+	}
+
+	req := ConvertToOrderRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+	if req.Type != "" && !slices.Contains(orderTypes, req.Type) {
+		utils.SendResponse(w, http.StatusBadRequest, "Tipo de pedido inválido", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+
+	budget := schemas.Budget{}
+	err = db.Collection(database.COLLECTION_BUDGETS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&budget)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Orçamento não encontrado", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_BUDGET_BY_ID_IN_MONGODB)
+		return
+	}
+	if !budget.Approved {
+		utils.SendResponse(w, http.StatusConflict, "Apenas orçamentos aprovados podem virar pedido", nil, 0)
+		return
+	}
+	if !budget.RelatedOrder.IsZero() {
+		utils.SendResponse(w, http.StatusConflict, "Orçamento já convertido em pedido", nil, 0)
+		return
+	}
+	existing, err := db.Collection(database.COLLECTION_ORDERS).CountDocuments(ctx, bson.D{{Key: "related_budget", Value: id}})
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDERS_IN_MONGODB)
+		return
+	}
+	if existing > 0 {
+		utils.SendResponse(w, http.StatusConflict, "Orçamento já convertido em pedido", nil, 0)
+		return
+	}
+
+	if err := applyLineItems(&budget); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	now := time.Now()
+	order := schemas.Order{
+		ID:                 bson.NewObjectID(),
+		CreatedBy:          user.ID,
+		RelatedSeller:      budget.Seller,
+		Status:             schemas.StatusPendente,
+		Stage:              schemas.StageDesign,
+		Type:               req.Type,
+		ProductsListLegacy: budget.OldProductsList,
+		Items:              budget.Items,
+		RelatedBudget:      budget.ID,
+		ExpectedDate:       req.ExpectedDate,
+		Notes:              req.Notes,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+		StageEnteredAt:     now,
+		StatusChangedAt:    now,
+	}
+	if order.Type == "" {
+		order.Type = orderTypeForBudget(budget)
+	}
+	if order.ExpectedDate.IsZero() {
+		order.ExpectedDate = expectedDateForBudget(budget, now)
+	}
+	if order.Notes == "" {
+		order.Notes = budget.Notes
+	}
+
+	session, err := mongoClient.StartSession()
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, convertBudgetToOrder(ctx, db, budget, order, now)
+	})
+	if errors.Is(err, errBudgetAlreadyConverted) {
+		utils.SendResponse(w, http.StatusConflict, "Orçamento já convertido em pedido", nil, 0)
+		return
+	}
+	if err != nil {
+		log.Printf("[ConvertToOrder] Erro ao converter o orçamento %s: %v", id.Hex(), err)
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_INSERT_ORDER_TO_MONGODB)
+		return
+	}
+
+	tier, err := leads.AssignLeadTier(ctx, db, budget.RelatedLead, leads.TIER_REASON_ORDERS)
+	if err != nil {
+		log.Printf("[ConvertToOrder] Erro ao recalcular o tier do lead %s: %v", budget.RelatedLead.Hex(), err)
+	}
+
+	leads.QueueScoreRecompute(budget.RelatedLead)
+	funnels.NotifyBoards(ctx, db, "budget_converted", order.ID.Hex(), budget.RelatedLead, budget.ID)
+	orders.NotifyBoard("order_created", order, "", "")
+
+	utils.SendResponse(w, http.StatusCreated, "", ConvertToOrderResponse{Order: order, Tier: tier}, 0)
+}
+
+// convertBudgetToOrder executa as escritas da conversão; deve rodar dentro da transação.
+func convertBudgetToOrder(ctx context.Context, db *mongo.Database, budget schemas.Budget, order schemas.Order, now time.Time) error {
+	// O filtro por related_order evita converter duas vezes em requisições concorrentes
+	result, err := db.Collection(database.COLLECTION_BUDGETS).UpdateOne(ctx,
+		bson.D{
+			{Key: "_id", Value: budget.ID},
+			{Key: "approved", Value: true},
+			{Key: "related_order", Value: bson.D{{Key: "$exists", Value: false}}},
+		},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "related_order", Value: order.ID},
+			{Key: "items", Value: budget.Items},
+			{Key: "totals", Value: budget.Totals},
+			{Key: "updated_at", Value: now},
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errBudgetAlreadyConverted
+	}
+
+	if _, err := db.Collection(database.COLLECTION_ORDERS).InsertOne(ctx, order); err != nil {
+		return err
+	}
+
+	clientID := budget.RelatedClient
+	if !budget.RelatedLead.IsZero() {
+		lead := schemas.Lead{}
+		err := db.Collection(database.COLLECTION_LEADS).FindOne(ctx, bson.D{{Key: "_id", Value: budget.RelatedLead}}).Decode(&lead)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return err
+		}
+		if err == nil {
+			set := bson.D{{Key: "updated_at", Value: now}}
+			if lead.RelatedClient.IsZero() && !clientID.IsZero() {
+				set = append(set, bson.E{Key: "related_client", Value: clientID})
+			}
+			if clientID.IsZero() {
+				clientID = lead.RelatedClient
+			}
+			_, err = db.Collection(database.COLLECTION_LEADS).UpdateOne(ctx,
+				bson.D{{Key: "_id", Value: lead.ID}},
+				bson.D{
+					{Key: "$addToSet", Value: bson.D{
+						{Key: "related_orders", Value: order.ID},
+						{Key: "related_budgets", Value: budget.ID},
+					}},
+					{Key: "$set", Value: set},
+				},
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if clientID.IsZero() {
+		return nil
+	}
+	return updateClientFromBudget(ctx, db, clientID, budget, now)
+}
+
+// updateClientFromBudget registra o orçamento no cliente e completa o endereço quando o cliente ainda não tem um.
+func updateClientFromBudget(ctx context.Context, db *mongo.Database, clientID bson.ObjectID, budget schemas.Budget, now time.Time) error {
+	collection := db.Collection(database.COLLECTION_CLIENTS)
+
+	client := schemas.Client{}
+	err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: clientID}}).Decode(&client)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	set := bson.D{{Key: "updated_at", Value: now}}
+	if client.Contact.ZipCode == "" && client.Contact.Address == "" && budget.Address.CEP != "" {
+		set = append(set,
+			bson.E{Key: "contact.zip_code", Value: budget.Address.CEP},
+			bson.E{Key: "contact.address", Value: budget.Address.Details},
+		)
+		// Com o CEP já consultado, o cliente recebe o endereço separado nos campos do contato
+		fields := bson.D{
+			{Key: "contact.neighborhood", Value: budget.Address.Neighborhood},
+			{Key: "contact.city", Value: budget.Address.City},
+			{Key: "contact.state", Value: budget.Address.State},
+		}
+		for _, field := range fields {
+			if field.Value != "" {
+				set = append(set, field)
+			}
+		}
+	}
+	update := bson.D{{Key: "$set", Value: set}}
+	if budget.OldID != 0 {
+		update = append(update, bson.E{Key: "$addToSet", Value: bson.D{{Key: "budget_ids", Value: int(budget.OldID)}}})
+	}
+
+	_, err = collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: clientID}}, update)
+	return err
+}
@@ -0,0 +1,113 @@
+package budgets
+
+import (
+	"api/database"
+	"api/entities/funnels"
+	"api/entities/leads"
+	"api/entities/products"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func CreateOne(w http.ResponseWriter, r *http.Request) {
+	budget := &schemas.Budget{}
+	if err := json.NewDecoder(r.Body).Decode(&budget); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	if err := validateBudget(budget); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+
+	count, err := db.Collection(database.COLLECTION_LEADS).CountDocuments(ctx, bson.D{{Key: "_id", Value: budget.RelatedLead}})
+	if err != nil || count == 0 {
+		utils.SendResponse(w, http.StatusBadRequest, "Lead não encontrado", nil, utils.CANNOT_FIND_LEAD_BY_ID_IN_MONGODB)
+		return
+	}
+
+	if err := products.PriceLineItems(ctx, db, budget.Items); err != nil {
+		if errors.Is(err, products.ErrProductNotFound) {
+			utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		} else {
+			utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_PRODUCTS_IN_MONGODB)
+		}
+		return
+	}
+	if err := applyLineItems(budget); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+	if err := normalizeAddress(ctx, &budget.Address); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.INVALID_CEP)
+		return
+	}
+
+	budget.ID = bson.ObjectID{}
+	budget.CreatedBy = user.ID
+	if budget.Seller.IsZero() {
+		budget.Seller = user.ID
+	}
+	budget.Approved = false
+	budget.ApprovalStatus = schemas.BUDGETS_APPROVAL_STATUS_PENDING
+	budget.ApprovedBy = bson.ObjectID{}
+	budget.ApprovedAt = time.Time{}
+	budget.RejectedBy = bson.ObjectID{}
+	budget.RejectedAt = time.Time{}
+	budget.RejectionReason = ""
+	budget.CreatedAt = time.Now()
+	budget.UpdatedAt = time.Now()
+
+	result, err := db.Collection(database.COLLECTION_BUDGETS).InsertOne(ctx, budget)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_INSERT_BUDGET_TO_MONGODB)
+		return
+	}
+	budget.ID = result.InsertedID.(bson.ObjectID)
+
+	_, err = db.Collection(database.COLLECTION_LEADS).UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: budget.RelatedLead}},
+		bson.D{{Key: "$addToSet", Value: bson.D{{Key: "related_budgets", Value: budget.ID}}}},
+	)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_LEAD_IN_MONGODB)
+		return
+	}
+
+	leads.QueueScoreRecompute(budget.RelatedLead)
+	funnels.NotifyBoards(ctx, db, "budget_created", budget.ID.Hex(), budget.RelatedLead, budget.ID)
+
+	utils.SendResponse(w, http.StatusCreated, "", budget, 0)
+}
@@ -1,16 +1,18 @@
 package budgets
 
 import (
+	"api/database"
+	"api/schemas"
 	"api/utils"
-	"bytes"
+	"context"
 	"encoding/json"
-	"io"
 	"net/http"
 	"os"
-)
+	"strconv"
 
-const SHIPPING_SERVICE_CODE = "03220"
-const RECIPIENT_COUNTRY = "BR"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
 
 type ShippingQuoteItem struct {
 	Height   float64 `json:"Height"`
@@ -20,31 +22,12 @@ type ShippingQuoteItem struct {
 	Width    float64 `json:"Width"`
 }
 
+// ShippingQuoteRequest é a cotação já normalizada, enviada à cadeia de provedores de frete.
 type ShippingQuoteRequest struct {
 	SellerCEP            string              `json:"SellerCEP"`
 	RecipientCEP         string              `json:"RecipientCEP"`
 	ShipmentInvoiceValue float64             `json:"ShipmentInvoiceValue"`
-	ShippingServiceCode  string              `json:"ShippingServiceCode"`
 	ShippingItemArray    []ShippingQuoteItem `json:"ShippingItemArray"`
-	RecipientCountry     string              `json:"RecipientCountry"`
-}
-
-type ShippingQuoteService struct {
-	Carrier               string `json:"Carrier"`
-	CarrierCode           string `json:"CarrierCode"`
-	DeliveryTime          string `json:"DeliveryTime"`
-	Msg                   string `json:"Msg"`
-	ServiceCode           string `json:"ServiceCode"`
-	ServiceDescription    string `json:"ServiceDescription"`
-	ShippingPrice         string `json:"ShippingPrice"`
-	OriginalDeliveryTime  string `json:"OriginalDeliveryTime"`
-	OriginalShippingPrice string `json:"OriginalShippingPrice"`
-	Error                 bool   `json:"Error"`
-}
-
-type ShippingQuoteResponse struct {
-	ShippingSevicesArray []ShippingQuoteService `json:"ShippingSevicesArray"`
-	Timeout              int                    `json:"Timeout"`
 }
 
 type ShippingQuoteInput struct {
@@ -55,6 +38,12 @@ type ShippingQuoteInput struct {
 	Length               float64 `json:"length"`
 	Weight               float64 `json:"weight"`
 	Width                float64 `json:"width"`
+	BudgetID             string  `json:"budget_id,omitempty"`
+	LeadID               string  `json:"lead_id,omitempty"`
+	// Quando informados, os volumes e o valor da mercadoria vêm do catálogo de produtos
+	Items []schemas.LineItem `json:"items,omitempty"`
+	// Volumes já medidos, um por entrada; substituem as medidas avulsas acima
+	Packages []ShippingQuoteItem `json:"packages,omitempty"`
 }
 
 type SedexData struct {
@@ -112,74 +101,34 @@ func CreateShippingQuote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if input.SellerCEP == "" || input.RecipientCEP == "" || input.ShipmentInvoiceValue == 0 || input.Height == 0 || input.Length == 0 || input.Weight == 0 || input.Width == 0 {
-		utils.SendResponse(w, http.StatusBadRequest, "Todos os campos são obrigatórios e devem ser preenchidos corretamente", nil, 0)
-		return
-	}
-
-	req := ShippingQuoteRequest{
-		SellerCEP:            input.SellerCEP,
-		RecipientCEP:         input.RecipientCEP,
-		ShipmentInvoiceValue: input.ShipmentInvoiceValue,
-		ShippingServiceCode:  shippingServiceCode,
-		ShippingItemArray: []ShippingQuoteItem{
-			{
-				Height:   input.Height,
-				Length:   input.Length,
-				Quantity: 1,
-				Weight:   input.Weight,
-				Width:    input.Width,
-			},
-		},
-		RecipientCountry: RECIPIENT_COUNTRY,
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), SHIPPING_QUOTE_TIMEOUT)
+	defer cancel()
 
-	frenetToken := os.Getenv("FRENET_API_KEY")
-	if frenetToken == "" {
-		utils.SendResponse(w, http.StatusInternalServerError, "Token Frenet não configurado", nil, 0)
-		return
-	}
-
-	jsonBody, err := json.Marshal(req)
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
 	if err != nil {
-		utils.SendResponse(w, http.StatusInternalServerError, "Erro ao serializar request", nil, 0)
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
 		return
 	}
+	defer mongoClient.Disconnect(ctx)
 
-	httpReq, err := http.NewRequest("POST", "https://api.frenet.com.br/shipping/quote", io.NopCloser(bytes.NewReader(jsonBody)))
+	req, err := prepareShippingQuote(ctx, mongoClient.Database(database.GetDB()), input)
 	if err != nil {
-		utils.SendResponse(w, http.StatusInternalServerError, "Erro ao criar request para Frenet", nil, 0)
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, 0)
 		return
 	}
-	httpReq.Header.Set("Accept", "application/json")
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("token", frenetToken)
 
-	client := &http.Client{}
-	resp, err := client.Do(httpReq)
+	quote, _, err := requestShippingQuote(ctx, req)
 	if err != nil {
-		utils.SendResponse(w, http.StatusBadGateway, "Erro ao conectar na Frenet", nil, 0)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		utils.SendResponse(w, http.StatusBadGateway, "Erro da Frenet", nil, 0)
-		return
-	}
-
-	frenetResp := ShippingQuoteResponse{}
-	if err := json.NewDecoder(resp.Body).Decode(&frenetResp); err != nil {
-		utils.SendResponse(w, http.StatusBadGateway, "Erro ao ler resposta da Frenet", nil, 0)
+		sendShippingError(w, err)
 		return
 	}
 
 	var sedexData *SedexData = nil
-	for _, svc := range frenetResp.ShippingSevicesArray {
-		if svc.ServiceCode == shippingServiceCode {
+	for _, svc := range quote.Services {
+		if svc.ServiceCode == shippingServiceCode && svc.Available() {
 			sedexData = &SedexData{
-				Price:              svc.ShippingPrice,
-				DeliveryTime:       svc.DeliveryTime,
+				Price:              strconv.FormatFloat(svc.Price, 'f', 2, 64),
+				DeliveryTime:       strconv.FormatUint(uint64(svc.DeliveryTime), 10),
 				Carrier:            svc.Carrier,
 				ServiceDescription: svc.ServiceDescription,
 			}
@@ -188,7 +137,7 @@ func CreateShippingQuote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if sedexData == nil {
-		utils.SendResponse(w, http.StatusNotFound, "Serviço não encontrado na resposta da Frenet", nil, 0)
+		utils.SendResponse(w, http.StatusNotFound, "Serviço não encontrado na cotação", nil, 0)
 		return
 	}
 
@@ -0,0 +1,49 @@
+package budgets
+
+import (
+	"api/database"
+	"api/entities/exports"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var exportColumns = []exports.Column{
+	{Key: "id", Header: "ID", Path: "_id"},
+	{Key: "old_id", Header: "ID antigo", Path: "old_id"},
+	{Key: "lead", Header: "Lead", Path: "lead_name"},
+	{Key: "lead_phone", Header: "Telefone do lead", Path: "lead_phone"},
+	{Key: "client", Header: "Cliente", Path: "client_name"},
+	{Key: "seller", Header: "Vendedor", Path: "seller_name"},
+	{Key: "created_by", Header: "Criado por", Path: "created_by_name"},
+	{Key: "subtotal", Header: "Subtotal", Path: "totals.subtotal"},
+	{Key: "discount", Header: "Desconto", Path: "totals.discount"},
+	{Key: "shipping", Header: "Frete", Path: "totals.shipping"},
+	{Key: "total", Header: "Total", Path: "totals.total"},
+	{Key: "payment_method", Header: "Forma de pagamento", Path: "payment_method"},
+	{Key: "delivery_option", Header: "Entrega", Path: "delivery.option"},
+	{Key: "approved", Header: "Aprovado", Path: "approved"},
+	{Key: "approval_status", Header: "Situação da aprovação", Path: "approval_status"},
+	{Key: "related_order", Header: "Pedido", Path: "related_order"},
+	{Key: "delivery_forecast", Header: "Previsão de entrega", Path: "delivery_forecast"},
+	{Key: "created_at", Header: "Criado em", Path: "created_at"},
+	{Key: "updated_at", Header: "Atualizado em", Path: "updated_at"},
+}
+
+// Export exporta todos os orçamentos em CSV ou XLSX; assim como GET /v1/budgets, não aceita filtros.
+func Export(w http.ResponseWriter, r *http.Request) {
+	stages := exports.LookupField(database.COLLECTION_LEADS, "related_lead", "name", "lead_name")
+	stages = append(stages, exports.LookupField(database.COLLECTION_LEADS, "related_lead", "phone", "lead_phone")...)
+	stages = append(stages, exports.LookupField(database.COLLECTION_CLIENTS, "related_client", "contact.name", "client_name")...)
+	stages = append(stages, exports.LookupField(database.COLLECTION_USERS, "seller", "name", "seller_name")...)
+	stages = append(stages, exports.LookupField(database.COLLECTION_USERS, "created_by", "name", "created_by_name")...)
+
+	exports.Export(w, r, exports.Source{
+		Entity:     "budgets",
+		Collection: database.COLLECTION_BUDGETS,
+		Filter:     bson.D{},
+		Sort:       bson.D{{Key: "created_at", Value: -1}},
+		Stages:     stages,
+		Columns:    exportColumns,
+	})
+}
@@ -0,0 +1,26 @@
+package budgets
+
+import (
+	"api/schemas"
+	"api/utils"
+	"errors"
+)
+
+// applyLineItems garante os itens tipados (convertendo a lista legada quando só ela foi enviada)
+// e recalcula os totais no servidor, ignorando qualquer total enviado pelo cliente.
+func applyLineItems(budget *schemas.Budget) error {
+	if len(budget.Items) == 0 && budget.OldProductsList != "" {
+		items, err := utils.LegacyLineItems(budget.OldProductsList)
+		if err != nil {
+			return errors.New("A lista de produtos legada é inválida")
+		}
+		budget.Items = items
+	}
+	if budget.Items == nil {
+		budget.Items = []schemas.LineItem{}
+	}
+
+	totals := utils.CalculateBudgetTotals(budget)
+	budget.Totals = &totals
+	return nil
+}
@@ -0,0 +1,291 @@
+package budgets
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	LINE_ITEMS_MIGRATION_DEFAULT_LIMIT = 500
+	LINE_ITEMS_MIGRATION_MAX_LIMIT     = 5000
+
+	// As gravações vão em BulkWrite de até tantos documentos, com um prazo próprio, maior que o das requisições
+	LINE_ITEMS_MIGRATION_BATCH_SIZE = 500
+	LINE_ITEMS_MIGRATION_TIMEOUT    = 10 * time.Minute
+
+	// Documentos cuja lista legada não pôde ser convertida recebem este campo e deixam de ser reprocessados
+	LINE_ITEMS_MIGRATION_ERROR_FIELD = "line_items_migration_error"
+)
+
+var lineItemsMigrationRoles = []string{schemas.USERS_ROLE_SUPER_ADMIN, schemas.USERS_ROLE_IT, schemas.USERS_ROLE_ADMIN}
+
+type LineItemsMigrationCounts struct {
+	Migrated  int64 `json:"migrated"`
+	Failed    int64 `json:"failed"`
+	Remaining int64 `json:"remaining"`
+}
+
+type LineItemsMigrationFailure struct {
+	Collection string        `json:"collection"`
+	ID         bson.ObjectID `json:"id"`
+	Error      string        `json:"error"`
+}
+
+type LineItemsMigrationResult struct {
+	DryRun   bool                        `json:"dry_run"`
+	Budgets  LineItemsMigrationCounts    `json:"budgets"`
+	Orders   LineItemsMigrationCounts    `json:"orders"`
+	Failures []LineItemsMigrationFailure `json:"failures"`
+}
+
+// MigrateLineItems converte em lote as listas legadas (old_products_list e products_list_legacy) em itens tipados.
+// Processa até `limit` documentos de cada coleção por chamada; repita até `remaining` chegar a zero.
+// Com `dry_run=true` apenas simula a conversão, sem gravar.
+func MigrateLineItems(w http.ResponseWriter, r *http.Request) {
+	limit := int64(LINE_ITEMS_MIGRATION_DEFAULT_LIMIT)
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.ParseInt(l, 10, 64)
+		if err != nil || parsed <= 0 {
+			utils.SendResponse(w, http.StatusBadRequest, "Parâmetro 'limit' inválido", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+			return
+		}
+		limit = min(parsed, LINE_ITEMS_MIGRATION_MAX_LIMIT)
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	ctx, cancel := context.WithTimeout(context.Background(), LINE_ITEMS_MIGRATION_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+	if !utils.HasAnyRole(user, lineItemsMigrationRoles) {
+		utils.SendResponse(w, http.StatusForbidden, "Usuário sem permissão para migrar os itens", nil, 0)
+		return
+	}
+
+	result := LineItemsMigrationResult{DryRun: dryRun, Failures: []LineItemsMigrationFailure{}}
+
+	if err := migrateBudgetsLineItems(ctx, db, limit, &result); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_BUDGET_IN_MONGODB)
+		return
+	}
+	if err := migrateOrdersLineItems(ctx, db, limit, &result); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDERS_IN_MONGODB)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", result, 0)
+}
+
+// pendingLineItemsFilter seleciona os documentos ainda sem o campo items. Orçamentos gravados pela API sempre
+// têm o campo (o bson de Budget.Items não tem omitempty), então só os legados entram na migração.
+func pendingLineItemsFilter(extra ...bson.E) bson.D {
+	filter := bson.D{
+		{Key: "items", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: LINE_ITEMS_MIGRATION_ERROR_FIELD, Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	return append(filter, extra...)
+}
+
+// migrateBudgetsLineItems converte os orçamentos e grava também os totais calculados no servidor.
+func migrateBudgetsLineItems(ctx context.Context, db *mongo.Database, limit int64, result *LineItemsMigrationResult) error {
+	collection := db.Collection(database.COLLECTION_BUDGETS)
+	filter := pendingLineItemsFilter()
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetLimit(limit))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	batch := newLineItemsBatch(collection, database.COLLECTION_BUDGETS, result, &result.Budgets)
+	processed := int64(0)
+	for cursor.Next(ctx) {
+		processed++
+		budget := schemas.Budget{}
+		if err := cursor.Decode(&budget); err != nil {
+			id, _ := cursor.Current.Lookup("_id").ObjectIDOK()
+			if err := batch.fail(ctx, id, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := applyLineItems(&budget); err != nil {
+			if err := batch.fail(ctx, budget.ID, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Mesmo sem itens o campo é gravado, para o orçamento não voltar na próxima execução
+		if budget.Items == nil {
+			budget.Items = []schemas.LineItem{}
+		}
+		result.Budgets.Migrated++
+		if err := batch.set(ctx, budget.ID, bson.D{
+			{Key: "items", Value: budget.Items},
+			{Key: "totals", Value: budget.Totals},
+		}); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if err := batch.flush(ctx); err != nil {
+		return err
+	}
+
+	remaining, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DryRun {
+		remaining -= processed
+	}
+	result.Budgets.Remaining = max(remaining, 0)
+	return nil
+}
+
+// migrateOrdersLineItems converte os pedidos com lista legada. Quantidade zero vira 1,
+// como os relatórios de pedidos já consideravam.
+func migrateOrdersLineItems(ctx context.Context, db *mongo.Database, limit int64, result *LineItemsMigrationResult) error {
+	collection := db.Collection(database.COLLECTION_ORDERS)
+	filter := pendingLineItemsFilter(bson.E{Key: "products_list_legacy", Value: bson.D{{Key: "$nin", Value: bson.A{nil, ""}}}})
+
+	cursor, err := collection.Find(ctx, filter,
+		options.Find().SetLimit(limit).SetProjection(bson.D{{Key: "products_list_legacy", Value: 1}}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	batch := newLineItemsBatch(collection, database.COLLECTION_ORDERS, result, &result.Orders)
+	processed := int64(0)
+	for cursor.Next(ctx) {
+		processed++
+		var order struct {
+			ID                 bson.ObjectID `bson:"_id"`
+			ProductsListLegacy string        `bson:"products_list_legacy"`
+		}
+		if err := cursor.Decode(&order); err != nil {
+			id, _ := cursor.Current.Lookup("_id").ObjectIDOK()
+			if err := batch.fail(ctx, id, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		items, err := utils.LegacyLineItems(order.ProductsListLegacy)
+		if err != nil {
+			if err := batch.fail(ctx, order.ID, "A lista de produtos legada é inválida"); err != nil {
+				return err
+			}
+			continue
+		}
+		for i := range items {
+			if items[i].Quantity == 0 {
+				items[i].Quantity = 1
+			}
+		}
+		utils.SumLineItems(items)
+
+		if items == nil {
+			items = []schemas.LineItem{}
+		}
+		result.Orders.Migrated++
+		if err := batch.set(ctx, order.ID, bson.D{{Key: "items", Value: items}}); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if err := batch.flush(ctx); err != nil {
+		return err
+	}
+
+	remaining, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DryRun {
+		remaining -= processed
+	}
+	result.Orders.Remaining = max(remaining, 0)
+	return nil
+}
+
+// lineItemsBatch junta as gravações da migração de uma coleção e as envia em BulkWrite, registrando o
+// progresso no log a cada lote. Em dry_run nada é gravado.
+type lineItemsBatch struct {
+	collection *mongo.Collection
+	name       string
+	result     *LineItemsMigrationResult
+	counts     *LineItemsMigrationCounts
+	models     []mongo.WriteModel
+}
+
+func newLineItemsBatch(collection *mongo.Collection, name string, result *LineItemsMigrationResult, counts *LineItemsMigrationCounts) *lineItemsBatch {
+	return &lineItemsBatch{collection: collection, name: name, result: result, counts: counts}
+}
+
+func (b *lineItemsBatch) set(ctx context.Context, id bson.ObjectID, set bson.D) error {
+	if b.result.DryRun || id.IsZero() {
+		return nil
+	}
+	b.models = append(b.models, mongo.NewUpdateOneModel().
+		SetFilter(bson.D{{Key: "_id", Value: id}}).
+		SetUpdate(bson.D{{Key: "$set", Value: set}}))
+	if len(b.models) >= LINE_ITEMS_MIGRATION_BATCH_SIZE {
+		return b.flush(ctx)
+	}
+	return nil
+}
+
+// fail conta a falha e marca o documento com o erro, para ele não ser reprocessado.
+func (b *lineItemsBatch) fail(ctx context.Context, id bson.ObjectID, message string) error {
+	b.counts.Failed++
+	b.result.Failures = append(b.result.Failures, LineItemsMigrationFailure{Collection: b.name, ID: id, Error: message})
+	return b.set(ctx, id, bson.D{{Key: LINE_ITEMS_MIGRATION_ERROR_FIELD, Value: message}})
+}
+
+func (b *lineItemsBatch) flush(ctx context.Context) error {
+	if len(b.models) == 0 {
+		return nil
+	}
+	if _, err := b.collection.BulkWrite(ctx, b.models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return err
+	}
+	b.models = b.models[:0]
+	log.Printf("[MigrateLineItems] %s: %d migrado(s) e %d com erro até agora", b.name, b.counts.Migrated, b.counts.Failed)
+	return nil
+}
@@ -0,0 +1,53 @@
+package budgets
+
+import (
+	"api/database"
+	"api/schemas"
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var ErrRecipientCEPNotFound = errors.New("CEP de destino não encontrado")
+
+// resolveRecipientCEP busca o CEP de destino quando a cotação é feita a partir de um orçamento ou lead:
+// endereço do orçamento, depois o endereço proposto e por fim a última localização enviada pelo lead.
+func resolveRecipientCEP(ctx context.Context, db *mongo.Database, budgetID, leadID string) (string, error) {
+	if budgetID != "" {
+		id, err := bson.ObjectIDFromHex(budgetID)
+		if err != nil {
+			return "", err
+		}
+		budget := schemas.Budget{}
+		if err := db.Collection(database.COLLECTION_BUDGETS).FindOne(ctx, bson.M{"_id": id}).Decode(&budget); err != nil {
+			return "", err
+		}
+		if budget.Address.CEP != "" {
+			return budget.Address.CEP, nil
+		}
+		if budget.ProposedAddress != nil && budget.ProposedAddress.CEP != "" {
+			return budget.ProposedAddress.CEP, nil
+		}
+		if leadID == "" && !budget.RelatedLead.IsZero() {
+			leadID = budget.RelatedLead.Hex()
+		}
+	}
+
+	if leadID != "" {
+		id, err := bson.ObjectIDFromHex(leadID)
+		if err != nil {
+			return "", err
+		}
+		lead := schemas.Lead{}
+		if err := db.Collection(database.COLLECTION_LEADS).FindOne(ctx, bson.M{"_id": id}).Decode(&lead); err != nil {
+			return "", err
+		}
+		if lead.LastLocation != nil && lead.LastLocation.CEP != "" {
+			return lead.LastLocation.CEP, nil
+		}
+	}
+
+	return "", ErrRecipientCEPNotFound
+}
@@ -0,0 +1,292 @@
+package budgets
+
+import (
+	"api/schemas"
+	"api/utils"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	pdfMarginX      = 40.0
+	pdfContentRight = utils.PDF_PAGE_WIDTH - pdfMarginX
+	pdfBottomLimit  = utils.PDF_PAGE_HEIGHT - 60
+)
+
+var (
+	pdfBrandColor = [3]uint8{18, 38, 74}
+	pdfAccent     = [3]uint8{242, 120, 24}
+	pdfTextColor  = [3]uint8{40, 40, 40}
+	pdfMutedColor = [3]uint8{110, 110, 110}
+	pdfLightFill  = [3]uint8{242, 244, 247}
+	pdfWhite      = [3]uint8{255, 255, 255}
+)
+
+// budgetPDFData reúne o que aparece no PDF além do próprio orçamento.
+type budgetPDFData struct {
+	Budget schemas.Budget
+	Lead   schemas.Lead
+	Client schemas.Client
+	Seller schemas.User
+}
+
+type budgetPDFWriter struct {
+	doc *utils.PDFDocument
+	y   float64
+}
+
+// budgetNumber usa o id legado quando existe, senão o final do ObjectID.
+func budgetNumber(budget schemas.Budget) string {
+	if budget.OldID != 0 {
+		return fmt.Sprint(budget.OldID)
+	}
+	hex := budget.ID.Hex()
+	return strings.ToUpper(hex[len(hex)-6:])
+}
+
+func formatBRL(value float64) string {
+	negative := value < 0
+	cents := int64(math.Round(math.Abs(value) * 100))
+	integer := fmt.Sprint(cents / 100)
+
+	groups := []string{}
+	for len(integer) > 3 {
+		groups = append([]string{integer[len(integer)-3:]}, groups...)
+		integer = integer[:len(integer)-3]
+	}
+	groups = append([]string{integer}, groups...)
+
+	formatted := fmt.Sprintf("R$ %s,%02d", strings.Join(groups, "."), cents%100)
+	if negative {
+		return "- " + formatted
+	}
+	return formatted
+}
+
+func formatPDFDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("02/01/2006")
+}
+
+func formatQuantity(quantity float64) string {
+	if quantity == math.Trunc(quantity) {
+		return fmt.Sprint(int64(quantity))
+	}
+	return strings.Replace(fmt.Sprintf("%.2f", quantity), ".", ",", 1)
+}
+
+// ensureSpace abre uma nova página quando o próximo bloco não cabe na atual.
+func (p *budgetPDFWriter) ensureSpace(height float64) bool {
+	if p.y+height <= pdfBottomLimit {
+		return false
+	}
+	p.doc.AddPage()
+	p.y = 50
+	return true
+}
+
+func (p *budgetPDFWriter) section(title string) {
+	p.ensureSpace(40)
+	p.y += 18
+	p.doc.Text(pdfMarginX, p.y, 11, true, pdfBrandColor, strings.ToUpper(title))
+	p.y += 5
+	p.doc.Line(pdfMarginX, p.y, pdfContentRight, p.y, 1, pdfAccent)
+	p.y += 14
+}
+
+func (p *budgetPDFWriter) field(label, value string) {
+	if value == "" {
+		return
+	}
+	lines := utils.PDFWrapText(value, 9.5, false, pdfContentRight-pdfMarginX-130)
+	p.ensureSpace(float64(len(lines)) * 13)
+	p.doc.Text(pdfMarginX, p.y, 9.5, true, pdfMutedColor, label)
+	for _, line := range lines {
+		p.doc.Text(pdfMarginX+130, p.y, 9.5, false, pdfTextColor, line)
+		p.y += 13
+	}
+}
+
+func (p *budgetPDFWriter) header(budget schemas.Budget) {
+	p.doc.Rect(0, 0, utils.PDF_PAGE_WIDTH, 90, pdfBrandColor)
+	p.doc.Rect(0, 90, utils.PDF_PAGE_WIDTH, 4, pdfAccent)
+	p.doc.Text(pdfMarginX, 48, 22, true, pdfWhite, "ARTE ARENA")
+	p.doc.Text(pdfMarginX, 68, 10, false, pdfWhite, "Uniformes e artigos personalizados")
+	p.doc.TextRight(pdfContentRight, 44, 16, true, pdfWhite, "Orçamento nº "+budgetNumber(budget))
+	p.doc.TextRight(pdfContentRight, 64, 10, false, pdfWhite, "Emitido em "+formatPDFDate(time.Now()))
+	p.y = 110
+}
+
+const (
+	colQuantity = 360.0
+	colUnit     = 455.0
+)
+
+func (p *budgetPDFWriter) itemsHeader() {
+	p.doc.Rect(pdfMarginX, p.y-12, pdfContentRight-pdfMarginX, 18, pdfBrandColor)
+	p.doc.Text(pdfMarginX+6, p.y, 9, true, pdfWhite, "Descrição")
+	p.doc.TextRight(colQuantity, p.y, 9, true, pdfWhite, "Qtd.")
+	p.doc.TextRight(colUnit, p.y, 9, true, pdfWhite, "Unitário")
+	p.doc.TextRight(pdfContentRight-6, p.y, 9, true, pdfWhite, "Total")
+	p.y += 20
+}
+
+func (p *budgetPDFWriter) items(items []schemas.LineItem) {
+	p.section("Itens")
+	p.itemsHeader()
+
+	for i, item := range items {
+		description := item.Description
+		if item.SKU != "" {
+			description = fmt.Sprintf("%s (%s)", description, item.SKU)
+		}
+		width := colQuantity - pdfMarginX - 60
+		lines := utils.PDFWrapText(description, 9, false, width)
+		detailLines := []string{}
+		if c := item.Customization; c != nil {
+			details := []string{}
+			for _, d := range [][2]string{{"Tamanho", c.Size}, {"Cor", c.Color}, {"Nome", c.Name}, {"Número", c.Number}, {"Obs.", c.Notes}} {
+				if d[1] != "" {
+					details = append(details, d[0]+": "+d[1])
+				}
+			}
+			if len(details) > 0 {
+				detailLines = utils.PDFWrapText(strings.Join(details, " | "), 8, false, width)
+			}
+		}
+
+		height := float64(len(lines)+len(detailLines))*12 + 6
+		if p.ensureSpace(height) {
+			p.itemsHeader()
+		}
+		if i%2 == 1 {
+			p.doc.Rect(pdfMarginX, p.y-11, pdfContentRight-pdfMarginX, height, pdfLightFill)
+		}
+
+		p.doc.TextRight(colQuantity, p.y, 9, false, pdfTextColor, formatQuantity(item.Quantity))
+		p.doc.TextRight(colUnit, p.y, 9, false, pdfTextColor, formatBRL(utils.LineItemPrice(item)))
+		p.doc.TextRight(pdfContentRight-6, p.y, 9, true, pdfTextColor, formatBRL(utils.LineItemPrice(item)*item.Quantity))
+		lineY := p.y
+		for _, line := range lines {
+			p.doc.Text(pdfMarginX+6, lineY, 9, false, pdfTextColor, line)
+			lineY += 12
+		}
+		for _, line := range detailLines {
+			p.doc.Text(pdfMarginX+6, lineY, 8, false, pdfMutedColor, line)
+			lineY += 12
+		}
+		p.y += height
+	}
+}
+
+func (p *budgetPDFWriter) totals(totals schemas.BudgetTotals) {
+	rows := [][2]string{{"Subtotal", formatBRL(totals.Subtotal)}}
+	if totals.Discount > 0 {
+		rows = append(rows, [2]string{"Desconto", formatBRL(-totals.Discount)})
+	}
+	if totals.EarlyTax > 0 {
+		rows = append(rows, [2]string{"Taxa de antecipação", formatBRL(totals.EarlyTax)})
+	}
+	rows = append(rows, [2]string{"Entrega", formatBRL(totals.Shipping)})
+
+	p.ensureSpace(float64(len(rows))*14 + 34)
+	p.y += 8
+	for _, row := range rows {
+		p.doc.TextRight(colUnit, p.y, 9.5, false, pdfMutedColor, row[0])
+		p.doc.TextRight(pdfContentRight-6, p.y, 9.5, false, pdfTextColor, row[1])
+		p.y += 14
+	}
+	p.doc.Rect(colUnit-120, p.y-10, pdfContentRight-colUnit+120, 22, pdfAccent)
+	p.doc.TextRight(colUnit, p.y+5, 11, true, pdfWhite, "TOTAL")
+	p.doc.TextRight(pdfContentRight-6, p.y+5, 11, true, pdfWhite, formatBRL(totals.Total))
+	p.y += 24
+}
+
+func (p *budgetPDFWriter) footer() {
+	pages := p.doc.PageCount()
+	for i := 0; i < pages; i++ {
+		p.doc.SetPage(i)
+		y := utils.PDF_PAGE_HEIGHT - 30
+		p.doc.Line(pdfMarginX, y-12, pdfContentRight, y-12, 0.5, pdfMutedColor)
+		p.doc.Text(pdfMarginX, y, 8, false, pdfMutedColor, "Orçamento válido por 7 dias. Valores sujeitos à confirmação da arte e das quantidades.")
+		p.doc.TextRight(pdfContentRight, y, 8, false, pdfMutedColor, fmt.Sprintf("Página %d de %d", i+1, pages))
+	}
+}
+
+// renderBudgetPDF monta o PDF do orçamento: cliente, itens, totais, entrega, prazos e pagamento.
+func renderBudgetPDF(data budgetPDFData) ([]byte, error) {
+	budget := data.Budget
+	p := &budgetPDFWriter{doc: utils.NewPDFDocument()}
+	p.header(budget)
+
+	contact := data.Client.Contact
+	p.section("Cliente")
+	name := contact.Name
+	if name == "" {
+		name = data.Lead.Name
+	}
+	p.field("Nome", name)
+	p.field("Empresa", contact.CompanyName)
+	if contact.CNPJ != "" {
+		p.field("CNPJ", contact.CNPJ)
+	} else {
+		p.field("CPF", contact.CPF)
+	}
+	phone := contact.CellPhone
+	if phone == "" {
+		phone = data.Lead.Phone
+	}
+	p.field("Telefone", phone)
+	p.field("E-mail", contact.Email)
+	p.field("Vendedor", data.Seller.Name)
+
+	items := budget.Items
+	if len(items) == 0 && budget.OldProductsList != "" {
+		items, _ = utils.LegacyLineItems(budget.OldProductsList)
+	}
+	totals := utils.CalculateBudgetTotals(&schemas.Budget{Items: items, Discount: budget.Discount, EarlyMode: budget.EarlyMode, Delivery: budget.Delivery})
+	p.items(items)
+	p.totals(totals)
+
+	p.section("Entrega")
+	p.field("Opção", budget.Delivery.Option)
+	if budget.Delivery.Deadline > 0 {
+		p.field("Prazo de transporte", fmt.Sprintf("%d dias úteis", budget.Delivery.Deadline))
+	}
+	address := strings.TrimSpace(strings.Join([]string{budget.Address.Details, budget.Address.CEP}, " - "))
+	p.field("Endereço", strings.Trim(address, " -"))
+	if budget.ProductionDeadline > 0 {
+		p.field("Prazo de produção", fmt.Sprintf("%d dias úteis", budget.ProductionDeadline))
+	}
+	if !budget.EarlyMode.Date.IsZero() {
+		p.field("Antecipado para", formatPDFDate(budget.EarlyMode.Date))
+	}
+	if !budget.DeliveryForecast.IsZero() {
+		p.field("Previsão de entrega", formatPDFDate(budget.DeliveryForecast))
+	}
+
+	if budget.PaymentMethod != "" || len(budget.Billing.Installments) > 0 {
+		p.section("Pagamento")
+		p.field("Forma de pagamento", budget.PaymentMethod)
+		p.field("Faturamento", budget.Billing.Type)
+		for i, installment := range budget.Billing.Installments {
+			p.field(fmt.Sprintf("Parcela %d", i+1), fmt.Sprintf("%s em %s", formatBRL(installment.Value), formatPDFDate(installment.Date)))
+		}
+	}
+
+	if strings.TrimSpace(budget.Notes) != "" {
+		p.section("Observações")
+		for _, line := range utils.PDFWrapText(budget.Notes, 9.5, false, pdfContentRight-pdfMarginX) {
+			p.ensureSpace(13)
+			p.doc.Text(pdfMarginX, p.y, 9.5, false, pdfTextColor, line)
+			p.y += 13
+		}
+	}
+
+	p.footer()
+	return p.doc.Bytes()
+}
@@ -0,0 +1,175 @@
+package budgets
+
+import (
+	"api/database"
+	"api/entities/products"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"os"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// catalogShippingItems monta os volumes da cotação com as medidas do catálogo e calcula
+// o valor da mercadoria pela tabela de preços, para quando a cotação é feita a partir dos itens.
+func catalogShippingItems(ctx context.Context, db *mongo.Database, items []schemas.LineItem) ([]ShippingQuoteItem, float64, error) {
+	packages, err := products.ShippingItems(ctx, db, items)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := products.PriceLineItems(ctx, db, items); err != nil {
+		return nil, 0, err
+	}
+
+	quoteItems := make([]ShippingQuoteItem, 0, len(packages))
+	for _, p := range packages {
+		quoteItems = append(quoteItems, ShippingQuoteItem{
+			Height:   p.Height,
+			Length:   p.Length,
+			Quantity: p.Quantity,
+			Weight:   p.Weight,
+			Width:    p.Width,
+		})
+	}
+	return quoteItems, utils.SumLineItems(items), nil
+}
+
+var ErrBudgetWithoutItems = errors.New("o orçamento não tem itens do catálogo")
+
+// budgetShippingItems carrega os itens e o subtotal do orçamento, para cotar o frete só com o ID.
+func budgetShippingItems(ctx context.Context, db *mongo.Database, budgetID string) ([]schemas.LineItem, float64, error) {
+	id, err := bson.ObjectIDFromHex(budgetID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	budget := schemas.Budget{}
+	err = db.Collection(database.COLLECTION_BUDGETS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&budget)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(budget.Items) == 0 {
+		return nil, 0, ErrBudgetWithoutItems
+	}
+
+	subtotal := 0.0
+	if budget.Totals != nil {
+		subtotal = budget.Totals.Subtotal
+	}
+	return budget.Items, subtotal, nil
+}
+
+// GetBudgetPackages mostra os volumes que o frete do orçamento vai usar, calculados pelas medidas e
+// regras de embalagem dos produtos, com o total de volumes e o peso total.
+func GetBudgetPackages(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_BUDGET_ID_FORMAT)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	budget := schemas.Budget{}
+	err = db.Collection(database.COLLECTION_BUDGETS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&budget)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Orçamento não encontrado", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_BUDGET_BY_ID_IN_MONGODB)
+		return
+	}
+	if len(budget.Items) == 0 {
+		utils.SendResponse(w, http.StatusUnprocessableEntity, "O orçamento não tem itens do catálogo", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	packages, err := products.ShippingItems(ctx, db, budget.Items)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnprocessableEntity, err.Error(), nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	volumes, totalWeight := 0, 0.0
+	for _, p := range packages {
+		volumes += p.Quantity
+		totalWeight += p.Weight * float64(p.Quantity)
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", map[string]any{
+		"budget_id":    id.Hex(),
+		"packages":     packages,
+		"volumes":      volumes,
+		"total_weight": math.Round(totalWeight*1000) / 1000,
+	}, 0)
+}
+
+// QuoteBudgetShipping cota todos os serviços para o orçamento só pelo ID: os volumes saem dos itens,
+// o destino do endereço do orçamento ou do lead e a origem de SHIPPING_ORIGIN_CEP. Os parâmetros
+// `seller_cep` e `recipient_cep` substituem os CEPs e `sort` segue o de POST /v1/budgets/shipping.
+func QuoteBudgetShipping(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_BUDGET_ID_FORMAT)
+		return
+	}
+
+	query := r.URL.Query()
+	sortBy := query.Get("sort")
+	if sortBy == "" {
+		sortBy = SHIPPING_SORT_PRICE
+	}
+	if sortBy != SHIPPING_SORT_PRICE && sortBy != SHIPPING_SORT_DEADLINE {
+		utils.SendResponse(w, http.StatusBadRequest, "Parâmetro 'sort' inválido. Use 'price' ou 'deadline'", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	input := ShippingQuoteInput{
+		BudgetID:     id.Hex(),
+		SellerCEP:    query.Get("seller_cep"),
+		RecipientCEP: query.Get("recipient_cep"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), SHIPPING_QUOTE_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	comparison, err := compareShipping(ctx, mongoClient.Database(database.GetDB()), input, sortBy)
+	if isShippingError(err) {
+		sendShippingError(w, err)
+		return
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		utils.SendResponse(w, http.StatusNotFound, "Orçamento não encontrado", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", comparison, 0)
+}
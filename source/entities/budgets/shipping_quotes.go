@@ -0,0 +1,471 @@
+package budgets
+
+import (
+	"api/database"
+	"api/entities/funnels"
+	"api/integrations/shipping"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	// Cobre a cadeia inteira de provedores, cada um com seu próprio prazo
+	SHIPPING_QUOTE_TIMEOUT = 30 * time.Second
+
+	// As tabelas de frete mudam pouco ao longo do dia; a mesma cotação é reaproveitada por CEP e volumes
+	SHIPPING_QUOTE_CACHE_TTL    = 6 * time.Hour
+	SHIPPING_QUOTE_CACHE_PREFIX = "budgets:shipping:quote:"
+
+	SHIPPING_SORT_PRICE    = "price"
+	SHIPPING_SORT_DEADLINE = "deadline"
+)
+
+var ErrMissingQuoteFields = errors.New("Todos os campos são obrigatórios e devem ser preenchidos corretamente")
+
+// ShippingOption é um serviço cotado com preço e prazo já convertidos para número.
+type ShippingOption struct {
+	Rank               int     `json:"rank"`
+	Provider           string  `json:"provider"`
+	ServiceCode        string  `json:"service_code"`
+	ServiceDescription string  `json:"service_description"`
+	Carrier            string  `json:"carrier"`
+	CarrierCode        string  `json:"carrier_code,omitempty"`
+	Price              float64 `json:"price"`
+	OriginalPrice      float64 `json:"original_price,omitempty"`
+	DeliveryTime       uint    `json:"delivery_time"`
+	Cheapest           bool    `json:"cheapest,omitempty"`
+	Fastest            bool    `json:"fastest,omitempty"`
+}
+
+type UnavailableShippingService struct {
+	Provider           string `json:"provider"`
+	ServiceCode        string `json:"service_code"`
+	ServiceDescription string `json:"service_description"`
+	Carrier            string `json:"carrier"`
+	Message            string `json:"message,omitempty"`
+}
+
+type ShippingComparison struct {
+	SellerCEP    string                       `json:"seller_cep"`
+	RecipientCEP string                       `json:"recipient_cep"`
+	InvoiceValue float64                      `json:"invoice_value"`
+	Packages     []ShippingQuoteItem          `json:"packages"`
+	Sort         string                       `json:"sort"`
+	Provider     string                       `json:"provider"`
+	Attempts     []shipping.Attempt           `json:"attempts"`
+	Cached       bool                         `json:"cached"`
+	Options      []ShippingOption             `json:"options"`
+	Unavailable  []UnavailableShippingService `json:"unavailable"`
+}
+
+type ShippingChoiceInput struct {
+	ShippingQuoteInput
+	ServiceCode string `json:"service_code"`
+}
+
+// prepareShippingQuote monta a cotação: resolve o CEP de destino pelo orçamento ou lead e usa,
+// nessa ordem, os itens do catálogo, os volumes informados ou as medidas avulsas. Sem nenhum deles, os
+// volumes saem dos itens do orçamento. O CEP de origem padrão vem de SHIPPING_ORIGIN_CEP e os CEPs são
+// validados antes da cotação.
+func prepareShippingQuote(ctx context.Context, db *mongo.Database, input ShippingQuoteInput) (ShippingQuoteRequest, error) {
+	if input.SellerCEP == "" {
+		input.SellerCEP = os.Getenv(utils.SHIPPING_ORIGIN_CEP)
+	}
+	noDimensions := input.Height == 0 && input.Length == 0 && input.Weight == 0 && input.Width == 0
+	if input.BudgetID != "" && len(input.Items) == 0 && len(input.Packages) == 0 && noDimensions {
+		items, subtotal, err := budgetShippingItems(ctx, db, input.BudgetID)
+		if err != nil {
+			return ShippingQuoteRequest{}, fmt.Errorf("Não foi possível montar os volumes pelo orçamento: %w", err)
+		}
+		input.Items = items
+		if input.ShipmentInvoiceValue == 0 {
+			input.ShipmentInvoiceValue = subtotal
+		}
+	}
+
+	if input.RecipientCEP == "" && (input.BudgetID != "" || input.LeadID != "") {
+		cep, err := resolveRecipientCEP(ctx, db, input.BudgetID, input.LeadID)
+		if err != nil {
+			return ShippingQuoteRequest{}, fmt.Errorf("Não foi possível obter o CEP de destino: %w", err)
+		}
+		input.RecipientCEP = cep
+	}
+
+	shippingItems := []ShippingQuoteItem{{
+		Height:   input.Height,
+		Length:   input.Length,
+		Quantity: 1,
+		Weight:   input.Weight,
+		Width:    input.Width,
+	}}
+	missingDimensions := input.Height == 0 || input.Length == 0 || input.Weight == 0 || input.Width == 0
+	if len(input.Items) > 0 {
+		items, invoiceValue, err := catalogShippingItems(ctx, db, input.Items)
+		if err != nil {
+			return ShippingQuoteRequest{}, fmt.Errorf("Não foi possível montar os volumes pelo catálogo: %w", err)
+		}
+		shippingItems, missingDimensions = items, false
+		if input.ShipmentInvoiceValue == 0 {
+			input.ShipmentInvoiceValue = invoiceValue
+		}
+	} else if len(input.Packages) > 0 {
+		shippingItems, missingDimensions = []ShippingQuoteItem{}, false
+		for i, p := range input.Packages {
+			if p.Height <= 0 || p.Length <= 0 || p.Weight <= 0 || p.Width <= 0 {
+				return ShippingQuoteRequest{}, fmt.Errorf("O volume %d precisa ter altura, comprimento, largura e peso", i+1)
+			}
+			p.Quantity = max(p.Quantity, 1)
+			shippingItems = append(shippingItems, p)
+		}
+	}
+
+	if input.SellerCEP == "" || input.RecipientCEP == "" || input.ShipmentInvoiceValue == 0 || missingDimensions {
+		return ShippingQuoteRequest{}, ErrMissingQuoteFields
+	}
+	if err := validateQuoteCEPs(ctx, input.SellerCEP, input.RecipientCEP); err != nil {
+		return ShippingQuoteRequest{}, err
+	}
+
+	return ShippingQuoteRequest{
+		SellerCEP:            utils.OnlyDigits(input.SellerCEP),
+		RecipientCEP:         utils.OnlyDigits(input.RecipientCEP),
+		ShipmentInvoiceValue: input.ShipmentInvoiceValue,
+		ShippingItemArray:    shippingItems,
+	}, nil
+}
+
+// shippingQuoteCacheKey identifica a cotação pelos CEPs, valor da mercadoria e conjunto de volumes,
+// independente da ordem em que os volumes foram enviados.
+func shippingQuoteCacheKey(req ShippingQuoteRequest) string {
+	items := append([]ShippingQuoteItem{}, req.ShippingItemArray...)
+	sort.Slice(items, func(i, j int) bool {
+		return fmt.Sprint(items[i]) < fmt.Sprint(items[j])
+	})
+	raw, _ := json.Marshal([]any{req.SellerCEP, req.RecipientCEP, req.ShipmentInvoiceValue, items})
+	sum := sha256.Sum256(raw)
+	return SHIPPING_QUOTE_CACHE_PREFIX + hex.EncodeToString(sum[:])
+}
+
+func shippingQuoteCache() *redis.Client {
+	opts, err := redis.ParseURL(os.Getenv(utils.REDIS_URI))
+	if err != nil {
+		return nil
+	}
+	return redis.NewClient(opts)
+}
+
+// requestShippingQuote cota todos os serviços na cadeia de provedores (Frenet e, se ela falhar, os contratos
+// diretos), usando o cache do Redis quando a mesma cotação já foi feita. Sem Redis disponível, a cotação
+// é feita sem cache.
+func requestShippingQuote(ctx context.Context, req ShippingQuoteRequest) (*shipping.Quote, bool, error) {
+	key := shippingQuoteCacheKey(req)
+	rdb := shippingQuoteCache()
+	if rdb != nil {
+		defer rdb.Close()
+		if cached, err := rdb.Get(ctx, key).Bytes(); err == nil {
+			quote := shipping.Quote{}
+			if json.Unmarshal(cached, &quote) == nil {
+				return &quote, true, nil
+			}
+		}
+	}
+
+	providers, err := shipping.Default()
+	if err != nil {
+		return nil, false, err
+	}
+
+	packages := []shipping.Package{}
+	for _, item := range req.ShippingItemArray {
+		packages = append(packages, shipping.Package{
+			Height:   item.Height,
+			Length:   item.Length,
+			Width:    item.Width,
+			Weight:   item.Weight,
+			Quantity: item.Quantity,
+		})
+	}
+	quote, err := providers.Quote(ctx, shipping.QuoteRequest{
+		SellerCEP:    req.SellerCEP,
+		RecipientCEP: req.RecipientCEP,
+		InvoiceValue: req.ShipmentInvoiceValue,
+		Packages:     packages,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Só guarda cotações com algum serviço disponível, para não fixar por horas uma falha momentânea
+	if rdb != nil && len(rankShippingOptions(quote, SHIPPING_SORT_PRICE)) > 0 {
+		if raw, err := json.Marshal(quote); err == nil {
+			rdb.Set(ctx, key, raw, SHIPPING_QUOTE_CACHE_TTL)
+		}
+	}
+	return quote, false, nil
+}
+
+func sendShippingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, shipping.ErrNoProviders):
+		utils.SendResponse(w, http.StatusInternalServerError, "Nenhum provedor de frete configurado", nil, 0)
+	case errors.Is(err, shipping.ErrAllProvidersFailed):
+		utils.SendResponse(w, http.StatusBadGateway, err.Error(), nil, 0)
+	default:
+		utils.SendResponse(w, http.StatusInternalServerError, "Erro ao cotar o frete", nil, 0)
+	}
+}
+
+// rankShippingOptions ordena os serviços disponíveis por preço e, no empate, por prazo (ou o contrário com
+// sortBy=deadline) e marca o mais barato e o mais rápido.
+func rankShippingOptions(quote *shipping.Quote, sortBy string) []ShippingOption {
+	options := []ShippingOption{}
+	for _, svc := range quote.Services {
+		if !svc.Available() {
+			continue
+		}
+		options = append(options, ShippingOption{
+			Provider:           svc.Provider,
+			ServiceCode:        svc.ServiceCode,
+			ServiceDescription: svc.ServiceDescription,
+			Carrier:            svc.Carrier,
+			CarrierCode:        svc.CarrierCode,
+			Price:              svc.Price,
+			OriginalPrice:      svc.OriginalPrice,
+			DeliveryTime:       svc.DeliveryTime,
+		})
+	}
+
+	sort.SliceStable(options, func(i, j int) bool {
+		a, b := options[i], options[j]
+		if sortBy == SHIPPING_SORT_DEADLINE && a.DeliveryTime != b.DeliveryTime {
+			return a.DeliveryTime < b.DeliveryTime
+		}
+		if a.Price != b.Price {
+			return a.Price < b.Price
+		}
+		return a.DeliveryTime < b.DeliveryTime
+	})
+
+	cheapest, fastest := -1, -1
+	for i := range options {
+		options[i].Rank = i + 1
+		if cheapest < 0 || options[i].Price < options[cheapest].Price {
+			cheapest = i
+		}
+		if fastest < 0 || options[i].DeliveryTime < options[fastest].DeliveryTime {
+			fastest = i
+		}
+	}
+	if cheapest >= 0 {
+		options[cheapest].Cheapest = true
+		options[fastest].Fastest = true
+	}
+	return options
+}
+
+func unavailableShippingServices(quote *shipping.Quote) []UnavailableShippingService {
+	unavailable := []UnavailableShippingService{}
+	for _, svc := range quote.Services {
+		if !svc.Available() {
+			unavailable = append(unavailable, UnavailableShippingService{
+				Provider:           svc.Provider,
+				ServiceCode:        svc.ServiceCode,
+				ServiceDescription: svc.ServiceDescription,
+				Carrier:            svc.Carrier,
+				Message:            svc.Message,
+			})
+		}
+	}
+	return unavailable
+}
+
+// compareShipping cota e ranqueia todos os serviços para a entrada.
+func compareShipping(ctx context.Context, db *mongo.Database, input ShippingQuoteInput, sortBy string) (*ShippingComparison, error) {
+	req, err := prepareShippingQuote(ctx, db, input)
+	if err != nil {
+		return nil, err
+	}
+	quote, cached, err := requestShippingQuote(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &ShippingComparison{
+		SellerCEP:    req.SellerCEP,
+		RecipientCEP: req.RecipientCEP,
+		InvoiceValue: req.ShipmentInvoiceValue,
+		Packages:     req.ShippingItemArray,
+		Sort:         sortBy,
+		Provider:     quote.Provider,
+		Attempts:     quote.Attempts,
+		Cached:       cached,
+		Options:      rankShippingOptions(quote, sortBy),
+		Unavailable:  unavailableShippingServices(quote),
+	}, nil
+}
+
+func isShippingError(err error) bool {
+	return errors.Is(err, shipping.ErrNoProviders) || errors.Is(err, shipping.ErrAllProvidersFailed)
+}
+
+// CompareShippingQuotes retorna todos os serviços cotados para o envio, ranqueados por preço e prazo
+// (`sort=deadline` prioriza o prazo). Aceita vários volumes em `packages`.
+func CompareShippingQuotes(w http.ResponseWriter, r *http.Request) {
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy == "" {
+		sortBy = SHIPPING_SORT_PRICE
+	}
+	if sortBy != SHIPPING_SORT_PRICE && sortBy != SHIPPING_SORT_DEADLINE {
+		utils.SendResponse(w, http.StatusBadRequest, "Parâmetro 'sort' inválido. Use 'price' ou 'deadline'", nil, 0)
+		return
+	}
+
+	input := ShippingQuoteInput{}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "Dados inválidos", nil, 0)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), SHIPPING_QUOTE_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	comparison, err := compareShipping(ctx, mongoClient.Database(database.GetDB()), input, sortBy)
+	if isShippingError(err) {
+		sendShippingError(w, err)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, 0)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", comparison, 0)
+}
+
+// UpdateBudgetDelivery cota o envio do orçamento e grava em Delivery o serviço escolhido em `service_code`,
+// com o preço e o prazo da cotação (nunca os enviados pelo cliente), recalculando os totais.
+func UpdateBudgetDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_BUDGET_ID_FORMAT)
+		return
+	}
+
+	input := ShippingChoiceInput{}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+	if input.ServiceCode == "" {
+		utils.SendResponse(w, http.StatusBadRequest, "Informe o serviço escolhido em 'service_code'", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+	input.BudgetID = id.Hex()
+
+	ctx, cancel := context.WithTimeout(context.Background(), SHIPPING_QUOTE_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	collection := db.Collection(database.COLLECTION_BUDGETS)
+
+	budget := schemas.Budget{}
+	err = collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&budget)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Orçamento não encontrado", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_BUDGET_BY_ID_IN_MONGODB)
+		return
+	}
+	if budget.Approved {
+		utils.SendResponse(w, http.StatusConflict, "Orçamento já aprovado não pode ser alterado", nil, 0)
+		return
+	}
+	if input.ShipmentInvoiceValue == 0 && budget.Totals != nil {
+		input.ShipmentInvoiceValue = budget.Totals.Subtotal
+	}
+
+	comparison, err := compareShipping(ctx, db, input.ShippingQuoteInput, SHIPPING_SORT_PRICE)
+	if isShippingError(err) {
+		sendShippingError(w, err)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	var chosen *ShippingOption
+	for i := range comparison.Options {
+		if comparison.Options[i].ServiceCode == input.ServiceCode {
+			chosen = &comparison.Options[i]
+			break
+		}
+	}
+	if chosen == nil {
+		utils.SendResponse(w, http.StatusUnprocessableEntity, "Serviço indisponível para este envio", comparison, 0)
+		return
+	}
+
+	budget.Delivery = schemas.Delivery{
+		Option:      chosen.ServiceDescription,
+		Deadline:    chosen.DeliveryTime,
+		Price:       chosen.Price,
+		ServiceCode: chosen.ServiceCode,
+		Carrier:     chosen.Carrier,
+		Provider:    chosen.Provider,
+		QuotedAt:    time.Now(),
+	}
+	if err := applyLineItems(&budget); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+	budget.UpdatedAt = time.Now()
+
+	_, err = collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}, {Key: "approved", Value: false}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "delivery", Value: budget.Delivery},
+			{Key: "items", Value: budget.Items},
+			{Key: "totals", Value: budget.Totals},
+			{Key: "updated_at", Value: budget.UpdatedAt},
+		}}},
+	)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_BUDGET_IN_MONGODB)
+		return
+	}
+
+	funnels.NotifyBoards(ctx, db, "budget_updated", id.Hex(), budget.RelatedLead, id)
+
+	utils.SendResponse(w, http.StatusOK, "", map[string]any{"budget": budget, "quote": comparison}, 0)
+}
@@ -0,0 +1,161 @@
+package budgets
+
+import (
+	"api/database"
+	"api/entities/funnels"
+	"api/entities/leads"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Perfis que podem aprovar ou reprovar orçamentos
+var budgetApprovalRoles = []string{schemas.USERS_ROLE_SUPER_ADMIN, schemas.USERS_ROLE_IT, schemas.USERS_ROLE_ADMIN, schemas.USERS_ROLE_LEADER}
+
+type UpdateApprovalRequest struct {
+	Action string `json:"action"` // approve ou reject
+	Reason string `json:"reason,omitempty"`
+}
+
+// UpdateApproval aprova ou reprova um orçamento pendente, registrando quem e quando. Só os perfis de
+// budgetApprovalRoles podem decidir.
+func UpdateApproval(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_BUDGET_ID_FORMAT)
+		return
+	}
+
+	req := UpdateApprovalRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Action != "approve" && req.Action != "reject" {
+		utils.SendResponse(w, http.StatusBadRequest, "Ação inválida. Use 'approve' ou 'reject'", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+	if req.Action == "reject" && req.Reason == "" {
+		utils.SendResponse(w, http.StatusBadRequest, "Informe o motivo da reprovação", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	collection := db.Collection(database.COLLECTION_BUDGETS)
+
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+	if !utils.HasAnyRole(user, budgetApprovalRoles) {
+		utils.SendResponse(w, http.StatusForbidden, "Usuário sem permissão para aprovar ou reprovar orçamentos", nil, 0)
+		return
+	}
+
+	budget := schemas.Budget{}
+	err = collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&budget)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Orçamento não encontrado", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_BUDGET_BY_ID_IN_MONGODB)
+		return
+	}
+	if budget.Approved {
+		utils.SendResponse(w, http.StatusConflict, "Orçamento já aprovado", nil, 0)
+		return
+	}
+
+	now := time.Now()
+	set := bson.D{{Key: "updated_at", Value: now}}
+	unset := bson.D{}
+	action := ""
+	if req.Action == "approve" {
+		if err := validateBudget(&budget); err != nil {
+			utils.SendResponse(w, http.StatusBadRequest, "Orçamento inválido para aprovação: "+err.Error(), nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+			return
+		}
+		budget.Approved = true
+		budget.ApprovalStatus = schemas.BUDGETS_APPROVAL_STATUS_APPROVED
+		budget.ApprovedBy = user.ID
+		budget.ApprovedAt = now
+		set = append(set,
+			bson.E{Key: "approved", Value: true},
+			bson.E{Key: "approval_status", Value: budget.ApprovalStatus},
+			bson.E{Key: "approved_by", Value: user.ID},
+			bson.E{Key: "approved_at", Value: now},
+		)
+		// Um orçamento reprovado antes pode ser aprovado depois; a reprovação anterior deixa de valer
+		budget.RejectedBy = bson.ObjectID{}
+		budget.RejectedAt = time.Time{}
+		budget.RejectionReason = ""
+		unset = append(unset,
+			bson.E{Key: "rejected_by", Value: ""},
+			bson.E{Key: "rejected_at", Value: ""},
+			bson.E{Key: "rejection_reason", Value: ""},
+		)
+		action = "budget_approved"
+	} else {
+		budget.ApprovalStatus = schemas.BUDGETS_APPROVAL_STATUS_REJECTED
+		budget.RejectedBy = user.ID
+		budget.RejectedAt = now
+		budget.RejectionReason = req.Reason
+		set = append(set,
+			bson.E{Key: "approved", Value: false},
+			bson.E{Key: "approval_status", Value: budget.ApprovalStatus},
+			bson.E{Key: "rejected_by", Value: user.ID},
+			bson.E{Key: "rejected_at", Value: now},
+			bson.E{Key: "rejection_reason", Value: req.Reason},
+		)
+		action = "budget_rejected"
+	}
+
+	update := bson.D{{Key: "$set", Value: set}}
+	if len(unset) > 0 {
+		update = append(update, bson.E{Key: "$unset", Value: unset})
+	}
+
+	// O filtro por approved evita aprovar duas vezes em requisições concorrentes
+	result, err := collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}, {Key: "approved", Value: bson.D{{Key: "$ne", Value: true}}}},
+		update,
+	)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_BUDGET_IN_MONGODB)
+		return
+	}
+	if result.MatchedCount == 0 {
+		utils.SendResponse(w, http.StatusConflict, "Orçamento já aprovado", nil, 0)
+		return
+	}
+
+	leads.QueueScoreRecompute(budget.RelatedLead)
+	funnels.NotifyBoards(ctx, db, action, id.Hex(), budget.RelatedLead, id)
+
+	utils.SendResponse(w, http.StatusOK, "", budget, 0)
+}
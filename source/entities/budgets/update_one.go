@@ -0,0 +1,221 @@
+package budgets
+
+import (
+	"api/database"
+	"api/entities/funnels"
+	"api/entities/leads"
+	"api/entities/products"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// UpdateOne atualiza só os campos enviados; o orçamento resultante é validado por inteiro antes de gravar.
+// Orçamentos aprovados não podem ser alterados.
+func UpdateOne(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_BUDGET_ID_FORMAT)
+		return
+	}
+
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+	input := schemas.Budget{}
+	raw := map[string]any{}
+	if err := json.Unmarshal(bodyBytes, &input); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+	_ = json.Unmarshal(bodyBytes, &raw)
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	collection := db.Collection(database.COLLECTION_BUDGETS)
+
+	budget := schemas.Budget{}
+	err = collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&budget)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Orçamento não encontrado", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_BUDGET_BY_ID_IN_MONGODB)
+		return
+	}
+	if budget.Approved {
+		utils.SendResponse(w, http.StatusConflict, "Orçamento já aprovado não pode ser alterado", nil, 0)
+		return
+	}
+
+	previousLead := budget.RelatedLead
+	updateDoc := bson.D{}
+	has := func(key string) bool {
+		_, ok := raw[key]
+		return ok
+	}
+
+	if has("seller") {
+		budget.Seller = input.Seller
+		updateDoc = append(updateDoc, bson.E{Key: "seller", Value: input.Seller})
+	}
+	if has("related_lead") {
+		count, err := db.Collection(database.COLLECTION_LEADS).CountDocuments(ctx, bson.D{{Key: "_id", Value: input.RelatedLead}})
+		if err != nil || count == 0 {
+			utils.SendResponse(w, http.StatusBadRequest, "Lead não encontrado", nil, utils.CANNOT_FIND_LEAD_BY_ID_IN_MONGODB)
+			return
+		}
+		budget.RelatedLead = input.RelatedLead
+		updateDoc = append(updateDoc, bson.E{Key: "related_lead", Value: input.RelatedLead})
+	}
+	if has("related_client") {
+		budget.RelatedClient = input.RelatedClient
+		updateDoc = append(updateDoc, bson.E{Key: "related_client", Value: input.RelatedClient})
+	}
+	if has("old_products_list") {
+		budget.OldProductsList = input.OldProductsList
+		updateDoc = append(updateDoc, bson.E{Key: "old_products_list", Value: input.OldProductsList})
+		if !has("items") {
+			// Os itens passam a ser reconvertidos da nova lista legada
+			budget.Items = nil
+		}
+	}
+	if has("items") {
+		budget.Items = input.Items
+	}
+	if has("address") {
+		if err := normalizeAddress(ctx, &input.Address); err != nil {
+			utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.INVALID_CEP)
+			return
+		}
+		budget.Address = input.Address
+		updateDoc = append(updateDoc, bson.E{Key: "address", Value: input.Address})
+	}
+	if has("delivery") {
+		budget.Delivery = input.Delivery
+		updateDoc = append(updateDoc, bson.E{Key: "delivery", Value: input.Delivery})
+	}
+	if has("early_mode") {
+		budget.EarlyMode = input.EarlyMode
+		updateDoc = append(updateDoc, bson.E{Key: "early_mode", Value: input.EarlyMode})
+	}
+	if has("discount") {
+		budget.Discount = input.Discount
+		updateDoc = append(updateDoc, bson.E{Key: "discount", Value: input.Discount})
+	}
+	if has("old_gifts") {
+		budget.OldGifts = input.OldGifts
+		updateDoc = append(updateDoc, bson.E{Key: "old_gifts", Value: input.OldGifts})
+	}
+	if has("production_deadline") {
+		budget.ProductionDeadline = input.ProductionDeadline
+		updateDoc = append(updateDoc, bson.E{Key: "production_deadline", Value: input.ProductionDeadline})
+	}
+	if has("payment_method") {
+		budget.PaymentMethod = input.PaymentMethod
+		updateDoc = append(updateDoc, bson.E{Key: "payment_method", Value: input.PaymentMethod})
+	}
+	if has("billing") {
+		budget.Billing = input.Billing
+		updateDoc = append(updateDoc, bson.E{Key: "billing", Value: input.Billing})
+	}
+	if has("trello_uri") {
+		budget.Trello_uri = input.Trello_uri
+		updateDoc = append(updateDoc, bson.E{Key: "trello_uri", Value: input.Trello_uri})
+	}
+	if has("notes") {
+		budget.Notes = input.Notes
+		updateDoc = append(updateDoc, bson.E{Key: "notes", Value: input.Notes})
+	}
+	if has("delivery_forecast") {
+		budget.DeliveryForecast = input.DeliveryForecast
+		updateDoc = append(updateDoc, bson.E{Key: "delivery_forecast", Value: input.DeliveryForecast})
+	}
+
+	if len(updateDoc) == 0 && !has("items") {
+		utils.SendResponse(w, http.StatusBadRequest, "Nenhum campo para atualizar foi fornecido", nil, 0)
+		return
+	}
+
+	if err := validateBudget(&budget); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+	if err := products.PriceLineItems(ctx, db, budget.Items); err != nil {
+		if errors.Is(err, products.ErrProductNotFound) {
+			utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		} else {
+			utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_PRODUCTS_IN_MONGODB)
+		}
+		return
+	}
+	if err := applyLineItems(&budget); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.BUDGETS_INVALID_REQUEST_DATA)
+		return
+	}
+	updateDoc = append(updateDoc,
+		bson.E{Key: "items", Value: budget.Items},
+		bson.E{Key: "totals", Value: budget.Totals},
+	)
+
+	budget.UpdatedAt = time.Now()
+	updateDoc = append(updateDoc, bson.E{Key: "updated_at", Value: budget.UpdatedAt})
+
+	if _, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: updateDoc}}); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_BUDGET_IN_MONGODB)
+		return
+	}
+
+	// Com a troca de lead, o orçamento sai do related_budgets do lead anterior e entra no do novo
+	if budget.RelatedLead != previousLead {
+		leadsCollection := db.Collection(database.COLLECTION_LEADS)
+		if !previousLead.IsZero() {
+			if _, err := leadsCollection.UpdateOne(ctx,
+				bson.D{{Key: "_id", Value: previousLead}},
+				bson.D{{Key: "$pull", Value: bson.D{{Key: "related_budgets", Value: id}}}},
+			); err != nil {
+				utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_LEAD_IN_MONGODB)
+				return
+			}
+			leads.QueueScoreRecompute(previousLead)
+		}
+		if !budget.RelatedLead.IsZero() {
+			if _, err := leadsCollection.UpdateOne(ctx,
+				bson.D{{Key: "_id", Value: budget.RelatedLead}},
+				bson.D{{Key: "$addToSet", Value: bson.D{{Key: "related_budgets", Value: id}}}},
+			); err != nil {
+				utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_LEAD_IN_MONGODB)
+				return
+			}
+			leads.QueueScoreRecompute(budget.RelatedLead)
+		}
+	}
+
+	funnels.NotifyBoards(ctx, db, "budget_updated", id.Hex(), budget.RelatedLead, id)
+
+	utils.SendResponse(w, http.StatusOK, "", budget, 0)
+}
@@ -0,0 +1,85 @@
+package budgets
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// AcceptProposedAddress usa o endereço proposto (ex.: localização do WhatsApp) como endereço de entrega do orçamento.
+func AcceptProposedAddress(w http.ResponseWriter, r *http.Request) {
+	updateProposedAddress(w, r, true)
+}
+
+// DiscardProposedAddress descarta o endereço proposto sem alterar o endereço de entrega.
+func DiscardProposedAddress(w http.ResponseWriter, r *http.Request) {
+	updateProposedAddress(w, r, false)
+}
+
+func updateProposedAddress(w http.ResponseWriter, r *http.Request, accept bool) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_BUDGET_ID_FORMAT)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_BUDGETS)
+
+	budget := schemas.Budget{}
+	err = collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&budget)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Orçamento não encontrado", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_BUDGET_BY_ID_IN_MONGODB)
+		return
+	}
+	if budget.ProposedAddress == nil {
+		utils.SendResponse(w, http.StatusNotFound, "Orçamento sem endereço proposto", nil, utils.NOT_FOUND)
+		return
+	}
+	if accept && budget.Approved {
+		utils.SendResponse(w, http.StatusConflict, "Orçamento já aprovado não pode ter o endereço alterado", nil, 0)
+		return
+	}
+
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: "proposed_address", Value: ""}}}}
+	set := bson.D{{Key: "updated_at", Value: time.Now()}}
+	if accept {
+		budget.Address = schemas.Address{CEP: budget.ProposedAddress.CEP, Details: budget.ProposedAddress.Details}
+		if err := normalizeAddress(ctx, &budget.Address); err != nil {
+			utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.INVALID_CEP)
+			return
+		}
+		set = append(set, bson.E{Key: "address", Value: budget.Address})
+	}
+	update = append(update, bson.E{Key: "$set", Value: set})
+
+	if _, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.ERROR_TO_UPDATE_IN_MONGODB)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", bson.M{"address": budget.Address}, 0)
+}
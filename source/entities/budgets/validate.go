@@ -0,0 +1,78 @@
+package budgets
+
+import (
+	"api/schemas"
+	"api/utils"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// validateBudget confere os itens e as regras de entrega, desconto, antecipação e parcelas antes de gravar o orçamento.
+func validateBudget(budget *schemas.Budget) error {
+	if budget.RelatedLead.IsZero() {
+		return errors.New("O campo 'related_lead' é obrigatório")
+	}
+
+	for i, item := range budget.Items {
+		if item.Description == "" && item.ProductID.IsZero() && item.SKU == "" {
+			return fmt.Errorf("O item %d deve ter produto ou descrição", i+1)
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("O item %d deve ter quantidade maior que zero", i+1)
+		}
+		if utils.LineItemPrice(item) < 0 {
+			return fmt.Errorf("O item %d não pode ter preço unitário negativo", i+1)
+		}
+	}
+
+	if budget.Delivery.Price < 0 {
+		return errors.New("O valor da entrega não pode ser negativo")
+	}
+	if budget.Delivery.Option == "" && (budget.Delivery.Price > 0 || budget.Delivery.Deadline > 0) {
+		return errors.New("Informe a opção de entrega")
+	}
+
+	switch budget.Discount.Type {
+	case "":
+		if budget.Discount.Value != 0 || budget.Discount.Percentage != 0 {
+			return errors.New("Informe o tipo do desconto")
+		}
+	case schemas.BUDGETS_DISCOUNT_TYPE_VALUE:
+		if budget.Discount.Value <= 0 {
+			return errors.New("O valor do desconto deve ser maior que zero")
+		}
+	case schemas.BUDGETS_DISCOUNT_TYPE_PERCENTAGE:
+		if budget.Discount.Percentage <= 0 || budget.Discount.Percentage > 100 {
+			return errors.New("O percentual de desconto deve estar entre 0 e 100")
+		}
+	default:
+		return fmt.Errorf("Tipo de desconto inválido. Use '%s' ou '%s'", schemas.BUDGETS_DISCOUNT_TYPE_VALUE, schemas.BUDGETS_DISCOUNT_TYPE_PERCENTAGE)
+	}
+
+	if budget.EarlyMode.Tax < 0 {
+		return errors.New("A taxa de antecipação não pode ser negativa")
+	}
+	if budget.EarlyMode.Tax > 0 && budget.EarlyMode.Date.IsZero() {
+		return errors.New("Informe a data da antecipação")
+	}
+
+	previous := time.Time{}
+	for i, installment := range budget.Billing.Installments {
+		if installment.Value <= 0 {
+			return fmt.Errorf("A parcela %d deve ter valor maior que zero", i+1)
+		}
+		if installment.Date.IsZero() {
+			return fmt.Errorf("A parcela %d deve ter data", i+1)
+		}
+		if installment.Date.Before(previous) {
+			return errors.New("As parcelas devem estar em ordem de vencimento")
+		}
+		previous = installment.Date
+	}
+	if budget.Billing.Type != "" && len(budget.Billing.Installments) == 0 {
+		return errors.New("Informe ao menos uma parcela do faturamento")
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package clients
+
+import (
+	"api/database"
+	"api/entities/exports"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var exportColumns = []exports.Column{
+	{Key: "id", Header: "ID", Path: "_id"},
+	{Key: "name", Header: "Nome", Path: "contact.name"},
+	{Key: "email", Header: "E-mail", Path: "contact.email"},
+	{Key: "person_type", Header: "Tipo de pessoa", Path: "contact.person_type"},
+	{Key: "cpf", Header: "CPF", Path: "contact.cpf"},
+	{Key: "cnpj", Header: "CNPJ", Path: "contact.cnpj"},
+	{Key: "company_name", Header: "Razão social", Path: "contact.company_name"},
+	{Key: "state_registration", Header: "Inscrição estadual", Path: "contact.state_registration"},
+	{Key: "cell_phone", Header: "Celular", Path: "contact.cell_phone"},
+	{Key: "zip_code", Header: "CEP", Path: "contact.zip_code"},
+	{Key: "address", Header: "Endereço", Path: "contact.address"},
+	{Key: "number", Header: "Número", Path: "contact.number"},
+	{Key: "complement", Header: "Complemento", Path: "contact.complement"},
+	{Key: "neighborhood", Header: "Bairro", Path: "contact.neighborhood"},
+	{Key: "city", Header: "Cidade", Path: "contact.city"},
+	{Key: "state", Header: "UF", Path: "contact.state"},
+	{Key: "created_at", Header: "Criado em", Path: "created_at"},
+	{Key: "updated_at", Header: "Atualizado em", Path: "updated_at"},
+}
+
+// Export exporta todos os clientes em CSV ou XLSX; assim como GET /v1/clients, não aceita filtros.
+func Export(w http.ResponseWriter, r *http.Request) {
+	exports.Export(w, r, exports.Source{
+		Entity:     "clients",
+		Collection: database.COLLECTION_CLIENTS,
+		Filter:     bson.D{},
+		Sort:       bson.D{{Key: "created_at", Value: -1}},
+		Columns:    exportColumns,
+	})
+}
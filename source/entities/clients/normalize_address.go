@@ -0,0 +1,138 @@
+package clients
+
+import (
+	"api/database"
+	"api/integrations/cep"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// NormalizeAddress consulta o CEP do contato (e o de cobrança, quando diferente) e grava o CEP formatado
+// com logradouro, bairro, cidade e UF do CEP. Número e complemento são mantidos.
+func NormalizeAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_CLIENT_ID_FORMAT)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_CLIENTS)
+
+	client := schemas.Client{}
+	err = collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&client)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Cliente não encontrado", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_CLIENT_BY_ID_IN_MONGODB)
+		return
+	}
+	if client.Contact.ZipCode == "" {
+		utils.SendResponse(w, http.StatusUnprocessableEntity, "Cliente sem CEP cadastrado", nil, utils.INVALID_CEP)
+		return
+	}
+
+	set := bson.D{}
+	address, err := lookupCEP(ctx, "contato", client.Contact.ZipCode)
+	if err != nil {
+		sendCEPError(w, err)
+		return
+	}
+	set = appendAddress(set, "contact.", address, &client.Contact.ZipCode, &client.Contact.Address,
+		&client.Contact.Neighborhood, &client.Contact.City, &client.Contact.State)
+
+	if client.Contact.DifferentBillingAddress && client.Contact.BillingZipCode != "" {
+		address, err := lookupCEP(ctx, "cobrança", client.Contact.BillingZipCode)
+		if err != nil {
+			sendCEPError(w, err)
+			return
+		}
+		set = appendAddress(set, "contact.billing_", address, &client.Contact.BillingZipCode, &client.Contact.BillingAddress,
+			&client.Contact.BillingNeighborhood, &client.Contact.BillingCity, &client.Contact.BillingState)
+	}
+
+	client.UpdatedAt = time.Now()
+	set = append(set, bson.E{Key: "updated_at", Value: client.UpdatedAt})
+	if _, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: set}}); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_CLIENT_IN_MONGODB)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", client, 0)
+}
+
+type cepError struct {
+	status int
+	err    error
+}
+
+func (e cepError) Error() string {
+	return e.err.Error()
+}
+
+func lookupCEP(ctx context.Context, label, zipCode string) (*cep.Address, error) {
+	address, err := cep.Lookup(ctx, zipCode)
+	switch {
+	case errors.Is(err, cep.ErrInvalid):
+		return nil, cepError{http.StatusUnprocessableEntity, fmt.Errorf("CEP de %s '%s' inválido", label, zipCode)}
+	case errors.Is(err, cep.ErrNotFound):
+		return nil, cepError{http.StatusUnprocessableEntity, fmt.Errorf("CEP de %s '%s' não encontrado", label, zipCode)}
+	case err != nil:
+		return nil, cepError{http.StatusBadGateway, errors.New("Não foi possível consultar o CEP")}
+	}
+	return address, nil
+}
+
+func sendCEPError(w http.ResponseWriter, err error) {
+	var ce cepError
+	if errors.As(err, &ce) {
+		utils.SendResponse(w, ce.status, ce.Error(), nil, utils.INVALID_CEP)
+		return
+	}
+	utils.SendResponse(w, http.StatusInternalServerError, err.Error(), nil, 0)
+}
+
+// appendAddress grava os campos do endereço com o prefixo informado, só sobrescrevendo os que o CEP traz
+// (CEPs de cidade pequena vêm sem logradouro e bairro).
+func appendAddress(set bson.D, prefix string, address *cep.Address, zipCode, street, neighborhood, city, state *string) bson.D {
+	fields := []struct {
+		key   string
+		value string
+		field *string
+	}{
+		{"zip_code", address.CEP, zipCode},
+		{"address", address.Street, street},
+		{"neighborhood", address.Neighborhood, neighborhood},
+		{"city", address.City, city},
+		{"state", address.State, state},
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		*f.field = f.value
+		set = append(set, bson.E{Key: prefix + f.key, Value: f.value})
+	}
+	return set
+}
@@ -0,0 +1,87 @@
+package exports
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Download envia o arquivo de uma exportação concluída direto do GridFS, apenas para quem a criou e para os
+// administradores.
+func Download(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "ID da exportação inválido", nil, utils.INVALID_EXPORT_REQUEST)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), EXPORT_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+
+	export := schemas.Export{}
+	err = db.Collection(database.COLLECTION_EXPORTS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&export)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		utils.SendResponse(w, http.StatusNotFound, "Exportação não encontrada", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_EXPORTS_IN_MONGODB)
+		return
+	}
+	if !canAccessExport(user, export) {
+		utils.SendResponse(w, http.StatusForbidden, "Apenas quem criou a exportação ou administradores podem acessá-la", nil, 0)
+		return
+	}
+	if export.Status != EXPORT_DONE || export.FileID.IsZero() {
+		utils.SendResponse(w, http.StatusConflict, fmt.Sprintf("Exportação ainda não disponível (status: %s)", export.Status), export, 0)
+		return
+	}
+
+	stream, err := exportsBucket(db).OpenDownloadStream(ctx, export.FileID)
+	if errors.Is(err, mongo.ErrFileNotFound) {
+		utils.SendResponse(w, http.StatusNotFound, "Arquivo da exportação não encontrado", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_EXPORTS_IN_MONGODB)
+		return
+	}
+	defer stream.Close()
+
+	w.Header().Set("Content-Type", utils.SpreadsheetContentType(export.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.FileName))
+	w.Header().Set("Content-Length", strconv.FormatInt(stream.GetFile().Length, 10))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, stream); err != nil {
+		log.Printf("[Download] Erro ao enviar a exportação %s: %v", export.ID.Hex(), err)
+	}
+}
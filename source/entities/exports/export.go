@@ -0,0 +1,452 @@
+package exports
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	// Acima deste total a exportação roda em segundo plano mesmo sem async=true
+	EXPORT_SYNC_MAX_ROWS = 20000
+	EXPORT_TIMEOUT       = 30 * time.Minute
+	EXPORT_BATCH_SIZE    = 500
+
+	// O progresso das exportações em segundo plano é gravado a cada tantas linhas
+	EXPORT_PROGRESS_EVERY = 5000
+
+	// Arquivos gerados ficam disponíveis para download por este tempo
+	EXPORT_RETENTION = 7 * 24 * time.Hour
+
+	EXPORT_PENDING = "pending"
+	EXPORT_RUNNING = "running"
+	EXPORT_DONE    = "done"
+	EXPORT_FAILED  = "failed"
+)
+
+// exportsAdminRoles acompanham e baixam as exportações de todos os usuários; os demais, só as próprias.
+var exportsAdminRoles = []string{schemas.USERS_ROLE_SUPER_ADMIN, schemas.USERS_ROLE_IT, schemas.USERS_ROLE_ADMIN}
+
+// canAccessExport diz se o usuário pode ver e baixar a exportação.
+func canAccessExport(user schemas.User, export schemas.Export) bool {
+	return utils.HasAnyRole(user, exportsAdminRoles) || (!export.CreatedByID.IsZero() && export.CreatedByID == user.ID)
+}
+
+// Column é uma coluna exportável. Path é o caminho do valor no documento, com pontos entre os níveis;
+// Value, quando informado, calcula o valor a partir do documento inteiro.
+type Column struct {
+	Key    string
+	Header string
+	Path   string
+	Value  func(doc bson.M) any
+}
+
+// Source descreve o que exportar: o filtro vem do mesmo construtor usado no GetAll da entidade e Stages
+// traz os $lookup que resolvem nomes para as colunas, aplicados depois do filtro e da ordenação.
+type Source struct {
+	Entity     string
+	Collection string
+	Filter     bson.D
+	Sort       bson.D
+	Stages     mongo.Pipeline
+	Columns    []Column
+}
+
+// LookupField devolve os estágios que buscam um campo de outra coleção pelo ID em localField e gravam o
+// valor em as, sem trazer o documento relacionado inteiro.
+func LookupField(from, localField, field, as string) mongo.Pipeline {
+	lookupAs := as + "_lookup"
+	return mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: from},
+			{Key: "localField", Value: localField},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: lookupAs},
+		}}},
+		{{Key: "$addFields", Value: bson.D{
+			{Key: as, Value: bson.D{{Key: "$arrayElemAt", Value: bson.A{"$" + lookupAs + "." + field, 0}}}},
+		}}},
+		{{Key: "$project", Value: bson.D{{Key: lookupAs, Value: 0}}}},
+	}
+}
+
+// Export responde GET /v1/{entidade}/export. Parâmetros: format (csv ou xlsx), columns (chaves separadas
+// por vírgula; sem ele vão todas) e async=true para gerar o arquivo em segundo plano. Exportações grandes
+// vão para segundo plano de qualquer forma e são acompanhadas em GET /v1/exports/{id}.
+func Export(w http.ResponseWriter, r *http.Request, source Source) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = utils.SPREADSHEET_CSV
+	}
+	if format != utils.SPREADSHEET_CSV && format != utils.SPREADSHEET_XLSX {
+		utils.SendResponse(w, http.StatusBadRequest, "format deve ser 'csv' ou 'xlsx'", nil, utils.INVALID_EXPORT_REQUEST)
+		return
+	}
+	columns, message := selectColumns(source.Columns, r.URL.Query().Get("columns"))
+	if message != "" {
+		utils.SendResponse(w, http.StatusBadRequest, message, nil, utils.INVALID_EXPORT_REQUEST)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), EXPORT_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	total, err := db.Collection(source.Collection).CountDocuments(ctx, source.Filter)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_EXPORTS_IN_MONGODB)
+		return
+	}
+
+	if r.URL.Query().Get("async") == "true" || total > EXPORT_SYNC_MAX_ROWS {
+		user, err := middlewares.CurrentUser(ctx, db, r)
+		if err != nil {
+			utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+			return
+		}
+		export := schemas.Export{
+			Entity:      source.Entity,
+			Format:      format,
+			Columns:     columnKeys(columns),
+			Query:       r.URL.RawQuery,
+			Status:      EXPORT_PENDING,
+			TotalRows:   total,
+			CreatedBy:   user.Name,
+			CreatedByID: user.ID,
+			CreatedAt:   time.Now(),
+		}
+		result, err := db.Collection(database.COLLECTION_EXPORTS).InsertOne(ctx, export)
+		if err != nil {
+			utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_INSERT_EXPORT_TO_MONGODB)
+			return
+		}
+		export.ID = result.InsertedID.(bson.ObjectID)
+
+		go runExport(export, source, columns)
+
+		utils.SendResponse(w, http.StatusAccepted, "Exportação iniciada", export, 0)
+		return
+	}
+
+	w.Header().Set("Content-Type", utils.SpreadsheetContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, exportFileName(source.Entity, format, time.Now())))
+	w.WriteHeader(http.StatusOK)
+	// Com o cabeçalho já enviado, um erro no meio do arquivo só pode ser registrado no log
+	if _, err := writeExport(ctx, db, w, source, columns, format, nil); err != nil {
+		log.Printf("[Export] Erro ao exportar %s: %v", source.Entity, err)
+	}
+}
+
+func selectColumns(available []Column, selected string) ([]Column, string) {
+	if strings.TrimSpace(selected) == "" {
+		return available, ""
+	}
+	columns := []Column{}
+	for _, key := range strings.Split(selected, ",") {
+		key = strings.TrimSpace(key)
+		index := slices.IndexFunc(available, func(column Column) bool { return column.Key == key })
+		if index < 0 {
+			return nil, fmt.Sprintf("Coluna '%s' desconhecida. Colunas disponíveis: %s", key, strings.Join(columnKeys(available), ", "))
+		}
+		columns = append(columns, available[index])
+	}
+	return columns, ""
+}
+
+func columnKeys(columns []Column) []string {
+	keys := make([]string, len(columns))
+	for i, column := range columns {
+		keys[i] = column.Key
+	}
+	return keys
+}
+
+func exportFileName(entity, format string, at time.Time) string {
+	return fmt.Sprintf("%s-%s.%s", entity, at.In(saoPaulo()).Format("2006-01-02-1504"), format)
+}
+
+// writeExport percorre o resultado com um cursor e grava cada documento como uma linha, sem carregar a
+// exportação inteira em memória. progress, quando informado, recebe o total de linhas já gravadas.
+func writeExport(ctx context.Context, db *mongo.Database, w io.Writer, source Source, columns []Column, format string, progress func(rows int64)) (int64, error) {
+	writer, err := utils.NewSpreadsheetWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	header := make([]any, len(columns))
+	for i, column := range columns {
+		header[i] = column.Header
+	}
+	if err := writer.WriteRow(header); err != nil {
+		return 0, err
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: source.Filter}}}
+	if len(source.Sort) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: source.Sort}})
+	}
+	pipeline = append(pipeline, source.Stages...)
+	cursor, err := db.Collection(source.Collection).Aggregate(ctx, pipeline, options.Aggregate().SetBatchSize(EXPORT_BATCH_SIZE).SetAllowDiskUse(true))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	rows := int64(0)
+	for cursor.Next(ctx) {
+		doc := bson.M{}
+		if err := cursor.Decode(&doc); err != nil {
+			return rows, err
+		}
+		values := make([]any, len(columns))
+		for i, column := range columns {
+			if column.Value != nil {
+				values[i] = cellValue(column.Value(doc))
+			} else {
+				values[i] = cellValue(valueAt(doc, column.Path))
+			}
+		}
+		if err := writer.WriteRow(values); err != nil {
+			return rows, err
+		}
+		rows++
+		if progress != nil && rows%EXPORT_PROGRESS_EVERY == 0 {
+			progress(rows)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return rows, err
+	}
+	return rows, writer.Close()
+}
+
+// valueAt segue o caminho com pontos pelos subdocumentos, que chegam como bson.M ou bson.D.
+func valueAt(doc any, path string) any {
+	current := doc
+	for _, key := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case bson.M:
+			current = v[key]
+		case map[string]any:
+			current = v[key]
+		case bson.D:
+			current = nil
+			for _, elem := range v {
+				if elem.Key == key {
+					current = elem.Value
+					break
+				}
+			}
+		default:
+			return nil
+		}
+	}
+	return current
+}
+
+// cellValue converte o valor do banco para a planilha: números continuam números, datas vão no horário de
+// Brasília, IDs em hexadecimal e listas separadas por vírgula.
+func cellValue(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return v
+	case float64:
+		return v
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case int:
+		return float64(v)
+	case bool:
+		if v {
+			return "Sim"
+		}
+		return "Não"
+	case bson.ObjectID:
+		if v.IsZero() {
+			return nil
+		}
+		return v.Hex()
+	case bson.DateTime:
+		return formatExportDate(v.Time())
+	case time.Time:
+		return formatExportDate(v)
+	case bson.A:
+		parts := []string{}
+		for _, item := range v {
+			if cell := cellValue(item); cell != nil {
+				parts = append(parts, fmt.Sprint(cell))
+			}
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func formatExportDate(at time.Time) any {
+	if at.IsZero() || at.Unix() <= 0 {
+		return nil
+	}
+	return at.In(saoPaulo()).Format("02/01/2006 15:04")
+}
+
+func saoPaulo() *time.Location {
+	location, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
+
+func exportsBucket(db *mongo.Database) *mongo.GridFSBucket {
+	return db.GridFSBucket(options.GridFSBucket().SetName(database.GRIDFS_BUCKET_EXPORTS))
+}
+
+// runExport gera o arquivo da exportação direto no GridFS e registra o progresso e o resultado. Um panic
+// ou a falha ao conectar marcam a exportação como failed.
+func runExport(export schemas.Export, source Source, columns []Column) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[Export] Panic na exportação %s: %v", export.ID.Hex(), r)
+			failExport(export.ID, fmt.Errorf("erro inesperado: %v", r))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), EXPORT_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		log.Printf("[Export] Erro ao conectar para a exportação %s: %v", export.ID.Hex(), err)
+		failExport(export.ID, err)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	collection := db.Collection(database.COLLECTION_EXPORTS)
+	update := func(set bson.D) {
+		if _, err := collection.UpdateByID(ctx, export.ID, bson.D{{Key: "$set", Value: set}}); err != nil {
+			log.Printf("[Export] Erro ao atualizar a exportação %s: %v", export.ID.Hex(), err)
+		}
+	}
+	update(bson.D{{Key: "status", Value: EXPORT_RUNNING}})
+
+	fileID := bson.NewObjectID()
+	fileName := exportFileName(source.Entity, export.Format, export.CreatedAt)
+	bucket := exportsBucket(db)
+	stream, err := bucket.OpenUploadStreamWithID(ctx, fileID, fileName,
+		options.GridFSUpload().SetMetadata(bson.D{{Key: "export_id", Value: export.ID}, {Key: "content_type", Value: utils.SpreadsheetContentType(export.Format)}}),
+	)
+	if err != nil {
+		update(bson.D{{Key: "status", Value: EXPORT_FAILED}, {Key: "error", Value: err.Error()}, {Key: "finished_at", Value: time.Now()}})
+		return
+	}
+
+	rows, err := writeExport(ctx, db, stream, source, columns, export.Format, func(rows int64) {
+		update(bson.D{{Key: "rows", Value: rows}})
+	})
+	if err == nil {
+		err = stream.Close()
+	} else {
+		_ = stream.Abort()
+	}
+	if err != nil {
+		log.Printf("[Export] Erro na exportação %s: %v", export.ID.Hex(), err)
+		update(bson.D{{Key: "status", Value: EXPORT_FAILED}, {Key: "rows", Value: rows}, {Key: "error", Value: err.Error()}, {Key: "finished_at", Value: time.Now()}})
+		return
+	}
+
+	now := time.Now()
+	update(bson.D{
+		{Key: "status", Value: EXPORT_DONE},
+		{Key: "rows", Value: rows},
+		{Key: "file_id", Value: fileID},
+		{Key: "file_name", Value: fileName},
+		{Key: "finished_at", Value: now},
+		{Key: "expires_at", Value: now.Add(EXPORT_RETENTION)},
+	})
+}
+
+// failExport marca a exportação como failed com uma conexão própria, para os casos em que a da exportação
+// não chegou a ser aberta ou a execução foi interrompida por um panic.
+func failExport(id bson.ObjectID, cause error) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err == nil {
+		defer mongoClient.Disconnect(ctx)
+		_, err = mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_EXPORTS).UpdateByID(ctx, id, bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: EXPORT_FAILED},
+			{Key: "error", Value: cause.Error()},
+			{Key: "finished_at", Value: time.Now()},
+		}}})
+	}
+	if err != nil {
+		log.Printf("[Export] Erro ao marcar a exportação %s como failed: %v", id.Hex(), err)
+	}
+}
+
+// StartExportsCleanup remove, a cada hora, as exportações vencidas e os seus arquivos.
+func StartExportsCleanup() {
+	utils.RunEvery("ExportsCleanup", time.Hour, 10*time.Minute, func(ctx context.Context) error {
+		mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+		if err != nil {
+			return err
+		}
+		defer mongoClient.Disconnect(ctx)
+
+		db := mongoClient.Database(database.GetDB())
+		collection := db.Collection(database.COLLECTION_EXPORTS)
+		cursor, err := collection.Find(ctx, bson.D{{Key: "expires_at", Value: bson.D{{Key: "$lt", Value: time.Now()}}}})
+		if err != nil {
+			return err
+		}
+		expired := []schemas.Export{}
+		if err := cursor.All(ctx, &expired); err != nil {
+			return err
+		}
+
+		bucket := exportsBucket(db)
+		for _, export := range expired {
+			if !export.FileID.IsZero() {
+				if err := bucket.Delete(ctx, export.FileID); err != nil && err != mongo.ErrFileNotFound {
+					log.Printf("[ExportsCleanup] Erro ao remover o arquivo da exportação %s: %v", export.ID.Hex(), err)
+					continue
+				}
+			}
+			if _, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: export.ID}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,93 @@
+package exports
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// GetAll lista as exportações em segundo plano, das mais recentes para as mais antigas. Filtros: entity e
+// status. Administradores veem as exportações de todos os usuários; os demais, só as próprias.
+func GetAll(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	page := int64(1)
+	pageSize := int64(25)
+	if parsedPage, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64); err == nil && parsedPage > 0 {
+		page = parsedPage
+	}
+	if parsedPageSize, err := strconv.ParseInt(r.URL.Query().Get("pageSize"), 10, 64); err == nil && parsedPageSize > 0 {
+		pageSize = min(parsedPageSize, 100)
+	}
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+
+	filter := bson.D{}
+	if !utils.HasAnyRole(user, exportsAdminRoles) {
+		filter = append(filter, bson.E{Key: "created_by_id", Value: user.ID})
+	}
+	for _, key := range []string{"entity", "status"} {
+		if value := r.URL.Query().Get(key); value != "" {
+			filter = append(filter, bson.E{Key: key, Value: value})
+		}
+	}
+
+	collection := db.Collection(database.COLLECTION_EXPORTS)
+	totalItems, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_EXPORTS_IN_MONGODB)
+		return
+	}
+
+	findOpts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip((page - 1) * pageSize).
+		SetLimit(pageSize)
+	cursor, err := collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_EXPORTS_IN_MONGODB)
+		return
+	}
+	exports := []schemas.Export{}
+	if err := cursor.All(ctx, &exports); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_EXPORTS_IN_MONGODB)
+		return
+	}
+
+	response := map[string]any{
+		"items": exports,
+		"pagination": map[string]any{
+			"page":        page,
+			"page_size":   pageSize,
+			"total_items": totalItems,
+			"total_pages": int64(math.Ceil(float64(totalItems) / float64(pageSize))),
+		},
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", response, 0)
+}
@@ -0,0 +1,62 @@
+package exports
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// GetOne devolve a situação de uma exportação em segundo plano; com status "done" o arquivo está em
+// GET /v1/exports/{id}/download. Só quem criou a exportação e os administradores têm acesso.
+func GetOne(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "ID da exportação inválido", nil, utils.INVALID_EXPORT_REQUEST)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+
+	export := schemas.Export{}
+	err = db.Collection(database.COLLECTION_EXPORTS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&export)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		utils.SendResponse(w, http.StatusNotFound, "Exportação não encontrada", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_EXPORTS_IN_MONGODB)
+		return
+	}
+	if !canAccessExport(user, export) {
+		utils.SendResponse(w, http.StatusForbidden, "Apenas quem criou a exportação ou administradores podem acessá-la", nil, 0)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", export, 0)
+}
@@ -38,12 +38,7 @@ func GetOne(w http.ResponseWriter, r *http.Request) {
 	pipeline := mongo.Pipeline{
 		{{Key: "$match", Value: bson.D{{Key: "_id", Value: id}}}},
 		{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$stages"}, {Key: "preserveNullAndEmptyArrays", Value: true}}}},
-		{{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: database.COLLECTION_LEADS},
-			{Key: "localField", Value: "stages.related_leads"},
-			{Key: "foreignField", Value: "_id"},
-			{Key: "as", Value: "stages.related_leads_data"},
-		}}},
+		{{Key: "$lookup", Value: leadsLookup(r)}},
 		{{Key: "$lookup", Value: bson.D{
 			{Key: "from", Value: database.COLLECTION_BUDGETS},
 			{Key: "localField", Value: "stages.related_budgets"},
@@ -0,0 +1,34 @@
+package funnels
+
+import (
+	"api/database"
+	"net/http"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// leadsLookup traz os leads de cada etapa. Com score_min e/ou score_max, só entram os leads com a pontuação
+// na faixa, para o funil mostrar apenas os leads mais quentes (ou mais frios).
+func leadsLookup(r *http.Request) bson.D {
+	lookup := bson.D{
+		{Key: "from", Value: database.COLLECTION_LEADS},
+		{Key: "localField", Value: "stages.related_leads"},
+		{Key: "foreignField", Value: "_id"},
+		{Key: "as", Value: "stages.related_leads_data"},
+	}
+
+	scoreRange := bson.D{}
+	if value, err := strconv.ParseFloat(r.URL.Query().Get("score_min"), 64); err == nil {
+		scoreRange = append(scoreRange, bson.E{Key: "$gte", Value: value})
+	}
+	if value, err := strconv.ParseFloat(r.URL.Query().Get("score_max"), 64); err == nil {
+		scoreRange = append(scoreRange, bson.E{Key: "$lte", Value: value})
+	}
+	if len(scoreRange) > 0 {
+		lookup = append(lookup, bson.E{Key: "pipeline", Value: bson.A{
+			bson.D{{Key: "$match", Value: bson.D{{Key: "score.value", Value: scoreRange}}}},
+		}})
+	}
+	return lookup
+}
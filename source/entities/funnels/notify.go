@@ -0,0 +1,45 @@
+package funnels
+
+import (
+	"api/database"
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// NotifyBoards avisa pelo websocket os funis que contêm o lead ou o orçamento informado.
+// Quando nenhum funil contém os registros, a mensagem é enviada sem board para que os clientes recarreguem.
+func NotifyBoards(ctx context.Context, db *mongo.Database, action, details string, leadID, budgetID bson.ObjectID) {
+	or := bson.A{}
+	if !leadID.IsZero() {
+		or = append(or, bson.D{{Key: "stages.related_leads", Value: leadID}})
+	}
+	if !budgetID.IsZero() {
+		or = append(or, bson.D{{Key: "stages.related_budgets", Value: budgetID}})
+	}
+
+	boards := []bson.ObjectID{}
+	if len(or) > 0 {
+		var funnels []struct {
+			ID bson.ObjectID `bson:"_id"`
+		}
+		cursor, err := db.Collection(database.COLLECTION_FUNNELS).Find(ctx, bson.D{{Key: "$or", Value: or}})
+		if err != nil {
+			log.Printf("[NotifyBoards] Erro ao buscar funis: %v", err)
+		} else if err := cursor.All(ctx, &funnels); err == nil {
+			for _, funnel := range funnels {
+				boards = append(boards, funnel.ID)
+			}
+		}
+	}
+
+	if len(boards) == 0 {
+		broadcastFunnelUpdate(FunnelWSMessage{Action: action, Details: details})
+		return
+	}
+	for _, board := range boards {
+		broadcastFunnelUpdate(FunnelWSMessage{Action: action, Board: board, Details: details})
+	}
+}
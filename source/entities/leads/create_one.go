@@ -10,6 +10,7 @@ import (
 	"os"
 	"time"
 
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
@@ -38,11 +39,19 @@ func CreateOne(w http.ResponseWriter, r *http.Request) {
 
 	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_LEADS)
 
-	_, err = collection.InsertOne(ctx, lead)
+	normalizeLeadPhone(lead)
+
+	// O tier é calculado pelos pedidos em segundo plano, nunca enviado pelo cliente
+	lead.Tier = nil
+
+	result, err := collection.InsertOne(ctx, lead)
 	if err != nil {
 		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_INSERT_LEAD_TO_MONGODB)
 		return
 	}
+	if id, ok := result.InsertedID.(bson.ObjectID); ok {
+		QueueTierRecompute(TIER_REASON_CREATED, id)
+	}
 
 	utils.SendResponse(w, http.StatusCreated, "", nil, 0)
 }
@@ -60,18 +60,14 @@ func CreateOneTier(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	valueFilter := bson.D{{Key: "$or", Value: bson.A{
-		bson.D{{Key: "min_value", Value: tier.MinValue}},
-		bson.D{{Key: "max_value", Value: tier.MaxValue}},
-	}}}
-	cursor, err = collection.Find(ctx, valueFilter)
-	if err == nil {
-		var existing []schemas.LeadTier
-		_ = cursor.All(ctx, &existing)
-		if len(existing) > 0 {
-			utils.SendResponse(w, http.StatusBadRequest, "Já existe um tier com esse min_value ou max_value", nil, 0)
-			return
-		}
+	message, err := validateTierRange(ctx, collection, *tier)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_TIERS_IN_MONGODB)
+		return
+	}
+	if message != "" {
+		utils.SendResponse(w, http.StatusBadRequest, message, nil, 0)
+		return
 	}
 
 	tier.CreatedAt = time.Now()
@@ -83,5 +79,7 @@ func CreateOneTier(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tierDefinitionsChanged.Store(true)
+
 	utils.SendResponse(w, http.StatusCreated, "", nil, 0)
 }
@@ -0,0 +1,507 @@
+package leads
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	DUPLICATE_REASON_PHONE    = "phone"
+	DUPLICATE_REASON_DOCUMENT = "document"
+	DUPLICATE_REASON_CLIENT   = "client"
+	DUPLICATE_REASON_NAME     = "name"
+
+	// Semelhança mínima entre nomes (1 - distância de edição / tamanho) para apontar duplicidade
+	NAME_SIMILARITY_THRESHOLD = 0.85
+
+	DUPLICATE_GROUPS_LIMIT = 100
+
+	LEAD_DUPLICATES_SCAN_INTERVAL = time.Hour
+	LEAD_DUPLICATES_SCAN_TIMEOUT  = 5 * time.Minute
+)
+
+// Peso de cada motivo na pontuação de um candidato a duplicado
+var duplicateReasonScores = map[string]float64{
+	DUPLICATE_REASON_PHONE:    0.6,
+	DUPLICATE_REASON_DOCUMENT: 0.5,
+	DUPLICATE_REASON_CLIENT:   0.4,
+	DUPLICATE_REASON_NAME:     0.3,
+}
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+type DuplicateLead struct {
+	ID            bson.ObjectID `json:"id"`
+	Name          string        `json:"name,omitempty"`
+	Phone         string        `json:"phone,omitempty"`
+	Document      string        `json:"document,omitempty"`
+	RelatedClient bson.ObjectID `json:"related_client,omitzero"`
+	Budgets       int           `json:"budgets"`
+	Orders        int           `json:"orders"`
+}
+
+type DuplicateGroup struct {
+	Reason string          `json:"reason"`
+	Key    string          `json:"key"`
+	Leads  []DuplicateLead `json:"leads"`
+}
+
+type DuplicateCandidate struct {
+	Lead    DuplicateLead `json:"lead"`
+	Reasons []string      `json:"reasons"`
+	Score   float64       `json:"score"`
+}
+
+type DuplicatesPage struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Total       int              `json:"total"`
+	Page        int              `json:"page"`
+	Limit       int              `json:"limit"`
+	Groups      []DuplicateGroup `json:"groups"`
+}
+
+// duplicateEntry guarda o lead com as chaves usadas na comparação: telefones, documento do cliente
+// (CPF/CNPJ) e nome normalizado.
+type duplicateEntry struct {
+	lead   DuplicateLead
+	phones []string
+	name   string
+}
+
+type duplicateIndex struct {
+	entries []duplicateEntry
+}
+
+// duplicatesSnapshot é o último cálculo dos duplicados, feito em segundo plano para que as consultas não
+// percorram todos os leads a cada requisição.
+type duplicatesSnapshot struct {
+	index       *duplicateIndex
+	groups      []DuplicateGroup
+	generatedAt time.Time
+}
+
+var (
+	duplicatesMutex     sync.RWMutex
+	duplicatesCurrent   *duplicatesSnapshot
+	duplicatesScanMutex sync.Mutex
+)
+
+var errDuplicatesNotReady = errors.New("os duplicados ainda estão sendo calculados")
+
+// StartLeadDuplicates calcula os duplicados na subida do servidor e depois periodicamente.
+func StartLeadDuplicates() {
+	QueueDuplicatesScan()
+	utils.RunEvery("LeadDuplicates", LEAD_DUPLICATES_SCAN_INTERVAL, LEAD_DUPLICATES_SCAN_TIMEOUT, func(ctx context.Context) error {
+		if !duplicatesScanMutex.TryLock() {
+			return nil
+		}
+		defer duplicatesScanMutex.Unlock()
+		return scanDuplicates(ctx)
+	})
+}
+
+// QueueDuplicatesScan recalcula os duplicados em segundo plano (ex.: depois de uma mesclagem). Se já houver
+// um cálculo em andamento, espera ele terminar para que o resultado inclua as últimas alterações.
+func QueueDuplicatesScan() {
+	go func() {
+		duplicatesScanMutex.Lock()
+		defer duplicatesScanMutex.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), LEAD_DUPLICATES_SCAN_TIMEOUT)
+		defer cancel()
+		if err := scanDuplicates(ctx); err != nil {
+			log.Printf("[LeadDuplicates] Erro ao calcular os leads duplicados: %v", err)
+		}
+	}()
+}
+
+func scanDuplicates(ctx context.Context) error {
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		return err
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	index, err := loadDuplicateIndex(ctx, mongoClient.Database(database.GetDB()))
+	if err != nil {
+		return err
+	}
+	snapshot := &duplicatesSnapshot{index: index, groups: index.groups(), generatedAt: time.Now()}
+
+	duplicatesMutex.Lock()
+	duplicatesCurrent = snapshot
+	duplicatesMutex.Unlock()
+	return nil
+}
+
+func currentDuplicates() (*duplicatesSnapshot, error) {
+	duplicatesMutex.RLock()
+	defer duplicatesMutex.RUnlock()
+	if duplicatesCurrent == nil {
+		return nil, errDuplicatesNotReady
+	}
+	return duplicatesCurrent, nil
+}
+
+// GetDuplicates lista, página a página, os grupos de leads possivelmente duplicados: mesmo telefone (com ou
+// sem 55 e nono dígito), clientes com o mesmo CPF/CNPJ ou o mesmo cliente, e nomes parecidos. Um lead pode
+// aparecer em mais de um grupo. Os grupos são recalculados em segundo plano (generated_at indica quando);
+// use reason para filtrar um motivo, page e limit para paginar (padrão 100 grupos por página).
+func GetDuplicates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := DUPLICATE_GROUPS_LIMIT
+	if parsed, err := strconv.Atoi(query.Get("limit")); err == nil && parsed > 0 {
+		limit = min(parsed, DUPLICATE_GROUPS_LIMIT)
+	}
+	page := 1
+	if parsed, err := strconv.Atoi(query.Get("page")); err == nil && parsed > 0 {
+		page = parsed
+	}
+	reason := query.Get("reason")
+
+	snapshot, err := currentDuplicates()
+	if err != nil {
+		utils.SendResponse(w, http.StatusServiceUnavailable, "Os duplicados ainda estão sendo calculados. Tente novamente em instantes", nil, 0)
+		return
+	}
+
+	groups := []DuplicateGroup{}
+	for _, group := range snapshot.groups {
+		if reason == "" || group.Reason == reason {
+			groups = append(groups, group)
+		}
+	}
+	start := min((page-1)*limit, len(groups))
+	end := min(start+limit, len(groups))
+
+	utils.SendResponse(w, http.StatusOK, "", DuplicatesPage{
+		GeneratedAt: snapshot.generatedAt,
+		Total:       len(groups),
+		Page:        page,
+		Limit:       limit,
+		Groups:      groups[start:end],
+	}, 0)
+}
+
+// GetOneDuplicates lista os possíveis duplicados de um lead, com os motivos e uma pontuação de 0 a 1,
+// do mais para o menos provável. O lead é lido na hora e comparado com o último cálculo dos duplicados.
+func GetOneDuplicates(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_LEAD_ID_FORMAT)
+		return
+	}
+
+	snapshot, err := currentDuplicates()
+	if err != nil {
+		utils.SendResponse(w, http.StatusServiceUnavailable, "Os duplicados ainda estão sendo calculados. Tente novamente em instantes", nil, 0)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	lead := schemas.Lead{}
+	err = db.Collection(database.COLLECTION_LEADS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}, options.FindOne().SetProjection(duplicateProjection)).Decode(&lead)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		utils.SendResponse(w, http.StatusNotFound, "Lead não encontrado", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_IN_MONGODB)
+		return
+	}
+	documents, err := clientDocuments(ctx, db, []schemas.Lead{lead})
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_IN_MONGODB)
+		return
+	}
+	entry := newDuplicateEntry(lead, documents)
+
+	candidates := []DuplicateCandidate{}
+	for _, other := range snapshot.index.entries {
+		if other.lead.ID == id {
+			continue
+		}
+		reasons, score := compareDuplicates(entry, other)
+		if len(reasons) > 0 {
+			candidates = append(candidates, DuplicateCandidate{Lead: other.lead, Reasons: reasons, Score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+
+	utils.SendResponse(w, http.StatusOK, "", candidates, 0)
+}
+
+var duplicateProjection = bson.D{
+	{Key: "name", Value: 1},
+	{Key: "phone", Value: 1},
+	{Key: "alternate_phones", Value: 1},
+	{Key: "related_client", Value: 1},
+	{Key: "related_budgets", Value: 1},
+	{Key: "related_orders", Value: 1},
+}
+
+func loadDuplicateIndex(ctx context.Context, db *mongo.Database) (*duplicateIndex, error) {
+	cursor, err := db.Collection(database.COLLECTION_LEADS).Find(ctx, bson.D{}, options.Find().SetProjection(duplicateProjection).SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	leads := []schemas.Lead{}
+	if err := cursor.All(ctx, &leads); err != nil {
+		return nil, err
+	}
+
+	documents, err := clientDocuments(ctx, db, leads)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &duplicateIndex{}
+	for _, lead := range leads {
+		index.entries = append(index.entries, newDuplicateEntry(lead, documents))
+	}
+	return index, nil
+}
+
+// clientDocuments busca o CPF/CNPJ (só os dígitos, com o CNPJ na frente) dos clientes vinculados aos leads.
+func clientDocuments(ctx context.Context, db *mongo.Database, leads []schemas.Lead) (map[bson.ObjectID]string, error) {
+	documents := map[bson.ObjectID]string{}
+	clientIDs := []bson.ObjectID{}
+	for _, lead := range leads {
+		if !lead.RelatedClient.IsZero() {
+			clientIDs = append(clientIDs, lead.RelatedClient)
+		}
+	}
+	if len(clientIDs) == 0 {
+		return documents, nil
+	}
+
+	clientsOpts := options.Find().SetProjection(bson.D{{Key: "contact.cpf", Value: 1}, {Key: "contact.cnpj", Value: 1}})
+	cursor, err := db.Collection(database.COLLECTION_CLIENTS).Find(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: clientIDs}}}}, clientsOpts)
+	if err != nil {
+		return nil, err
+	}
+	clients := []schemas.Client{}
+	if err := cursor.All(ctx, &clients); err != nil {
+		return nil, err
+	}
+	for _, client := range clients {
+		document := utils.OnlyDigits(client.Contact.CNPJ)
+		if document == "" {
+			document = utils.OnlyDigits(client.Contact.CPF)
+		}
+		if document != "" {
+			documents[client.ID] = document
+		}
+	}
+	return documents, nil
+}
+
+func newDuplicateEntry(lead schemas.Lead, documents map[bson.ObjectID]string) duplicateEntry {
+	keys := []string{}
+	for _, phone := range append([]string{lead.Phone}, lead.AlternatePhones...) {
+		if key := utils.PhoneKey(phone); key != "" && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return duplicateEntry{
+		lead: DuplicateLead{
+			ID:            lead.ID,
+			Name:          lead.Name,
+			Phone:         lead.Phone,
+			Document:      documents[lead.RelatedClient],
+			RelatedClient: lead.RelatedClient,
+			Budgets:       len(lead.RelatedBudgets),
+			Orders:        len(lead.RelatedOrders),
+		},
+		phones: keys,
+		name:   normalizeName(lead.Name),
+	}
+}
+
+// groups monta os grupos por telefone, documento e cliente pela chave exata e por nome comparando apenas
+// leads com o mesmo primeiro nome, para não comparar todos contra todos.
+func (d *duplicateIndex) groups() []DuplicateGroup {
+	groups := []DuplicateGroup{}
+	collect := func(reason string, keys func(entry duplicateEntry) []string) {
+		members := map[string][]int{}
+		order := []string{}
+		for i, entry := range d.entries {
+			for _, key := range keys(entry) {
+				if _, ok := members[key]; !ok {
+					order = append(order, key)
+				}
+				members[key] = append(members[key], i)
+			}
+		}
+		for _, key := range order {
+			if len(members[key]) > 1 {
+				groups = append(groups, d.group(reason, key, members[key]))
+			}
+		}
+	}
+
+	collect(DUPLICATE_REASON_PHONE, func(entry duplicateEntry) []string { return entry.phones })
+	collect(DUPLICATE_REASON_DOCUMENT, func(entry duplicateEntry) []string { return nonEmpty(entry.lead.Document) })
+	collect(DUPLICATE_REASON_CLIENT, func(entry duplicateEntry) []string {
+		if entry.lead.RelatedClient.IsZero() {
+			return nil
+		}
+		return []string{entry.lead.RelatedClient.Hex()}
+	})
+
+	blocks := map[string][]int{}
+	order := []string{}
+	for i, entry := range d.entries {
+		first, ok := firstName(entry.name)
+		if !ok {
+			continue
+		}
+		if _, ok := blocks[first]; !ok {
+			order = append(order, first)
+		}
+		blocks[first] = append(blocks[first], i)
+	}
+	for _, first := range order {
+		members := blocks[first]
+		grouped := map[int]bool{}
+		for a, i := range members {
+			if grouped[i] {
+				continue
+			}
+			similar := []int{i}
+			for _, j := range members[a+1:] {
+				if !grouped[j] && nameSimilarity(d.entries[i].name, d.entries[j].name) >= NAME_SIMILARITY_THRESHOLD {
+					similar = append(similar, j)
+					grouped[j] = true
+				}
+			}
+			if len(similar) > 1 {
+				groups = append(groups, d.group(DUPLICATE_REASON_NAME, d.entries[i].name, similar))
+			}
+		}
+	}
+	return groups
+}
+
+func (d *duplicateIndex) group(reason, key string, members []int) DuplicateGroup {
+	group := DuplicateGroup{Reason: reason, Key: key}
+	for _, i := range members {
+		group.Leads = append(group.Leads, d.entries[i].lead)
+	}
+	return group
+}
+
+// compareDuplicates devolve os motivos que indicam que os dois leads são o mesmo e a pontuação da suspeita.
+// Como nos grupos, os nomes só são comparados quando o primeiro nome é igual.
+func compareDuplicates(a, b duplicateEntry) ([]string, float64) {
+	reasons := []string{}
+	if slices.ContainsFunc(a.phones, func(key string) bool { return slices.Contains(b.phones, key) }) {
+		reasons = append(reasons, DUPLICATE_REASON_PHONE)
+	}
+	if a.lead.Document != "" && a.lead.Document == b.lead.Document {
+		reasons = append(reasons, DUPLICATE_REASON_DOCUMENT)
+	}
+	if !a.lead.RelatedClient.IsZero() && a.lead.RelatedClient == b.lead.RelatedClient {
+		reasons = append(reasons, DUPLICATE_REASON_CLIENT)
+	}
+	firstA, okA := firstName(a.name)
+	firstB, okB := firstName(b.name)
+	if okA && okB && firstA == firstB && nameSimilarity(a.name, b.name) >= NAME_SIMILARITY_THRESHOLD {
+		reasons = append(reasons, DUPLICATE_REASON_NAME)
+	}
+
+	score := 0.0
+	for _, reason := range reasons {
+		score += duplicateReasonScores[reason]
+	}
+	return reasons, min(1, score)
+}
+
+// firstName devolve o primeiro nome de um nome normalizado. Nomes sem sobrenome não são comparados, porque
+// só o primeiro nome gera semelhanças demais.
+func firstName(name string) (string, bool) {
+	first, _, ok := strings.Cut(name, " ")
+	return first, ok && first != ""
+}
+
+// normalizeName deixa o nome em minúsculas, sem acentos, pontuação ou espaços repetidos.
+func normalizeName(name string) string {
+	name = accentReplacer.Replace(strings.ToLower(name))
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return ' '
+	}, name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// nameSimilarity compara dois nomes normalizados pela distância de edição, de 0 (nada em comum) a 1 (iguais).
+func nameSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return 1 - float64(previous[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
@@ -0,0 +1,168 @@
+package leads
+
+import (
+	"api/schemas"
+	"math"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"João da Silva", "joao da silva"},
+		{"  MARIA   Conceição ", "maria conceicao"},
+		{"José-Carlos (Zé)", "jose carlos ze"},
+		{"Loja 123", "loja 123"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeName(tt.name); got != tt.want {
+				t.Fatalf("normalizeName(%q) = %q, esperado %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"joao da silva", "joao da silva", 1},
+		{"joao da silva", "joao da silvaa", 1 - 1.0/14},
+		{"joao silva", "joana silva", 1 - 2.0/11},
+		{"ana", "bia", 1 - 2.0/3},
+		{"joao", "", 0},
+		{"", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			got := nameSimilarity(tt.a, tt.b)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("nameSimilarity(%q, %q) = %v, esperado %v", tt.a, tt.b, got, tt.want)
+			}
+			if reverse := nameSimilarity(tt.b, tt.a); math.Abs(reverse-got) > 1e-9 {
+				t.Fatalf("nameSimilarity não é simétrica: %v e %v", got, reverse)
+			}
+		})
+	}
+}
+
+func duplicateTestEntry(id bson.ObjectID, name, phone string, client bson.ObjectID, document string) duplicateEntry {
+	lead := schemas.Lead{ID: id, Name: name, Phone: phone, RelatedClient: client}
+	documents := map[bson.ObjectID]string{}
+	if !client.IsZero() {
+		documents[client] = document
+	}
+	return newDuplicateEntry(lead, documents)
+}
+
+func TestCompareDuplicates(t *testing.T) {
+	client, otherClient := bson.NewObjectID(), bson.NewObjectID()
+	tests := []struct {
+		name    string
+		a, b    duplicateEntry
+		reasons []string
+		score   float64
+	}{
+		{
+			name:    "telefone com e sem o nono dígito",
+			a:       duplicateTestEntry(bson.NewObjectID(), "Ana Souza", "(11) 98765-4321", bson.ObjectID{}, ""),
+			b:       duplicateTestEntry(bson.NewObjectID(), "Bruno Lima", "551187654321", bson.ObjectID{}, ""),
+			reasons: []string{DUPLICATE_REASON_PHONE},
+			score:   0.6,
+		},
+		{
+			name:    "mesmo cliente e documento",
+			a:       duplicateTestEntry(bson.NewObjectID(), "Ana Souza", "", client, "12345678000199"),
+			b:       duplicateTestEntry(bson.NewObjectID(), "Bruno Lima", "", client, "12345678000199"),
+			reasons: []string{DUPLICATE_REASON_DOCUMENT, DUPLICATE_REASON_CLIENT},
+			score:   0.9,
+		},
+		{
+			name:    "clientes diferentes com o mesmo documento",
+			a:       duplicateTestEntry(bson.NewObjectID(), "Ana Souza", "", client, "12345678000199"),
+			b:       duplicateTestEntry(bson.NewObjectID(), "Bruno Lima", "", otherClient, "12345678000199"),
+			reasons: []string{DUPLICATE_REASON_DOCUMENT},
+			score:   0.5,
+		},
+		{
+			name:    "nome parecido",
+			a:       duplicateTestEntry(bson.NewObjectID(), "João da Silva", "", bson.ObjectID{}, ""),
+			b:       duplicateTestEntry(bson.NewObjectID(), "Joao da Silvaa", "", bson.ObjectID{}, ""),
+			reasons: []string{DUPLICATE_REASON_NAME},
+			score:   0.3,
+		},
+		{
+			name:    "nome parecido com outro primeiro nome",
+			a:       duplicateTestEntry(bson.NewObjectID(), "Joao da Silva", "", bson.ObjectID{}, ""),
+			b:       duplicateTestEntry(bson.NewObjectID(), "Joan da Silva", "", bson.ObjectID{}, ""),
+			reasons: []string{},
+		},
+		{
+			name:    "só o primeiro nome",
+			a:       duplicateTestEntry(bson.NewObjectID(), "Maria", "", bson.ObjectID{}, ""),
+			b:       duplicateTestEntry(bson.NewObjectID(), "Maria", "", bson.ObjectID{}, ""),
+			reasons: []string{},
+		},
+		{
+			name:    "todos os motivos somam no máximo 1",
+			a:       duplicateTestEntry(bson.NewObjectID(), "Ana Souza", "11987654321", client, "12345678000199"),
+			b:       duplicateTestEntry(bson.NewObjectID(), "Ana Souza", "+55 11 98765-4321", client, "12345678000199"),
+			reasons: []string{DUPLICATE_REASON_PHONE, DUPLICATE_REASON_DOCUMENT, DUPLICATE_REASON_CLIENT, DUPLICATE_REASON_NAME},
+			score:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasons, score := compareDuplicates(tt.a, tt.b)
+			if !slices.Equal(reasons, tt.reasons) || math.Abs(score-tt.score) > 1e-9 {
+				t.Fatalf("compareDuplicates() = %v, %v, esperado %v, %v", reasons, score, tt.reasons, tt.score)
+			}
+		})
+	}
+}
+
+func TestDuplicateGroups(t *testing.T) {
+	ids := []bson.ObjectID{bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID()}
+	index := &duplicateIndex{entries: []duplicateEntry{
+		duplicateTestEntry(ids[0], "Ana Souza", "(11) 98765-4321", bson.ObjectID{}, ""),
+		duplicateTestEntry(ids[1], "Ana Souza Lima", "551187654321", bson.ObjectID{}, ""),
+		duplicateTestEntry(ids[2], "Carlos Pereira", "21 3456-7890", bson.ObjectID{}, ""),
+		duplicateTestEntry(ids[3], "Carlos Pereiraa", "", bson.ObjectID{}, ""),
+		duplicateTestEntry(ids[4], "Beatriz", "", bson.ObjectID{}, ""),
+	}}
+
+	type group struct {
+		reason string
+		key    string
+		ids    []bson.ObjectID
+	}
+	want := []group{
+		{DUPLICATE_REASON_PHONE, "551187654321", []bson.ObjectID{ids[0], ids[1]}},
+		{DUPLICATE_REASON_NAME, "carlos pereira", []bson.ObjectID{ids[2], ids[3]}},
+	}
+
+	groups := index.groups()
+	if len(groups) != len(want) {
+		t.Fatalf("%d grupos, esperado %d: %+v", len(groups), len(want), groups)
+	}
+	for i, g := range groups {
+		got := []bson.ObjectID{}
+		for _, lead := range g.Leads {
+			got = append(got, lead.ID)
+		}
+		if g.Reason != want[i].reason || g.Key != want[i].key || !slices.Equal(got, want[i].ids) {
+			t.Fatalf("grupo %d = %s/%s %v, esperado %s/%s %v", i, g.Reason, g.Key, got, want[i].reason, want[i].key, want[i].ids)
+		}
+	}
+}
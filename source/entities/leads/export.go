@@ -0,0 +1,47 @@
+package leads
+
+import (
+	"api/database"
+	"api/entities/exports"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var exportColumns = []exports.Column{
+	{Key: "id", Header: "ID", Path: "_id"},
+	{Key: "name", Header: "Nome", Path: "name"},
+	{Key: "nickname", Header: "Apelido", Path: "nickname"},
+	{Key: "phone", Header: "Telefone", Path: "phone"},
+	{Key: "type", Header: "Tipo", Path: "type"},
+	{Key: "segment", Header: "Segmento", Path: "segment"},
+	{Key: "status", Header: "Status", Path: "status"},
+	{Key: "source", Header: "Origem", Path: "source"},
+	{Key: "rating", Header: "Avaliação", Path: "rating"},
+	{Key: "responsible", Header: "Responsável", Path: "responsible_name"},
+	{Key: "client", Header: "Cliente", Path: "client_name"},
+	{Key: "tier", Header: "Tier", Path: "tier.label"},
+	{Key: "score", Header: "Pontuação", Path: "score.value"},
+	{Key: "orders", Header: "Pedidos", Path: "orders_count"},
+	{Key: "notes", Header: "Observações", Path: "notes"},
+	{Key: "created_at", Header: "Criado em", Path: "created_at"},
+	{Key: "updated_at", Header: "Atualizado em", Path: "updated_at"},
+}
+
+// Export exporta os leads em CSV ou XLSX com os mesmos filtros e ordenação de GET /v1/leads.
+func Export(w http.ResponseWriter, r *http.Request) {
+	stages := exports.LookupField(database.COLLECTION_USERS, "responsible", "name", "responsible_name")
+	stages = append(stages, exports.LookupField(database.COLLECTION_CLIENTS, "related_client", "contact.name", "client_name")...)
+	stages = append(stages, bson.D{{Key: "$addFields", Value: bson.D{
+		{Key: "orders_count", Value: bson.D{{Key: "$size", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$related_orders", bson.A{}}}}}}},
+	}}})
+
+	exports.Export(w, r, exports.Source{
+		Entity:     "leads",
+		Collection: database.COLLECTION_LEADS,
+		Filter:     buildFilterFromQueryParams(r),
+		Sort:       sortFromQueryParams(r),
+		Stages:     stages,
+		Columns:    exportColumns,
+	})
+}
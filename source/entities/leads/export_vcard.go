@@ -0,0 +1,84 @@
+package leads
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ExportVCard exporta os leads filtrados pelos mesmos parâmetros de GetAll em um arquivo .vcf.
+func ExportVCard(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_LEADS)
+
+	filter := buildFilterFromQueryParams(r)
+	filter = append(filter, bson.E{Key: "phone", Value: bson.D{{Key: "$nin", Value: bson.A{nil, ""}}}})
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_IN_MONGODB)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	w.Header().Set("Content-Type", "text/vcard; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="leads.vcf"`)
+	w.WriteHeader(http.StatusOK)
+
+	for cursor.Next(ctx) {
+		lead := schemas.Lead{}
+		if err := cursor.Decode(&lead); err != nil {
+			continue
+		}
+		if err := utils.WriteVCard(w, vCardFromLead(lead)); err != nil {
+			return
+		}
+	}
+}
+
+func vCardFromLead(lead schemas.Lead) utils.VCard {
+	name := lead.Name
+	if name == "" {
+		name = lead.Nickname
+	}
+	if name == "" {
+		name = lead.Phone
+	}
+
+	firstName, lastName := name, ""
+	if parts := strings.Fields(name); len(parts) > 1 {
+		firstName, lastName = parts[0], strings.Join(parts[1:], " ")
+	}
+
+	phone := strings.TrimPrefix(lead.Phone, "+")
+	card := utils.VCard{
+		FormattedName: name,
+		FirstName:     firstName,
+		LastName:      lastName,
+		Phones:        []utils.VCardPhone{{Number: "+" + phone, Type: "CELL", WaID: phone}},
+		Note:          lead.Notes,
+	}
+	if lead.Segment != "" {
+		card.Org = lead.Segment
+	}
+	return card
+}
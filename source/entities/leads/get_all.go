@@ -11,8 +11,6 @@ import (
 	"strings"
 	"time"
 
-	"api/schemas"
-
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
@@ -68,7 +66,7 @@ func GetAll(w http.ResponseWriter, r *http.Request) {
 
 	pipeline := mongo.Pipeline{
 		{{Key: "$match", Value: filter}},
-		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: -1}}}},
+		{{Key: "$sort", Value: sortFromQueryParams(r)}},
 		{{Key: "$skip", Value: skip}},
 		{{Key: "$limit", Value: pageSize}},
 		{{Key: "$lookup", Value: bson.D{
@@ -125,34 +123,7 @@ func GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tiersCollection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_LEADS_TIERS)
-	tiersCursor, err := tiersCollection.Find(ctx, bson.D{})
-	if err != nil {
-		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_TIERS_IN_MONGODB)
-		return
-	}
-	defer tiersCursor.Close(ctx)
-
-	tiers := []schemas.LeadTier{}
-	if err = tiersCursor.All(ctx, &tiers); err != nil {
-		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_TIERS_IN_MONGODB)
-		return
-	}
-
 	for i, lead := range leads {
-		if relatedOrders, ok := lead["related_orders"].(bson.A); ok {
-			if len(relatedOrders) > 0 {
-				tier, err := utils.CalculateLeadTier(relatedOrders, tiers)
-				if err != nil {
-					utils.SendResponse(w, http.StatusInternalServerError, err.Error(), nil, 0)
-					return
-				}
-				leads[i]["tier"] = tier
-			}
-		} else {
-			utils.SendResponse(w, http.StatusInternalServerError, "Erro ao processar JSON dos pedidos relacionados do lead", nil, 0)
-			return
-		}
 		if r.URL.Query().Get("allow_funnels") == "true" {
 			funnelName, stageName, err := getLeadCurrentFunnelAndStageForMany(ctx, mongoClient, database.GetDB(), lead["_id"])
 			if err != nil {
@@ -228,6 +199,7 @@ func buildFilterFromQueryParams(r *http.Request) bson.D {
 	objectIDFields := map[string]string{
 		"related_client": "related_client",
 		"responsible":    "responsible",
+		"tier_id":        "tier.tier_id",
 	}
 
 	for param, field := range objectIDFields {
@@ -238,6 +210,17 @@ func buildFilterFromQueryParams(r *http.Request) bson.D {
 		}
 	}
 
+	scoreRange := bson.D{}
+	if value, err := strconv.ParseFloat(queryParams.Get("score_min"), 64); err == nil {
+		scoreRange = append(scoreRange, bson.E{Key: "$gte", Value: value})
+	}
+	if value, err := strconv.ParseFloat(queryParams.Get("score_max"), 64); err == nil {
+		scoreRange = append(scoreRange, bson.E{Key: "$lte", Value: value})
+	}
+	if len(scoreRange) > 0 {
+		filter = append(filter, bson.E{Key: "score.value", Value: scoreRange})
+	}
+
 	multiValueFields := []string{"status", "type", "segment", "source", "rating"}
 	for _, field := range multiValueFields {
 		if values := queryParams.Get(field + "_in"); values != "" {
@@ -255,6 +238,18 @@ func buildFilterFromQueryParams(r *http.Request) bson.D {
 	return filter
 }
 
+// sortFromQueryParams ordena pela pontuação com sort=score (maior primeiro) ou sort=score_asc; sem sort,
+// os mais recentes vêm primeiro. O _id desempata para a paginação ficar estável.
+func sortFromQueryParams(r *http.Request) bson.D {
+	switch r.URL.Query().Get("sort") {
+	case "score":
+		return bson.D{{Key: "score.value", Value: -1}, {Key: "_id", Value: -1}}
+	case "score_asc":
+		return bson.D{{Key: "score.value", Value: 1}, {Key: "_id", Value: 1}}
+	}
+	return bson.D{{Key: "created_at", Value: -1}}
+}
+
 func getLeadCurrentFunnelAndStageForMany(ctx context.Context, mongoClient *mongo.Client, dbName string, leadID any) (funnelName string, stageName string, err error) {
 	funnelsCollection := mongoClient.Database(dbName).Collection(database.COLLECTION_FUNNELS)
 	pipeline := mongo.Pipeline{
@@ -0,0 +1,186 @@
+package leads
+
+import (
+	"api/database"
+	"api/utils"
+	"context"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type TierTransition struct {
+	From      string `json:"from" bson:"from"`
+	To        string `json:"to" bson:"to"`
+	Direction string `json:"direction" bson:"direction"`
+	Count     int64  `json:"count" bson:"count"`
+}
+
+type TierChangesSummary struct {
+	Upgrades    int64            `json:"upgrades"`
+	Downgrades  int64            `json:"downgrades"`
+	Transitions []TierTransition `json:"transitions"`
+}
+
+// GetTierChanges lista as trocas de tier dos leads, das mais recentes para as mais antigas, com o resumo de
+// upgrades e downgrades por par de tiers no mesmo filtro. Filtros: start, end, direction, lead_id, tier_id
+// (como origem ou destino) e reason.
+func GetTierChanges(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	page := int64(1)
+	pageSize := int64(25)
+	if parsedPage, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64); err == nil && parsedPage > 0 {
+		page = parsedPage
+	}
+	if parsedPageSize, err := strconv.ParseInt(r.URL.Query().Get("pageSize"), 10, 64); err == nil && parsedPageSize > 0 {
+		pageSize = min(parsedPageSize, 100)
+	}
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_LEADS_TIERS_HISTORY)
+	filter := tierChangesFilter(r)
+
+	totalItems, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_TIERS_IN_MONGODB)
+		return
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: -1}}}},
+		{{Key: "$skip", Value: (page - 1) * pageSize}},
+		{{Key: "$limit", Value: pageSize}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: database.COLLECTION_LEADS},
+			{Key: "localField", Value: "lead_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "lead_data"},
+		}}},
+		{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$lead_data"}, {Key: "preserveNullAndEmptyArrays", Value: true}}}},
+		{{Key: "$addFields", Value: bson.D{
+			{Key: "lead_name", Value: "$lead_data.name"},
+			{Key: "lead_phone", Value: "$lead_data.phone"},
+		}}},
+		{{Key: "$project", Value: bson.D{{Key: "lead_data", Value: 0}}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_TIERS_IN_MONGODB)
+		return
+	}
+	items := []bson.M{}
+	if err := cursor.All(ctx, &items); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_TIERS_IN_MONGODB)
+		return
+	}
+
+	summary, err := tierChangesSummary(ctx, collection, filter)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_TIERS_IN_MONGODB)
+		return
+	}
+
+	response := map[string]any{
+		"items":   items,
+		"summary": summary,
+		"pagination": map[string]any{
+			"page":        page,
+			"page_size":   pageSize,
+			"total_items": totalItems,
+			"total_pages": int64(math.Ceil(float64(totalItems) / float64(pageSize))),
+		},
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", response, 0)
+}
+
+func tierChangesFilter(r *http.Request) bson.D {
+	filter := bson.D{}
+	queryParams := r.URL.Query()
+
+	createdAt := bson.D{}
+	if start, err := time.Parse(time.RFC3339, queryParams.Get("start")); err == nil {
+		createdAt = append(createdAt, bson.E{Key: "$gte", Value: start})
+	}
+	if end, err := time.Parse(time.RFC3339, queryParams.Get("end")); err == nil {
+		createdAt = append(createdAt, bson.E{Key: "$lte", Value: end})
+	}
+	if len(createdAt) > 0 {
+		filter = append(filter, bson.E{Key: "created_at", Value: createdAt})
+	}
+
+	if direction := queryParams.Get("direction"); direction == TIER_CHANGE_UPGRADE || direction == TIER_CHANGE_DOWNGRADE {
+		filter = append(filter, bson.E{Key: "direction", Value: direction})
+	}
+	if reason := queryParams.Get("reason"); reason != "" {
+		filter = append(filter, bson.E{Key: "reason", Value: reason})
+	}
+	if leadID, err := bson.ObjectIDFromHex(queryParams.Get("lead_id")); err == nil {
+		filter = append(filter, bson.E{Key: "lead_id", Value: leadID})
+	}
+	if tierID, err := bson.ObjectIDFromHex(queryParams.Get("tier_id")); err == nil {
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "from.tier_id", Value: tierID}},
+			bson.D{{Key: "to.tier_id", Value: tierID}},
+		}})
+	}
+	return filter
+}
+
+// tierChangesSummary agrupa as trocas do filtro por tier de origem, tier de destino e direção.
+func tierChangesSummary(ctx context.Context, collection *mongo.Collection, filter bson.D) (TierChangesSummary, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "from", Value: "$from.label"},
+				{Key: "to", Value: "$to.label"},
+				{Key: "direction", Value: "$direction"},
+			}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "from", Value: "$_id.from"},
+			{Key: "to", Value: "$_id.to"},
+			{Key: "direction", Value: "$_id.direction"},
+			{Key: "count", Value: 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return TierChangesSummary{}, err
+	}
+
+	summary := TierChangesSummary{Transitions: []TierTransition{}}
+	if err := cursor.All(ctx, &summary.Transitions); err != nil {
+		return TierChangesSummary{}, err
+	}
+	for _, transition := range summary.Transitions {
+		switch transition.Direction {
+		case TIER_CHANGE_UPGRADE:
+			summary.Upgrades += transition.Count
+		case TIER_CHANGE_DOWNGRADE:
+			summary.Downgrades += transition.Count
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,460 @@
+package leads
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	TIMELINE_LEAD_CREATED    = "lead_created"
+	TIMELINE_LEAD_UPDATED    = "lead_updated"
+	TIMELINE_LEAD_MERGED     = "lead_merged"
+	TIMELINE_NOTE            = "note"
+	TIMELINE_FUNNEL          = "funnel"
+	TIMELINE_MESSAGE         = "message"
+	TIMELINE_BUDGET_CREATED  = "budget_created"
+	TIMELINE_BUDGET_APPROVED = "budget_approved"
+	TIMELINE_BUDGET_REJECTED = "budget_rejected"
+	TIMELINE_ORDER_CREATED   = "order_created"
+	TIMELINE_ORDER_STATUS    = "order_status"
+	TIMELINE_TIER_CHANGED    = "tier_changed"
+)
+
+// TimelineEvent é um acontecimento da vida do lead. Ref aponta para o registro de origem e Data traz os
+// detalhes de cada tipo (campos alterados, mensagem, status do pedido etc.).
+type TimelineEvent struct {
+	Type   string        `json:"type"`
+	At     time.Time     `json:"at"`
+	Title  string        `json:"title"`
+	Actor  string        `json:"actor,omitempty"`
+	Source string        `json:"source"`
+	Ref    bson.ObjectID `json:"ref,omitzero"`
+	Data   any           `json:"data,omitempty"`
+}
+
+type timelineQuery struct {
+	types    []string
+	start    time.Time
+	end      time.Time
+	page     int64
+	pageSize int64
+}
+
+func (q timelineQuery) wants(eventType string) bool {
+	return len(q.types) == 0 || slices.Contains(q.types, eventType)
+}
+
+func (q timelineQuery) inRange(at time.Time) bool {
+	return (q.start.IsZero() || !at.Before(q.start)) && (q.end.IsZero() || !at.After(q.end))
+}
+
+// GetTimeline devolve, do mais recente para o mais antigo, tudo o que aconteceu com o lead: criação,
+// alterações de cadastro e observações, mesclagens, movimentações nos funis, mensagens do WhatsApp,
+// orçamentos criados, aprovados ou reprovados e pedidos com suas mudanças de status. Aceita types
+// (lista separada por vírgula), start e end (RFC3339) e a paginação de sempre (page e pageSize).
+func GetTimeline(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_LEAD_ID_FORMAT)
+		return
+	}
+
+	query := timelineQuery{page: 1, pageSize: 25}
+	queryParams := r.URL.Query()
+	if parsed, err := strconv.ParseInt(queryParams.Get("page"), 10, 64); err == nil && parsed > 0 {
+		query.page = parsed
+	}
+	if parsed, err := strconv.ParseInt(queryParams.Get("pageSize"), 10, 64); err == nil && parsed > 0 {
+		query.pageSize = min(parsed, 100)
+	}
+	for _, t := range strings.Split(queryParams.Get("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			query.types = append(query.types, t)
+		}
+	}
+	if parsed, err := time.Parse(time.RFC3339, queryParams.Get("start")); err == nil {
+		query.start = parsed
+	}
+	if parsed, err := time.Parse(time.RFC3339, queryParams.Get("end")); err == nil {
+		query.end = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	lead := schemas.Lead{}
+	if err := db.Collection(database.COLLECTION_LEADS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&lead); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.SendResponse(w, http.StatusNotFound, "Lead não encontrado", nil, 0)
+			return
+		}
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEAD_BY_ID_IN_MONGODB)
+		return
+	}
+
+	events, total, err := leadTimeline(ctx, db, lead, query)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEAD_BY_ID_IN_MONGODB)
+		return
+	}
+
+	skip := min((query.page-1)*query.pageSize, int64(len(events)))
+	end := min(skip+query.pageSize, int64(len(events)))
+
+	response := map[string]any{
+		"items": events[skip:end],
+		"pagination": map[string]any{
+			"page":        query.page,
+			"page_size":   query.pageSize,
+			"total_items": total,
+			"total_pages": int64(math.Ceil(float64(total) / float64(query.pageSize))),
+		},
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", response, 0)
+}
+
+// leadTimeline junta os eventos de todas as fontes, ordenados do mais recente para o mais antigo. As
+// mensagens, que podem ser milhares, vêm só até o fim da página pedida; as demais fontes são pequenas por
+// lead e entram inteiras. Devolve também o total de eventos para a paginação.
+func leadTimeline(ctx context.Context, db *mongo.Database, lead schemas.Lead, query timelineQuery) ([]TimelineEvent, int64, error) {
+	events := []TimelineEvent{}
+	add := func(event TimelineEvent) {
+		if query.wants(event.Type) && query.inRange(event.At) && !event.At.IsZero() {
+			events = append(events, event)
+		}
+	}
+
+	add(TimelineEvent{Type: TIMELINE_LEAD_CREATED, At: lead.CreatedAt, Title: "Lead criado", Source: database.COLLECTION_LEADS, Ref: lead.ID, Data: bson.M{"source": lead.Source}})
+
+	if err := addLeadHistoryEvents(ctx, db, lead.ID, add); err != nil {
+		return nil, 0, err
+	}
+	if err := addFunnelEvents(ctx, db, lead.ID, add); err != nil {
+		return nil, 0, err
+	}
+	if err := addBudgetAndOrderEvents(ctx, db, lead, add); err != nil {
+		return nil, 0, err
+	}
+
+	total := int64(len(events))
+	if query.wants(TIMELINE_MESSAGE) {
+		messages, count, err := messageEvents(ctx, db, lead.ID, query)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, messages...)
+		total += count
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At.After(events[j].At) })
+	return events, total, nil
+}
+
+func addLeadHistoryEvents(ctx context.Context, db *mongo.Database, leadID bson.ObjectID, add func(TimelineEvent)) error {
+	cursor, err := db.Collection(database.COLLECTION_LEADS_HISTORY).Find(ctx, bson.D{{Key: "lead_id", Value: leadID}})
+	if err != nil {
+		return err
+	}
+	history := []schemas.LeadHistory{}
+	if err := cursor.All(ctx, &history); err != nil {
+		return err
+	}
+	for _, entry := range history {
+		changes := []schemas.LeadFieldChange{}
+		for _, change := range entry.Changes {
+			if change.Field == "notes" {
+				add(TimelineEvent{Type: TIMELINE_NOTE, At: entry.CreatedAt, Title: "Observação atualizada", Actor: entry.UserName, Source: database.COLLECTION_LEADS_HISTORY, Ref: entry.ID, Data: bson.M{"notes": change.To}})
+				continue
+			}
+			changes = append(changes, change)
+		}
+		if len(changes) > 0 {
+			fields := []string{}
+			for _, change := range changes {
+				fields = append(fields, change.Field)
+			}
+			add(TimelineEvent{Type: TIMELINE_LEAD_UPDATED, At: entry.CreatedAt, Title: "Cadastro alterado: " + strings.Join(fields, ", "), Actor: entry.UserName, Source: database.COLLECTION_LEADS_HISTORY, Ref: entry.ID, Data: changes})
+		}
+	}
+
+	cursor, err = db.Collection(database.COLLECTION_LEADS_MERGES).Find(ctx, bson.D{{Key: "target", Value: leadID}})
+	if err != nil {
+		return err
+	}
+	merges := []schemas.LeadMerge{}
+	if err := cursor.All(ctx, &merges); err != nil {
+		return err
+	}
+	for _, merge := range merges {
+		names := []string{}
+		for _, source := range merge.Sources {
+			names = append(names, leadLabel(source))
+		}
+		add(TimelineEvent{Type: TIMELINE_LEAD_MERGED, At: merge.CreatedAt, Title: "Mesclado com " + strings.Join(names, ", "), Actor: merge.MergedBy, Source: database.COLLECTION_LEADS_MERGES, Ref: merge.ID, Data: bson.M{"moved": merge.Moved}})
+	}
+
+	cursor, err = db.Collection(database.COLLECTION_LEADS_TIERS_HISTORY).Find(ctx, bson.D{{Key: "lead_id", Value: leadID}})
+	if err != nil {
+		return err
+	}
+	tierChanges := []schemas.LeadTierChange{}
+	if err := cursor.All(ctx, &tierChanges); err != nil {
+		return err
+	}
+	for _, change := range tierChanges {
+		title := "Tier alterado para " + change.To.Label
+		if change.To.TierID.IsZero() {
+			title = "Lead ficou sem tier"
+		}
+		add(TimelineEvent{Type: TIMELINE_TIER_CHANGED, At: change.CreatedAt, Title: title, Source: database.COLLECTION_LEADS_TIERS_HISTORY, Ref: change.ID, Data: bson.M{"from": change.From, "to": change.To, "direction": change.Direction}})
+	}
+	return nil
+}
+
+func addFunnelEvents(ctx context.Context, db *mongo.Database, leadID bson.ObjectID, add func(TimelineEvent)) error {
+	cursor, err := db.Collection(database.COLLECTION_FUNNELS_HISTORY).Find(ctx, bson.D{{Key: "related_lead", Value: leadID}})
+	if err != nil {
+		return err
+	}
+	history := []schemas.FunnelsHistory{}
+	if err := cursor.All(ctx, &history); err != nil {
+		return err
+	}
+	for _, entry := range history {
+		add(TimelineEvent{Type: TIMELINE_FUNNEL, At: entry.CreatedAt, Title: entry.Action, Actor: entry.RelatedUserName, Source: database.COLLECTION_FUNNELS_HISTORY, Ref: entry.ID, Data: bson.M{"funnel": entry.RelatedFunnel}})
+	}
+	return nil
+}
+
+// addBudgetAndOrderEvents busca os orçamentos e pedidos do lead, inclusive os pedidos gerados a partir dos
+// seus orçamentos, e resolve o nome de quem criou, aprovou ou movimentou cada um.
+func addBudgetAndOrderEvents(ctx context.Context, db *mongo.Database, lead schemas.Lead, add func(TimelineEvent)) error {
+	budgetFilter := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "related_lead", Value: lead.ID}},
+		bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: nonNilIDs(lead.RelatedBudgets)}}}},
+	}}}
+	budgetOpts := options.Find().SetProjection(bson.D{{Key: "items", Value: 0}, {Key: "old_products_list", Value: 0}})
+	cursor, err := db.Collection(database.COLLECTION_BUDGETS).Find(ctx, budgetFilter, budgetOpts)
+	if err != nil {
+		return err
+	}
+	budgets := []schemas.Budget{}
+	if err := cursor.All(ctx, &budgets); err != nil {
+		return err
+	}
+
+	budgetIDs := []bson.ObjectID{}
+	for _, budget := range budgets {
+		budgetIDs = append(budgetIDs, budget.ID)
+	}
+	orderFilter := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: nonNilIDs(lead.RelatedOrders)}}}},
+		bson.D{{Key: "related_budget", Value: bson.D{{Key: "$in", Value: budgetIDs}}}},
+	}}}
+	orderOpts := options.Find().SetProjection(bson.D{
+		{Key: "old_id", Value: 1}, {Key: "status", Value: 1}, {Key: "stage", Value: 1}, {Key: "created_by", Value: 1},
+		{Key: "related_seller", Value: 1}, {Key: "related_budget", Value: 1}, {Key: "created_at", Value: 1}, {Key: "history", Value: 1},
+	})
+	cursor, err = db.Collection(database.COLLECTION_ORDERS).Find(ctx, orderFilter, orderOpts)
+	if err != nil {
+		return err
+	}
+	orders := []schemas.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return err
+	}
+
+	userIDs := []bson.ObjectID{}
+	for _, budget := range budgets {
+		userIDs = append(userIDs, budget.CreatedBy, budget.ApprovedBy, budget.RejectedBy)
+	}
+	for _, order := range orders {
+		userIDs = append(userIDs, order.CreatedBy)
+		for _, transition := range order.History {
+			userIDs = append(userIDs, transition.UserID)
+		}
+	}
+	names, err := userNames(ctx, db, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, budget := range budgets {
+		label := fmt.Sprintf("Orçamento %d", budget.OldID)
+		if budget.OldID == 0 {
+			label = "Orçamento " + budget.ID.Hex()
+		}
+		data := bson.M{"budget_id": budget.ID, "approval_status": budget.ApprovalStatus}
+		if budget.Totals != nil {
+			data["totals"] = budget.Totals
+		}
+		add(TimelineEvent{Type: TIMELINE_BUDGET_CREATED, At: budget.CreatedAt, Title: label + " criado", Actor: names[budget.CreatedBy], Source: database.COLLECTION_BUDGETS, Ref: budget.ID, Data: data})
+		if !budget.ApprovedAt.IsZero() {
+			add(TimelineEvent{Type: TIMELINE_BUDGET_APPROVED, At: budget.ApprovedAt, Title: label + " aprovado", Actor: names[budget.ApprovedBy], Source: database.COLLECTION_BUDGETS, Ref: budget.ID})
+		}
+		if !budget.RejectedAt.IsZero() {
+			add(TimelineEvent{Type: TIMELINE_BUDGET_REJECTED, At: budget.RejectedAt, Title: label + " reprovado", Actor: names[budget.RejectedBy], Source: database.COLLECTION_BUDGETS, Ref: budget.ID, Data: bson.M{"reason": budget.RejectionReason}})
+		}
+	}
+
+	for _, order := range orders {
+		label := fmt.Sprintf("Pedido %d", order.OldID)
+		if order.OldID == 0 {
+			label = "Pedido " + order.ID.Hex()
+		}
+		add(TimelineEvent{Type: TIMELINE_ORDER_CREATED, At: order.CreatedAt, Title: label + " criado", Actor: names[order.CreatedBy], Source: database.COLLECTION_ORDERS, Ref: order.ID, Data: bson.M{"related_budget": order.RelatedBudget}})
+		for _, transition := range order.History {
+			add(TimelineEvent{
+				Type:   TIMELINE_ORDER_STATUS,
+				At:     transition.At,
+				Title:  fmt.Sprintf("%s: %s", label, transition.ToStatus),
+				Actor:  names[transition.UserID],
+				Source: database.COLLECTION_ORDERS,
+				Ref:    order.ID,
+				Data:   transition,
+			})
+		}
+	}
+	return nil
+}
+
+// messageEvents lê as mensagens dos chats do lead, apenas as necessárias para montar a página pedida,
+// e devolve também o total de mensagens no período.
+func messageEvents(ctx context.Context, db *mongo.Database, leadID bson.ObjectID, query timelineQuery) ([]TimelineEvent, int64, error) {
+	chatIDs := []bson.ObjectID{}
+	cursor, err := db.Collection(database.COLLECTION_SPACE_DESK_CHAT).Find(ctx, bson.D{{Key: "lead_id", Value: leadID}}, options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, 0, err
+	}
+	for cursor.Next(ctx) {
+		chat := struct {
+			ID bson.ObjectID `bson:"_id"`
+		}{}
+		if err := cursor.Decode(&chat); err == nil {
+			chatIDs = append(chatIDs, chat.ID)
+		}
+	}
+	cursor.Close(ctx)
+	if len(chatIDs) == 0 {
+		return nil, 0, nil
+	}
+
+	filter := bson.D{{Key: "chat_id", Value: bson.D{{Key: "$in", Value: chatIDs}}}}
+	dateRange := bson.D{}
+	if !query.start.IsZero() {
+		dateRange = append(dateRange, bson.E{Key: "$gte", Value: query.start})
+	}
+	if !query.end.IsZero() {
+		dateRange = append(dateRange, bson.E{Key: "$lte", Value: query.end})
+	}
+	if len(dateRange) > 0 {
+		filter = append(filter, bson.E{Key: "created_at", Value: dateRange})
+	}
+
+	collection := db.Collection(database.COLLECTION_SPACE_DESK_MESSAGE)
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOpts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(query.page * query.pageSize).
+		SetProjection(bson.D{{Key: "chat_id", Value: 1}, {Key: "type", Value: 1}, {Key: "body", Value: 1}, {Key: "from", Value: 1}, {Key: "user", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: 1}})
+	cursor, err = collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, 0, err
+	}
+	messages := []struct {
+		ID        bson.ObjectID `bson:"_id"`
+		ChatID    bson.ObjectID `bson:"chat_id"`
+		Type      string        `bson:"type"`
+		Body      string        `bson:"body"`
+		From      string        `bson:"from"`
+		User      string        `bson:"user"`
+		Status    string        `bson:"status"`
+		CreatedAt time.Time     `bson:"created_at"`
+	}{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, 0, err
+	}
+
+	events := []TimelineEvent{}
+	for _, message := range messages {
+		at := message.CreatedAt
+		if at.IsZero() {
+			at = message.ID.Timestamp()
+		}
+		direction, title := "inbound", "Mensagem recebida"
+		if message.From == "company" {
+			direction, title = "outbound", "Mensagem enviada"
+		}
+		events = append(events, TimelineEvent{
+			Type:   TIMELINE_MESSAGE,
+			At:     at,
+			Title:  title,
+			Actor:  message.User,
+			Source: database.COLLECTION_SPACE_DESK_MESSAGE,
+			Ref:    message.ID,
+			Data:   bson.M{"chat_id": message.ChatID, "direction": direction, "message_type": message.Type, "body": message.Body, "status": message.Status},
+		})
+	}
+	return events, total, nil
+}
+
+func userNames(ctx context.Context, db *mongo.Database, ids []bson.ObjectID) (map[bson.ObjectID]string, error) {
+	names := map[bson.ObjectID]string{}
+	ids = nonNilIDs(ids)
+	if len(ids) == 0 {
+		return names, nil
+	}
+	cursor, err := db.Collection(database.COLLECTION_USERS).Find(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}}, options.Find().SetProjection(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	users := []schemas.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		names[user.ID] = user.Name
+	}
+	return names, nil
+}
+
+func nonNilIDs(ids []bson.ObjectID) []bson.ObjectID {
+	result := []bson.ObjectID{}
+	for _, id := range ids {
+		if !id.IsZero() && !slices.Contains(result, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package leads
+
+import (
+	"api/database"
+	"api/schemas"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// recordLeadChanges grava em leads_history os campos do $set que de fato mudaram em relação ao lead antes
+// da atualização. Falhas só são logadas para não desfazer uma atualização já feita.
+func recordLeadChanges(ctx context.Context, db *mongo.Database, leadID bson.ObjectID, before bson.M, set bson.D, userName string) {
+	changes := []schemas.LeadFieldChange{}
+	for _, field := range set {
+		if field.Key == "updated_at" {
+			continue
+		}
+		previous := before[field.Key]
+		if sameValue(previous, field.Value) {
+			continue
+		}
+		changes = append(changes, schemas.LeadFieldChange{Field: field.Key, From: previous, To: field.Value})
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	history := schemas.LeadHistory{LeadID: leadID, UserName: userName, Changes: changes, CreatedAt: time.Now()}
+	if _, err := db.Collection(database.COLLECTION_LEADS_HISTORY).InsertOne(ctx, history); err != nil {
+		log.Printf("[LeadHistory] Erro ao registrar as alterações do lead %s: %v", leadID.Hex(), err)
+	}
+}
+
+// sameValue compara o valor gravado com o novo pela representação BSON, já que o documento lido do banco
+// traz bson.A onde a atualização tem slices tipados.
+func sameValue(previous, next any) bool {
+	if previous == nil || next == nil {
+		return previous == nil && (next == nil || fmt.Sprint(next) == "" || fmt.Sprint(next) == "[]")
+	}
+	a, errA := bson.Marshal(bson.D{{Key: "v", Value: previous}})
+	b, errB := bson.Marshal(bson.D{{Key: "v", Value: next}})
+	if errA != nil || errB != nil {
+		return fmt.Sprint(previous) == fmt.Sprint(next)
+	}
+	return string(a) == string(b)
+}
@@ -0,0 +1,613 @@
+package leads
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	LEAD_IMPORT_MAX_FILE_SIZE = 10 << 20
+	LEAD_IMPORT_MAX_ROWS      = 20000
+	LEAD_IMPORT_TIMEOUT       = 30 * time.Minute
+
+	// O progresso é gravado a cada tantas linhas processadas
+	LEAD_IMPORT_PROGRESS_EVERY = 100
+
+	LEAD_IMPORT_PENDING = "pending"
+	LEAD_IMPORT_RUNNING = "running"
+	LEAD_IMPORT_DONE    = "done"
+	LEAD_IMPORT_FAILED  = "failed"
+
+	LEAD_IMPORT_DEFAULT_SOURCE = "Importação"
+)
+
+// leadImportMutex faz as importações rodarem uma de cada vez; as demais esperam como pending
+var leadImportMutex sync.Mutex
+
+// Campos do lead que podem ser mapeados e os cabeçalhos reconhecidos quando o mapeamento não é enviado
+var leadImportFields = map[string][]string{
+	"name":        {"name", "nome", "nome completo", "cliente", "contato"},
+	"phone":       {"phone", "telefone", "celular", "whatsapp", "fone", "telefone celular"},
+	"segment":     {"segment", "segmento", "ramo", "empresa"},
+	"source":      {"source", "origem", "fonte", "canal"},
+	"responsible": {"responsible", "responsavel", "vendedor", "atendente", "consultor"},
+}
+
+// CreateImport recebe um CSV ou XLSX no campo "file" e importa os leads em segundo plano. O campo
+// "mapping" (JSON) liga os campos do lead às colunas da planilha, pelo nome do cabeçalho ou pela letra da
+// coluna; sem ele, as colunas são reconhecidas pelo cabeçalho. "source" é a origem das linhas sem origem.
+// Responde com a importação criada, que deve ser acompanhada em GET /v1/leads/imports/{id}.
+func CreateImport(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, LEAD_IMPORT_MAX_FILE_SIZE+1<<20)
+	if err := r.ParseMultipartForm(LEAD_IMPORT_MAX_FILE_SIZE); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "Envie a planilha no campo 'file' com até 10 MB", nil, utils.LEADS_INVALID_REQUEST_DATA)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "Arquivo não encontrado", nil, utils.LEADS_INVALID_REQUEST_DATA)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.LEADS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	rows, err := utils.ReadSpreadsheet(header.Filename, data)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.LEADS_INVALID_REQUEST_DATA)
+		return
+	}
+	if len(rows) < 2 {
+		utils.SendResponse(w, http.StatusBadRequest, "A planilha não tem linhas além do cabeçalho", nil, 0)
+		return
+	}
+	if len(rows)-1 > LEAD_IMPORT_MAX_ROWS {
+		utils.SendResponse(w, http.StatusBadRequest, fmt.Sprintf("A planilha pode ter no máximo %d linhas", LEAD_IMPORT_MAX_ROWS), nil, 0)
+		return
+	}
+
+	mapping := map[string]string{}
+	if raw := r.FormValue("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			utils.SendResponse(w, http.StatusBadRequest, "Mapeamento inválido", nil, utils.LEADS_INVALID_REQUEST_DATA)
+			return
+		}
+	} else {
+		mapping = guessImportMapping(rows[0])
+	}
+	columns, message := importColumns(rows[0], mapping)
+	if message != "" {
+		utils.SendResponse(w, http.StatusBadRequest, message, nil, utils.LEADS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	fileID, err := leadImportsBucket(db).UploadFromStream(ctx, header.Filename, bytes.NewReader(data))
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_INSERT_LEAD_TO_MONGODB)
+		return
+	}
+	leadImport := schemas.LeadImport{
+		FileName:      header.Filename,
+		FileID:        fileID,
+		Mapping:       mapping,
+		DefaultSource: strings.TrimSpace(r.FormValue("source")),
+		Status:        LEAD_IMPORT_PENDING,
+		TotalRows:     len(rows) - 1,
+		Errors:        []schemas.LeadImportRowError{},
+		CreatedAt:     time.Now(),
+	}
+	if leadImport.DefaultSource == "" {
+		leadImport.DefaultSource = LEAD_IMPORT_DEFAULT_SOURCE
+	}
+	if user, err := middlewares.CurrentUser(ctx, db, r); err == nil {
+		leadImport.CreatedBy = user.Name
+	}
+
+	result, err := db.Collection(database.COLLECTION_LEADS_IMPORTS).InsertOne(ctx, leadImport)
+	if err != nil {
+		_ = leadImportsBucket(db).Delete(ctx, fileID)
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_INSERT_LEAD_TO_MONGODB)
+		return
+	}
+	leadImport.ID = result.InsertedID.(bson.ObjectID)
+
+	go runLeadImport(leadImport, rows[1:], columns)
+
+	utils.SendResponse(w, http.StatusAccepted, "Importação iniciada", leadImport, 0)
+}
+
+func leadImportsBucket(db *mongo.Database) *mongo.GridFSBucket {
+	return db.GridFSBucket(options.GridFSBucket().SetName(database.GRIDFS_BUCKET_LEADS_IMPORTS))
+}
+
+// guessImportMapping reconhece as colunas pelo cabeçalho, comparado como nomes (sem acentos nem pontuação).
+func guessImportMapping(header []string) map[string]string {
+	mapping := map[string]string{}
+	for _, column := range header {
+		key := normalizeName(column)
+		for field, names := range leadImportFields {
+			if _, ok := mapping[field]; ok {
+				continue
+			}
+			for _, name := range names {
+				if key == name {
+					mapping[field] = column
+				}
+			}
+		}
+	}
+	return mapping
+}
+
+// importColumns resolve o mapeamento para os índices das colunas. Devolve a mensagem para o usuário quando
+// o mapeamento usa um campo desconhecido, uma coluna que não existe ou não tem o telefone.
+func importColumns(header []string, mapping map[string]string) (map[string]int, string) {
+	columns := map[string]int{}
+	for field, column := range mapping {
+		if _, ok := leadImportFields[field]; !ok {
+			return nil, fmt.Sprintf("Campo '%s' não pode ser importado. Use name, phone, segment, source ou responsible", field)
+		}
+		index := columnIndex(header, column)
+		if index < 0 {
+			return nil, fmt.Sprintf("Coluna '%s' não encontrada na planilha", column)
+		}
+		columns[field] = index
+	}
+	if _, ok := columns["phone"]; !ok {
+		return nil, "Informe a coluna do telefone no mapeamento"
+	}
+	return columns, ""
+}
+
+// columnIndex procura a coluna pelo cabeçalho e, sem cabeçalho igual, pela letra da coluna (A, B, ..., AA).
+func columnIndex(header []string, column string) int {
+	key := normalizeName(column)
+	if key == "" {
+		return -1
+	}
+	for i, name := range header {
+		if normalizeName(name) == key {
+			return i
+		}
+	}
+	letters := strings.ToUpper(strings.TrimSpace(column))
+	index := 0
+	for _, c := range letters {
+		if c < 'A' || c > 'Z' {
+			return -1
+		}
+		index = index*26 + int(c-'A'+1)
+	}
+	if index-1 >= len(header) {
+		return -1
+	}
+	return index - 1
+}
+
+// runLeadImport processa as linhas de uma importação a partir das já processadas, gravando o progresso a
+// cada LEAD_IMPORT_PROGRESS_EVERY linhas e o relatório final. Uma falha geral, inclusive um panic, marca a
+// importação como failed com as linhas já processadas.
+func runLeadImport(leadImport schemas.LeadImport, rows [][]string, columns map[string]int) {
+	leadImportMutex.Lock()
+	defer leadImportMutex.Unlock()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[LeadImport] Panic na importação %s: %v", leadImport.ID.Hex(), r)
+			failLeadImport(leadImport.ID, fmt.Errorf("erro inesperado: %v", r))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), LEAD_IMPORT_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		log.Printf("[LeadImport] Erro ao conectar para a importação %s: %v", leadImport.ID.Hex(), err)
+		failLeadImport(leadImport.ID, err)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	imports := db.Collection(database.COLLECTION_LEADS_IMPORTS)
+
+	leadImport.Status = LEAD_IMPORT_RUNNING
+	if leadImport.StartedAt.IsZero() {
+		leadImport.StartedAt = time.Now()
+	}
+	saveProgress := func() {
+		_, err := imports.UpdateByID(ctx, leadImport.ID, bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: leadImport.Status},
+			{Key: "processed_rows", Value: leadImport.ProcessedRows},
+			{Key: "created", Value: leadImport.Created},
+			{Key: "duplicates", Value: leadImport.Duplicates},
+			{Key: "failed", Value: leadImport.Failed},
+			{Key: "errors", Value: leadImport.Errors},
+			{Key: "error", Value: leadImport.Error},
+			{Key: "started_at", Value: leadImport.StartedAt},
+			{Key: "finished_at", Value: leadImport.FinishedAt},
+		}}})
+		if err != nil {
+			log.Printf("[LeadImport] Erro ao gravar o progresso da importação %s: %v", leadImport.ID.Hex(), err)
+		}
+	}
+	saveProgress()
+
+	err = importLeadRows(ctx, db, &leadImport, rows, columns, func() {
+		if leadImport.ProcessedRows%LEAD_IMPORT_PROGRESS_EVERY == 0 {
+			saveProgress()
+		}
+	})
+
+	leadImport.Status = LEAD_IMPORT_DONE
+	if err != nil {
+		log.Printf("[LeadImport] Erro na importação %s: %v", leadImport.ID.Hex(), err)
+		leadImport.Status = LEAD_IMPORT_FAILED
+		leadImport.Error = err.Error()
+	}
+	leadImport.FinishedAt = time.Now()
+	saveProgress()
+}
+
+// failLeadImport marca a importação como failed quando não dá para usar a conexão da própria importação.
+// Se nem assim for possível gravar, a importação fica como está e é retomada na próxima subida.
+func failLeadImport(id bson.ObjectID, cause error) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err == nil {
+		defer mongoClient.Disconnect(ctx)
+		_, err = mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_LEADS_IMPORTS).UpdateByID(ctx, id, bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: LEAD_IMPORT_FAILED},
+			{Key: "error", Value: cause.Error()},
+			{Key: "finished_at", Value: time.Now()},
+		}}})
+	}
+	if err != nil {
+		log.Printf("[LeadImport] Erro ao marcar a importação %s como failed: %v", id.Hex(), err)
+	}
+}
+
+// StartLeadImports retoma, na subida do servidor, as importações que ficaram pending ou running. A planilha
+// é relida do GridFS e o processamento continua do último progresso gravado; as linhas processadas depois
+// dele aparecem como duplicadas dos leads que elas mesmas criaram. Sem a planilha, a importação falha.
+func StartLeadImports() {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[LeadImport] Panic ao retomar as importações: %v", r)
+			}
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+		defer cancel()
+
+		mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+		if err != nil {
+			log.Printf("[LeadImport] Erro ao conectar para retomar as importações: %v", err)
+			return
+		}
+		defer mongoClient.Disconnect(ctx)
+
+		db := mongoClient.Database(database.GetDB())
+		cursor, err := db.Collection(database.COLLECTION_LEADS_IMPORTS).Find(ctx,
+			bson.D{{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{LEAD_IMPORT_PENDING, LEAD_IMPORT_RUNNING}}}}},
+			options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+		)
+		if err != nil {
+			log.Printf("[LeadImport] Erro ao buscar as importações interrompidas: %v", err)
+			return
+		}
+		interrupted := []schemas.LeadImport{}
+		if err := cursor.All(ctx, &interrupted); err != nil {
+			log.Printf("[LeadImport] Erro ao buscar as importações interrompidas: %v", err)
+			return
+		}
+
+		for _, leadImport := range interrupted {
+			rows, columns, err := loadLeadImportRows(ctx, db, leadImport)
+			if err != nil {
+				log.Printf("[LeadImport] Importação %s não pode ser retomada: %v", leadImport.ID.Hex(), err)
+				failLeadImport(leadImport.ID, fmt.Errorf("importação interrompida e não retomada: %w", err))
+				continue
+			}
+			log.Printf("[LeadImport] Retomando a importação %s da linha %d", leadImport.ID.Hex(), leadImport.ProcessedRows+2)
+			go runLeadImport(leadImport, rows, columns)
+		}
+	}()
+}
+
+// loadLeadImportRows relê a planilha guardada da importação e resolve o mapeamento gravado.
+func loadLeadImportRows(ctx context.Context, db *mongo.Database, leadImport schemas.LeadImport) ([][]string, map[string]int, error) {
+	if leadImport.FileID.IsZero() {
+		return nil, nil, errors.New("planilha não foi guardada")
+	}
+	stream, err := leadImportsBucket(db).OpenDownloadStream(ctx, leadImport.FileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer stream.Close()
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := utils.ReadSpreadsheet(leadImport.FileName, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) < 2 {
+		return nil, nil, errors.New("a planilha não tem linhas além do cabeçalho")
+	}
+	columns, message := importColumns(rows[0], leadImport.Mapping)
+	if message != "" {
+		return nil, nil, errors.New(message)
+	}
+	return rows[1:], columns, nil
+}
+
+type leadImportRow struct {
+	number int
+	values map[string]string
+}
+
+func (row leadImportRow) fail(field, message string) schemas.LeadImportRowError {
+	return schemas.LeadImportRowError{Row: row.number, Field: field, Value: row.values[field], Message: message}
+}
+
+// importLeadRows valida cada linha ainda não processada e cria os leads. Telefones inválidos, responsáveis desconhecidos e
+// telefones já cadastrados (em outro lead ou em uma linha anterior da planilha) entram no relatório.
+func importLeadRows(ctx context.Context, db *mongo.Database, leadImport *schemas.LeadImport, rows [][]string, columns map[string]int, progress func()) error {
+	collection := db.Collection(database.COLLECTION_LEADS)
+	responsibles, err := responsibleIndex(ctx, db)
+	if err != nil {
+		return err
+	}
+	seen := map[string]int{}
+
+	for i := leadImport.ProcessedRows; i < len(rows); i++ {
+		values := rows[i]
+		row := leadImportRow{number: i + 2, values: map[string]string{}}
+		empty := true
+		for field, column := range columns {
+			if column < len(values) {
+				row.values[field] = strings.TrimSpace(values[column])
+				empty = empty && row.values[field] == ""
+			}
+		}
+
+		rowErr, err := importLeadRow(ctx, collection, leadImport, row, responsibles, seen, empty)
+		if err != nil {
+			return err
+		}
+		if rowErr != nil {
+			leadImport.Errors = append(leadImport.Errors, *rowErr)
+		}
+		leadImport.ProcessedRows++
+		progress()
+	}
+	return nil
+}
+
+func importLeadRow(ctx context.Context, collection *mongo.Collection, leadImport *schemas.LeadImport, row leadImportRow, responsibles map[string]bson.ObjectID, seen map[string]int, empty bool) (*schemas.LeadImportRowError, error) {
+	// Linhas em branco no meio da planilha são ignoradas sem erro
+	if empty {
+		return nil, nil
+	}
+
+	fail := func(rowErr schemas.LeadImportRowError) (*schemas.LeadImportRowError, error) {
+		leadImport.Failed++
+		return &rowErr, nil
+	}
+	duplicate := func(rowErr schemas.LeadImportRowError) (*schemas.LeadImportRowError, error) {
+		leadImport.Duplicates++
+		return &rowErr, nil
+	}
+
+	if row.values["phone"] == "" {
+		return fail(row.fail("phone", "Telefone não informado"))
+	}
+	phone, ok := utils.NormalizePhone(row.values["phone"])
+	if !ok {
+		return fail(row.fail("phone", "Telefone inválido"))
+	}
+
+	responsible := bson.ObjectID{}
+	if value := row.values["responsible"]; value != "" {
+		id, ok := responsibles[normalizeName(value)]
+		if !ok {
+			return fail(row.fail("responsible", "Responsável não encontrado"))
+		}
+		responsible = id
+	}
+
+	key := utils.PhoneKey(phone)
+	if previous, ok := seen[key]; ok {
+		return duplicate(row.fail("phone", fmt.Sprintf("Telefone repetido na linha %d", previous)))
+	}
+	seen[key] = row.number
+
+	existing, err := FindLeadByPhone(ctx, collection, phone, bson.ObjectID{})
+	if err == nil {
+		rowErr := row.fail("phone", "Telefone já cadastrado em outro lead")
+		rowErr.LeadID = existing.ID
+		return duplicate(rowErr)
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	lead := schemas.Lead{
+		Name:        row.values["name"],
+		Phone:       phone,
+		Segment:     row.values["segment"],
+		Source:      row.values["source"],
+		Responsible: responsible,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if lead.Source == "" {
+		lead.Source = leadImport.DefaultSource
+	}
+	result, err := collection.InsertOne(ctx, lead)
+	if err != nil {
+		return nil, err
+	}
+	if id, ok := result.InsertedID.(bson.ObjectID); ok {
+		QueueTierRecompute(TIER_REASON_CREATED, id)
+	}
+	leadImport.Created++
+	return nil, nil
+}
+
+// responsibleIndex liga o nome, o e-mail e o ID de cada usuário ao seu ID, para a coluna de responsável.
+func responsibleIndex(ctx context.Context, db *mongo.Database) (map[string]bson.ObjectID, error) {
+	projection := bson.D{{Key: "name", Value: 1}, {Key: "email", Value: 1}}
+	cursor, err := db.Collection(database.COLLECTION_USERS).Find(ctx, bson.D{}, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+	users := []schemas.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	index := map[string]bson.ObjectID{}
+	for _, user := range users {
+		for _, key := range []string{user.Name, user.Email, user.ID.Hex()} {
+			if key = normalizeName(key); key != "" {
+				index[key] = user.ID
+			}
+		}
+	}
+	return index, nil
+}
+
+// GetImport devolve a importação com o progresso e o relatório das linhas com erro.
+func GetImport(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_LEAD_ID_FORMAT)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	leadImport := schemas.LeadImport{}
+	err = mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_LEADS_IMPORTS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&leadImport)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		utils.SendResponse(w, http.StatusNotFound, "Importação não encontrada", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_IN_MONGODB)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", leadImport, 0)
+}
+
+// GetAllImports lista as importações, das mais recentes para as mais antigas, sem o relatório de erros.
+func GetAllImports(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	page := int64(1)
+	pageSize := int64(25)
+	if parsedPage, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64); err == nil && parsedPage > 0 {
+		page = parsedPage
+	}
+	if parsedPageSize, err := strconv.ParseInt(r.URL.Query().Get("pageSize"), 10, 64); err == nil && parsedPageSize > 0 {
+		pageSize = min(parsedPageSize, 100)
+	}
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_LEADS_IMPORTS)
+	totalItems, err := collection.CountDocuments(ctx, bson.D{})
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_IN_MONGODB)
+		return
+	}
+
+	findOpts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip((page - 1) * pageSize).
+		SetLimit(pageSize).
+		SetProjection(bson.D{{Key: "errors", Value: 0}})
+	cursor, err := collection.Find(ctx, bson.D{}, findOpts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_IN_MONGODB)
+		return
+	}
+	imports := []schemas.LeadImport{}
+	if err := cursor.All(ctx, &imports); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_IN_MONGODB)
+		return
+	}
+
+	response := map[string]any{
+		"items": imports,
+		"pagination": map[string]any{
+			"page":        page,
+			"page_size":   pageSize,
+			"total_items": totalItems,
+			"total_pages": int64(math.Ceil(float64(totalItems) / float64(pageSize))),
+		},
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", response, 0)
+}
@@ -0,0 +1,80 @@
+package leads
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestGuessImportMapping(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []string
+		want   map[string]string
+	}{
+		{
+			name:   "cabeçalhos em português com acentos e maiúsculas",
+			header: []string{"Nome Completo", "Telefone Celular", "Responsável", "Origem", "Ramo"},
+			want: map[string]string{
+				"name":        "Nome Completo",
+				"phone":       "Telefone Celular",
+				"responsible": "Responsável",
+				"source":      "Origem",
+				"segment":     "Ramo",
+			},
+		},
+		{
+			name:   "fica com a primeira coluna reconhecida de cada campo",
+			header: []string{"WhatsApp", "Telefone", "Cliente", "Nome"},
+			want:   map[string]string{"phone": "WhatsApp", "name": "Cliente"},
+		},
+		{
+			name:   "colunas desconhecidas são ignoradas",
+			header: []string{"E-mail", " fone ", "Observações"},
+			want:   map[string]string{"phone": " fone "},
+		},
+		{
+			name:   "sem cabeçalho reconhecido",
+			header: []string{"A", "B"},
+			want:   map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := guessImportMapping(tt.header); !maps.Equal(got, tt.want) {
+				t.Fatalf("guessImportMapping() = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	header := []string{"Nome", "Telefone", "Origem"}
+	wide := make([]string, 30)
+	wide[0] = "Nome"
+
+	tests := []struct {
+		name   string
+		header []string
+		column string
+		want   int
+	}{
+		{"pelo cabeçalho", header, "Telefone", 1},
+		{"cabeçalho sem diferenciar acentos e maiúsculas", header, " ORIGEM ", 2},
+		{"pela letra", header, "A", 0},
+		{"letra minúscula", header, "c", 2},
+		{"cabeçalho tem prioridade sobre a letra", []string{"B", "A"}, "A", 1},
+		{"letra além das colunas", header, "D", -1},
+		{"duas letras", wide, "AB", 27},
+		{"coluna inexistente", header, "Cidade", -1},
+		{"vazia", header, " ", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := columnIndex(tt.header, tt.column); got != tt.want {
+				t.Fatalf("columnIndex(%q) = %d, esperado %d", tt.column, got, tt.want)
+			}
+		})
+	}
+}
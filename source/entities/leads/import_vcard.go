@@ -0,0 +1,156 @@
+package leads
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// VCardImportContact é o resultado da importação de um contato do arquivo.
+type VCardImportContact struct {
+	Name  string        `json:"name"`
+	Phone string        `json:"phone,omitempty"`
+	ID    bson.ObjectID `json:"id,omitempty"`
+	Error string        `json:"error,omitempty"`
+}
+
+type VCardImportResult struct {
+	Created []VCardImportContact `json:"created"`
+	Skipped []VCardImportContact `json:"skipped"`
+	Invalid []VCardImportContact `json:"invalid"`
+	Failed  []VCardImportContact `json:"failed"`
+}
+
+// ImportVCard cria leads a partir de um arquivo .vcf enviado no campo "file" ou no corpo da requisição.
+// Cada contato tem seu próprio prazo no banco, então arquivos grandes não param no meio; a resposta
+// lista os contatos criados, os já existentes, os sem telefone válido e os que falharam.
+func ImportVCard(w http.ResponseWriter, r *http.Request) {
+	var reader io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.LEADS_INVALID_REQUEST_DATA)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			utils.SendResponse(w, http.StatusBadRequest, "Arquivo não encontrado", nil, utils.LEADS_INVALID_REQUEST_DATA)
+			return
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	cards, err := utils.ParseVCards(reader)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "Arquivo vCard inválido: "+err.Error(), nil, 0)
+		return
+	}
+	if len(cards) == 0 {
+		utils.SendResponse(w, http.StatusBadRequest, "Nenhum contato encontrado no arquivo", nil, 0)
+		return
+	}
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(context.Background())
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_LEADS)
+
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		source = "vCard"
+	}
+
+	result := VCardImportResult{
+		Created: []VCardImportContact{},
+		Skipped: []VCardImportContact{},
+		Invalid: []VCardImportContact{},
+		Failed:  []VCardImportContact{},
+	}
+
+	for _, card := range cards {
+		contact := VCardImportContact{Name: card.FormattedName}
+		if contact.Name == "" {
+			contact.Name = strings.TrimSpace(card.FirstName + " " + card.LastName)
+		}
+
+		contact.Phone = vCardPhone(card.Phones)
+		if contact.Phone == "" {
+			result.Invalid = append(result.Invalid, contact)
+			continue
+		}
+
+		created, err := importVCardContact(collection, card, contact, source)
+		switch {
+		case err != nil:
+			contact.Error = err.Error()
+			result.Failed = append(result.Failed, contact)
+		case created.ID.IsZero():
+			result.Skipped = append(result.Skipped, contact)
+		default:
+			result.Created = append(result.Created, created)
+		}
+	}
+
+	utils.SendResponse(w, http.StatusCreated, "", result, 0)
+}
+
+// importVCardContact cria o lead do contato, com um prazo só dele. Devolve o contato sem ID quando já existe
+// um lead com o telefone.
+func importVCardContact(collection *mongo.Collection, card utils.VCard, contact VCardImportContact, source string) (VCardImportContact, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	_, err := FindLeadByPhone(ctx, collection, contact.Phone, bson.ObjectID{})
+	if err == nil {
+		return contact, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return contact, errors.New("não foi possível consultar os leads")
+	}
+
+	lead := schemas.Lead{
+		Name:      contact.Name,
+		Phone:     contact.Phone,
+		Segment:   card.Org,
+		Notes:     card.Note,
+		Source:    source,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	insertResult, err := collection.InsertOne(ctx, lead)
+	if err != nil {
+		return contact, errors.New("não foi possível criar o lead")
+	}
+	contact.ID = insertResult.InsertedID.(bson.ObjectID)
+	return contact, nil
+}
+
+// vCardPhone devolve, normalizado, o primeiro telefone válido do contato; o wa_id tem preferência sobre o
+// número digitado.
+func vCardPhone(phones []utils.VCardPhone) string {
+	for _, p := range phones {
+		for _, number := range []string{p.WaID, p.Number} {
+			if normalized, ok := utils.NormalizePhone(number); ok {
+				return normalized
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,375 @@
+package leads
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// leadsAdminRoles podem mesclar leads e rodar as manutenções em massa.
+var leadsAdminRoles = []string{schemas.USERS_ROLE_SUPER_ADMIN, schemas.USERS_ROLE_IT, schemas.USERS_ROLE_ADMIN}
+
+var (
+	errMergeLeadNotFound    = errors.New("lead não encontrado")
+	errMergeClientsConflict = errors.New("os leads estão vinculados a clientes diferentes")
+)
+
+// Coleções que apontam para o lead por lead_id e passam a apontar para o lead mantido na mesclagem.
+var leadReferences = []string{
+	database.COLLECTION_SPACE_DESK_CHAT,
+	database.COLLECTION_SPACE_DESK_INTERACTIVE,
+	database.COLLECTION_SPACE_DESK_INTERACTIVE_RES,
+	database.COLLECTION_SPACE_DESK_SURVEYS,
+	database.COLLECTION_LEADS_HISTORY,
+	database.COLLECTION_LEADS_TIERS_HISTORY,
+}
+
+type MergeRequest struct {
+	SourceIDs []bson.ObjectID `json:"source_ids"`
+}
+
+type MergeResult struct {
+	Lead    schemas.Lead     `json:"lead"`
+	Merged  []bson.ObjectID  `json:"merged"`
+	Moved   map[string]int64 `json:"moved"`
+	MergeID bson.ObjectID    `json:"merge_id"`
+}
+
+// Merge mescla os leads de source_ids no lead {id}. O lead mantido herda os campos que estavam vazios, os
+// orçamentos, pedidos e telefones dos demais; orçamentos, chats, envios interativos, pesquisas e históricos
+// passam a apontar para ele e, nos funis, ele assume o lugar dos mesclados. Tudo roda em uma transação, com o
+// registro da mesclagem em leads_merges e uma entrada no histórico de cada funil alterado. Apenas administradores
+// podem mesclar.
+func Merge(w http.ResponseWriter, r *http.Request) {
+	targetID, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_LEAD_ID_FORMAT)
+		return
+	}
+
+	req := MergeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.LEADS_INVALID_REQUEST_DATA)
+		return
+	}
+	sourceIDs := []bson.ObjectID{}
+	for _, id := range req.SourceIDs {
+		if !id.IsZero() && id != targetID && !slices.Contains(sourceIDs, id) {
+			sourceIDs = append(sourceIDs, id)
+		}
+	}
+	if len(sourceIDs) == 0 {
+		utils.SendResponse(w, http.StatusBadRequest, "Informe ao menos um lead diferente do lead mantido em 'source_ids'", nil, utils.LEADS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, 0)
+		return
+	}
+	if !utils.HasAnyRole(user, leadsAdminRoles) {
+		utils.SendResponse(w, http.StatusForbidden, "Apenas administradores podem mesclar leads", nil, 0)
+		return
+	}
+
+	session, err := mongoClient.StartSession()
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer session.EndSession(ctx)
+
+	result, err := session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return mergeLeads(ctx, db, targetID, sourceIDs, user.Name, time.Now())
+	})
+	if errors.Is(err, errMergeLeadNotFound) {
+		utils.SendResponse(w, http.StatusNotFound, "Lead não encontrado", nil, 0)
+		return
+	}
+	if errors.Is(err, errMergeClientsConflict) {
+		utils.SendResponse(w, http.StatusConflict, "Os leads estão vinculados a clientes diferentes. Desvincule um deles antes de mesclar", nil, 0)
+		return
+	}
+	if err != nil {
+		log.Printf("[Merge] Erro ao mesclar os leads %v em %s: %v", sourceIDs, targetID.Hex(), err)
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_MERGE_LEADS_IN_MONGODB)
+		return
+	}
+
+	// Os pedidos dos leads de origem passam a contar no tier do lead mantido
+	QueueTierRecompute(TIER_REASON_MERGE, targetID)
+	QueueDuplicatesScan()
+
+	utils.SendResponse(w, http.StatusOK, "", result, 0)
+}
+
+func mergeLeads(ctx context.Context, db *mongo.Database, targetID bson.ObjectID, sourceIDs []bson.ObjectID, userName string, now time.Time) (*MergeResult, error) {
+	leadsCollection := db.Collection(database.COLLECTION_LEADS)
+
+	target := schemas.Lead{}
+	if err := leadsCollection.FindOne(ctx, bson.D{{Key: "_id", Value: targetID}}).Decode(&target); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errMergeLeadNotFound
+		}
+		return nil, err
+	}
+
+	cursor, err := leadsCollection.Find(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: sourceIDs}}}})
+	if err != nil {
+		return nil, err
+	}
+	sources := []schemas.Lead{}
+	if err := cursor.All(ctx, &sources); err != nil {
+		return nil, err
+	}
+	if len(sources) != len(sourceIDs) {
+		return nil, errMergeLeadNotFound
+	}
+
+	set, err := mergeLeadFields(&target, sources)
+	if err != nil {
+		return nil, err
+	}
+	set = append(set, bson.E{Key: "updated_at", Value: now})
+
+	budgets, orders, phones := []bson.ObjectID{}, []bson.ObjectID{}, []string{}
+	for _, source := range sources {
+		budgets = append(budgets, source.RelatedBudgets...)
+		orders = append(orders, source.RelatedOrders...)
+		for _, phone := range append([]string{source.Phone}, source.AlternatePhones...) {
+			if phone != "" && phone != target.Phone {
+				phones = append(phones, phone)
+			}
+		}
+	}
+	update := bson.D{
+		{Key: "$set", Value: set},
+		{Key: "$addToSet", Value: bson.D{
+			{Key: "related_budgets", Value: bson.D{{Key: "$each", Value: budgets}}},
+			{Key: "related_orders", Value: bson.D{{Key: "$each", Value: orders}}},
+			{Key: "alternate_phones", Value: bson.D{{Key: "$each", Value: phones}}},
+		}},
+	}
+	if _, err := leadsCollection.UpdateByID(ctx, targetID, update); err != nil {
+		return nil, err
+	}
+
+	moved := map[string]int64{}
+	res, err := db.Collection(database.COLLECTION_BUDGETS).UpdateMany(ctx,
+		bson.D{{Key: "related_lead", Value: bson.D{{Key: "$in", Value: sourceIDs}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "related_lead", Value: targetID}}}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	moved[database.COLLECTION_BUDGETS] = res.ModifiedCount
+
+	for _, name := range leadReferences {
+		res, err := db.Collection(name).UpdateMany(ctx,
+			bson.D{{Key: "lead_id", Value: bson.D{{Key: "$in", Value: sourceIDs}}}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "lead_id", Value: targetID}}}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		moved[name] = res.ModifiedCount
+	}
+
+	res, err = db.Collection(database.COLLECTION_FUNNELS_HISTORY).UpdateMany(ctx,
+		bson.D{{Key: "related_lead", Value: bson.D{{Key: "$in", Value: sourceIDs}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "related_lead", Value: targetID}}}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	moved[database.COLLECTION_FUNNELS_HISTORY] = res.ModifiedCount
+
+	funnels, err := moveFunnelMemberships(ctx, db, target, sources, userName, now)
+	if err != nil {
+		return nil, err
+	}
+	moved[database.COLLECTION_FUNNELS] = funnels
+
+	merge := schemas.LeadMerge{Target: targetID, Sources: sources, Moved: moved, MergedBy: userName, CreatedAt: now}
+	inserted, err := db.Collection(database.COLLECTION_LEADS_MERGES).InsertOne(ctx, merge)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := leadsCollection.DeleteMany(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: sourceIDs}}}}); err != nil {
+		return nil, err
+	}
+
+	merged := schemas.Lead{}
+	if err := leadsCollection.FindOne(ctx, bson.D{{Key: "_id", Value: targetID}}).Decode(&merged); err != nil {
+		return nil, err
+	}
+	return &MergeResult{Lead: merged, Merged: sourceIDs, Moved: moved, MergeID: inserted.InsertedID.(bson.ObjectID)}, nil
+}
+
+// mergeLeadFields completa os campos vazios do lead mantido com os dos leads mesclados, na ordem em que
+// vieram, e junta as observações. Devolve o $set com o que mudou.
+func mergeLeadFields(target *schemas.Lead, sources []schemas.Lead) (bson.D, error) {
+	set := bson.D{}
+	fill := func(key string, field *string, value string) {
+		if strings.TrimSpace(*field) == "" && strings.TrimSpace(value) != "" {
+			*field = value
+			set = append(set, bson.E{Key: key, Value: value})
+		}
+	}
+
+	notes := []string{}
+	if strings.TrimSpace(target.Notes) != "" {
+		notes = append(notes, target.Notes)
+	}
+	for _, source := range sources {
+		if !source.RelatedClient.IsZero() && !target.RelatedClient.IsZero() && source.RelatedClient != target.RelatedClient {
+			return nil, errMergeClientsConflict
+		}
+
+		fill("name", &target.Name, source.Name)
+		fill("nickname", &target.Nickname, source.Nickname)
+		fill("phone", &target.Phone, source.Phone)
+		fill("type", &target.Type, source.Type)
+		fill("segment", &target.Segment, source.Segment)
+		fill("status", &target.Status, source.Status)
+		fill("source", &target.Source, source.Source)
+		fill("platform_id", &target.PlatformId, source.PlatformId)
+		fill("rating", &target.Rating, source.Rating)
+
+		if target.RelatedClient.IsZero() && !source.RelatedClient.IsZero() {
+			target.RelatedClient = source.RelatedClient
+			set = append(set, bson.E{Key: "related_client", Value: source.RelatedClient})
+		}
+		if target.Responsible.IsZero() && !source.Responsible.IsZero() {
+			target.Responsible = source.Responsible
+			set = append(set, bson.E{Key: "responsible", Value: source.Responsible})
+		}
+		if target.LastLocation == nil && source.LastLocation != nil {
+			target.LastLocation = source.LastLocation
+			set = append(set, bson.E{Key: "last_location", Value: source.LastLocation})
+		}
+		if source.Blocked && !target.Blocked {
+			target.Blocked = true
+			set = append(set, bson.E{Key: "blocked", Value: true})
+		}
+		if note := strings.TrimSpace(source.Notes); note != "" && !slices.Contains(notes, note) {
+			notes = append(notes, note)
+		}
+	}
+
+	if joined := strings.Join(notes, "\n\n"); joined != target.Notes {
+		target.Notes = joined
+		set = append(set, bson.E{Key: "notes", Value: joined})
+	}
+	return set, nil
+}
+
+// moveFunnelMemberships coloca o lead mantido no lugar dos mesclados nas etapas dos funis e registra no
+// histórico de cada funil alterado. Devolve quantos funis mudaram.
+func moveFunnelMemberships(ctx context.Context, db *mongo.Database, target schemas.Lead, sources []schemas.Lead, userName string, now time.Time) (int64, error) {
+	sourceIDs := []bson.ObjectID{}
+	names := []string{}
+	for _, source := range sources {
+		sourceIDs = append(sourceIDs, source.ID)
+		names = append(names, leadLabel(source))
+	}
+
+	funnelsCollection := db.Collection(database.COLLECTION_FUNNELS)
+	cursor, err := funnelsCollection.Find(ctx, bson.D{{Key: "stages.related_leads", Value: bson.D{{Key: "$in", Value: sourceIDs}}}})
+	if err != nil {
+		return 0, err
+	}
+	funnels := []schemas.Funnel{}
+	if err := cursor.All(ctx, &funnels); err != nil {
+		return 0, err
+	}
+
+	history := []any{}
+	for _, funnel := range funnels {
+		stage := replaceStageLeads(funnel.Stages, target.ID, sourceIDs)
+		update := bson.D{{Key: "$set", Value: bson.D{{Key: "stages", Value: funnel.Stages}, {Key: "updated_at", Value: now}}}}
+		if _, err := funnelsCollection.UpdateByID(ctx, funnel.ID, update); err != nil {
+			return 0, err
+		}
+
+		action := fmt.Sprintf("Lead %s mesclado em %s", strings.Join(names, ", "), leadLabel(target))
+		if stage != "" {
+			action += fmt.Sprintf(" (etapa %s)", stage)
+		}
+		history = append(history, schemas.FunnelsHistory{RelatedUserName: userName, RelatedFunnel: funnel.ID, RelatedLead: target.ID, Action: action, CreatedAt: now})
+	}
+	if len(history) > 0 {
+		if _, err := db.Collection(database.COLLECTION_FUNNELS_HISTORY).InsertMany(ctx, history); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(funnels)), nil
+}
+
+// replaceStageLeads tira os leads mesclados das etapas do funil. Se o lead mantido ainda não estava no funil,
+// ele entra na primeira etapa em que um mesclado estava, que é devolvida; se já estava, continua onde está.
+func replaceStageLeads(stages []schemas.FunnelStage, targetID bson.ObjectID, sourceIDs []bson.ObjectID) string {
+	inFunnel := slices.ContainsFunc(stages, func(stage schemas.FunnelStage) bool {
+		return slices.Contains(stage.RelatedLeads, targetID)
+	})
+
+	placed := ""
+	for i := range stages {
+		leads := []bson.ObjectID{}
+		replaced := false
+		for _, id := range stages[i].RelatedLeads {
+			if slices.Contains(sourceIDs, id) {
+				replaced = true
+				continue
+			}
+			leads = append(leads, id)
+		}
+		if replaced && !inFunnel {
+			leads = append(leads, targetID)
+			inFunnel = true
+			placed = stages[i].Name
+		}
+		stages[i].RelatedLeads = leads
+	}
+	return placed
+}
+
+func leadLabel(lead schemas.Lead) string {
+	if lead.Name != "" {
+		return lead.Name
+	}
+	if lead.Phone != "" {
+		return lead.Phone
+	}
+	return lead.ID.Hex()
+}
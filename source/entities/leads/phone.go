@@ -0,0 +1,134 @@
+package leads
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// PhoneFilter casa leads com o telefone em qualquer um dos formatos já gravados, no telefone principal ou nos
+// alternativos.
+func PhoneFilter(phone string) bson.D {
+	variants := utils.PhoneVariants(phone)
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "phone", Value: bson.D{{Key: "$in", Value: variants}}}},
+		bson.D{{Key: "alternate_phones", Value: bson.D{{Key: "$in", Value: variants}}}},
+	}}}
+}
+
+// FindLeadByPhone devolve o lead que já usa o telefone, ignorando o próprio lead em exceptID. Sem lead,
+// volta mongo.ErrNoDocuments.
+func FindLeadByPhone(ctx context.Context, collection *mongo.Collection, phone string, exceptID bson.ObjectID) (schemas.Lead, error) {
+	lead := schemas.Lead{}
+	filter := PhoneFilter(phone)
+	if !exceptID.IsZero() {
+		filter = append(filter, bson.E{Key: "_id", Value: bson.D{{Key: "$ne", Value: exceptID}}})
+	}
+	err := collection.FindOne(ctx, filter).Decode(&lead)
+	return lead, err
+}
+
+// normalizeLeadPhone padroniza o telefone em E.164. Telefones inválidos ou que já pertencem a outro lead
+// continuam sendo gravados como vieram; os duplicados aparecem em GET /v1/leads/duplicates.
+func normalizeLeadPhone(lead *schemas.Lead) {
+	if normalized, ok := utils.NormalizePhone(lead.Phone); ok {
+		lead.Phone = normalized
+	}
+}
+
+type PhoneNormalizationResult struct {
+	Total     int64                `json:"total"`
+	Updated   int64                `json:"updated"`
+	Unchanged int64                `json:"unchanged"`
+	Invalid   []PhoneNormalizeSkip `json:"invalid"`
+	DryRun    bool                 `json:"dry_run"`
+}
+
+type PhoneNormalizeSkip struct {
+	ID    bson.ObjectID `json:"id"`
+	Name  string        `json:"name,omitempty"`
+	Phone string        `json:"phone"`
+}
+
+// NormalizePhones regrava em E.164 os telefones dos leads já cadastrados. O formato antigo fica em
+// alternate_phones para que buscas pelo número como estava continuem encontrando o lead. Com dry_run=true
+// apenas conta o que seria alterado. Duplicados que aparecerem depois da normalização são tratados em
+// GET /v1/leads/duplicates.
+func NormalizePhones(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, 0)
+		return
+	}
+	if !utils.HasAnyRole(user, leadsAdminRoles) {
+		utils.SendResponse(w, http.StatusForbidden, "Apenas administradores podem normalizar os telefones dos leads", nil, 0)
+		return
+	}
+
+	collection := db.Collection(database.COLLECTION_LEADS)
+	findOpts := options.Find().SetProjection(bson.D{{Key: "name", Value: 1}, {Key: "phone", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.D{{Key: "phone", Value: bson.D{{Key: "$nin", Value: bson.A{nil, ""}}}}}, findOpts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_IN_MONGODB)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	result := PhoneNormalizationResult{Invalid: []PhoneNormalizeSkip{}, DryRun: r.URL.Query().Get("dry_run") == "true"}
+	for cursor.Next(ctx) {
+		lead := schemas.Lead{}
+		if err := cursor.Decode(&lead); err != nil {
+			continue
+		}
+		result.Total++
+
+		normalized, ok := utils.NormalizePhone(lead.Phone)
+		if !ok {
+			result.Invalid = append(result.Invalid, PhoneNormalizeSkip{ID: lead.ID, Name: lead.Name, Phone: lead.Phone})
+			continue
+		}
+		if normalized == lead.Phone {
+			result.Unchanged++
+			continue
+		}
+		if result.DryRun {
+			result.Updated++
+			continue
+		}
+
+		update := bson.D{
+			{Key: "$set", Value: bson.D{{Key: "phone", Value: normalized}, {Key: "updated_at", Value: time.Now()}}},
+			{Key: "$addToSet", Value: bson.D{{Key: "alternate_phones", Value: lead.Phone}}},
+		}
+		if _, err := collection.UpdateByID(ctx, lead.ID, update); err != nil {
+			log.Printf("[NormalizePhones] Erro ao atualizar o telefone do lead %s: %v", lead.ID.Hex(), err)
+			continue
+		}
+		result.Updated++
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", result, 0)
+}
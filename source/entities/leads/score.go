@@ -0,0 +1,448 @@
+package leads
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	// Leads com eventos novos (mensagens, orçamentos, pedidos) são recalculados em lote neste intervalo
+	LEAD_SCORE_QUEUE_INTERVAL = time.Minute
+	LEAD_SCORE_QUEUE_TIMEOUT  = 2 * time.Minute
+
+	// O recálculo completo roda uma vez por noite, neste horário de Brasília
+	LEAD_SCORE_NIGHTLY_HOUR    = 3
+	LEAD_SCORE_NIGHTLY_TIMEOUT = time.Hour
+
+	// Limite de mensagens lidas por lead no cálculo do engajamento
+	LEAD_SCORE_MAX_MESSAGES = 5000
+)
+
+var (
+	// scoringMutex evita dois recálculos completos ao mesmo tempo
+	scoringMutex sync.Mutex
+
+	scoreQueueMutex sync.Mutex
+	scoreQueue      = map[bson.ObjectID]bool{}
+)
+
+// QueueScoreRecompute agenda o recálculo da pontuação dos leads no próximo lote. Os eventos que mudam a
+// pontuação chamam esta função em vez de recalcular na hora, para não pesar a requisição nem recalcular o
+// mesmo lead a cada mensagem.
+func QueueScoreRecompute(ids ...bson.ObjectID) {
+	scoreQueueMutex.Lock()
+	defer scoreQueueMutex.Unlock()
+	for _, id := range ids {
+		if !id.IsZero() {
+			scoreQueue[id] = true
+		}
+	}
+}
+
+func takeScoreQueue() []bson.ObjectID {
+	scoreQueueMutex.Lock()
+	defer scoreQueueMutex.Unlock()
+	ids := []bson.ObjectID{}
+	for id := range scoreQueue {
+		ids = append(ids, id)
+	}
+	scoreQueue = map[bson.ObjectID]bool{}
+	return ids
+}
+
+// StartLeadScoring agenda o recálculo dos leads com eventos novos a cada minuto e o recálculo completo
+// de madrugada, quando tiers e recência mudam para todos.
+func StartLeadScoring() {
+	utils.RunEvery("LeadScoreQueue", LEAD_SCORE_QUEUE_INTERVAL, LEAD_SCORE_QUEUE_TIMEOUT, func(ctx context.Context) error {
+		ids := takeScoreQueue()
+		if len(ids) == 0 {
+			return nil
+		}
+		_, err := withScoringDB(ctx, func(db *mongo.Database) (int, error) {
+			return recomputeScores(ctx, db, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}})
+		})
+		if err != nil {
+			// Volta para a fila para tentar no próximo lote
+			QueueScoreRecompute(ids...)
+		}
+		return err
+	})
+
+	utils.RunEvery("LeadScoreNightly", time.Hour, LEAD_SCORE_NIGHTLY_TIMEOUT, func(ctx context.Context) error {
+		location, err := time.LoadLocation("America/Sao_Paulo")
+		if err != nil {
+			location = time.Local
+		}
+		if time.Now().In(location).Hour() != LEAD_SCORE_NIGHTLY_HOUR {
+			return nil
+		}
+		return recomputeAllScores(ctx)
+	})
+}
+
+func recomputeAllScores(ctx context.Context) error {
+	if !scoringMutex.TryLock() {
+		return nil
+	}
+	defer scoringMutex.Unlock()
+
+	count, err := withScoringDB(ctx, func(db *mongo.Database) (int, error) {
+		return recomputeScores(ctx, db, bson.D{})
+	})
+	if err == nil {
+		log.Printf("[LeadScore] %d lead(s) recalculado(s)", count)
+	}
+	return err
+}
+
+func withScoringDB(ctx context.Context, fn func(db *mongo.Database) (int, error)) (int, error) {
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		return 0, err
+	}
+	defer mongoClient.Disconnect(ctx)
+	return fn(mongoClient.Database(database.GetDB()))
+}
+
+func loadScoringModel(ctx context.Context, db *mongo.Database) (schemas.LeadScoringModel, error) {
+	model := schemas.LeadScoringModel{}
+	err := db.Collection(database.COLLECTION_LEADS_SCORING).FindOne(ctx, bson.D{}).Decode(&model)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return utils.DefaultLeadScoringModel(), nil
+	}
+	return model, err
+}
+
+// loadTiersByValue devolve os tiers do menor para o maior valor mínimo, a ordem usada na nota de tier.
+func loadTiersByValue(ctx context.Context, db *mongo.Database) ([]schemas.LeadTier, error) {
+	cursor, err := db.Collection(database.COLLECTION_LEADS_TIERS).Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "min_value", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	tiers := []schemas.LeadTier{}
+	err = cursor.All(ctx, &tiers)
+	return tiers, err
+}
+
+// recomputeScores recalcula e grava a pontuação dos leads do filtro. Erros em um lead são logados e não
+// interrompem os demais.
+func recomputeScores(ctx context.Context, db *mongo.Database, filter bson.D) (int, error) {
+	model, err := loadScoringModel(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	tiers, err := loadTiersByValue(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	collection := db.Collection(database.COLLECTION_LEADS)
+	projection := bson.D{{Key: "related_orders", Value: 1}, {Key: "related_budgets", Value: 1}, {Key: "created_at", Value: 1}, {Key: "tier", Value: 1}}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	count := 0
+	for cursor.Next(ctx) {
+		lead := schemas.Lead{}
+		if err := cursor.Decode(&lead); err != nil {
+			continue
+		}
+		score, err := scoreLead(ctx, db, lead, model, tiers, time.Now())
+		if err != nil {
+			log.Printf("[LeadScore] Erro ao calcular a pontuação do lead %s: %v", lead.ID.Hex(), err)
+			continue
+		}
+		if _, err := collection.UpdateByID(ctx, lead.ID, bson.D{{Key: "$set", Value: bson.D{{Key: "score", Value: score}}}}); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, cursor.Err()
+}
+
+// scoreLead reúne os sinais do lead (tier gravado, mensagens dos chats, orçamentos e última atividade)
+// e calcula a pontuação pelo modelo.
+func scoreLead(ctx context.Context, db *mongo.Database, lead schemas.Lead, model schemas.LeadScoringModel, tiers []schemas.LeadTier, now time.Time) (schemas.LeadScore, error) {
+	signals := schemas.LeadScoreSignals{}
+	lastActivity := lead.CreatedAt
+
+	// O tier vem do recálculo em segundo plano gravado no lead
+	tierScore := 0.0
+	if lead.Tier != nil && !lead.Tier.TierID.IsZero() {
+		signals.Tier = lead.Tier.Label
+		if position := slices.IndexFunc(tiers, func(t schemas.LeadTier) bool { return t.ID == lead.Tier.TierID }); position >= 0 {
+			tierScore = float64(position+1) / float64(len(tiers))
+		}
+	}
+
+	if orderIDs := nonNilIDs(lead.RelatedOrders); len(orderIDs) > 0 {
+		orderOpts := options.Find().SetProjection(bson.D{{Key: "created_at", Value: 1}})
+		cursor, err := db.Collection(database.COLLECTION_ORDERS).Find(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: orderIDs}}}}, orderOpts)
+		if err != nil {
+			return schemas.LeadScore{}, err
+		}
+		orders := []schemas.Order{}
+		if err := cursor.All(ctx, &orders); err != nil {
+			return schemas.LeadScore{}, err
+		}
+		for _, order := range orders {
+			if order.CreatedAt.After(lastActivity) {
+				lastActivity = order.CreatedAt
+			}
+		}
+	}
+
+	budgetFilter := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "related_lead", Value: lead.ID}},
+		bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: nonNilIDs(lead.RelatedBudgets)}}}},
+	}}}
+	budgetOpts := options.Find().SetProjection(bson.D{{Key: "approved", Value: 1}, {Key: "related_order", Value: 1}, {Key: "created_at", Value: 1}})
+	cursor, err := db.Collection(database.COLLECTION_BUDGETS).Find(ctx, budgetFilter, budgetOpts)
+	if err != nil {
+		return schemas.LeadScore{}, err
+	}
+	budgets := []schemas.Budget{}
+	if err := cursor.All(ctx, &budgets); err != nil {
+		return schemas.LeadScore{}, err
+	}
+	for _, budget := range budgets {
+		signals.Budgets++
+		if budget.Approved || !budget.RelatedOrder.IsZero() {
+			signals.ConvertedBudgets++
+		}
+		if budget.CreatedAt.After(lastActivity) {
+			lastActivity = budget.CreatedAt
+		}
+	}
+
+	lastMessage, err := addMessageSignals(ctx, db, lead.ID, model, now, &signals)
+	if err != nil {
+		return schemas.LeadScore{}, err
+	}
+	if lastMessage.After(lastActivity) {
+		lastActivity = lastMessage
+	}
+	signals.LastActivityAt = lastActivity
+
+	return utils.CalculateLeadScore(signals, tierScore, model, now), nil
+}
+
+// addMessageSignals conta as mensagens da janela de engajamento e mede quanto o lead demora, em média, para
+// responder uma mensagem da empresa. Devolve a data da última mensagem do lead.
+func addMessageSignals(ctx context.Context, db *mongo.Database, leadID bson.ObjectID, model schemas.LeadScoringModel, now time.Time, signals *schemas.LeadScoreSignals) (time.Time, error) {
+	chatIDs := []bson.ObjectID{}
+	cursor, err := db.Collection(database.COLLECTION_SPACE_DESK_CHAT).Find(ctx, bson.D{{Key: "lead_id", Value: leadID}}, options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return time.Time{}, err
+	}
+	chats := []struct {
+		ID bson.ObjectID `bson:"_id"`
+	}{}
+	if err := cursor.All(ctx, &chats); err != nil {
+		return time.Time{}, err
+	}
+	for _, chat := range chats {
+		chatIDs = append(chatIDs, chat.ID)
+	}
+	if len(chatIDs) == 0 {
+		return time.Time{}, nil
+	}
+
+	since := now.AddDate(0, 0, -model.EngagementWindowDays)
+	filter := bson.D{
+		{Key: "chat_id", Value: bson.D{{Key: "$in", Value: chatIDs}}},
+		{Key: "created_at", Value: bson.D{{Key: "$gte", Value: since}}},
+	}
+	findOpts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(LEAD_SCORE_MAX_MESSAGES).
+		SetProjection(bson.D{{Key: "from", Value: 1}, {Key: "created_at", Value: 1}})
+	cursor, err = db.Collection(database.COLLECTION_SPACE_DESK_MESSAGE).Find(ctx, filter, findOpts)
+	if err != nil {
+		return time.Time{}, err
+	}
+	messages := []struct {
+		From      string    `bson:"from"`
+		CreatedAt time.Time `bson:"created_at"`
+	}{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return time.Time{}, err
+	}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].CreatedAt.Before(messages[j].CreatedAt) })
+
+	lastInbound := time.Time{}
+	waitingSince := time.Time{}
+	replies, replyMinutes := 0, 0.0
+	for _, message := range messages {
+		if message.From == "company" {
+			signals.MessagesSent++
+			if waitingSince.IsZero() {
+				waitingSince = message.CreatedAt
+			}
+			continue
+		}
+		signals.MessagesReceived++
+		lastInbound = message.CreatedAt
+		if !waitingSince.IsZero() {
+			replies++
+			replyMinutes += message.CreatedAt.Sub(waitingSince).Minutes()
+			waitingSince = time.Time{}
+		}
+	}
+	if replies > 0 {
+		signals.AvgReplyMinutes = float64(int(replyMinutes/float64(replies)*10)) / 10
+	}
+	return lastInbound, nil
+}
+
+// GetScoringModel devolve o modelo de pontuação em uso.
+func GetScoringModel(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	model, err := loadScoringModel(ctx, mongoClient.Database(database.GetDB()))
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_IN_MONGODB)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", model, 0)
+}
+
+// UpdateScoringModel salva o modelo de pontuação e recalcula todos os leads em segundo plano. Restrito aos
+// administradores.
+func UpdateScoringModel(w http.ResponseWriter, r *http.Request) {
+	model := schemas.LeadScoringModel{}
+	if err := json.NewDecoder(r.Body).Decode(&model); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.LEADS_INVALID_REQUEST_DATA)
+		return
+	}
+	if message := validateScoringModel(model); message != "" {
+		utils.SendResponse(w, http.StatusBadRequest, message, nil, utils.LEADS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, 0)
+		return
+	}
+	if !utils.HasAnyRole(user, leadsAdminRoles) {
+		utils.SendResponse(w, http.StatusForbidden, "Apenas administradores podem alterar a pontuação dos leads", nil, 0)
+		return
+	}
+
+	model.ID = bson.ObjectID{}
+	model.UpdatedBy = user.Name
+	model.UpdatedAt = time.Now()
+	_, err = db.Collection(database.COLLECTION_LEADS_SCORING).ReplaceOne(ctx, bson.D{}, model, options.Replace().SetUpsert(true))
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_LEAD_IN_MONGODB)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), LEAD_SCORE_NIGHTLY_TIMEOUT)
+		defer cancel()
+		if err := recomputeAllScores(ctx); err != nil {
+			log.Printf("[LeadScore] Erro ao recalcular as pontuações: %v", err)
+		}
+	}()
+
+	utils.SendResponse(w, http.StatusOK, "Modelo salvo. As pontuações serão recalculadas em segundo plano", model, 0)
+}
+
+// RecomputeScore recalcula na hora a pontuação de um lead e devolve o resultado com os sinais usados.
+func RecomputeScore(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_LEAD_ID_FORMAT)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	count, err := recomputeScores(ctx, db, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_LEAD_IN_MONGODB)
+		return
+	}
+	if count == 0 {
+		utils.SendResponse(w, http.StatusNotFound, "Lead não encontrado", nil, 0)
+		return
+	}
+
+	lead := schemas.Lead{}
+	if err := db.Collection(database.COLLECTION_LEADS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}, options.FindOne().SetProjection(bson.D{{Key: "score", Value: 1}})).Decode(&lead); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEAD_BY_ID_IN_MONGODB)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", lead.Score, 0)
+}
+
+func validateScoringModel(model schemas.LeadScoringModel) string {
+	weights := model.Weights
+	if weights.Tier < 0 || weights.Engagement < 0 || weights.Conversion < 0 || weights.Recency < 0 {
+		return "Os pesos não podem ser negativos"
+	}
+	if weights.Tier+weights.Engagement+weights.Conversion+weights.Recency == 0 {
+		return "Informe ao menos um peso maior que zero"
+	}
+	if model.TargetMessages <= 0 || model.TargetReplyMinutes <= 0 || model.EngagementWindowDays <= 0 || model.RecencyHalfLifeDays <= 0 {
+		return "'target_messages', 'target_reply_minutes', 'engagement_window_days' e 'recency_half_life_days' devem ser maiores que zero"
+	}
+	return ""
+}
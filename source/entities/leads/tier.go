@@ -0,0 +1,323 @@
+package leads
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	// Leads com pedidos novos ou alterados são recalculados em lote neste intervalo
+	LEAD_TIER_QUEUE_INTERVAL = time.Minute
+	LEAD_TIER_QUEUE_TIMEOUT  = 2 * time.Minute
+
+	// O recálculo completo roda de madrugada, antes do recálculo da pontuação, que usa o tier gravado
+	LEAD_TIER_NIGHTLY_HOUR = 2
+	LEAD_TIER_FULL_TIMEOUT = time.Hour
+
+	TIER_CHANGE_UPGRADE   = "upgrade"
+	TIER_CHANGE_DOWNGRADE = "downgrade"
+
+	// Motivos do recálculo, gravados no histórico de troca de tier
+	TIER_REASON_CREATED     = "created"
+	TIER_REASON_ORDERS      = "orders"
+	TIER_REASON_MERGE       = "merge"
+	TIER_REASON_DEFINITIONS = "tier_definitions"
+	TIER_REASON_NIGHTLY     = "nightly"
+	TIER_REASON_BACKFILL    = "backfill"
+)
+
+var (
+	// tierMutex evita dois recálculos completos ao mesmo tempo
+	tierMutex sync.Mutex
+
+	tierQueueMutex sync.Mutex
+	tierQueue      = map[bson.ObjectID]string{}
+
+	// tierDefinitionsChanged é marcado quando um tier é criado ou alterado; o job recalcula todos os leads
+	tierDefinitionsChanged atomic.Bool
+
+	errTierRecomputeRunning = errors.New("recálculo completo dos tiers já em andamento")
+)
+
+// QueueTierRecompute agenda o recálculo do tier dos leads no próximo lote, com o motivo que vai para o
+// histórico caso o tier mude.
+func QueueTierRecompute(reason string, ids ...bson.ObjectID) {
+	tierQueueMutex.Lock()
+	defer tierQueueMutex.Unlock()
+	for _, id := range ids {
+		if !id.IsZero() {
+			tierQueue[id] = reason
+		}
+	}
+}
+
+func takeTierQueue() map[bson.ObjectID]string {
+	tierQueueMutex.Lock()
+	defer tierQueueMutex.Unlock()
+	queue := tierQueue
+	tierQueue = map[bson.ObjectID]string{}
+	return queue
+}
+
+// StartLeadTiers calcula na subida o tier dos leads que ainda não têm um e agenda o recálculo: dos leads na
+// fila a cada minuto, de todos os leads quando as faixas dos tiers mudam e de todos os leads de madrugada,
+// para pegar pedidos alterados por fora da API.
+func StartLeadTiers() {
+	go backfillTiers()
+
+	utils.RunEvery("LeadTierQueue", LEAD_TIER_QUEUE_INTERVAL, LEAD_TIER_QUEUE_TIMEOUT, func(ctx context.Context) error {
+		queue := takeTierQueue()
+		if len(queue) == 0 {
+			return nil
+		}
+		byReason := map[string][]bson.ObjectID{}
+		for id, reason := range queue {
+			byReason[reason] = append(byReason[reason], id)
+		}
+		_, err := withScoringDB(ctx, func(db *mongo.Database) (int, error) {
+			count := 0
+			for reason, ids := range byReason {
+				n, err := recomputeTiers(ctx, db, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}}, reason)
+				count += n
+				if err != nil {
+					return count, err
+				}
+			}
+			return count, nil
+		})
+		if err != nil {
+			// Volta para a fila para tentar no próximo lote
+			for reason, ids := range byReason {
+				QueueTierRecompute(reason, ids...)
+			}
+		}
+		return err
+	})
+
+	utils.RunEvery("LeadTierDefinitions", LEAD_TIER_QUEUE_INTERVAL, LEAD_TIER_FULL_TIMEOUT, func(ctx context.Context) error {
+		if !tierDefinitionsChanged.Swap(false) {
+			return nil
+		}
+		err := recomputeAllTiers(ctx, TIER_REASON_DEFINITIONS)
+		if err != nil {
+			tierDefinitionsChanged.Store(true)
+		}
+		return err
+	})
+
+	utils.RunEvery("LeadTierNightly", time.Hour, LEAD_TIER_FULL_TIMEOUT, func(ctx context.Context) error {
+		location, err := time.LoadLocation("America/Sao_Paulo")
+		if err != nil {
+			location = time.Local
+		}
+		if time.Now().In(location).Hour() != LEAD_TIER_NIGHTLY_HOUR {
+			return nil
+		}
+		return recomputeAllTiers(ctx, TIER_REASON_NIGHTLY)
+	})
+}
+
+// backfillTiers calcula o tier dos leads criados antes dos tiers existirem ou gravados por fora da API, para
+// que não fiquem sem tier até o recálculo da madrugada.
+func backfillTiers() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[LeadTier] Panic ao calcular os tiers pendentes: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), LEAD_TIER_FULL_TIMEOUT)
+	defer cancel()
+
+	tierMutex.Lock()
+	defer tierMutex.Unlock()
+
+	count, err := withScoringDB(ctx, func(db *mongo.Database) (int, error) {
+		return recomputeTiers(ctx, db, bson.D{{Key: "tier", Value: bson.D{{Key: "$exists", Value: false}}}}, TIER_REASON_BACKFILL)
+	})
+	if err != nil {
+		log.Printf("[LeadTier] Erro ao calcular os tiers pendentes: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("[LeadTier] Tier calculado para %d lead(s) sem tier", count)
+	}
+}
+
+func recomputeAllTiers(ctx context.Context, reason string) error {
+	if !tierMutex.TryLock() {
+		return errTierRecomputeRunning
+	}
+	defer tierMutex.Unlock()
+
+	count, err := withScoringDB(ctx, func(db *mongo.Database) (int, error) {
+		return recomputeTiers(ctx, db, bson.D{}, reason)
+	})
+	if err == nil {
+		log.Printf("[LeadTier] Tier de %d lead(s) recalculado(s)", count)
+	}
+	return err
+}
+
+// recomputeTiers recalcula e grava o tier dos leads do filtro. Erros em um lead são logados e não
+// interrompem os demais.
+func recomputeTiers(ctx context.Context, db *mongo.Database, filter bson.D, reason string) (int, error) {
+	tiers, err := loadTiersByValue(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	projection := bson.D{{Key: "related_orders", Value: 1}, {Key: "tier", Value: 1}}
+	cursor, err := db.Collection(database.COLLECTION_LEADS).Find(ctx, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	count := 0
+	for cursor.Next(ctx) {
+		lead := schemas.Lead{}
+		if err := cursor.Decode(&lead); err != nil {
+			continue
+		}
+		if _, err := assignLeadTier(ctx, db, lead, tiers, reason, time.Now()); err != nil {
+			log.Printf("[LeadTier] Erro ao recalcular o tier do lead %s: %v", lead.ID.Hex(), err)
+			continue
+		}
+		count++
+	}
+	return count, cursor.Err()
+}
+
+// AssignLeadTier recalcula na hora o tier de um lead, para quem precisa do resultado na resposta (como a
+// conversão de orçamento em pedido). Devolve nil quando o lead não é informado.
+func AssignLeadTier(ctx context.Context, db *mongo.Database, leadID bson.ObjectID, reason string) (*schemas.LeadTierAssignment, error) {
+	if leadID.IsZero() {
+		return nil, nil
+	}
+
+	lead := schemas.Lead{}
+	projection := bson.D{{Key: "related_orders", Value: 1}, {Key: "tier", Value: 1}}
+	if err := db.Collection(database.COLLECTION_LEADS).FindOne(ctx, bson.D{{Key: "_id", Value: leadID}}, options.FindOne().SetProjection(projection)).Decode(&lead); err != nil {
+		return nil, err
+	}
+	tiers, err := loadTiersByValue(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	return assignLeadTier(ctx, db, lead, tiers, reason, time.Now())
+}
+
+// assignLeadTier calcula o tier pelos pedidos do lead e grava no lead. Quando o tier muda em relação ao já
+// gravado, registra a troca no histórico e agenda o recálculo da pontuação. O primeiro cálculo de um lead
+// não entra no histórico, para a carga inicial não aparecer como upgrade.
+func assignLeadTier(ctx context.Context, db *mongo.Database, lead schemas.Lead, tiers []schemas.LeadTier, reason string, now time.Time) (*schemas.LeadTierAssignment, error) {
+	orders := bson.A{}
+	if orderIDs := nonNilIDs(lead.RelatedOrders); len(orderIDs) > 0 {
+		projection := bson.D{{Key: "items", Value: 1}, {Key: "products_list_legacy", Value: 1}}
+		cursor, err := db.Collection(database.COLLECTION_ORDERS).Find(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: orderIDs}}}}, options.Find().SetProjection(projection))
+		if err != nil {
+			return nil, err
+		}
+		if err := cursor.All(ctx, &orders); err != nil {
+			return nil, err
+		}
+	}
+
+	values := utils.SumLeadOrders(orders)
+	assignment := &schemas.LeadTierAssignment{
+		TotalValue:    values.Total,
+		MaxOrderValue: values.Max,
+		SkippedOrders: values.Skipped,
+		ComputedAt:    now,
+	}
+	if tier := utils.CalculateLeadTier(values, tiers); tier != nil {
+		assignment.TierID = tier.ID
+		assignment.Label = tier.Label
+		assignment.Icon = tier.Icon
+		assignment.SumType = tier.SumType
+		assignment.MinValue = tier.MinValue
+		assignment.MaxValue = tier.MaxValue
+	}
+
+	_, err := db.Collection(database.COLLECTION_LEADS).UpdateByID(ctx, lead.ID, bson.D{{Key: "$set", Value: bson.D{{Key: "tier", Value: assignment}}}})
+	if err != nil {
+		return nil, err
+	}
+
+	if lead.Tier != nil && lead.Tier.TierID == assignment.TierID {
+		return assignment, nil
+	}
+	QueueScoreRecompute(lead.ID)
+	if lead.Tier == nil {
+		return assignment, nil
+	}
+
+	change := schemas.LeadTierChange{
+		LeadID:    lead.ID,
+		From:      lead.Tier,
+		To:        assignment,
+		Direction: tierChangeDirection(lead.Tier, assignment),
+		Reason:    reason,
+		CreatedAt: now,
+	}
+	if _, err := db.Collection(database.COLLECTION_LEADS_TIERS_HISTORY).InsertOne(ctx, change); err != nil {
+		log.Printf("[LeadTier] Erro ao registrar a troca de tier do lead %s: %v", lead.ID.Hex(), err)
+	}
+	return assignment, nil
+}
+
+// tierChangeDirection compara as faixas dos tiers: sair de nenhum tier é upgrade, ficar sem tier é
+// downgrade e, entre dois tiers, sobe quem tem a faixa mais alta.
+func tierChangeDirection(from, to *schemas.LeadTierAssignment) string {
+	switch {
+	case from.TierID.IsZero():
+		return TIER_CHANGE_UPGRADE
+	case to.TierID.IsZero():
+		return TIER_CHANGE_DOWNGRADE
+	case to.MinValue != from.MinValue:
+		if to.MinValue > from.MinValue {
+			return TIER_CHANGE_UPGRADE
+		}
+		return TIER_CHANGE_DOWNGRADE
+	case to.MaxValue > from.MaxValue:
+		return TIER_CHANGE_UPGRADE
+	default:
+		return TIER_CHANGE_DOWNGRADE
+	}
+}
+
+// validateTierRange confere a faixa do tier contra os demais tiers cadastrados. Devolve a mensagem para o
+// usuário quando a faixa é inválida ou se sobrepõe à de outro tier do mesmo sum_type.
+func validateTierRange(ctx context.Context, collection *mongo.Collection, tier schemas.LeadTier) (string, error) {
+	if tier.MinValue < 0 || tier.MaxValue < 0 {
+		return "min_value e max_value não podem ser negativos", nil
+	}
+	if tier.MinValue > tier.MaxValue {
+		return "min_value deve ser menor ou igual a max_value", nil
+	}
+
+	cursor, err := collection.Find(ctx, bson.D{{Key: "sum_type", Value: tier.SumType}})
+	if err != nil {
+		return "", err
+	}
+	tiers := []schemas.LeadTier{}
+	if err := cursor.All(ctx, &tiers); err != nil {
+		return "", err
+	}
+	if other, ok := utils.FindOverlappingTier(tier, tiers); ok {
+		return "A faixa de valores se sobrepõe à do tier " + other.Label, nil
+	}
+	return "", nil
+}
@@ -2,10 +2,12 @@ package leads
 
 import (
 	"api/database"
+	"api/middlewares"
 	"api/schemas"
 	"api/utils"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
 	"time"
@@ -46,6 +48,8 @@ func UpdateOne(w http.ResponseWriter, r *http.Request) {
 
 	filter := bson.D{{Key: "_id", Value: id}}
 
+	normalizeLeadPhone(lead)
+
 	updateDoc := bson.D{}
 
 	if lead.Name != "" {
@@ -105,15 +109,26 @@ func UpdateOne(w http.ResponseWriter, r *http.Request) {
 
 	update := bson.D{{Key: "$set", Value: updateDoc}}
 
-	result, err := collection.UpdateOne(ctx, filter, update)
+	before := bson.M{}
+	err = collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.Before)).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		utils.SendResponse(w, http.StatusNotFound, "Lead não encontrado", nil, 0)
+		return
+	}
 	if err != nil {
 		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_LEAD_IN_MONGODB)
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		utils.SendResponse(w, http.StatusNotFound, "Lead não encontrado", nil, 0)
-		return
+	db := mongoClient.Database(database.GetDB())
+	userName := ""
+	if user, err := middlewares.CurrentUser(ctx, db, r); err == nil {
+		userName = user.Name
+	}
+	recordLeadChanges(ctx, db, id, before, updateDoc, userName)
+	QueueScoreRecompute(id)
+	if lead.RelatedOrders != nil {
+		QueueTierRecompute(TIER_REASON_ORDERS, id)
 	}
 
 	utils.SendResponse(w, http.StatusOK, "", nil, 0)
@@ -6,6 +6,7 @@ import (
 	"api/utils"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
 	"strings"
@@ -68,26 +69,34 @@ func UpdateOneTier(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	valueOr := bson.A{}
-	if tier.MinValue != 0 {
-		valueOr = append(valueOr, bson.D{{Key: "min_value", Value: tier.MinValue}})
-	}
-	if tier.MaxValue != 0 {
-		valueOr = append(valueOr, bson.D{{Key: "max_value", Value: tier.MaxValue}})
-	}
-	if len(valueOr) > 0 {
-		valueFilter := bson.D{
-			{Key: "$or", Value: valueOr},
-			{Key: "_id", Value: bson.D{{Key: "$ne", Value: id}}},
+	if tier.MinValue != 0 || tier.MaxValue != 0 || tier.SumType != "" {
+		current := schemas.LeadTier{}
+		err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&current)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.SendResponse(w, http.StatusNotFound, "Tier não encontrado", nil, 0)
+			return
 		}
-		cursor, err := collection.Find(ctx, valueFilter)
-		if err == nil {
-			existing := []schemas.LeadTier{}
-			_ = cursor.All(ctx, &existing)
-			if len(existing) > 0 {
-				utils.SendResponse(w, http.StatusBadRequest, "Já existe um tier com esse min_value ou max_value", nil, 0)
-				return
-			}
+		if err != nil {
+			utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_TIERS_IN_MONGODB)
+			return
+		}
+		if tier.MinValue != 0 {
+			current.MinValue = tier.MinValue
+		}
+		if tier.MaxValue != 0 {
+			current.MaxValue = tier.MaxValue
+		}
+		if tier.SumType != "" {
+			current.SumType = tier.SumType
+		}
+		message, err := validateTierRange(ctx, collection, current)
+		if err != nil {
+			utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_TIERS_IN_MONGODB)
+			return
+		}
+		if message != "" {
+			utils.SendResponse(w, http.StatusBadRequest, message, nil, 0)
+			return
 		}
 	}
 
@@ -127,5 +136,8 @@ func UpdateOneTier(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Label e ícone também são copiados para os leads, então qualquer alteração recalcula os tiers
+	tierDefinitionsChanged.Store(true)
+
 	utils.SendResponse(w, http.StatusOK, "", nil, 0)
 }
@@ -0,0 +1,251 @@
+package orders
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const BOARD_MAX_ORDERS = 2000
+
+type BoardMove struct {
+	Stage          schemas.OrderStage  `json:"stage"`
+	Status         schemas.OrderStatus `json:"status"`
+	ReasonRequired bool                `json:"reason_required,omitempty"`
+}
+
+type BoardCard struct {
+	ID              bson.ObjectID       `json:"_id" bson:"_id"`
+	OldID           uint64              `json:"old_id" bson:"old_id"`
+	Stage           schemas.OrderStage  `json:"stage" bson:"stage"`
+	Status          schemas.OrderStatus `json:"status" bson:"status"`
+	Type            schemas.OrderType   `json:"type,omitempty" bson:"type"`
+	ExpectedDate    time.Time           `json:"expected_date,omitzero" bson:"expected_date"`
+	StageEnteredAt  time.Time           `json:"stage_entered_at,omitzero" bson:"stage_entered_at"`
+	TrackingCode    string              `json:"tracking_code,omitempty" bson:"tracking_code"`
+	TinyNumber      string              `json:"tiny_number,omitempty" bson:"tiny_number"`
+	RelatedBudget   bson.ObjectID       `json:"related_budget,omitzero" bson:"related_budget"`
+	RelatedSeller   bson.M              `json:"related_seller,omitempty" bson:"related_seller"`
+	RelatedDesigner bson.M              `json:"related_designer,omitempty" bson:"related_designer"`
+	CreatedAt       time.Time           `json:"created_at" bson:"created_at"`
+	DeadlineRisk    string              `json:"deadline_risk,omitempty" bson:"deadline_risk"`
+	Late            bool                `json:"late"`
+	AllowedMoves    []BoardMove         `json:"allowed_moves"`
+}
+
+type BoardColumn struct {
+	Stage  schemas.OrderStage  `json:"stage"`
+	Status schemas.OrderStatus `json:"status,omitempty"`
+	Count  int                 `json:"count"`
+	Orders []BoardCard         `json:"orders"`
+}
+
+type BoardMoveRequest struct {
+	OrderID string              `json:"order_id"`
+	Stage   schemas.OrderStage  `json:"stage"`
+	Status  schemas.OrderStatus `json:"status"`
+	Reason  string              `json:"reason,omitempty"`
+}
+
+// AllowedMoves lista as colunas para onde o pedido pode ser arrastado segundo o fluxo de produção.
+func AllowedMoves(order schemas.Order) []BoardMove {
+	moves := []BoardMove{}
+	for _, stage := range Workflow {
+		for _, status := range stage.Statuses {
+			if ValidateTransition(order, stage.Stage, status, "-") != nil {
+				continue
+			}
+			move := BoardMove{Stage: stage.Stage, Status: status}
+			move.ReasonRequired = ValidateTransition(order, stage.Stage, status, "") == ErrReasonRequired
+			moves = append(moves, move)
+		}
+	}
+	return moves
+}
+
+// GetBoard monta o quadro de produção agrupado por etapa (`group_by=stage`, padrão) ou por etapa e status
+// (`group_by=status`). Aceita os mesmos filtros da listagem de pedidos, além de `seller`, `designer` e
+// `late=true`. Pedidos entregues ou devolvidos só aparecem com `include_finished=true`. O risco de atraso
+// nos cartões é o gravado pela última verificação de prazos.
+func GetBoard(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	groupByStatus := query.Get("group_by") == "status"
+
+	filter, invalid := appendUserFilters(buildFilterFromQueryParams(r), query)
+	if invalid != "" {
+		utils.SendResponse(w, http.StatusBadRequest, "Parâmetro '"+invalid+"' inválido", nil, utils.ORDERS_INVALID_REQUEST_DATA)
+		return
+	}
+	now := time.Now()
+	if query.Get("late") == "true" {
+		filter = append(filter, bson.E{Key: "expected_date", Value: bson.D{{Key: "$lt", Value: now}}})
+	}
+	if query.Get("include_finished") != "true" {
+		filter = append(filter, bson.E{Key: "$nor", Value: bson.A{bson.D{
+			{Key: "stage", Value: schemas.StageExpedicao},
+			{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{schemas.StatusEntregue, schemas.StatusDevolucao}}}},
+		}}})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_ORDERS)
+
+	userLookup := func(field string) bson.D {
+		return bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: database.COLLECTION_USERS},
+			{Key: "localField", Value: field},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "pipeline", Value: bson.A{bson.D{{Key: "$project", Value: bson.D{{Key: "name", Value: 1}}}}}},
+			{Key: "as", Value: field + "_data"},
+		}}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: "expected_date", Value: 1}, {Key: "created_at", Value: 1}}}},
+		{{Key: "$limit", Value: BOARD_MAX_ORDERS}},
+		userLookup("related_seller"),
+		userLookup("related_designer"),
+		{{Key: "$project", Value: bson.D{
+			{Key: "old_id", Value: 1},
+			{Key: "stage", Value: 1},
+			{Key: "status", Value: 1},
+			{Key: "type", Value: 1},
+			{Key: "expected_date", Value: 1},
+			{Key: "stage_entered_at", Value: 1},
+			{Key: "tracking_code", Value: 1},
+			{Key: "tiny_number", Value: "$tiny.number"},
+			{Key: "related_budget", Value: 1},
+			{Key: "created_at", Value: 1},
+			{Key: "deadline_risk", Value: 1},
+			{Key: "related_seller", Value: bson.D{{Key: "$first", Value: "$related_seller_data"}}},
+			{Key: "related_designer", Value: bson.D{{Key: "$first", Value: "$related_designer_data"}}},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDERS_IN_MONGODB)
+		return
+	}
+	cards := []BoardCard{}
+	if err := cursor.All(ctx, &cards); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDERS_IN_MONGODB)
+		return
+	}
+
+	columns := []BoardColumn{}
+	for _, stage := range Workflow {
+		if groupByStatus {
+			for _, status := range stage.Statuses {
+				columns = append(columns, BoardColumn{Stage: stage.Stage, Status: status, Orders: []BoardCard{}})
+			}
+		} else {
+			columns = append(columns, BoardColumn{Stage: stage.Stage, Orders: []BoardCard{}})
+		}
+	}
+
+	for _, card := range cards {
+		order := schemas.Order{Stage: card.Stage, Status: card.Status}
+		card.Stage, card.Status = CurrentState(order)
+		card.Late = !card.ExpectedDate.IsZero() && card.ExpectedDate.Before(now) && !IsFinished(order)
+		card.AllowedMoves = AllowedMoves(order)
+
+		for i := range columns {
+			if columns[i].Stage == card.Stage && (!groupByStatus || columns[i].Status == card.Status) {
+				columns[i].Orders = append(columns[i].Orders, card)
+				columns[i].Count++
+				break
+			}
+		}
+	}
+
+	groupBy := "stage"
+	if groupByStatus {
+		groupBy = "status"
+	}
+	utils.SendResponse(w, http.StatusOK, "", map[string]any{"group_by": groupBy, "columns": columns}, 0)
+}
+
+// MoveOnBoard aplica o arraste de um cartão no quadro, com as mesmas regras e permissões do
+// endpoint de transição. Quando a movimentação é recusada, retorna as colunas permitidas.
+func MoveOnBoard(w http.ResponseWriter, r *http.Request) {
+	req := BoardMoveRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.ORDERS_INVALID_REQUEST_DATA)
+		return
+	}
+	id, err := bson.ObjectIDFromHex(req.OrderID)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_ORDER_ID_FORMAT)
+		return
+	}
+	if req.Stage == "" || req.Status == "" {
+		utils.SendResponse(w, http.StatusBadRequest, "Informe a etapa e o status de destino", nil, utils.ORDERS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+
+	order := schemas.Order{}
+	err = db.Collection(database.COLLECTION_ORDERS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Pedido não encontrado", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDER_BY_ID_IN_MONGODB)
+		return
+	}
+
+	updated, status, err := moveOrder(ctx, db, order, user, TransitionRequest{
+		Stage:  req.Stage,
+		Status: req.Status,
+		Reason: strings.TrimSpace(req.Reason),
+	})
+	if err != nil {
+		utils.SendResponse(w, status, err.Error(), map[string]any{"allowed_moves": AllowedMoves(order)}, 0)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", updated, 0)
+}
@@ -0,0 +1,464 @@
+package orders
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	DEADLINE_CHECK_INTERVAL = time.Hour
+	DEADLINE_CHECK_TIMEOUT  = 2 * time.Minute
+
+	// Pedidos atrasados há mais tempo que isso são considerados abandonados e deixam de ser monitorados
+	DEADLINE_MAX_LATE_DAYS = 60
+)
+
+// deadlineMutex evita que a verificação agendada e a manual alertem o mesmo pedido duas vezes.
+var deadlineMutex sync.Mutex
+
+type DeadlineUser struct {
+	ID   bson.ObjectID `json:"_id" bson:"_id"`
+	Name string        `json:"name" bson:"name"`
+}
+
+// DeadlineOrder é o pedido em produção com o prazo (data prevista do pedido ou, na falta dela, a
+// previsão de entrega do orçamento) e o risco calculado.
+type DeadlineOrder struct {
+	ID              bson.ObjectID       `json:"_id" bson:"_id"`
+	OldID           uint64              `json:"old_id" bson:"old_id"`
+	Stage           schemas.OrderStage  `json:"stage" bson:"stage"`
+	Status          schemas.OrderStatus `json:"status" bson:"status"`
+	Type            schemas.OrderType   `json:"type,omitempty" bson:"type"`
+	TinyNumber      string              `json:"tiny_number,omitempty" bson:"tiny_number"`
+	Deadline        time.Time           `json:"deadline" bson:"deadline"`
+	StageEnteredAt  time.Time           `json:"stage_entered_at,omitzero" bson:"stage_entered_at"`
+	RelatedSeller   *DeadlineUser       `json:"related_seller,omitempty" bson:"related_seller"`
+	RelatedDesigner *DeadlineUser       `json:"related_designer,omitempty" bson:"related_designer"`
+	Risk            string              `json:"risk" bson:"-"`
+	RemainingDays   int                 `json:"remaining_days" bson:"-"`
+	RequiredDays    int                 `json:"required_days" bson:"-"`
+	StoredRisk      string              `json:"-" bson:"deadline_risk"`
+	Alerts          []string            `json:"-" bson:"deadline_alerts"`
+}
+
+type DeadlineCount struct {
+	Late   int `json:"late"`
+	AtRisk int `json:"at_risk"`
+}
+
+func (c *DeadlineCount) add(risk string) {
+	if risk == schemas.ORDER_DEADLINE_RISK_LATE {
+		c.Late++
+	} else {
+		c.AtRisk++
+	}
+}
+
+type DeadlineStage struct {
+	Stage schemas.OrderStage `json:"stage"`
+	DeadlineCount
+	Orders []DeadlineOrder `json:"orders"`
+}
+
+type DeadlineSeller struct {
+	Seller *DeadlineUser `json:"seller"`
+	DeadlineCount
+}
+
+type DeadlineCheckResult struct {
+	Checked int `json:"checked"`
+	Flagged int `json:"flagged"`
+	Cleared int `json:"cleared"`
+	Alerted int `json:"alerted"`
+}
+
+func dateOnly(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// businessDaysUntil conta os dias úteis (segunda a sexta) depois de `from` até `to`, inclusive.
+func businessDaysUntil(from, to time.Time) int {
+	days := 0
+	for date, end := dateOnly(from), dateOnly(to.In(from.Location())); date.Before(end); {
+		date = date.AddDate(0, 0, 1)
+		if date.Weekday() != time.Saturday && date.Weekday() != time.Sunday {
+			days++
+		}
+	}
+	return days
+}
+
+// requiredBusinessDays soma os dias úteis da etapa atual e das próximas etapas obrigatórias. Se o pedido
+// já está em um status final da etapa, ela não conta mais.
+func requiredBusinessDays(stage schemas.OrderStage, status schemas.OrderStatus) int {
+	i := stageIndex(stage)
+	if i < 0 {
+		return 0
+	}
+	days := 0
+	if !slices.Contains(Workflow[i].Final, status) {
+		days += Workflow[i].Days
+	}
+	for _, next := range Workflow[i+1:] {
+		if !next.Optional {
+			days += next.Days
+		}
+	}
+	return days
+}
+
+// DeadlineRisk classifica o prazo do pedido: atrasado quando a data já passou e em risco quando os dias
+// úteis restantes não cobrem o tempo típico das etapas que faltam. Retorna "" para pedidos no prazo.
+func DeadlineRisk(order schemas.Order, deadline, now time.Time) (string, int, int) {
+	if deadline.IsZero() || IsFinished(order) {
+		return "", 0, 0
+	}
+	stage, status := CurrentState(order)
+	required := requiredBusinessDays(stage, status)
+	if dateOnly(deadline.In(now.Location())).Before(dateOnly(now)) {
+		return schemas.ORDER_DEADLINE_RISK_LATE, 0, required
+	}
+	remaining := businessDaysUntil(now, deadline)
+	if remaining < required {
+		return schemas.ORDER_DEADLINE_RISK_AT_RISK, remaining, required
+	}
+	return "", remaining, required
+}
+
+// appendUserFilters acrescenta os filtros `seller` e `designer` (ids de usuário) usados no quadro e nos prazos.
+func appendUserFilters(filter bson.D, query url.Values) (bson.D, string) {
+	for param, field := range map[string]string{"seller": "related_seller", "designer": "related_designer"} {
+		if value := query.Get(param); value != "" {
+			id, err := bson.ObjectIDFromHex(value)
+			if err != nil {
+				return filter, param
+			}
+			filter = append(filter, bson.E{Key: field, Value: id})
+		}
+	}
+	return filter, ""
+}
+
+// findDeadlineOrders busca os pedidos em produção que têm prazo, já com o risco calculado.
+func findDeadlineOrders(ctx context.Context, db *mongo.Database, filter bson.D, now time.Time) ([]DeadlineOrder, error) {
+	match := append(bson.D{{Key: "$nor", Value: bson.A{bson.D{
+		{Key: "stage", Value: schemas.StageExpedicao},
+		{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{schemas.StatusEntregue, schemas.StatusDevolucao}}}},
+	}}}}, filter...)
+
+	userLookup := func(field string) bson.D {
+		return bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: database.COLLECTION_USERS},
+			{Key: "localField", Value: field},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "pipeline", Value: bson.A{bson.D{{Key: "$project", Value: bson.D{{Key: "name", Value: 1}}}}}},
+			{Key: "as", Value: field + "_data"},
+		}}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: database.COLLECTION_BUDGETS},
+			{Key: "localField", Value: "related_budget"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "pipeline", Value: bson.A{bson.D{{Key: "$project", Value: bson.D{{Key: "delivery_forecast", Value: 1}}}}}},
+			{Key: "as", Value: "budget_data"},
+		}}},
+		{{Key: "$addFields", Value: bson.D{{Key: "deadline", Value: bson.D{
+			{Key: "$ifNull", Value: bson.A{"$expected_date", bson.D{{Key: "$first", Value: "$budget_data.delivery_forecast"}}}},
+		}}}}},
+		// Também descarta datas zeradas de orçamentos antigos
+		{{Key: "$match", Value: bson.D{{Key: "deadline", Value: bson.D{{Key: "$gte", Value: now.AddDate(0, 0, -DEADLINE_MAX_LATE_DAYS)}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "deadline", Value: 1}}}},
+		userLookup("related_seller"),
+		userLookup("related_designer"),
+		{{Key: "$project", Value: bson.D{
+			{Key: "old_id", Value: 1},
+			{Key: "stage", Value: 1},
+			{Key: "status", Value: 1},
+			{Key: "type", Value: 1},
+			{Key: "tiny_number", Value: "$tiny.number"},
+			{Key: "deadline", Value: 1},
+			{Key: "stage_entered_at", Value: 1},
+			{Key: "deadline_risk", Value: 1},
+			{Key: "deadline_alerts", Value: 1},
+			{Key: "related_seller", Value: bson.D{{Key: "$first", Value: "$related_seller_data"}}},
+			{Key: "related_designer", Value: bson.D{{Key: "$first", Value: "$related_designer_data"}}},
+		}}},
+	}
+
+	cursor, err := db.Collection(database.COLLECTION_ORDERS).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	orders := []DeadlineOrder{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		order := schemas.Order{Stage: orders[i].Stage, Status: orders[i].Status}
+		orders[i].Stage, orders[i].Status = CurrentState(order)
+		orders[i].Risk, orders[i].RemainingDays, orders[i].RequiredDays = DeadlineRisk(order, orders[i].Deadline, now)
+	}
+	return orders, nil
+}
+
+// deadlineRecipients retorna o vendedor e o designer do pedido.
+func deadlineRecipients(order DeadlineOrder) []string {
+	recipients := []string{}
+	for _, user := range []*DeadlineUser{order.RelatedSeller, order.RelatedDesigner} {
+		if user != nil && !slices.Contains(recipients, user.ID.Hex()) {
+			recipients = append(recipients, user.ID.Hex())
+		}
+	}
+	return recipients
+}
+
+// deadlineAlertKey identifica, em deadline_alerts, o alerta de um nível de risco já entregue ao usuário.
+func deadlineAlertKey(risk, userID string) string {
+	return risk + ":" + userID
+}
+
+// notifyDeadline avisa os usuários pelo websocket de pedidos e retorna os que estavam conectados.
+func notifyDeadline(order DeadlineOrder, recipients []string) []string {
+	number := orderNumber(schemas.Order{ID: order.ID, OldID: order.OldID, Tiny: schemas.TinyOrder{Number: order.TinyNumber}})
+	details := fmt.Sprintf("Pedido nº %s está atrasado: a data prevista era %s", number, order.Deadline.Format("02/01/2006"))
+	if order.Risk == schemas.ORDER_DEADLINE_RISK_AT_RISK {
+		details = fmt.Sprintf("Pedido nº %s em risco de atraso: faltam %d dia(s) úteis para %s e as etapas restantes levam %d",
+			number, order.RemainingDays, order.Deadline.Format("02/01/2006"), order.RequiredDays)
+	}
+
+	return notifyOrderUsers(recipients, OrderBoardWSMessage{
+		Action:  "order_deadline_alert",
+		Order:   order,
+		Stage:   order.Stage,
+		Status:  order.Status,
+		Details: details,
+	})
+}
+
+// checkDeadlines grava o risco de cada pedido, limpa o dos que voltaram ao prazo ou saíram da produção e
+// alerta vendedor e designer uma única vez por nível de risco. O alerta só é registrado para quem estava
+// conectado; quem estava offline recebe na próxima verificação.
+func checkDeadlines(ctx context.Context, db *mongo.Database) (DeadlineCheckResult, error) {
+	result := DeadlineCheckResult{}
+	now := time.Now()
+	collection := db.Collection(database.COLLECTION_ORDERS)
+
+	orders, err := findDeadlineOrders(ctx, db, bson.D{}, now)
+	if err != nil {
+		return result, err
+	}
+
+	flagged := bson.A{}
+	for _, order := range orders {
+		result.Checked++
+		if order.Risk == "" {
+			continue
+		}
+		flagged = append(flagged, order.ID)
+
+		if order.StoredRisk != order.Risk {
+			update := bson.D{{Key: "$set", Value: bson.D{{Key: "deadline_risk", Value: order.Risk}, {Key: "deadline_risk_since", Value: now}}}}
+			if _, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: order.ID}}, update); err != nil {
+				return result, err
+			}
+			result.Flagged++
+		}
+
+		pending := []string{}
+		for _, userID := range deadlineRecipients(order) {
+			if !slices.Contains(order.Alerts, deadlineAlertKey(order.Risk, userID)) {
+				pending = append(pending, userID)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		delivered := notifyDeadline(order, pending)
+		if len(delivered) == 0 {
+			continue
+		}
+		keys := bson.A{}
+		for _, userID := range delivered {
+			keys = append(keys, deadlineAlertKey(order.Risk, userID))
+		}
+		update := bson.D{{Key: "$addToSet", Value: bson.D{{Key: "deadline_alerts", Value: bson.D{{Key: "$each", Value: keys}}}}}}
+		if _, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: order.ID}}, update); err != nil {
+			return result, err
+		}
+		result.Alerted++
+	}
+
+	cleared, err := collection.UpdateMany(ctx,
+		bson.D{
+			{Key: "deadline_risk", Value: bson.D{{Key: "$exists", Value: true}}},
+			{Key: "_id", Value: bson.D{{Key: "$nin", Value: flagged}}},
+		},
+		bson.D{{Key: "$unset", Value: bson.D{
+			{Key: "deadline_risk", Value: ""},
+			{Key: "deadline_risk_since", Value: ""},
+			{Key: "deadline_alerts", Value: ""},
+		}}},
+	)
+	if err != nil {
+		return result, err
+	}
+	result.Cleared = int(cleared.ModifiedCount)
+	return result, nil
+}
+
+// StartDeadlineMonitor agenda a verificação periódica dos prazos dos pedidos em produção.
+func StartDeadlineMonitor() {
+	utils.RunEvery("DeadlineMonitor", DEADLINE_CHECK_INTERVAL, DEADLINE_CHECK_TIMEOUT, func(ctx context.Context) error {
+		if !deadlineMutex.TryLock() {
+			return nil
+		}
+		defer deadlineMutex.Unlock()
+
+		mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+		if err != nil {
+			return err
+		}
+		defer mongoClient.Disconnect(ctx)
+
+		_, err = checkDeadlines(ctx, mongoClient.Database(database.GetDB()))
+		return err
+	})
+}
+
+// GetDeadlines lista os pedidos atrasados e em risco agrupados por etapa, com o total por vendedor.
+// Aceita os filtros `seller`, `designer`, `stage` e `risk` (late ou at_risk).
+func GetDeadlines(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, invalid := appendUserFilters(bson.D{}, query)
+	if invalid != "" {
+		utils.SendResponse(w, http.StatusBadRequest, "Parâmetro '"+invalid+"' inválido", nil, utils.ORDERS_INVALID_REQUEST_DATA)
+		return
+	}
+	stageFilter := schemas.OrderStage(query.Get("stage"))
+	if stageFilter != "" && stageIndex(stageFilter) < 0 {
+		utils.SendResponse(w, http.StatusBadRequest, "Parâmetro 'stage' inválido", nil, utils.ORDERS_INVALID_REQUEST_DATA)
+		return
+	}
+	riskFilter := query.Get("risk")
+	if riskFilter != "" && riskFilter != schemas.ORDER_DEADLINE_RISK_LATE && riskFilter != schemas.ORDER_DEADLINE_RISK_AT_RISK {
+		utils.SendResponse(w, http.StatusBadRequest, "Parâmetro 'risk' inválido", nil, utils.ORDERS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	orders, err := findDeadlineOrders(ctx, mongoClient.Database(database.GetDB()), filter, time.Now())
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDERS_IN_MONGODB)
+		return
+	}
+
+	summary := DeadlineCount{}
+	stages := []DeadlineStage{}
+	for _, stage := range Workflow {
+		stages = append(stages, DeadlineStage{Stage: stage.Stage, Orders: []DeadlineOrder{}})
+	}
+	sellers := map[bson.ObjectID]*DeadlineSeller{}
+	for _, order := range orders {
+		if order.Risk == "" || (riskFilter != "" && order.Risk != riskFilter) || (stageFilter != "" && order.Stage != stageFilter) {
+			continue
+		}
+		summary.add(order.Risk)
+
+		if i := stageIndex(order.Stage); i >= 0 {
+			stages[i].add(order.Risk)
+			stages[i].Orders = append(stages[i].Orders, order)
+		}
+
+		sellerID := bson.ObjectID{}
+		if order.RelatedSeller != nil {
+			sellerID = order.RelatedSeller.ID
+		}
+		if sellers[sellerID] == nil {
+			sellers[sellerID] = &DeadlineSeller{Seller: order.RelatedSeller}
+		}
+		sellers[sellerID].add(order.Risk)
+	}
+
+	bySeller := []DeadlineSeller{}
+	for _, seller := range sellers {
+		bySeller = append(bySeller, *seller)
+	}
+	sort.Slice(bySeller, func(i, j int) bool {
+		a, b := bySeller[i], bySeller[j]
+		if a.Late+a.AtRisk != b.Late+b.AtRisk {
+			return a.Late+a.AtRisk > b.Late+b.AtRisk
+		}
+		return a.Seller != nil && (b.Seller == nil || a.Seller.Name < b.Seller.Name)
+	})
+
+	utils.SendResponse(w, http.StatusOK, "", map[string]any{
+		"summary": summary,
+		"stages":  stages,
+		"sellers": bySeller,
+	}, 0)
+}
+
+// CheckDeadlines executa agora a verificação de prazos, sem esperar o agendamento.
+func CheckDeadlines(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), DEADLINE_CHECK_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+	if !utils.HasAnyRole(user, workflowAdminRoles) {
+		utils.SendResponse(w, http.StatusForbidden, "Usuário sem permissão para verificar os prazos", nil, 0)
+		return
+	}
+
+	if !deadlineMutex.TryLock() {
+		utils.SendResponse(w, http.StatusConflict, "Já existe uma verificação de prazos em andamento", nil, 0)
+		return
+	}
+	defer deadlineMutex.Unlock()
+
+	result, err := checkDeadlines(ctx, db)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_ORDER_IN_MONGODB)
+		return
+	}
+	utils.SendResponse(w, http.StatusOK, "", result, 0)
+}
@@ -0,0 +1,51 @@
+package orders
+
+import (
+	"api/database"
+	"api/entities/exports"
+	"api/utils"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var exportColumns = []exports.Column{
+	{Key: "id", Header: "ID", Path: "_id"},
+	{Key: "old_id", Header: "ID antigo", Path: "old_id"},
+	{Key: "tiny_number", Header: "Número no Tiny", Path: "tiny.number"},
+	{Key: "type", Header: "Tipo", Path: "type"},
+	{Key: "status", Header: "Status", Path: "status"},
+	{Key: "stage", Header: "Etapa", Path: "stage"},
+	{Key: "seller", Header: "Vendedor", Path: "seller_name"},
+	{Key: "designer", Header: "Designer", Path: "designer_name"},
+	{Key: "related_budget", Header: "Orçamento", Path: "related_budget"},
+	{Key: "total", Header: "Total", Value: func(doc bson.M) any {
+		if value, ok := utils.OrderValue(doc); ok {
+			return value
+		}
+		return nil
+	}},
+	{Key: "tracking_code", Header: "Código de rastreio", Path: "tracking_code"},
+	{Key: "tracking_status", Header: "Situação do rastreio", Path: "tracking_status"},
+	{Key: "deadline_risk", Header: "Risco de prazo", Path: "deadline_risk"},
+	{Key: "invoice_number", Header: "Nota fiscal", Path: "invoice.number"},
+	{Key: "expected_date", Header: "Data prevista", Path: "expected_date"},
+	{Key: "payment_date", Header: "Data de pagamento", Path: "payment_date"},
+	{Key: "created_at", Header: "Criado em", Path: "created_at"},
+	{Key: "updated_at", Header: "Atualizado em", Path: "updated_at"},
+}
+
+// Export exporta os pedidos em CSV ou XLSX com os mesmos filtros de GET /v1/orders.
+func Export(w http.ResponseWriter, r *http.Request) {
+	stages := exports.LookupField(database.COLLECTION_USERS, "related_seller", "name", "seller_name")
+	stages = append(stages, exports.LookupField(database.COLLECTION_USERS, "related_designer", "name", "designer_name")...)
+
+	exports.Export(w, r, exports.Source{
+		Entity:     "orders",
+		Collection: database.COLLECTION_ORDERS,
+		Filter:     buildFilterFromQueryParams(r),
+		Sort:       bson.D{{Key: "created_at", Value: -1}},
+		Stages:     stages,
+		Columns:    exportColumns,
+	})
+}
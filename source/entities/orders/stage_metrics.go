@@ -0,0 +1,164 @@
+package orders
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type StageMetrics struct {
+	Stage schemas.OrderStage `json:"stage"`
+	// Pedidos que saíram da etapa no período e o tempo (em segundos) que ficaram nela
+	CompletedOrders int64   `json:"completed_orders"`
+	AvgSeconds      float64 `json:"avg_seconds"`
+	MaxSeconds      float64 `json:"max_seconds"`
+	// Pedidos parados na etapa agora e há quanto tempo, em média
+	InProgress        int64   `json:"in_progress"`
+	AvgWaitingSeconds float64 `json:"avg_waiting_seconds"`
+}
+
+type StageMetricsResponse struct {
+	Stages []StageMetrics `json:"stages"`
+	// Etapa com maior tempo médio de permanência e etapa com mais pedidos acumulados
+	Bottleneck        schemas.OrderStage `json:"bottleneck,omitempty"`
+	LargestQueueStage schemas.OrderStage `json:"largest_queue_stage,omitempty"`
+}
+
+// GetStageMetrics calcula os gargalos da produção por etapa a partir do histórico de transições.
+// Os parâmetros `from` e `until` (RFC3339) limitam as transições consideradas.
+func GetStageMetrics(w http.ResponseWriter, r *http.Request) {
+	atFilter := bson.D{}
+	for param, operator := range map[string]string{"from": "$gte", "until": "$lte"} {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			utils.SendResponse(w, http.StatusBadRequest, "Parâmetro '"+param+"' inválido", nil, utils.ORDERS_INVALID_REQUEST_DATA)
+			return
+		}
+		atFilter = append(atFilter, bson.E{Key: operator, Value: parsed})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_ORDERS)
+
+	historyMatch := bson.D{{Key: "history.0", Value: bson.D{{Key: "$exists", Value: true}}}}
+	if len(atFilter) > 0 {
+		historyMatch = bson.D{{Key: "history.at", Value: atFilter}}
+	}
+	completedPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: historyMatch}},
+		{{Key: "$unwind", Value: "$history"}},
+	}
+	if len(atFilter) > 0 {
+		completedPipeline = append(completedPipeline, bson.D{{Key: "$match", Value: bson.D{{Key: "history.at", Value: atFilter}}}})
+	}
+	// Soma primeiro por pedido e etapa: mudanças de status dentro da etapa contam como a mesma passagem
+	completedPipeline = append(completedPipeline,
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "order", Value: "$_id"}, {Key: "stage", Value: "$history.from_stage"}}},
+			{Key: "seconds", Value: bson.D{{Key: "$sum", Value: "$history.duration"}}},
+		}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$_id.stage"},
+			{Key: "orders", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "avg", Value: bson.D{{Key: "$avg", Value: "$seconds"}}},
+			{Key: "max", Value: bson.D{{Key: "$max", Value: "$seconds"}}},
+		}}},
+	)
+
+	var completed []struct {
+		Stage  schemas.OrderStage `bson:"_id"`
+		Orders int64              `bson:"orders"`
+		Avg    float64            `bson:"avg"`
+		Max    float64            `bson:"max"`
+	}
+	cursor, err := collection.Aggregate(ctx, completedPipeline)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDERS_IN_MONGODB)
+		return
+	}
+	if err := cursor.All(ctx, &completed); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDERS_IN_MONGODB)
+		return
+	}
+
+	now := time.Now()
+	waitingPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "$nor", Value: bson.A{
+			bson.D{
+				{Key: "stage", Value: schemas.StageExpedicao},
+				{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{schemas.StatusEntregue, schemas.StatusDevolucao}}}},
+			},
+		}}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$stage", schemas.StageDesign}}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "avg", Value: bson.D{{Key: "$avg", Value: bson.D{{Key: "$divide", Value: bson.A{
+				bson.D{{Key: "$subtract", Value: bson.A{now, bson.D{{Key: "$ifNull", Value: bson.A{"$stage_entered_at", "$created_at"}}}}}},
+				1000,
+			}}}}}},
+		}}},
+	}
+
+	var waiting []struct {
+		Stage schemas.OrderStage `bson:"_id"`
+		Count int64              `bson:"count"`
+		Avg   float64            `bson:"avg"`
+	}
+	cursor, err = collection.Aggregate(ctx, waitingPipeline)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDERS_IN_MONGODB)
+		return
+	}
+	if err := cursor.All(ctx, &waiting); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDERS_IN_MONGODB)
+		return
+	}
+
+	response := StageMetricsResponse{Stages: []StageMetrics{}}
+	slowest, largest := 0.0, int64(0)
+	for _, stage := range Workflow {
+		metrics := StageMetrics{Stage: stage.Stage}
+		for _, c := range completed {
+			if c.Stage == stage.Stage {
+				metrics.CompletedOrders, metrics.AvgSeconds, metrics.MaxSeconds = c.Orders, c.Avg, c.Max
+			}
+		}
+		for _, wt := range waiting {
+			if wt.Stage == stage.Stage {
+				metrics.InProgress, metrics.AvgWaitingSeconds = wt.Count, wt.Avg
+			}
+		}
+		if metrics.AvgSeconds > slowest {
+			slowest, response.Bottleneck = metrics.AvgSeconds, stage.Stage
+		}
+		if metrics.InProgress > largest {
+			largest, response.LargestQueueStage = metrics.InProgress, stage.Stage
+		}
+		response.Stages = append(response.Stages, metrics)
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", response, 0)
+}
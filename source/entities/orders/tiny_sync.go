@@ -0,0 +1,662 @@
+package orders
+
+import (
+	"api/database"
+	"api/integrations/tiny"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	// Buscar cada pedido e nota no Tiny é lento; a sincronização tem um prazo maior que o das demais rotas
+	TINY_SYNC_TIMEOUT = 3 * time.Minute
+
+	// Quantos pedidos sem tiny.id são enviados por execução
+	TINY_SYNC_EXPORT_LIMIT = 50
+
+	TINY_SYNC_TRANSITION_REASON = "Sincronização com o Tiny"
+)
+
+// tinySyncMutex evita duas sincronizações simultâneas criando o mesmo pedido duas vezes.
+var tinySyncMutex sync.Mutex
+
+type tinySync struct {
+	db     *mongo.Database
+	client tiny.Client
+	log    *schemas.TinySyncLog
+}
+
+func (s *tinySync) fail(orderID bson.ObjectID, tinyID string, err error) {
+	s.log.Errors = append(s.log.Errors, schemas.TinySyncError{OrderID: orderID, TinyID: tinyID, Error: err.Error()})
+}
+
+func (s *tinySync) conflict(order schemas.Order, tinyID, field, local, remote, message string) {
+	s.log.Conflicts = append(s.log.Conflicts, schemas.TinySyncConflict{
+		OrderID: order.ID, TinyID: tinyID, Field: field, Local: local, Remote: remote, Message: message,
+	})
+}
+
+// lastTinyImport retorna o início da última importação bem-sucedida, usado como dataAtualizacao.
+func lastTinyImport(ctx context.Context, db *mongo.Database) time.Time {
+	last := schemas.TinySyncLog{}
+	err := db.Collection(database.COLLECTION_TINY_SYNC_LOGS).FindOne(ctx,
+		bson.D{
+			{Key: "success", Value: true},
+			{Key: "direction", Value: bson.D{{Key: "$in", Value: bson.A{schemas.TINY_SYNC_DIRECTION_IMPORT, schemas.TINY_SYNC_DIRECTION_BOTH}}}},
+		},
+		options.FindOne().SetSort(bson.D{{Key: "started_at", Value: -1}}),
+	).Decode(&last)
+	if err != nil {
+		return time.Time{}
+	}
+	return last.StartedAt
+}
+
+// importOrders percorre os pedidos alterados no Tiny desde `since` e cria ou atualiza os pedidos locais.
+func (s *tinySync) importOrders(ctx context.Context, since time.Time) error {
+	for page, pages := 1, 1; page <= pages; page++ {
+		summaries, total, err := s.client.SearchOrders(ctx, since, page)
+		if err != nil {
+			return fmt.Errorf("erro ao pesquisar pedidos no Tiny: %w", err)
+		}
+		pages = total
+
+		for _, summary := range summaries {
+			// A pesquisa não traz itens, rastreio nem nota; o pedido completo vem de pedido.obter
+			remote, err := s.client.GetOrder(ctx, summary.ID)
+			if err != nil {
+				s.fail(bson.ObjectID{}, summary.ID, err)
+				continue
+			}
+			if err := s.syncOrder(ctx, *remote); err != nil {
+				s.fail(bson.ObjectID{}, remote.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// findLocalOrder procura o pedido pelo tiny.id ou, para pedidos enviados por nós, pelo numero_ecommerce.
+func (s *tinySync) findLocalOrder(ctx context.Context, remote schemas.TinyOrder) (*schemas.Order, error) {
+	collection := s.db.Collection(database.COLLECTION_ORDERS)
+	order := schemas.Order{}
+	err := collection.FindOne(ctx, bson.D{{Key: "tiny.id", Value: remote.ID}}).Decode(&order)
+	if err == nil {
+		return &order, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if remote.NumeroEcommerce == nil {
+		return nil, nil
+	}
+	id, err := bson.ObjectIDFromHex(*remote.NumeroEcommerce)
+	if err != nil {
+		return nil, nil
+	}
+	err = collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (s *tinySync) syncOrder(ctx context.Context, remote schemas.TinyOrder) error {
+	local, err := s.findLocalOrder(ctx, remote)
+	if err != nil {
+		return err
+	}
+	if local == nil {
+		return s.createLocalOrder(ctx, remote)
+	}
+	return s.updateLocalOrder(ctx, *local, remote)
+}
+
+func lineItemsFromTiny(remote schemas.TinyOrder) []schemas.LineItem {
+	items := []schemas.LineItem{}
+	for _, wrapped := range remote.Itens {
+		item := wrapped.Item
+		price := tiny.ParseNumber(item.ValorUnitario)
+		items = append(items, schemas.LineItem{
+			SKU:         item.Codigo,
+			Description: item.Descricao,
+			Quantity:    tiny.ParseNumber(item.Quantidade),
+			UnitPrice:   &price,
+		})
+	}
+	utils.SumLineItems(items)
+	return items
+}
+
+func (s *tinySync) fetchInvoice(ctx context.Context, id string) (*schemas.OrderInvoice, error) {
+	invoice, err := s.client.GetInvoice(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar a nota fiscal %s: %w", id, err)
+	}
+	result := &schemas.OrderInvoice{
+		ID:        invoice.ID,
+		Number:    invoice.Numero,
+		Series:    invoice.Serie,
+		AccessKey: invoice.ChaveAcesso,
+		Total:     tiny.ParseNumber(invoice.ValorNota),
+	}
+	if issuedAt, err := tiny.ParseDate(invoice.DataEmissao); err == nil {
+		result.IssuedAt = issuedAt
+	}
+	if result.ID == "" {
+		result.ID = id
+	}
+	return result, nil
+}
+
+// createLocalOrder importa um pedido que só existe no Tiny.
+func (s *tinySync) createLocalOrder(ctx context.Context, remote schemas.TinyOrder) error {
+	now := time.Now()
+	order := schemas.Order{
+		Type:            schemas.TypePrazoNormal,
+		Stage:           schemas.StageDesign,
+		Status:          schemas.StatusPendente,
+		Items:           lineItemsFromTiny(remote),
+		TrackingCode:    remote.CodigoRastreamento,
+		Tiny:            remote,
+		Notes:           remote.Obs,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		StageEnteredAt:  now,
+		StatusChangedAt: now,
+		TinySyncedAt:    now,
+	}
+	if status, ok := tiny.StatusFromSituacao(remote.Situacao); ok {
+		order.Stage, order.Status = schemas.StageExpedicao, status
+	}
+	if createdAt, err := tiny.ParseDate(remote.DataPedido); err == nil {
+		order.CreatedAt = createdAt
+	}
+	if expected, err := tiny.ParseDate(remote.DataPrevista); err == nil {
+		order.ExpectedDate = expected
+	}
+	if remote.IdNotaFiscal != "" {
+		invoice, err := s.fetchInvoice(ctx, remote.IdNotaFiscal)
+		if err != nil {
+			s.fail(bson.ObjectID{}, remote.ID, err)
+		}
+		order.Invoice = invoice
+	}
+
+	result, err := s.db.Collection(database.COLLECTION_ORDERS).InsertOne(ctx, order)
+	if err != nil {
+		return err
+	}
+	order.ID, _ = result.InsertedID.(bson.ObjectID)
+	s.log.Imported++
+	NotifyBoard("order_created", order, "", "")
+	return nil
+}
+
+// updateLocalOrder atualiza o snapshot do Tiny, rastreio, nota e status. Divergências que não podem ser
+// resolvidas sem perder informação local viram conflitos no log.
+func (s *tinySync) updateLocalOrder(ctx context.Context, local schemas.Order, remote schemas.TinyOrder) error {
+	changed := false
+	set := bson.D{{Key: "tiny", Value: remote}, {Key: "tiny_synced_at", Value: time.Now()}}
+
+	if !sameTinySnapshot(local.Tiny, remote) {
+		changed = true
+	}
+
+	if remote.CodigoRastreamento != "" && remote.CodigoRastreamento != local.TrackingCode {
+		if local.TrackingCode == "" {
+			set = append(set, bson.E{Key: "tracking_code", Value: remote.CodigoRastreamento})
+			changed = true
+		} else {
+			s.conflict(local, remote.ID, "tracking_code", local.TrackingCode, remote.CodigoRastreamento,
+				"Código de rastreio diferente no Tiny; o código local foi mantido")
+		}
+	}
+
+	if remote.IdNotaFiscal != "" && (local.Invoice == nil || local.Invoice.ID != remote.IdNotaFiscal) {
+		invoice, err := s.fetchInvoice(ctx, remote.IdNotaFiscal)
+		if err != nil {
+			s.fail(local.ID, remote.ID, err)
+		} else {
+			set = append(set, bson.E{Key: "invoice", Value: invoice})
+			changed = true
+		}
+	}
+
+	if tiny.IsCancelled(remote.Situacao) {
+		_, localStatus := CurrentState(local)
+		s.conflict(local, remote.ID, "status", string(localStatus), remote.Situacao, "Pedido cancelado no Tiny")
+	} else if status, ok := tiny.StatusFromSituacao(remote.Situacao); ok && status != local.Status {
+		if err := ValidateTransition(local, schemas.StageExpedicao, status, ""); err != nil {
+			_, localStatus := CurrentState(local)
+			s.conflict(local, remote.ID, "status", string(localStatus), remote.Situacao, err.Error())
+		} else {
+			fromStage, fromStatus := CurrentState(local)
+			updated, err := ApplyTransition(ctx, s.db, local, schemas.OrderTransition{
+				ToStage:  schemas.StageExpedicao,
+				ToStatus: status,
+				Reason:   TINY_SYNC_TRANSITION_REASON,
+			})
+			if err != nil {
+				s.fail(local.ID, remote.ID, err)
+			} else {
+				NotifyBoard("order_moved", updated, fromStage, fromStatus)
+				changed = true
+			}
+		}
+	}
+
+	_, err := s.db.Collection(database.COLLECTION_ORDERS).UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: local.ID}},
+		bson.D{
+			{Key: "$set", Value: set},
+			{Key: "$unset", Value: bson.D{{Key: "tiny_sync_error", Value: ""}}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if changed {
+		s.log.Updated++
+		queueLeadTier(ctx, s.db, local.ID)
+	} else {
+		s.log.Unchanged++
+	}
+	return nil
+}
+
+func sameTinySnapshot(a, b schemas.TinyOrder) bool {
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(rawA) == string(rawB)
+}
+
+// exportOrders envia ao Tiny os pedidos criados a partir de orçamentos que ainda não têm tiny.id.
+// Pedidos que falharam ficam marcados com tiny_sync_error e só são reenviados individualmente.
+func (s *tinySync) exportOrders(ctx context.Context) error {
+	cursor, err := s.db.Collection(database.COLLECTION_ORDERS).Find(ctx,
+		bson.D{
+			{Key: "tiny.id", Value: bson.D{{Key: "$in", Value: bson.A{nil, ""}}}},
+			{Key: "related_budget", Value: bson.D{{Key: "$exists", Value: true}}},
+			{Key: "tiny_sync_error", Value: bson.D{{Key: "$exists", Value: false}}},
+		},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(TINY_SYNC_EXPORT_LIMIT),
+	)
+	if err != nil {
+		return err
+	}
+	orders := []schemas.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		if err := s.pushOrder(ctx, order); err != nil {
+			s.fail(order.ID, "", err)
+		}
+	}
+	return nil
+}
+
+// tinyOrderInput monta o pedido do Tiny com os dados do orçamento, do cliente e do vendedor.
+func (s *tinySync) tinyOrderInput(ctx context.Context, order schemas.Order) (tiny.OrderInput, error) {
+	input := tiny.OrderInput{
+		DataPedido:            order.CreatedAt.Format("02/01/2006"),
+		NumeroPedidoEcommerce: order.ID.Hex(),
+		Obs:                   order.Notes,
+	}
+	if !order.ExpectedDate.IsZero() {
+		input.DataPrevista = order.ExpectedDate.Format("02/01/2006")
+	}
+	if len(order.Items) == 0 {
+		return input, errors.New("pedido sem itens")
+	}
+	for _, item := range order.Items {
+		input.Itens = append(input.Itens, schemas.TinyItem{Item: schemas.TinyItemDetail{
+			Codigo:        item.SKU,
+			Descricao:     item.Description,
+			Unidade:       "UN",
+			Quantidade:    tiny.FormatNumber(item.Quantity),
+			ValorUnitario: tiny.FormatNumber(utils.LineItemPrice(item)),
+		}})
+	}
+
+	budget := schemas.Budget{}
+	if !order.RelatedBudget.IsZero() {
+		_ = s.db.Collection(database.COLLECTION_BUDGETS).FindOne(ctx, bson.D{{Key: "_id", Value: order.RelatedBudget}}).Decode(&budget)
+	}
+	lead := schemas.Lead{}
+	if !budget.RelatedLead.IsZero() {
+		_ = s.db.Collection(database.COLLECTION_LEADS).FindOne(ctx, bson.D{{Key: "_id", Value: budget.RelatedLead}}).Decode(&lead)
+	}
+	clientID := budget.RelatedClient
+	if clientID.IsZero() {
+		clientID = lead.RelatedClient
+	}
+	contact := schemas.Contact{}
+	if !clientID.IsZero() {
+		client := schemas.Client{}
+		if err := s.db.Collection(database.COLLECTION_CLIENTS).FindOne(ctx, bson.D{{Key: "_id", Value: clientID}}).Decode(&client); err == nil {
+			contact = client.Contact
+		}
+	}
+	seller := schemas.User{}
+	if !order.RelatedSeller.IsZero() {
+		_ = s.db.Collection(database.COLLECTION_USERS).FindOne(ctx, bson.D{{Key: "_id", Value: order.RelatedSeller}}).Decode(&seller)
+	}
+
+	cliente := schemas.TinyCliente{
+		Nome:        contact.Name,
+		Email:       contact.Email,
+		Fone:        contact.CellPhone,
+		Cep:         contact.ZipCode,
+		Endereco:    contact.Address,
+		Numero:      contact.Number,
+		Complemento: contact.Complement,
+		Bairro:      contact.Neighborhood,
+		Cidade:      contact.City,
+		Uf:          contact.State,
+		CpfCnpj:     contact.CPF,
+		TipoPessoa:  "F",
+	}
+	if contact.CNPJ != "" {
+		cliente.CpfCnpj, cliente.TipoPessoa = contact.CNPJ, "J"
+		if contact.CompanyName != "" {
+			cliente.NomeFantasia = contact.CompanyName
+		}
+	}
+	if cliente.Nome == "" {
+		cliente.Nome = lead.Name
+	}
+	if cliente.Fone == "" {
+		cliente.Fone = lead.Phone
+	}
+	if cliente.Cep == "" {
+		cliente.Cep, cliente.Endereco = budget.Address.CEP, budget.Address.Details
+		cliente.Bairro, cliente.Cidade, cliente.Uf = budget.Address.Neighborhood, budget.Address.City, budget.Address.State
+	}
+	if cliente.Nome == "" {
+		return input, errors.New("pedido sem cliente identificado")
+	}
+	input.Cliente = cliente
+
+	if budget.Delivery.Price > 0 {
+		input.ValorFrete = tiny.FormatNumber(budget.Delivery.Price)
+	}
+	if budget.Totals != nil && budget.Totals.Discount > 0 {
+		input.ValorDesconto = tiny.FormatNumber(budget.Totals.Discount)
+	}
+	input.FormaPagamento = budget.PaymentMethod
+	input.NomeVendedor = seller.Name
+	return input, nil
+}
+
+func (s *tinySync) pushOrder(ctx context.Context, order schemas.Order) error {
+	collection := s.db.Collection(database.COLLECTION_ORDERS)
+
+	input, err := s.tinyOrderInput(ctx, order)
+	var created *tiny.CreatedOrder
+	if err == nil {
+		created, err = s.client.CreateOrder(ctx, input)
+	}
+	if err != nil {
+		_, _ = collection.UpdateOne(ctx,
+			bson.D{{Key: "_id", Value: order.ID}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "tiny_sync_error", Value: err.Error()}}}},
+		)
+		return err
+	}
+
+	_, err = collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: order.ID}},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "tiny.id", Value: created.ID},
+				{Key: "tiny.number", Value: created.Number},
+				{Key: "tiny_synced_at", Value: time.Now()},
+			}},
+			{Key: "$unset", Value: bson.D{{Key: "tiny_sync_error", Value: ""}}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	s.log.Pushed++
+	return nil
+}
+
+func (s *tinySync) save(ctx context.Context) {
+	s.log.FinishedAt = time.Now()
+	s.log.Success = len(s.log.Errors) == 0
+	result, err := s.db.Collection(database.COLLECTION_TINY_SYNC_LOGS).InsertOne(ctx, s.log)
+	if err != nil {
+		log.Printf("[TinySync] Erro ao gravar o log da sincronização: %v", err)
+		return
+	}
+	s.log.ID, _ = result.InsertedID.(bson.ObjectID)
+}
+
+func connectTinySync(w http.ResponseWriter) (context.Context, context.CancelFunc, *mongo.Client, tiny.Client, bool) {
+	client, err := tiny.Default()
+	if err != nil {
+		utils.SendResponse(w, http.StatusServiceUnavailable, "Integração com o Tiny não configurada", nil, 0)
+		return nil, nil, nil, nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), TINY_SYNC_TIMEOUT)
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		cancel()
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return nil, nil, nil, nil, false
+	}
+	return ctx, cancel, mongoClient, client, true
+}
+
+// SyncTiny executa a sincronização com o Tiny: `direction=import` atualiza os pedidos a partir do Tiny,
+// `export` envia os pedidos novos e `both` (padrão) faz as duas coisas. A importação considera os pedidos
+// alterados desde a última importação bem-sucedida; use `since` (RFC3339) ou `full=true` para reprocessar.
+func SyncTiny(w http.ResponseWriter, r *http.Request) {
+	direction := r.URL.Query().Get("direction")
+	if direction == "" {
+		direction = schemas.TINY_SYNC_DIRECTION_BOTH
+	}
+	if direction != schemas.TINY_SYNC_DIRECTION_IMPORT && direction != schemas.TINY_SYNC_DIRECTION_EXPORT && direction != schemas.TINY_SYNC_DIRECTION_BOTH {
+		utils.SendResponse(w, http.StatusBadRequest, "Parâmetro 'direction' inválido", nil, utils.ORDERS_INVALID_REQUEST_DATA)
+		return
+	}
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			utils.SendResponse(w, http.StatusBadRequest, "Parâmetro 'since' inválido", nil, utils.ORDERS_INVALID_REQUEST_DATA)
+			return
+		}
+		since = parsed
+	}
+
+	ctx, cancel, mongoClient, client, ok := connectTinySync(w)
+	if !ok {
+		return
+	}
+	defer cancel()
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+	if !utils.HasAnyRole(user, workflowAdminRoles) {
+		utils.SendResponse(w, http.StatusForbidden, "Usuário sem permissão para sincronizar com o Tiny", nil, 0)
+		return
+	}
+
+	if !tinySyncMutex.TryLock() {
+		utils.SendResponse(w, http.StatusConflict, "Já existe uma sincronização com o Tiny em andamento", nil, 0)
+		return
+	}
+	defer tinySyncMutex.Unlock()
+
+	if since.IsZero() && r.URL.Query().Get("full") != "true" {
+		since = lastTinyImport(ctx, db)
+	}
+
+	syncer := &tinySync{db: db, client: client, log: &schemas.TinySyncLog{
+		Direction: direction,
+		StartedBy: user.ID,
+		Since:     since,
+		Conflicts: []schemas.TinySyncConflict{},
+		Errors:    []schemas.TinySyncError{},
+		StartedAt: time.Now(),
+	}}
+
+	if direction != schemas.TINY_SYNC_DIRECTION_EXPORT {
+		if err := syncer.importOrders(ctx, since); err != nil {
+			syncer.fail(bson.ObjectID{}, "", err)
+		}
+	}
+	if direction != schemas.TINY_SYNC_DIRECTION_IMPORT {
+		if err := syncer.exportOrders(ctx); err != nil {
+			syncer.fail(bson.ObjectID{}, "", err)
+		}
+	}
+	syncer.save(ctx)
+
+	utils.SendResponse(w, http.StatusOK, "", syncer.log, 0)
+}
+
+// PushOrderToTiny envia um pedido ao Tiny, inclusive os que falharam antes na sincronização.
+func PushOrderToTiny(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_ORDER_ID_FORMAT)
+		return
+	}
+
+	ctx, cancel, mongoClient, client, ok := connectTinySync(w)
+	if !ok {
+		return
+	}
+	defer cancel()
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+
+	order := schemas.Order{}
+	err = db.Collection(database.COLLECTION_ORDERS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Pedido não encontrado", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDER_BY_ID_IN_MONGODB)
+		return
+	}
+	if order.Tiny.ID != "" {
+		utils.SendResponse(w, http.StatusConflict, "Pedido já enviado ao Tiny", nil, 0)
+		return
+	}
+
+	syncer := &tinySync{db: db, client: client, log: &schemas.TinySyncLog{
+		Direction: schemas.TINY_SYNC_DIRECTION_EXPORT,
+		StartedBy: user.ID,
+		Conflicts: []schemas.TinySyncConflict{},
+		Errors:    []schemas.TinySyncError{},
+		StartedAt: time.Now(),
+	}}
+	if err := syncer.pushOrder(ctx, order); err != nil {
+		syncer.fail(order.ID, "", err)
+	}
+	syncer.save(ctx)
+
+	if !syncer.log.Success {
+		utils.SendResponse(w, http.StatusBadGateway, "Erro ao enviar o pedido ao Tiny: "+syncer.log.Errors[0].Error, nil, 0)
+		return
+	}
+	utils.SendResponse(w, http.StatusOK, "", syncer.log, 0)
+}
+
+// GetTinySyncLogs lista as execuções da sincronização, das mais recentes para as mais antigas.
+func GetTinySyncLogs(w http.ResponseWriter, r *http.Request) {
+	page, pageSize := int64(1), int64(25)
+	if parsed, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64); err == nil && parsed > 0 {
+		page = parsed
+	}
+	if parsed, err := strconv.ParseInt(r.URL.Query().Get("pageSize"), 10, 64); err == nil && parsed > 0 {
+		pageSize = min(parsed, 100)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_TINY_SYNC_LOGS)
+
+	filter := bson.D{}
+	if r.URL.Query().Get("with_conflicts") == "true" {
+		filter = append(filter, bson.E{Key: "conflicts.0", Value: bson.D{{Key: "$exists", Value: true}}})
+	}
+
+	totalItems, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.ERROR_TO_FIND_IN_MONGODB)
+		return
+	}
+
+	cursor, err := collection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}}).SetSkip((page-1)*pageSize).SetLimit(pageSize),
+	)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.ERROR_TO_FIND_IN_MONGODB)
+		return
+	}
+	logs := []schemas.TinySyncLog{}
+	if err := cursor.All(ctx, &logs); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.ERROR_TO_FIND_IN_MONGODB)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", map[string]any{
+		"items": logs,
+		"pagination": map[string]any{
+			"page":        page,
+			"page_size":   pageSize,
+			"total_items": totalItems,
+			"total_pages": int64(math.Ceil(float64(totalItems) / float64(pageSize))),
+		},
+	}, 0)
+}
@@ -0,0 +1,504 @@
+package orders
+
+import (
+	"api/database"
+	spacedesk "api/entities/space_desk"
+	"api/integrations/tracking"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	TRACKING_POLL_INTERVAL = 2 * time.Hour
+	TRACKING_POLL_TIMEOUT  = 5 * time.Minute
+
+	// Quantos pedidos são consultados por execução, começando pelos verificados há mais tempo
+	TRACKING_POLL_LIMIT = 200
+
+	// Pedidos mais antigos que isso deixam de ser acompanhados, mesmo sem a entrega confirmada
+	TRACKING_MAX_AGE_DAYS = 90
+
+	TRACKING_TRANSITION_REASON = "Atualização do rastreio"
+)
+
+// Notificações enviadas ao cliente. Os templates precisam estar aprovados na 360dialog com três
+// variáveis no corpo: nome do cliente, número do pedido e código ou link de rastreio.
+const (
+	TRACKING_NOTIFICATION_SHIPPED          = "shipped"
+	TRACKING_NOTIFICATION_OUT_FOR_DELIVERY = "out_for_delivery"
+	TRACKING_NOTIFICATION_DELIVERED        = "delivered"
+)
+
+var trackingTemplates = map[string]struct {
+	Name    string
+	Excerpt string
+}{
+	TRACKING_NOTIFICATION_SHIPPED:          {Name: "pedido_enviado", Excerpt: "Olá, %s! Seu pedido nº %s foi enviado. Acompanhe a entrega: %s"},
+	TRACKING_NOTIFICATION_OUT_FOR_DELIVERY: {Name: "pedido_saiu_para_entrega", Excerpt: "Olá, %s! Seu pedido nº %s saiu para entrega. Rastreio: %s"},
+	TRACKING_NOTIFICATION_DELIVERED:        {Name: "pedido_entregue", Excerpt: "Olá, %s! Seu pedido nº %s foi entregue. Rastreio: %s"},
+}
+
+var ErrTrackingChatNotFound = errors.New("nenhum chat do lead encontrado para o pedido")
+
+// trackingMutex evita duas consultas simultâneas movendo e notificando o mesmo pedido duas vezes.
+var trackingMutex sync.Mutex
+
+type TrackingPollError struct {
+	OrderID      bson.ObjectID `json:"order_id"`
+	TrackingCode string        `json:"tracking_code"`
+	Error        string        `json:"error"`
+}
+
+type TrackingPollResult struct {
+	Checked  int                 `json:"checked"`
+	Updated  int                 `json:"updated"`
+	Moved    int                 `json:"moved"`
+	Notified int                 `json:"notified"`
+	Errors   []TrackingPollError `json:"errors"`
+}
+
+type trackingPoller struct {
+	mongoClient *mongo.Client
+	db          *mongo.Database
+	provider    tracking.Provider
+	result      *TrackingPollResult
+}
+
+func (p *trackingPoller) fail(order schemas.Order, err error) {
+	p.result.Errors = append(p.result.Errors, TrackingPollError{OrderID: order.ID, TrackingCode: order.TrackingCode, Error: err.Error()})
+}
+
+// trackingTargetStatus diz para qual status da expedição o evento de rastreio leva o pedido.
+func trackingTargetStatus(status string) (schemas.OrderStatus, bool) {
+	switch status {
+	case schemas.TRACKING_STATUS_POSTED, schemas.TRACKING_STATUS_IN_TRANSIT, schemas.TRACKING_STATUS_OUT_FOR_DELIVERY:
+		return schemas.StatusEmEntrega, true
+	case schemas.TRACKING_STATUS_DELIVERED:
+		return schemas.StatusEntregue, true
+	}
+	return "", false
+}
+
+func trackingNotificationKey(status string) string {
+	switch status {
+	case schemas.TRACKING_STATUS_POSTED, schemas.TRACKING_STATUS_IN_TRANSIT:
+		return TRACKING_NOTIFICATION_SHIPPED
+	case schemas.TRACKING_STATUS_OUT_FOR_DELIVERY:
+		return TRACKING_NOTIFICATION_OUT_FOR_DELIVERY
+	case schemas.TRACKING_STATUS_DELIVERED:
+		return TRACKING_NOTIFICATION_DELIVERED
+	}
+	return ""
+}
+
+func orderNumber(order schemas.Order) string {
+	if order.Tiny.Number != "" {
+		return order.Tiny.Number
+	}
+	if order.OldID != 0 {
+		return fmt.Sprint(order.OldID)
+	}
+	hex := order.ID.Hex()
+	return strings.ToUpper(hex[len(hex)-6:])
+}
+
+// pollOrders consulta o rastreio dos pedidos com código que ainda não foram entregues.
+func (p *trackingPoller) pollOrders(ctx context.Context) error {
+	cursor, err := p.db.Collection(database.COLLECTION_ORDERS).Find(ctx,
+		bson.D{
+			{Key: "tracking_code", Value: bson.D{{Key: "$nin", Value: bson.A{nil, ""}}}},
+			{Key: "created_at", Value: bson.D{{Key: "$gte", Value: time.Now().AddDate(0, 0, -TRACKING_MAX_AGE_DAYS)}}},
+			{Key: "$nor", Value: bson.A{bson.D{
+				{Key: "stage", Value: schemas.StageExpedicao},
+				{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{schemas.StatusEntregue, schemas.StatusDevolucao}}}},
+			}}},
+		},
+		options.Find().SetSort(bson.D{{Key: "tracking_checked_at", Value: 1}}).SetLimit(TRACKING_POLL_LIMIT),
+	)
+	if err != nil {
+		return err
+	}
+	orders := []schemas.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		if _, err := p.trackOrder(ctx, order); err != nil {
+			p.fail(order, err)
+		}
+	}
+	return nil
+}
+
+// trackOrder grava os eventos do rastreio no pedido, move o status da expedição e avisa o cliente.
+// Falhas na movimentação ou na notificação entram no resultado sem interromper o restante.
+func (p *trackingPoller) trackOrder(ctx context.Context, order schemas.Order) (schemas.Order, error) {
+	collection := p.db.Collection(database.COLLECTION_ORDERS)
+	p.result.Checked++
+
+	now := time.Now()
+	events, err := p.provider.Track(ctx, order.TrackingCode)
+	if err != nil {
+		_, _ = collection.UpdateOne(ctx,
+			bson.D{{Key: "_id", Value: order.ID}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "tracking_checked_at", Value: now}, {Key: "tracking_error", Value: err.Error()}}}},
+		)
+		order.TrackingCheckedAt, order.TrackingError = now, err.Error()
+		return order, err
+	}
+
+	set := bson.D{{Key: "tracking_events", Value: events}, {Key: "tracking_checked_at", Value: now}}
+	latest := schemas.OrderTrackingEvent{}
+	if len(events) > 0 {
+		latest = events[len(events)-1]
+		set = append(set, bson.E{Key: "tracking_status", Value: latest.Status})
+	}
+	_, err = collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: order.ID}},
+		bson.D{
+			{Key: "$set", Value: set},
+			{Key: "$unset", Value: bson.D{{Key: "tracking_error", Value: ""}}},
+		},
+	)
+	if err != nil {
+		return order, err
+	}
+	if len(events) != len(order.TrackingEvents) || latest.Status != order.TrackingStatus {
+		p.result.Updated++
+	}
+	order.TrackingEvents, order.TrackingStatus, order.TrackingCheckedAt, order.TrackingError = events, latest.Status, now, ""
+	if len(events) == 0 {
+		return order, nil
+	}
+
+	if updated, err := p.moveByTracking(ctx, order, latest.Status); err != nil {
+		p.fail(order, err)
+	} else {
+		order = updated
+	}
+	if err := p.notifyCustomer(ctx, order, latest.Status); err != nil {
+		p.fail(order, err)
+	}
+	return order, nil
+}
+
+// moveByTracking leva o pedido para Em entrega ou Entregue conforme o último evento, respeitando o fluxo.
+func (p *trackingPoller) moveByTracking(ctx context.Context, order schemas.Order, trackingStatus string) (schemas.Order, error) {
+	target, ok := trackingTargetStatus(trackingStatus)
+	if !ok {
+		return order, nil
+	}
+	fromStage, fromStatus := CurrentState(order)
+	if fromStage == schemas.StageExpedicao && fromStatus == target {
+		return order, nil
+	}
+	if err := ValidateTransition(order, schemas.StageExpedicao, target, ""); err != nil {
+		// Entregue só volta para Devolução; um evento atrasado não deve ser tratado como erro
+		if IsFinished(order) {
+			return order, nil
+		}
+		return order, fmt.Errorf("rastreio indica %s, mas o pedido está em %s/%s: %w", target, fromStage, fromStatus, err)
+	}
+
+	updated, err := ApplyTransition(ctx, p.db, order, schemas.OrderTransition{
+		ToStage:  schemas.StageExpedicao,
+		ToStatus: target,
+		Reason:   TRACKING_TRANSITION_REASON,
+	})
+	if err != nil {
+		return order, err
+	}
+	p.result.Moved++
+	NotifyBoard("order_moved", updated, fromStage, fromStatus)
+	return updated, nil
+}
+
+// findOrderChat encontra o lead do pedido (pelo related_orders ou pelo orçamento) e o chat mais recente dele.
+func findOrderChat(ctx context.Context, db *mongo.Database, order schemas.Order) (schemas.Lead, schemas.SpaceDeskChat, error) {
+	lead := schemas.Lead{}
+	chat := schemas.SpaceDeskChat{}
+	leads := db.Collection(database.COLLECTION_LEADS)
+
+	err := leads.FindOne(ctx, bson.D{{Key: "related_orders", Value: order.ID}}).Decode(&lead)
+	if err == mongo.ErrNoDocuments && !order.RelatedBudget.IsZero() {
+		budget := schemas.Budget{}
+		if err = db.Collection(database.COLLECTION_BUDGETS).FindOne(ctx, bson.D{{Key: "_id", Value: order.RelatedBudget}}).Decode(&budget); err == nil {
+			err = leads.FindOne(ctx, bson.D{{Key: "_id", Value: budget.RelatedLead}}).Decode(&lead)
+		}
+	}
+	if err == mongo.ErrNoDocuments {
+		return lead, chat, ErrTrackingChatNotFound
+	}
+	if err != nil {
+		return lead, chat, err
+	}
+
+	chats := db.Collection(database.COLLECTION_SPACE_DESK_CHAT)
+	latest := options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}})
+	err = chats.FindOne(ctx, bson.D{{Key: "lead_id", Value: lead.ID}}, latest).Decode(&chat)
+	if err == mongo.ErrNoDocuments && lead.Phone != "" {
+		// O chat guarda o wa_id, que pode não ter o nono dígito ou estar em outro formato que o telefone do lead
+		phones := bson.D{{Key: "$in", Value: utils.PhoneVariants(lead.Phone)}}
+		err = chats.FindOne(ctx, bson.D{{Key: "cliente_phone_number", Value: phones}}, latest).Decode(&chat)
+	}
+	if err == mongo.ErrNoDocuments {
+		return lead, chat, ErrTrackingChatNotFound
+	}
+	return lead, chat, err
+}
+
+// notifyCustomer envia o template do evento ao chat do lead, uma única vez por tipo de notificação.
+func (p *trackingPoller) notifyCustomer(ctx context.Context, order schemas.Order, trackingStatus string) error {
+	key := trackingNotificationKey(trackingStatus)
+	if key == "" || slices.Contains(order.TrackingNotified, key) {
+		return nil
+	}
+	// Depois da entrega não faz sentido avisar que o pedido foi enviado ou saiu para entrega
+	if key != TRACKING_NOTIFICATION_DELIVERED && slices.Contains(order.TrackingNotified, TRACKING_NOTIFICATION_DELIVERED) {
+		return nil
+	}
+
+	lead, chat, err := findOrderChat(ctx, p.db, order)
+	if err != nil {
+		return err
+	}
+
+	name := lead.Nickname
+	if name == "" {
+		name = strings.SplitN(strings.TrimSpace(lead.Name), " ", 2)[0]
+	}
+	if name == "" {
+		name = "cliente"
+	}
+	trackingInfo := order.TrackingCode
+	if order.Tiny.UrlRastreamento != "" {
+		trackingInfo = order.Tiny.UrlRastreamento
+	}
+	number := orderNumber(order)
+	template := trackingTemplates[key]
+
+	parameters := []map[string]string{}
+	for _, value := range []string{name, number, trackingInfo} {
+		parameters = append(parameters, map[string]string{"type": "text", "text": value})
+	}
+	_, err = spacedesk.SendMessageToChat(ctx, p.mongoClient, spacedesk.OutgoingMessage{
+		ChatID:  chat.ID,
+		UserID:  chat.UserID,
+		Type:    "template",
+		Excerpt: fmt.Sprintf(template.Excerpt, name, number, trackingInfo),
+		Payload: map[string]any{"template": map[string]any{
+			"name":       template.Name,
+			"language":   map[string]string{"code": "pt_BR"},
+			"components": []any{map[string]any{"type": "body", "parameters": parameters}},
+		}},
+		Extra: bson.M{"order_id": order.ID, "tracking_notification": key},
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao notificar o cliente (%s): %w", key, err)
+	}
+
+	_, err = p.db.Collection(database.COLLECTION_ORDERS).UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: order.ID}},
+		bson.D{{Key: "$addToSet", Value: bson.D{{Key: "tracking_notified", Value: key}}}},
+	)
+	if err != nil {
+		return err
+	}
+	p.result.Notified++
+	return nil
+}
+
+func newTrackingPoller(mongoClient *mongo.Client, provider tracking.Provider) *trackingPoller {
+	return &trackingPoller{
+		mongoClient: mongoClient,
+		db:          mongoClient.Database(database.GetDB()),
+		provider:    provider,
+		result:      &TrackingPollResult{Errors: []TrackingPollError{}},
+	}
+}
+
+// StartTrackingPoller agenda a consulta periódica do rastreio dos pedidos em expedição.
+func StartTrackingPoller() {
+	utils.RunEvery("TrackingPoller", TRACKING_POLL_INTERVAL, TRACKING_POLL_TIMEOUT, func(ctx context.Context) error {
+		provider, err := tracking.Default()
+		if err != nil {
+			return err
+		}
+		if !trackingMutex.TryLock() {
+			return nil
+		}
+		defer trackingMutex.Unlock()
+
+		mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+		if err != nil {
+			return err
+		}
+		defer mongoClient.Disconnect(ctx)
+
+		poller := newTrackingPoller(mongoClient, provider)
+		if err := poller.pollOrders(ctx); err != nil {
+			return err
+		}
+		if len(poller.result.Errors) > 0 {
+			return fmt.Errorf("%d pedido(s) com erro no rastreio, primeiro: %s", len(poller.result.Errors), poller.result.Errors[0].Error)
+		}
+		return nil
+	})
+}
+
+func connectTracking(w http.ResponseWriter) (context.Context, context.CancelFunc, *mongo.Client, tracking.Provider, bool) {
+	provider, err := tracking.Default()
+	if err != nil {
+		utils.SendResponse(w, http.StatusServiceUnavailable, "Integração de rastreio não configurada", nil, 0)
+		return nil, nil, nil, nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), TRACKING_POLL_TIMEOUT)
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		cancel()
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return nil, nil, nil, nil, false
+	}
+	return ctx, cancel, mongoClient, provider, true
+}
+
+// PollTracking consulta agora o rastreio de todos os pedidos acompanhados, sem esperar o agendamento.
+func PollTracking(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel, mongoClient, provider, ok := connectTracking(w)
+	if !ok {
+		return
+	}
+	defer cancel()
+	defer mongoClient.Disconnect(ctx)
+
+	user, err := middlewares.CurrentUser(ctx, mongoClient.Database(database.GetDB()), r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+	if !utils.HasAnyRole(user, workflowAdminRoles) {
+		utils.SendResponse(w, http.StatusForbidden, "Usuário sem permissão para atualizar o rastreio", nil, 0)
+		return
+	}
+
+	if !trackingMutex.TryLock() {
+		utils.SendResponse(w, http.StatusConflict, "Já existe uma consulta de rastreio em andamento", nil, 0)
+		return
+	}
+	defer trackingMutex.Unlock()
+
+	poller := newTrackingPoller(mongoClient, provider)
+	if err := poller.pollOrders(ctx); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDERS_IN_MONGODB)
+		return
+	}
+	utils.SendResponse(w, http.StatusOK, "", poller.result, 0)
+}
+
+func trackingResponse(order schemas.Order) map[string]any {
+	events := order.TrackingEvents
+	if events == nil {
+		events = []schemas.OrderTrackingEvent{}
+	}
+	return map[string]any{
+		"tracking_code":       order.TrackingCode,
+		"tracking_url":        order.Tiny.UrlRastreamento,
+		"tracking_status":     order.TrackingStatus,
+		"tracking_checked_at": order.TrackingCheckedAt,
+		"tracking_error":      order.TrackingError,
+		"tracking_notified":   order.TrackingNotified,
+		"events":              events,
+	}
+}
+
+func findTrackedOrder(ctx context.Context, db *mongo.Database, w http.ResponseWriter, r *http.Request) (schemas.Order, bool) {
+	order := schemas.Order{}
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_ORDER_ID_FORMAT)
+		return order, false
+	}
+	err = db.Collection(database.COLLECTION_ORDERS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Pedido não encontrado", nil, 0)
+		return order, false
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDER_BY_ID_IN_MONGODB)
+		return order, false
+	}
+	return order, true
+}
+
+// GetOrderTracking retorna os eventos de rastreio já gravados no pedido.
+func GetOrderTracking(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	order, ok := findTrackedOrder(ctx, mongoClient.Database(database.GetDB()), w, r)
+	if !ok {
+		return
+	}
+	utils.SendResponse(w, http.StatusOK, "", trackingResponse(order), 0)
+}
+
+// RefreshOrderTracking consulta o rastreio de um pedido na hora, com a mesma movimentação e
+// notificações da consulta agendada.
+func RefreshOrderTracking(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel, mongoClient, provider, ok := connectTracking(w)
+	if !ok {
+		return
+	}
+	defer cancel()
+	defer mongoClient.Disconnect(ctx)
+
+	order, ok := findTrackedOrder(ctx, mongoClient.Database(database.GetDB()), w, r)
+	if !ok {
+		return
+	}
+	if order.TrackingCode == "" {
+		utils.SendResponse(w, http.StatusBadRequest, "Pedido sem código de rastreio", nil, utils.ORDERS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	if !trackingMutex.TryLock() {
+		utils.SendResponse(w, http.StatusConflict, "Já existe uma consulta de rastreio em andamento", nil, 0)
+		return
+	}
+	defer trackingMutex.Unlock()
+
+	poller := newTrackingPoller(mongoClient, provider)
+	order, err := poller.trackOrder(ctx, order)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, tracking.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		utils.SendResponse(w, status, "Erro ao consultar o rastreio: "+err.Error(), nil, 0)
+		return
+	}
+	utils.SendResponse(w, http.StatusOK, "", map[string]any{"tracking": trackingResponse(order), "result": poller.result}, 0)
+}
@@ -0,0 +1,214 @@
+package orders
+
+import (
+	"api/database"
+	"api/entities/leads"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var ErrOrderChanged = errors.New("o pedido foi alterado por outra pessoa")
+
+type TransitionRequest struct {
+	Stage  schemas.OrderStage  `json:"stage,omitempty"` // vazio mantém a etapa atual
+	Status schemas.OrderStatus `json:"status"`
+	Reason string              `json:"reason,omitempty"`
+	Force  bool                `json:"force,omitempty"` // apenas administradores
+}
+
+// ApplyTransition grava a mudança de etapa/status e o histórico. O filtro pela etapa e status atuais
+// impede que duas movimentações concorrentes partam do mesmo estado.
+func ApplyTransition(ctx context.Context, db *mongo.Database, order schemas.Order, transition schemas.OrderTransition) (schemas.Order, error) {
+	fromStage, fromStatus := CurrentState(order)
+	now := transition.At
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	enteredAt := order.StatusChangedAt
+	if enteredAt.IsZero() {
+		enteredAt = order.CreatedAt
+	}
+	transition.FromStage = fromStage
+	transition.FromStatus = fromStatus
+	transition.At = now
+	if !enteredAt.IsZero() && now.After(enteredAt) {
+		transition.Duration = int64(now.Sub(enteredAt).Seconds())
+	}
+
+	set := bson.D{
+		{Key: "stage", Value: transition.ToStage},
+		{Key: "status", Value: transition.ToStatus},
+		{Key: "status_changed_at", Value: now},
+		{Key: "updated_at", Value: now},
+	}
+	if transition.ToStage != fromStage || order.StageEnteredAt.IsZero() {
+		set = append(set, bson.E{Key: "stage_entered_at", Value: now})
+		order.StageEnteredAt = now
+	}
+
+	result, err := db.Collection(database.COLLECTION_ORDERS).UpdateOne(ctx,
+		bson.D{
+			{Key: "_id", Value: order.ID},
+			{Key: "stage", Value: stateFilterValue(string(order.Stage))},
+			{Key: "status", Value: stateFilterValue(string(order.Status))},
+		},
+		bson.D{
+			{Key: "$set", Value: set},
+			{Key: "$push", Value: bson.D{{Key: "history", Value: transition}}},
+		},
+	)
+	if err != nil {
+		return order, err
+	}
+	if result.MatchedCount == 0 {
+		return order, ErrOrderChanged
+	}
+
+	order.Stage = transition.ToStage
+	order.Status = transition.ToStatus
+	order.StatusChangedAt = now
+	order.UpdatedAt = now
+	order.History = append(order.History, transition)
+	queueLeadTier(ctx, db, order.ID)
+	return order, nil
+}
+
+// queueLeadTier agenda o recálculo do tier do lead dono do pedido. Pedidos sem lead são ignorados e falhas
+// na busca ficam para o recálculo da madrugada.
+func queueLeadTier(ctx context.Context, db *mongo.Database, orderID bson.ObjectID) {
+	lead := schemas.Lead{}
+	err := db.Collection(database.COLLECTION_LEADS).FindOne(ctx,
+		bson.D{{Key: "related_orders", Value: orderID}},
+		options.FindOne().SetProjection(bson.D{{Key: "_id", Value: 1}}),
+	).Decode(&lead)
+	if err == nil {
+		leads.QueueTierRecompute(leads.TIER_REASON_ORDERS, lead.ID)
+	}
+}
+
+// stateFilterValue casa também pedidos antigos sem o campo quando o valor atual é vazio.
+func stateFilterValue(value string) any {
+	if value == "" {
+		return bson.D{{Key: "$in", Value: bson.A{nil, ""}}}
+	}
+	return value
+}
+
+// UpdateTransition move o pedido para outra etapa/status seguindo o fluxo de produção.
+// Designers movimentam pedidos no Design e a produção nas demais etapas; administradores
+// podem forçar transições fora do fluxo informando `force`.
+func UpdateTransition(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_ORDER_ID_FORMAT)
+		return
+	}
+
+	req := TransitionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.ORDERS_INVALID_REQUEST_DATA)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Status == "" {
+		utils.SendResponse(w, http.StatusBadRequest, "Informe o status de destino", nil, utils.ORDERS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+
+	order := schemas.Order{}
+	err = db.Collection(database.COLLECTION_ORDERS).FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Pedido não encontrado", nil, 0)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_ORDER_BY_ID_IN_MONGODB)
+		return
+	}
+
+	updated, status, err := moveOrder(ctx, db, order, user, req)
+	if err != nil {
+		utils.SendResponse(w, status, err.Error(), nil, 0)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", updated, 0)
+}
+
+// moveOrder valida permissão e regras do fluxo e aplica a transição. Em caso de erro retorna o status HTTP adequado.
+func moveOrder(ctx context.Context, db *mongo.Database, order schemas.Order, user schemas.User, req TransitionRequest) (schemas.Order, int, error) {
+	fromStage, fromStatus := CurrentState(order)
+	if req.Stage == "" {
+		req.Stage = fromStage
+	}
+
+	if !CanMove(user, fromStage) {
+		return order, http.StatusForbidden, errors.New("usuário sem permissão para movimentar pedidos na etapa " + string(fromStage))
+	}
+	if req.Force && !utils.HasAnyRole(user, workflowAdminRoles) {
+		return order, http.StatusForbidden, errors.New("apenas administradores podem forçar transições")
+	}
+
+	if err := ValidateTransition(order, req.Stage, req.Status, req.Reason); err != nil {
+		i := stageIndex(req.Stage)
+		if !req.Force || i < 0 || !slices.Contains(Workflow[i].Statuses, req.Status) {
+			return order, http.StatusUnprocessableEntity, err
+		}
+	}
+
+	updated, err := ApplyTransition(ctx, db, order, schemas.OrderTransition{
+		ToStage:  req.Stage,
+		ToStatus: req.Status,
+		UserID:   user.ID,
+		Reason:   req.Reason,
+		Forced:   req.Force,
+	})
+	if errors.Is(err, ErrOrderChanged) {
+		return order, http.StatusConflict, err
+	}
+	if err != nil {
+		return order, http.StatusInternalServerError, errors.New(utils.SendInternalError(utils.CANNOT_UPDATE_ORDER_IN_MONGODB))
+	}
+
+	NotifyBoard("order_moved", updated, fromStage, fromStatus)
+	return updated, http.StatusOK, nil
+}
+
+// GetWorkflow retorna as etapas, status e papéis do fluxo de produção.
+func GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	utils.SendResponse(w, http.StatusOK, "", Workflow, 0)
+}
@@ -0,0 +1,134 @@
+package orders
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type OrderBoardWSMessage struct {
+	Action     string              `json:"action"`
+	Order      any                 `json:"order"`
+	FromStage  schemas.OrderStage  `json:"from_stage,omitempty"`
+	FromStatus schemas.OrderStatus `json:"from_status,omitempty"`
+	Stage      schemas.OrderStage  `json:"stage,omitempty"`
+	Status     schemas.OrderStatus `json:"status,omitempty"`
+	Details    string              `json:"details,omitempty"`
+}
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+var wsClients = make(map[*websocket.Conn]bool)
+var wsClientUsers = make(map[*websocket.Conn]string)
+var wsMutex sync.Mutex
+
+func broadcastOrderUpdate(msg OrderBoardWSMessage) {
+	wsMutex.Lock()
+	defer wsMutex.Unlock()
+	for client := range wsClients {
+		err := client.WriteJSON(msg)
+		if err != nil {
+			client.Close()
+			delete(wsClients, client)
+			delete(wsClientUsers, client)
+		}
+	}
+}
+
+// notifyOrderUsers envia a mensagem apenas para as conexões dos usuários informados e retorna os usuários
+// que receberam a mensagem em ao menos uma conexão.
+func notifyOrderUsers(userIDs []string, msg any) []string {
+	wsMutex.Lock()
+	defer wsMutex.Unlock()
+	delivered := []string{}
+	for client, userID := range wsClientUsers {
+		if userID == "" || !slices.Contains(userIDs, userID) {
+			continue
+		}
+		if err := client.WriteJSON(msg); err != nil {
+			client.Close()
+			delete(wsClients, client)
+			delete(wsClientUsers, client)
+			continue
+		}
+		if !slices.Contains(delivered, userID) {
+			delivered = append(delivered, userID)
+		}
+	}
+	return delivered
+}
+
+// webSocketUserID identifica o usuário autenticado pelo LaravelAuth que abriu a conexão.
+func webSocketUserID(r *http.Request) string {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		return ""
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	user, err := middlewares.CurrentUser(ctx, mongoClient.Database(database.GetDB()), r)
+	if err != nil {
+		return ""
+	}
+	return user.ID.Hex()
+}
+
+// NotifyBoard avisa os quadros de produção abertos que o pedido foi criado ou mudou de coluna.
+func NotifyBoard(action string, order schemas.Order, fromStage schemas.OrderStage, fromStatus schemas.OrderStatus) {
+	stage, status := CurrentState(order)
+	broadcastOrderUpdate(OrderBoardWSMessage{
+		Action:     action,
+		Order:      order.ID,
+		FromStage:  fromStage,
+		FromStatus: fromStatus,
+		Stage:      stage,
+		Status:     status,
+	})
+}
+
+// OrdersWebSocketHandler mantém a conexão do quadro de produção. As atualizações partem da API
+// (movimentações validadas), por isso mensagens enviadas pelos clientes são ignoradas. Cada conexão
+// recebe também os alertas de prazo dos pedidos do usuário autenticado.
+func OrdersWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	userID := webSocketUserID(r)
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		http.Error(w, "Não foi possível fazer upgrade para websocket", http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	wsMutex.Lock()
+	wsClients[conn] = true
+	if userID != "" {
+		wsClientUsers[conn] = userID
+	}
+	wsMutex.Unlock()
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	wsMutex.Lock()
+	delete(wsClients, conn)
+	delete(wsClientUsers, conn)
+	wsMutex.Unlock()
+}
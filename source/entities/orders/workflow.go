@@ -0,0 +1,185 @@
+package orders
+
+import (
+	"api/schemas"
+	"api/utils"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// StageWorkflow descreve uma etapa da produção: os status possíveis (o primeiro é o de entrada),
+// os outros status em que o pedido pode entrar na etapa, os status que liberam a próxima etapa,
+// quem pode movimentar pedidos nela e quantos dias úteis ela costuma levar (usado para prever atrasos).
+type StageWorkflow struct {
+	Stage    schemas.OrderStage    `json:"stage"`
+	Statuses []schemas.OrderStatus `json:"statuses"`
+	Entry    []schemas.OrderStatus `json:"entry,omitempty"`
+	Final    []schemas.OrderStatus `json:"final"`
+	Optional bool                  `json:"optional"`
+	Roles    []string              `json:"roles"`
+	Days     int                   `json:"days"`
+}
+
+var (
+	ErrInvalidTransition = errors.New("transição inválida")
+	ErrReasonRequired    = errors.New("informe o motivo para voltar o pedido de etapa")
+)
+
+var productionRoles = []string{schemas.USERS_ROLE_PRODUCTION}
+var designRoles = []string{schemas.USERS_ROLE_DESIGNER, schemas.USERS_ROLE_DESIGNER_COORDINATOR}
+
+// workflowAdminRoles podem movimentar pedidos em qualquer etapa e forçar transições fora do fluxo.
+var workflowAdminRoles = []string{schemas.USERS_ROLE_SUPER_ADMIN, schemas.USERS_ROLE_IT, schemas.USERS_ROLE_ADMIN}
+
+// Workflow lista as etapas na ordem em que o pedido passa pela produção.
+var Workflow = []StageWorkflow{
+	{
+		Stage:    schemas.StageDesign,
+		Statuses: []schemas.OrderStatus{schemas.StatusPendente, schemas.StatusEmAndamento, schemas.StatusEmEspera, schemas.StatusCorTeste, schemas.StatusArteOK},
+		Final:    []schemas.OrderStatus{schemas.StatusArteOK},
+		Roles:    designRoles,
+		Days:     2,
+	},
+	{
+		Stage:    schemas.StageImpressao,
+		Statuses: []schemas.OrderStatus{schemas.StatusProcessando, schemas.StatusEmImpressao, schemas.StatusImpresso},
+		Final:    []schemas.OrderStatus{schemas.StatusImpresso},
+		Roles:    productionRoles,
+		Days:     1,
+	},
+	{
+		Stage:    schemas.StageSublimacao,
+		Statuses: []schemas.OrderStatus{schemas.StatusPrensa, schemas.StatusCalandra},
+		Entry:    []schemas.OrderStatus{schemas.StatusCalandra},
+		Final:    []schemas.OrderStatus{schemas.StatusPrensa, schemas.StatusCalandra},
+		Roles:    productionRoles,
+		Days:     1,
+	},
+	{
+		Stage:    schemas.StageCorte,
+		Statuses: []schemas.OrderStatus{schemas.StatusNaoCortado, schemas.StatusCortado},
+		Final:    []schemas.OrderStatus{schemas.StatusCortado},
+		Optional: true,
+		Roles:    productionRoles,
+		Days:     1,
+	},
+	{
+		Stage:    schemas.StageCostura,
+		Statuses: []schemas.OrderStatus{schemas.StatusEmAndamento, schemas.StatusCosturado},
+		Final:    []schemas.OrderStatus{schemas.StatusCosturado},
+		Optional: true,
+		Roles:    productionRoles,
+		Days:     2,
+	},
+	{
+		Stage:    schemas.StageConferencia,
+		Statuses: []schemas.OrderStatus{schemas.StatusNaoConferido, schemas.StatusConferido},
+		Final:    []schemas.OrderStatus{schemas.StatusConferido},
+		Roles:    productionRoles,
+		Days:     1,
+	},
+	// Pedidos despachados direto pela transportadora chegam pelo rastreio ou pelo Tiny já em entrega
+	{
+		Stage:    schemas.StageExpedicao,
+		Statuses: []schemas.OrderStatus{schemas.StatusSeparacao, schemas.StatusEmSeparacao, schemas.StatusRetirada, schemas.StatusEmEntrega, schemas.StatusEntregue, schemas.StatusDevolucao},
+		Entry:    []schemas.OrderStatus{schemas.StatusEmEntrega},
+		Final:    []schemas.OrderStatus{schemas.StatusEntregue, schemas.StatusDevolucao},
+		Roles:    productionRoles,
+		Days:     2,
+	},
+}
+
+// stageIndex retorna a posição da etapa no fluxo ou -1.
+func stageIndex(stage schemas.OrderStage) int {
+	return slices.IndexFunc(Workflow, func(s StageWorkflow) bool { return s.Stage == stage })
+}
+
+// CurrentState normaliza etapa e status do pedido: pedidos antigos sem etapa começam no Design
+// e sem status ficam no status de entrada da etapa.
+func CurrentState(order schemas.Order) (schemas.OrderStage, schemas.OrderStatus) {
+	stage, status := order.Stage, order.Status
+	if stage == "" {
+		stage = schemas.StageDesign
+	}
+	if status == "" {
+		if i := stageIndex(stage); i >= 0 {
+			status = Workflow[i].Statuses[0]
+		}
+	}
+	return stage, status
+}
+
+// canEnter indica se o pedido pode chegar na etapa pelo status informado: o status de entrada ou um dos
+// listados em Entry.
+func canEnter(stage StageWorkflow, status schemas.OrderStatus) bool {
+	return status == stage.Statuses[0] || slices.Contains(stage.Entry, status)
+}
+
+// IsFinished indica se o pedido já saiu da produção (entregue ou devolvido).
+func IsFinished(order schemas.Order) bool {
+	stage, status := CurrentState(order)
+	return stage == schemas.StageExpedicao && slices.Contains(Workflow[stageIndex(stage)].Final, status)
+}
+
+// ValidateTransition aplica as regras do fluxo:
+//   - na mesma etapa o pedido pode ir para qualquer status dela, exceto depois de entregue/devolvido
+//     (entregue só pode virar devolução);
+//   - para avançar, o status atual precisa ser um status final da etapa, o destino é a próxima
+//     etapa, pulando apenas as opcionais, e o pedido entra nela pelo status de entrada (ou um dos
+//     permitidos em Entry);
+//   - voltar para uma etapa anterior (retrabalho) exige motivo.
+func ValidateTransition(order schemas.Order, toStage schemas.OrderStage, toStatus schemas.OrderStatus, reason string) error {
+	fromStage, fromStatus := CurrentState(order)
+	from, to := stageIndex(fromStage), stageIndex(toStage)
+	if to < 0 {
+		return fmt.Errorf("%w: etapa '%s' não existe", ErrInvalidTransition, toStage)
+	}
+	if !slices.Contains(Workflow[to].Statuses, toStatus) {
+		return fmt.Errorf("%w: status '%s' não pertence à etapa '%s'", ErrInvalidTransition, toStatus, toStage)
+	}
+	if from < 0 {
+		return fmt.Errorf("%w: etapa atual '%s' não existe no fluxo", ErrInvalidTransition, fromStage)
+	}
+	if fromStage == toStage && fromStatus == toStatus {
+		return fmt.Errorf("%w: o pedido já está em %s / %s", ErrInvalidTransition, toStage, toStatus)
+	}
+
+	switch {
+	case to == from:
+		if fromStatus == schemas.StatusDevolucao || (fromStatus == schemas.StatusEntregue && toStatus != schemas.StatusDevolucao) {
+			return fmt.Errorf("%w: pedido %s não pode mudar para '%s'", ErrInvalidTransition, fromStatus, toStatus)
+		}
+		return nil
+	case to > from:
+		if !slices.Contains(Workflow[from].Final, fromStatus) {
+			return fmt.Errorf("%w: conclua a etapa '%s' antes de avançar", ErrInvalidTransition, fromStage)
+		}
+		for i := from + 1; i < to; i++ {
+			if !Workflow[i].Optional {
+				return fmt.Errorf("%w: o pedido precisa passar pela etapa '%s'", ErrInvalidTransition, Workflow[i].Stage)
+			}
+		}
+		if !canEnter(Workflow[to], toStatus) {
+			return fmt.Errorf("%w: o pedido entra na etapa '%s' como '%s'", ErrInvalidTransition, toStage, Workflow[to].Statuses[0])
+		}
+		return nil
+	default:
+		if IsFinished(order) {
+			return fmt.Errorf("%w: pedido já finalizado", ErrInvalidTransition)
+		}
+		if reason == "" {
+			return ErrReasonRequired
+		}
+		return nil
+	}
+}
+
+// CanMove indica se o usuário pode movimentar pedidos que estão na etapa informada.
+func CanMove(user schemas.User, stage schemas.OrderStage) bool {
+	if utils.HasAnyRole(user, workflowAdminRoles) {
+		return true
+	}
+	i := stageIndex(stage)
+	return i >= 0 && utils.HasAnyRole(user, Workflow[i].Roles)
+}
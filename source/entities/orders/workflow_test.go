@@ -0,0 +1,187 @@
+package orders
+
+import (
+	"api/schemas"
+	"errors"
+	"testing"
+)
+
+func TestValidateTransition(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    schemas.Order
+		toStage  schemas.OrderStage
+		toStatus schemas.OrderStatus
+		reason   string
+		want     error
+	}{
+		{
+			name:     "mesma etapa, outro status",
+			order:    schemas.Order{Stage: schemas.StageDesign, Status: schemas.StatusPendente},
+			toStage:  schemas.StageDesign,
+			toStatus: schemas.StatusCorTeste,
+		},
+		{
+			name:     "pedido antigo sem etapa começa no Design",
+			order:    schemas.Order{},
+			toStage:  schemas.StageDesign,
+			toStatus: schemas.StatusEmAndamento,
+		},
+		{
+			name:     "mesmo status",
+			order:    schemas.Order{Stage: schemas.StageDesign, Status: schemas.StatusPendente},
+			toStage:  schemas.StageDesign,
+			toStatus: schemas.StatusPendente,
+			want:     ErrInvalidTransition,
+		},
+		{
+			name:     "status de outra etapa",
+			order:    schemas.Order{Stage: schemas.StageDesign, Status: schemas.StatusPendente},
+			toStage:  schemas.StageDesign,
+			toStatus: schemas.StatusImpresso,
+			want:     ErrInvalidTransition,
+		},
+		{
+			name:     "etapa inexistente",
+			order:    schemas.Order{Stage: schemas.StageDesign, Status: schemas.StatusArteOK},
+			toStage:  "Bordado",
+			toStatus: schemas.StatusPendente,
+			want:     ErrInvalidTransition,
+		},
+		{
+			name:     "avança pelo status de entrada",
+			order:    schemas.Order{Stage: schemas.StageDesign, Status: schemas.StatusArteOK},
+			toStage:  schemas.StageImpressao,
+			toStatus: schemas.StatusProcessando,
+		},
+		{
+			name:     "avança direto para um status do meio da etapa",
+			order:    schemas.Order{Stage: schemas.StageDesign, Status: schemas.StatusArteOK},
+			toStage:  schemas.StageImpressao,
+			toStatus: schemas.StatusImpresso,
+			want:     ErrInvalidTransition,
+		},
+		{
+			name:     "avança sem concluir a etapa",
+			order:    schemas.Order{Stage: schemas.StageDesign, Status: schemas.StatusEmAndamento},
+			toStage:  schemas.StageImpressao,
+			toStatus: schemas.StatusProcessando,
+			want:     ErrInvalidTransition,
+		},
+		{
+			name:     "avança por um status permitido em Entry",
+			order:    schemas.Order{Stage: schemas.StageImpressao, Status: schemas.StatusImpresso},
+			toStage:  schemas.StageSublimacao,
+			toStatus: schemas.StatusCalandra,
+		},
+		{
+			name:     "pula etapas opcionais",
+			order:    schemas.Order{Stage: schemas.StageSublimacao, Status: schemas.StatusPrensa},
+			toStage:  schemas.StageConferencia,
+			toStatus: schemas.StatusNaoConferido,
+		},
+		{
+			name:     "não pula etapa obrigatória",
+			order:    schemas.Order{Stage: schemas.StageImpressao, Status: schemas.StatusImpresso},
+			toStage:  schemas.StageCorte,
+			toStatus: schemas.StatusNaoCortado,
+			want:     ErrInvalidTransition,
+		},
+		{
+			name:     "entra na expedição já em entrega",
+			order:    schemas.Order{Stage: schemas.StageConferencia, Status: schemas.StatusConferido},
+			toStage:  schemas.StageExpedicao,
+			toStatus: schemas.StatusEmEntrega,
+		},
+		{
+			name:     "não entra na expedição já entregue",
+			order:    schemas.Order{Stage: schemas.StageConferencia, Status: schemas.StatusConferido},
+			toStage:  schemas.StageExpedicao,
+			toStatus: schemas.StatusEntregue,
+			want:     ErrInvalidTransition,
+		},
+		{
+			name:     "entregue só vira devolução",
+			order:    schemas.Order{Stage: schemas.StageExpedicao, Status: schemas.StatusEntregue},
+			toStage:  schemas.StageExpedicao,
+			toStatus: schemas.StatusEmEntrega,
+			want:     ErrInvalidTransition,
+		},
+		{
+			name:     "entregue vira devolução",
+			order:    schemas.Order{Stage: schemas.StageExpedicao, Status: schemas.StatusEntregue},
+			toStage:  schemas.StageExpedicao,
+			toStatus: schemas.StatusDevolucao,
+		},
+		{
+			name:     "devolução não muda",
+			order:    schemas.Order{Stage: schemas.StageExpedicao, Status: schemas.StatusDevolucao},
+			toStage:  schemas.StageExpedicao,
+			toStatus: schemas.StatusEntregue,
+			want:     ErrInvalidTransition,
+		},
+		{
+			name:     "retrabalho sem motivo",
+			order:    schemas.Order{Stage: schemas.StageImpressao, Status: schemas.StatusEmImpressao},
+			toStage:  schemas.StageDesign,
+			toStatus: schemas.StatusEmAndamento,
+			want:     ErrReasonRequired,
+		},
+		{
+			name:     "retrabalho com motivo",
+			order:    schemas.Order{Stage: schemas.StageImpressao, Status: schemas.StatusEmImpressao},
+			toStage:  schemas.StageDesign,
+			toStatus: schemas.StatusEmAndamento,
+			reason:   "Arte com erro",
+		},
+		{
+			name:     "pedido finalizado não volta de etapa",
+			order:    schemas.Order{Stage: schemas.StageExpedicao, Status: schemas.StatusEntregue},
+			toStage:  schemas.StageConferencia,
+			toStatus: schemas.StatusNaoConferido,
+			reason:   "Conferir de novo",
+			want:     ErrInvalidTransition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTransition(tt.order, tt.toStage, tt.toStatus, tt.reason)
+			if tt.want == nil && err != nil {
+				t.Fatalf("ValidateTransition() = %v, esperado nil", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("ValidateTransition() = %v, esperado %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanMove(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []string
+		stage schemas.OrderStage
+		want  bool
+	}{
+		{"admin em qualquer etapa", []string{schemas.USERS_ROLE_ADMIN}, schemas.StageExpedicao, true},
+		{"TI em qualquer etapa", []string{schemas.USERS_ROLE_IT}, schemas.StageDesign, true},
+		{"designer no Design", []string{schemas.USERS_ROLE_DESIGNER}, schemas.StageDesign, true},
+		{"coordenador de design no Design", []string{schemas.USERS_ROLE_DESIGNER_COORDINATOR}, schemas.StageDesign, true},
+		{"designer na Impressão", []string{schemas.USERS_ROLE_DESIGNER}, schemas.StageImpressao, false},
+		{"produção na Costura", []string{schemas.USERS_ROLE_PRODUCTION}, schemas.StageCostura, true},
+		{"produção no Design", []string{schemas.USERS_ROLE_PRODUCTION}, schemas.StageDesign, false},
+		{"vários perfis", []string{schemas.USERS_ROLE_COMMERCIAL, schemas.USERS_ROLE_PRODUCTION}, schemas.StageCorte, true},
+		{"comercial", []string{schemas.USERS_ROLE_COMMERCIAL}, schemas.StageExpedicao, false},
+		{"sem perfil", nil, schemas.StageDesign, false},
+		{"etapa inexistente", []string{schemas.USERS_ROLE_PRODUCTION}, "Bordado", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanMove(schemas.User{Role: tt.roles}, tt.stage); got != tt.want {
+				t.Fatalf("CanMove() = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
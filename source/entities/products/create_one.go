@@ -0,0 +1,97 @@
+package products
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func CreateOne(w http.ResponseWriter, r *http.Request) {
+	product := &schemas.Product{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.PRODUCTS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	if err := validateProduct(product); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.PRODUCTS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_PRODUCTS)
+
+	if conflict, err := findConflictingProduct(ctx, collection, product, bson.ObjectID{}); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_PRODUCTS_IN_MONGODB)
+		return
+	} else if conflict != "" {
+		utils.SendResponse(w, http.StatusConflict, conflict, nil, utils.ALREADY_EXISTS)
+		return
+	}
+
+	product.ID = bson.ObjectID{}
+	product.CreatedAt = time.Now()
+	product.UpdatedAt = time.Now()
+
+	result, err := collection.InsertOne(ctx, product)
+	if mongo.IsDuplicateKeyError(err) {
+		utils.SendResponse(w, http.StatusConflict, DUPLICATE_PRODUCT_MESSAGE, nil, utils.ALREADY_EXISTS)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_INSERT_PRODUCT_TO_MONGODB)
+		return
+	}
+	product.ID = result.InsertedID.(bson.ObjectID)
+
+	utils.SendResponse(w, http.StatusCreated, "", product, 0)
+}
+
+// DUPLICATE_PRODUCT_MESSAGE é usada quando o índice único barra um cadastro simultâneo ao da checagem prévia.
+const DUPLICATE_PRODUCT_MESSAGE = "Já existe um produto com esse SKU ou id do Tiny"
+
+// findConflictingProduct procura outro produto com o mesmo SKU ou o mesmo id do Tiny.
+// Retorna a mensagem de conflito, ou vazio quando não há conflito.
+func findConflictingProduct(ctx context.Context, collection *mongo.Collection, product *schemas.Product, ignoreID bson.ObjectID) (string, error) {
+	or := bson.A{bson.D{{Key: "sku", Value: product.SKU}}}
+	if product.TinyID != "" {
+		or = append(or, bson.D{{Key: "tiny_id", Value: product.TinyID}})
+	}
+	filter := bson.D{{Key: "$or", Value: or}}
+	if !ignoreID.IsZero() {
+		filter = append(filter, bson.E{Key: "_id", Value: bson.D{{Key: "$ne", Value: ignoreID}}})
+	}
+
+	existing := schemas.Product{}
+	err := collection.FindOne(ctx, filter).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if existing.SKU == product.SKU {
+		return "Já existe um produto com esse SKU", nil
+	}
+	return "Já existe um produto com esse id do Tiny", nil
+}
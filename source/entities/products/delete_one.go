@@ -0,0 +1,52 @@
+package products
+
+import (
+	"api/database"
+	"api/utils"
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// DeleteOne apenas desativa o produto, já que orçamentos e pedidos continuam referenciando o _id.
+func DeleteOne(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_PRODUCT_ID_FORMAT)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_PRODUCTS)
+
+	result, err := collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "active", Value: false}, {Key: "updated_at", Value: time.Now()}}}},
+	)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_PRODUCT_IN_MONGODB)
+		return
+	}
+	if result.MatchedCount == 0 {
+		utils.SendResponse(w, http.StatusNotFound, "Produto não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "Produto desativado com sucesso", nil, 0)
+}
@@ -0,0 +1,114 @@
+package products
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// GetAll lista o catálogo com paginação. Filtros: search (SKU ou nome), category, active e tiny_id.
+func GetAll(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	query := r.URL.Query()
+	pageStr := query.Get("page")
+	pageSizeStr := query.Get("pageSize")
+
+	page := int64(1)
+	pageSize := int64(25)
+
+	if pageStr != "" {
+		if parsedPage, err := strconv.ParseInt(pageStr, 10, 64); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+
+	if pageSizeStr != "" {
+		if parsedPageSize, err := strconv.ParseInt(pageSizeStr, 10, 64); err == nil && parsedPageSize > 0 {
+			pageSize = parsedPageSize
+			if pageSize > 100 {
+				pageSize = 100
+			}
+		}
+	}
+
+	skip := (page - 1) * pageSize
+
+	filter := bson.D{}
+	if search := query.Get("search"); search != "" {
+		pattern := bson.D{{Key: "$regex", Value: regexp.QuoteMeta(search)}, {Key: "$options", Value: "i"}}
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "sku", Value: pattern}},
+			bson.D{{Key: "name", Value: pattern}},
+		}})
+	}
+	if category := query.Get("category"); category != "" {
+		filter = append(filter, bson.E{Key: "category", Value: category})
+	}
+	if active := query.Get("active"); active != "" {
+		filter = append(filter, bson.E{Key: "active", Value: active == "true"})
+	}
+	if tinyID := query.Get("tiny_id"); tinyID != "" {
+		filter = append(filter, bson.E{Key: "tiny_id", Value: tinyID})
+	}
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_PRODUCTS)
+
+	totalItems, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_PRODUCTS_IN_MONGODB)
+		return
+	}
+
+	totalPages := int64(math.Ceil(float64(totalItems) / float64(pageSize)))
+
+	findOptions := options.Find().
+		SetSkip(skip).
+		SetLimit(pageSize).
+		SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_PRODUCTS_IN_MONGODB)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	products := []schemas.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_PRODUCTS_IN_MONGODB)
+		return
+	}
+
+	response := map[string]any{
+		"items": products,
+		"pagination": map[string]any{
+			"page":        page,
+			"page_size":   pageSize,
+			"total_items": totalItems,
+			"total_pages": totalPages,
+		},
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", response, 0)
+}
@@ -0,0 +1,245 @@
+package products
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var ErrProductNotFound = errors.New("produto não encontrado no catálogo")
+
+// ShippingItem é um volume para cotação de frete, com as medidas e o peso de cada volume, quantos volumes
+// iguais existem e quantas unidades do produto vão em cada um.
+type ShippingItem struct {
+	SKU            string  `json:"sku"`
+	Height         float64 `json:"height"`
+	Length         float64 `json:"length"`
+	Width          float64 `json:"width"`
+	Weight         float64 `json:"weight"`
+	Quantity       int     `json:"quantity"`
+	UnitsPerVolume int     `json:"units_per_volume"`
+}
+
+type PriceLookupRequest struct {
+	Items []schemas.LineItem `json:"items"`
+}
+
+// UnitPrice retorna o preço da maior faixa cuja quantidade mínima foi atingida, ou o preço base.
+func UnitPrice(product schemas.Product, quantity float64) float64 {
+	price := product.BasePrice
+	for _, priceBreak := range product.PriceBreaks {
+		if quantity >= priceBreak.MinQuantity {
+			price = priceBreak.UnitPrice
+		}
+	}
+	return price
+}
+
+// catalogForItems carrega de uma vez os produtos referenciados pelos itens (por product_id ou sku).
+func catalogForItems(ctx context.Context, db *mongo.Database, items []schemas.LineItem) (map[bson.ObjectID]schemas.Product, map[string]schemas.Product, error) {
+	ids := bson.A{}
+	skus := bson.A{}
+	for _, item := range items {
+		if !item.ProductID.IsZero() {
+			ids = append(ids, item.ProductID)
+		} else if item.SKU != "" {
+			skus = append(skus, strings.ToUpper(strings.TrimSpace(item.SKU)))
+		}
+	}
+
+	byID := map[bson.ObjectID]schemas.Product{}
+	bySKU := map[string]schemas.Product{}
+	if len(ids) == 0 && len(skus) == 0 {
+		return byID, bySKU, nil
+	}
+
+	filter := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}},
+		bson.D{{Key: "sku", Value: bson.D{{Key: "$in", Value: skus}}}},
+	}}}
+	cursor, err := db.Collection(database.COLLECTION_PRODUCTS).Find(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	products := []schemas.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, nil, err
+	}
+	for _, product := range products {
+		byID[product.ID] = product
+		bySKU[product.SKU] = product
+	}
+	return byID, bySKU, nil
+}
+
+func findItemProduct(item schemas.LineItem, byID map[bson.ObjectID]schemas.Product, bySKU map[string]schemas.Product) (schemas.Product, bool) {
+	if !item.ProductID.IsZero() {
+		product, ok := byID[item.ProductID]
+		return product, ok
+	}
+	product, ok := bySKU[strings.ToUpper(strings.TrimSpace(item.SKU))]
+	return product, ok
+}
+
+// PriceLineItems completa os itens que referenciam o catálogo (product_id, sku e descrição) e aplica a tabela
+// de preços quando o item não traz preço unitário (um preço zero informado é mantido). Itens avulsos, sem
+// product_id nem sku, não são alterados.
+func PriceLineItems(ctx context.Context, db *mongo.Database, items []schemas.LineItem) error {
+	byID, bySKU, err := catalogForItems(ctx, db, items)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		if items[i].ProductID.IsZero() && items[i].SKU == "" {
+			continue
+		}
+		product, ok := findItemProduct(items[i], byID, bySKU)
+		if !ok || !product.Active {
+			return fmt.Errorf("item %d: %w", i+1, ErrProductNotFound)
+		}
+
+		items[i].ProductID = product.ID
+		items[i].SKU = product.SKU
+		if items[i].Description == "" {
+			items[i].Description = product.Name
+		}
+		if items[i].UnitPrice == nil {
+			price := UnitPrice(product, items[i].Quantity)
+			items[i].UnitPrice = &price
+		}
+	}
+	utils.SumLineItems(items)
+	return nil
+}
+
+// ShippingItems monta os volumes da cotação de frete a partir das medidas cadastradas no catálogo.
+// Produtos com regra de embalagem viram caixas de até UnitsPerBox unidades (a última com o que sobrar);
+// os demais seguem um volume por unidade. Todos os itens precisam referenciar um produto com peso e,
+// sem embalagem, com as medidas preenchidas.
+func ShippingItems(ctx context.Context, db *mongo.Database, items []schemas.LineItem) ([]ShippingItem, error) {
+	byID, bySKU, err := catalogForItems(ctx, db, items)
+	if err != nil {
+		return nil, err
+	}
+
+	shippingItems := []ShippingItem{}
+	for i, item := range items {
+		product, ok := findItemProduct(item, byID, bySKU)
+		if !ok {
+			return nil, fmt.Errorf("item %d: %w", i+1, ErrProductNotFound)
+		}
+		units := int(math.Max(1, math.Ceil(item.Quantity)))
+		d := product.Dimensions
+
+		if packing := product.Packing; packing != nil && packing.UnitsPerBox > 0 {
+			if d.Weight == 0 {
+				return nil, fmt.Errorf("item %d: o produto %s não tem peso cadastrado", i+1, product.SKU)
+			}
+			box := packing.Box
+			fullBoxes, rest := units/packing.UnitsPerBox, units%packing.UnitsPerBox
+			if fullBoxes > 0 {
+				shippingItems = append(shippingItems, ShippingItem{
+					SKU:            product.SKU,
+					Height:         box.Height,
+					Length:         box.Length,
+					Width:          box.Width,
+					Weight:         roundWeight(float64(packing.UnitsPerBox)*d.Weight + box.Weight),
+					Quantity:       fullBoxes,
+					UnitsPerVolume: packing.UnitsPerBox,
+				})
+			}
+			if rest > 0 {
+				shippingItems = append(shippingItems, ShippingItem{
+					SKU:            product.SKU,
+					Height:         box.Height,
+					Length:         box.Length,
+					Width:          box.Width,
+					Weight:         roundWeight(float64(rest)*d.Weight + box.Weight),
+					Quantity:       1,
+					UnitsPerVolume: rest,
+				})
+			}
+			continue
+		}
+
+		if d.Height == 0 || d.Length == 0 || d.Width == 0 || d.Weight == 0 {
+			return nil, fmt.Errorf("item %d: o produto %s não tem medidas e peso cadastrados", i+1, product.SKU)
+		}
+		shippingItems = append(shippingItems, ShippingItem{
+			SKU:            product.SKU,
+			Height:         d.Height,
+			Length:         d.Length,
+			Width:          d.Width,
+			Weight:         d.Weight,
+			Quantity:       units,
+			UnitsPerVolume: 1,
+		})
+	}
+	return shippingItems, nil
+}
+
+// roundWeight arredonda o peso para gramas.
+func roundWeight(weight float64) float64 {
+	return math.Round(weight*1000) / 1000
+}
+
+// LookupPrices calcula os preços dos itens pela tabela do catálogo, sem gravar nada.
+func LookupPrices(w http.ResponseWriter, r *http.Request) {
+	req := PriceLookupRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Items) == 0 {
+		utils.SendResponse(w, http.StatusBadRequest, "Informe ao menos um item", nil, utils.PRODUCTS_INVALID_REQUEST_DATA)
+		return
+	}
+	for i, item := range req.Items {
+		if item.ProductID.IsZero() && item.SKU == "" {
+			utils.SendResponse(w, http.StatusBadRequest, fmt.Sprintf("O item %d deve ter 'product_id' ou 'sku'", i+1), nil, utils.PRODUCTS_INVALID_REQUEST_DATA)
+			return
+		}
+		if item.Quantity <= 0 {
+			utils.SendResponse(w, http.StatusBadRequest, fmt.Sprintf("O item %d deve ter quantidade maior que zero", i+1), nil, utils.PRODUCTS_INVALID_REQUEST_DATA)
+			return
+		}
+		// Na consulta o preço sempre vem da tabela
+		req.Items[i].UnitPrice = nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	err = PriceLineItems(ctx, mongoClient.Database(database.GetDB()), req.Items)
+	if errors.Is(err, ErrProductNotFound) {
+		utils.SendResponse(w, http.StatusNotFound, err.Error(), nil, utils.NOT_FOUND)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_PRODUCTS_IN_MONGODB)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", map[string]any{
+		"items":    req.Items,
+		"subtotal": utils.SumLineItems(req.Items),
+	}, 0)
+}
@@ -0,0 +1,151 @@
+package products
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// UpdateOne atualiza só os campos enviados e valida o produto resultante antes de gravar.
+func UpdateOne(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_PRODUCT_ID_FORMAT)
+		return
+	}
+
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.PRODUCTS_INVALID_REQUEST_DATA)
+		return
+	}
+	input := schemas.Product{}
+	raw := map[string]any{}
+	if err := json.Unmarshal(bodyBytes, &input); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.PRODUCTS_INVALID_REQUEST_DATA)
+		return
+	}
+	_ = json.Unmarshal(bodyBytes, &raw)
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv(utils.MONGODB_URI)
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_PRODUCTS)
+
+	product := schemas.Product{}
+	err = collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&product)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Produto não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_PRODUCTS_IN_MONGODB)
+		return
+	}
+
+	has := func(key string) bool {
+		_, ok := raw[key]
+		return ok
+	}
+
+	changed := false
+	if has("sku") {
+		product.SKU = input.SKU
+		changed = true
+	}
+	if has("name") {
+		product.Name = input.Name
+		changed = true
+	}
+	if has("category") {
+		product.Category = input.Category
+		changed = true
+	}
+	if has("base_price") {
+		product.BasePrice = input.BasePrice
+		changed = true
+	}
+	if has("price_breaks") {
+		product.PriceBreaks = input.PriceBreaks
+		changed = true
+	}
+	if has("dimensions") {
+		product.Dimensions = input.Dimensions
+		changed = true
+	}
+	if has("packing") {
+		product.Packing = input.Packing
+		changed = true
+	}
+	if has("tiny_id") {
+		product.TinyID = input.TinyID
+		changed = true
+	}
+	if has("active") {
+		product.Active = input.Active
+		changed = true
+	}
+
+	if !changed {
+		utils.SendResponse(w, http.StatusBadRequest, "Nenhum campo para atualizar foi fornecido", nil, 0)
+		return
+	}
+
+	if err := validateProduct(&product); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, err.Error(), nil, utils.PRODUCTS_INVALID_REQUEST_DATA)
+		return
+	}
+
+	if conflict, err := findConflictingProduct(ctx, collection, &product, id); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_PRODUCTS_IN_MONGODB)
+		return
+	} else if conflict != "" {
+		utils.SendResponse(w, http.StatusConflict, conflict, nil, utils.ALREADY_EXISTS)
+		return
+	}
+
+	product.UpdatedAt = time.Now()
+	updateDoc := bson.D{
+		{Key: "sku", Value: product.SKU},
+		{Key: "name", Value: product.Name},
+		{Key: "category", Value: product.Category},
+		{Key: "base_price", Value: product.BasePrice},
+		{Key: "price_breaks", Value: product.PriceBreaks},
+		{Key: "dimensions", Value: product.Dimensions},
+		{Key: "packing", Value: product.Packing},
+		{Key: "tiny_id", Value: product.TinyID},
+		{Key: "active", Value: product.Active},
+		{Key: "updated_at", Value: product.UpdatedAt},
+	}
+
+	_, err = collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: updateDoc}})
+	if mongo.IsDuplicateKeyError(err) {
+		utils.SendResponse(w, http.StatusConflict, DUPLICATE_PRODUCT_MESSAGE, nil, utils.ALREADY_EXISTS)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_UPDATE_PRODUCT_IN_MONGODB)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", product, 0)
+}
@@ -0,0 +1,61 @@
+package products
+
+import (
+	"api/schemas"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// validateProduct normaliza o SKU, ordena as faixas de preço e confere os valores antes de gravar.
+func validateProduct(product *schemas.Product) error {
+	product.SKU = strings.ToUpper(strings.TrimSpace(product.SKU))
+	product.Name = strings.TrimSpace(product.Name)
+	product.Category = strings.TrimSpace(product.Category)
+	product.TinyID = strings.TrimSpace(product.TinyID)
+
+	if product.SKU == "" {
+		return errors.New("O campo 'sku' é obrigatório")
+	}
+	if product.Name == "" {
+		return errors.New("O campo 'name' é obrigatório")
+	}
+	if product.BasePrice < 0 {
+		return errors.New("O preço base não pode ser negativo")
+	}
+
+	sort.Slice(product.PriceBreaks, func(i, j int) bool {
+		return product.PriceBreaks[i].MinQuantity < product.PriceBreaks[j].MinQuantity
+	})
+	for i, priceBreak := range product.PriceBreaks {
+		if priceBreak.MinQuantity <= 0 {
+			return fmt.Errorf("A faixa de preço %d deve ter quantidade mínima maior que zero", i+1)
+		}
+		if priceBreak.UnitPrice < 0 {
+			return fmt.Errorf("A faixa de preço %d não pode ter preço negativo", i+1)
+		}
+		if i > 0 && priceBreak.MinQuantity == product.PriceBreaks[i-1].MinQuantity {
+			return fmt.Errorf("Há mais de uma faixa de preço para a quantidade %v", priceBreak.MinQuantity)
+		}
+	}
+
+	d := product.Dimensions
+	if d.Height < 0 || d.Width < 0 || d.Length < 0 || d.Weight < 0 {
+		return errors.New("As dimensões e o peso não podem ser negativos")
+	}
+
+	if packing := product.Packing; packing != nil {
+		if packing.UnitsPerBox < 1 {
+			return errors.New("A embalagem deve comportar ao menos uma unidade por caixa")
+		}
+		if packing.Box.Height <= 0 || packing.Box.Width <= 0 || packing.Box.Length <= 0 {
+			return errors.New("Informe as medidas da caixa da embalagem")
+		}
+		if packing.Box.Weight < 0 {
+			return errors.New("O peso da caixa não pode ser negativo")
+		}
+	}
+
+	return nil
+}
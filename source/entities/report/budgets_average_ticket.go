@@ -3,7 +3,6 @@ package report
 import (
 	"api/database"
 	"context"
-	"encoding/json"
 	"os"
 	"time"
 
@@ -57,11 +56,6 @@ func GetBudgetsAverageTicket(from, until string, notApproved bool) (float64, err
 		}
 		defer cursor.Close(ctx)
 
-		type Product struct {
-			Preco      float64 `json:"preco"`
-			Quantidade int     `json:"quantidade"`
-		}
-
 		total := 0.0
 		count := 0
 		for cursor.Next(ctx) {
@@ -70,22 +64,7 @@ func GetBudgetsAverageTicket(from, until string, notApproved bool) (float64, err
 				continue
 			}
 
-			oldProductsList, _ := doc["old_products_list"].(string)
-			var products []Product
-			budgetTotal := 0.0
-			if oldProductsList != "" {
-				_ = json.Unmarshal([]byte(oldProductsList), &products)
-				for _, p := range products {
-					budgetTotal += p.Preco * float64(p.Quantidade)
-				}
-			}
-
-			delivery, _ := doc["delivery"].(bson.M)
-			if delivery != nil {
-				if price, ok := delivery["price"].(float64); ok {
-					budgetTotal += price
-				}
-			}
+			budgetTotal := budgetValue(doc)
 			if budgetTotal > 0 {
 				total += budgetTotal
 				count++
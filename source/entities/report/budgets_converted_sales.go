@@ -3,7 +3,6 @@ package report
 import (
 	"api/database"
 	"context"
-	"encoding/json"
 	"os"
 	"time"
 
@@ -57,11 +56,6 @@ func GetBudgetsConvertedSales(from, until string, notApproved bool) (int64, erro
 		}
 		defer cursor.Close(ctx)
 
-		type Product struct {
-			Preco      float64 `json:"preco"`
-			Quantidade int     `json:"quantidade"`
-		}
-
 		count := int64(0)
 		for cursor.Next(ctx) {
 			var doc bson.M
@@ -69,22 +63,7 @@ func GetBudgetsConvertedSales(from, until string, notApproved bool) (int64, erro
 				continue
 			}
 
-			oldProductsList, _ := doc["old_products_list"].(string)
-			var products []Product
-			budgetTotal := 0.0
-			if oldProductsList != "" {
-				_ = json.Unmarshal([]byte(oldProductsList), &products)
-				for _, p := range products {
-					budgetTotal += p.Preco * float64(p.Quantidade)
-				}
-			}
-
-			delivery, _ := doc["delivery"].(bson.M)
-			if delivery != nil {
-				if price, ok := delivery["price"].(float64); ok {
-					budgetTotal += price
-				}
-			}
+			budgetTotal := budgetValue(doc)
 			if budgetTotal > 0 {
 				count++
 			}
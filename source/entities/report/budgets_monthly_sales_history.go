@@ -3,7 +3,6 @@ package report
 import (
 	"api/database"
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"time"
@@ -58,11 +57,6 @@ func GetBudgetsMonthlySalesHistory(from, until string, notApproved bool) (map[st
 		}
 		defer cursor.Close(ctx)
 
-		type Product struct {
-			Preco      float64 `json:"preco"`
-			Quantidade int     `json:"quantidade"`
-		}
-
 		result := make(map[string]float64)
 		for cursor.Next(ctx) {
 			var doc bson.M
@@ -86,22 +80,7 @@ func GetBudgetsMonthlySalesHistory(from, until string, notApproved bool) (map[st
 
 			key := fmt.Sprintf("%04d-%02d", createdAt.Year(), int(createdAt.Month()))
 
-			oldProductsList, _ := doc["old_products_list"].(string)
-			var products []Product
-			budgetTotal := 0.0
-			if oldProductsList != "" {
-				_ = json.Unmarshal([]byte(oldProductsList), &products)
-				for _, p := range products {
-					budgetTotal += p.Preco * float64(p.Quantidade)
-				}
-			}
-
-			delivery, _ := doc["delivery"].(bson.M)
-			if delivery != nil {
-				if price, ok := delivery["price"].(float64); ok {
-					budgetTotal += price
-				}
-			}
+			budgetTotal := budgetValue(doc)
 			result[key] += budgetTotal
 		}
 		if err := cursor.Err(); err != nil {
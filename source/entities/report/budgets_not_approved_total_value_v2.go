@@ -3,7 +3,6 @@ package report
 import (
 	"api/database"
 	"context"
-	"encoding/json"
 	"os"
 	"time"
 
@@ -53,11 +52,6 @@ func GetBudgetsNotApprovedTotalValueV2(from, until string) (float64, error) {
 	}
 	defer cursor.Close(ctx)
 
-	type Product struct {
-		Preco      float64 `json:"preco"`
-		Quantidade int     `json:"quantidade"`
-	}
-
 	total := 0.0
 	for cursor.Next(ctx) {
 		var doc bson.M
@@ -65,21 +59,7 @@ func GetBudgetsNotApprovedTotalValueV2(from, until string) (float64, error) {
 			continue
 		}
 
-		oldProductsList, _ := doc["old_products_list"].(string)
-		var products []Product
-		if oldProductsList != "" {
-			_ = json.Unmarshal([]byte(oldProductsList), &products)
-			for _, p := range products {
-				total += p.Preco * float64(p.Quantidade)
-			}
-		}
-
-		delivery, _ := doc["delivery"].(bson.M)
-		if delivery != nil {
-			if price, ok := delivery["price"].(float64); ok {
-				total += price
-			}
-		}
+		total += budgetValue(doc)
 	}
 
 	if err := cursor.Err(); err != nil {
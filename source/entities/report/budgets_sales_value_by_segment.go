@@ -3,7 +3,6 @@ package report
 import (
 	"api/database"
 	"context"
-	"encoding/json"
 	"os"
 	"time"
 
@@ -57,11 +56,6 @@ func GetBudgetsSalesValueBySegment(from, until string, notApproved bool) (map[st
 		}
 		defer cursor.Close(ctx)
 
-		type Product struct {
-			Preco      float64 `json:"preco"`
-			Quantidade int     `json:"quantidade"`
-		}
-
 		result := make(map[string]float64)
 		for cursor.Next(ctx) {
 			var doc bson.M
@@ -76,22 +70,7 @@ func GetBudgetsSalesValueBySegment(from, until string, notApproved bool) (map[st
 				}
 			}
 
-			oldProductsList, _ := doc["old_products_list"].(string)
-			var products []Product
-			budgetTotal := 0.0
-			if oldProductsList != "" {
-				_ = json.Unmarshal([]byte(oldProductsList), &products)
-				for _, p := range products {
-					budgetTotal += p.Preco * float64(p.Quantidade)
-				}
-			}
-
-			delivery, _ := doc["delivery"].(bson.M)
-			if delivery != nil {
-				if price, ok := delivery["price"].(float64); ok {
-					budgetTotal += price
-				}
-			}
+			budgetTotal := budgetValue(doc)
 			result[segment] += budgetTotal
 		}
 		if err := cursor.Err(); err != nil {
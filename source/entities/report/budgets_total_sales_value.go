@@ -3,7 +3,6 @@ package report
 import (
 	"api/database"
 	"context"
-	"encoding/json"
 	"os"
 	"time"
 
@@ -57,11 +56,6 @@ func GetBudgetsTotalSalesValue(from, until string, notApproved bool) (float64, e
 		}
 		defer cursor.Close(ctx)
 
-		type Product struct {
-			Preco      float64 `json:"preco"`
-			Quantidade int     `json:"quantidade"`
-		}
-
 		total := 0.0
 		for cursor.Next(ctx) {
 			var doc bson.M
@@ -69,21 +63,7 @@ func GetBudgetsTotalSalesValue(from, until string, notApproved bool) (float64, e
 				continue
 			}
 
-			oldProductsList, _ := doc["old_products_list"].(string)
-			var products []Product
-			if oldProductsList != "" {
-				_ = json.Unmarshal([]byte(oldProductsList), &products)
-				for _, p := range products {
-					total += p.Preco * float64(p.Quantidade)
-				}
-			}
-
-			delivery, _ := doc["delivery"].(bson.M)
-			if delivery != nil {
-				if price, ok := delivery["price"].(float64); ok {
-					total += price
-				}
-			}
+			total += budgetValue(doc)
 		}
 		if err := cursor.Err(); err != nil {
 			return 0, err
@@ -104,7 +104,9 @@ func GetCommercialOrdersDailyValue(seller bson.ObjectID, from, until string) (ma
 
 		var orderTotal float64
 
-		if listStr, _ := doc["products_list_legacy"].(string); listStr != "" {
+		if typedTotal, ok := typedOrderValue(doc); ok {
+			orderTotal = typedTotal
+		} else if listStr, _ := doc["products_list_legacy"].(string); listStr != "" {
 			var products []legacyProduct
 			if err := json.Unmarshal([]byte(listStr), &products); err == nil {
 				for _, p := range products {
@@ -11,7 +11,7 @@ func GetByQuery(w http.ResponseWriter, r *http.Request) {
 	params := r.URL.Query()
 
 	reportType := params.Get("type")
-	reportTypeCheck := (reportType != schemas.REPORT_TYPE_CLIENTS) && (reportType != schemas.REPORT_TYPE_BUDGETS) && (reportType != schemas.REPORT_TYPE_LEADS) && (reportType != schemas.REPORT_TYPE_ORDERS)
+	reportTypeCheck := (reportType != schemas.REPORT_TYPE_CLIENTS) && (reportType != schemas.REPORT_TYPE_BUDGETS) && (reportType != schemas.REPORT_TYPE_LEADS) && (reportType != schemas.REPORT_TYPE_ORDERS) && (reportType != schemas.REPORT_TYPE_SPACE_DESK)
 	if reportTypeCheck {
 		utils.SendResponse(w, http.StatusBadRequest, "Tipo de relatório inválido", nil, 0)
 		return
@@ -268,6 +268,37 @@ func GetByQuery(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if reportType == schemas.REPORT_TYPE_SPACE_DESK {
+		if _, ok := params["space_desk_transfers_per_agent"]; ok {
+			var v map[string]SpaceDeskAgentTransfers
+			v, err = GetSpaceDeskTransfersPerAgent(period[0], period[1])
+			if handleErr(err) {
+				return
+			}
+			responseData["space_desk_transfers_per_agent"] = v
+		}
+
+		seller := params.Get("seller")
+
+		if _, ok := params["space_desk_csat"]; ok {
+			var v SpaceDeskCSATReport
+			v, err = GetSpaceDeskCSAT(period[0], period[1], seller)
+			if handleErr(err) {
+				return
+			}
+			responseData["space_desk_csat"] = v
+		}
+
+		if _, ok := params["space_desk_nps"]; ok {
+			var v SpaceDeskNPSReport
+			v, err = GetSpaceDeskNPS(period[0], period[1], seller)
+			if handleErr(err) {
+				return
+			}
+			responseData["space_desk_nps"] = v
+		}
+	}
+
 	if len(responseData) == 0 {
 		utils.SendResponse(w, http.StatusBadRequest, "Nenhum relatório selecionado", nil, 0)
 		return
@@ -0,0 +1,25 @@
+package report
+
+import (
+	"api/utils"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// typedOrderValue soma os itens tipados do pedido; ok é falso quando o pedido ainda usa a lista legada.
+func typedOrderValue(doc bson.M) (float64, bool) {
+	items, ok := utils.LineItemsFromDocument(doc)
+	if !ok {
+		return 0, false
+	}
+	return utils.SumLineItems(items), true
+}
+
+// budgetValue retorna o total do orçamento: o calculado no servidor ou, para orçamentos ainda não migrados, o
+// calculado da lista legada com a mesma regra (itens - desconto + antecipação + entrega).
+func budgetValue(doc bson.M) float64 {
+	if totals, ok := utils.BudgetTotalsFromDocument(doc); ok {
+		return totals.Total
+	}
+	return utils.LegacyBudgetTotals(doc).Total
+}
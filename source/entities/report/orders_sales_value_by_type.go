@@ -95,7 +95,9 @@ func GetOrdersSalesValueByType(from, until string) (map[string]float64, error) {
 		listStr, _ := doc["products_list_legacy"].(string)
 		var orderTotal float64
 
-		if listStr != "" {
+		if typedTotal, ok := typedOrderValue(doc); ok {
+			orderTotal = typedTotal
+		} else if listStr != "" {
 			var products []legacyProduct
 			if err := json.Unmarshal([]byte(listStr), &products); err != nil {
 				continue
@@ -89,7 +89,9 @@ func GetOrdersTotalSalesValue(from, until string) (float64, error) {
 
 		var orderTotal float64
 
-		if listStr, _ := doc["products_list_legacy"].(string); listStr != "" {
+		if typedTotal, ok := typedOrderValue(doc); ok {
+			orderTotal = typedTotal
+		} else if listStr, _ := doc["products_list_legacy"].(string); listStr != "" {
 			var products []legacyProduct
 			if err := json.Unmarshal([]byte(listStr), &products); err == nil {
 				for _, p := range products {
@@ -0,0 +1,160 @@
+package report
+
+import (
+	"api/database"
+	"api/schemas"
+	"context"
+	"math"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type SpaceDeskCSAT struct {
+	Responses int64   `json:"responses"`
+	Average   float64 `json:"average"`
+	Satisfied int64   `json:"satisfied"`
+	CSAT      float64 `json:"csat"`
+}
+
+type SpaceDeskNPS struct {
+	Responses  int64   `json:"responses"`
+	Promoters  int64   `json:"promoters"`
+	Passives   int64   `json:"passives"`
+	Detractors int64   `json:"detractors"`
+	NPS        float64 `json:"nps"`
+}
+
+type SpaceDeskCSATReport struct {
+	SpaceDeskCSAT
+	BySeller map[string]SpaceDeskCSAT `json:"by_seller"`
+	ByGroup  map[string]SpaceDeskCSAT `json:"by_group"`
+}
+
+type SpaceDeskNPSReport struct {
+	SpaceDeskNPS
+	BySeller map[string]SpaceDeskNPS `json:"by_seller"`
+	ByGroup  map[string]SpaceDeskNPS `json:"by_group"`
+}
+
+// GetSpaceDeskCSAT calcula o CSAT (% de notas 4 e 5) das pesquisas respondidas no período, geral, por vendedor e por grupo.
+func GetSpaceDeskCSAT(from, until, seller string) (SpaceDeskCSATReport, error) {
+	report := SpaceDeskCSATReport{BySeller: map[string]SpaceDeskCSAT{}, ByGroup: map[string]SpaceDeskCSAT{}}
+
+	surveys, err := findAnsweredSurveys(schemas.SPACE_DESK_SURVEY_CSAT, from, until, seller)
+	if err != nil {
+		return report, err
+	}
+
+	sums := map[string]float64{}
+	add := func(c SpaceDeskCSAT, key string, score int) SpaceDeskCSAT {
+		c.Responses++
+		if score >= 4 {
+			c.Satisfied++
+		}
+		sums[key] += float64(score)
+		c.Average = roundTwo(sums[key] / float64(c.Responses))
+		c.CSAT = roundTwo(float64(c.Satisfied) / float64(c.Responses) * 100)
+		return c
+	}
+
+	for _, survey := range surveys {
+		report.SpaceDeskCSAT = add(report.SpaceDeskCSAT, "", survey.Score)
+		report.BySeller[survey.UserID] = add(report.BySeller[survey.UserID], "seller:"+survey.UserID, survey.Score)
+		if survey.GroupID != "" {
+			report.ByGroup[survey.GroupID] = add(report.ByGroup[survey.GroupID], "group:"+survey.GroupID, survey.Score)
+		}
+	}
+	return report, nil
+}
+
+// GetSpaceDeskNPS calcula o NPS (% promotores - % detratores) das pesquisas respondidas no período, geral, por vendedor e por grupo.
+func GetSpaceDeskNPS(from, until, seller string) (SpaceDeskNPSReport, error) {
+	report := SpaceDeskNPSReport{BySeller: map[string]SpaceDeskNPS{}, ByGroup: map[string]SpaceDeskNPS{}}
+
+	surveys, err := findAnsweredSurveys(schemas.SPACE_DESK_SURVEY_NPS, from, until, seller)
+	if err != nil {
+		return report, err
+	}
+
+	add := func(n SpaceDeskNPS, score int) SpaceDeskNPS {
+		n.Responses++
+		switch {
+		case score >= 9:
+			n.Promoters++
+		case score >= 7:
+			n.Passives++
+		default:
+			n.Detractors++
+		}
+		n.NPS = roundTwo(float64(n.Promoters-n.Detractors) / float64(n.Responses) * 100)
+		return n
+	}
+
+	for _, survey := range surveys {
+		report.SpaceDeskNPS = add(report.SpaceDeskNPS, survey.Score)
+		report.BySeller[survey.UserID] = add(report.BySeller[survey.UserID], survey.Score)
+		if survey.GroupID != "" {
+			report.ByGroup[survey.GroupID] = add(report.ByGroup[survey.GroupID], survey.Score)
+		}
+	}
+	return report, nil
+}
+
+func findAnsweredSurveys(surveyType, from, until, seller string) ([]schemas.SpaceDeskSurvey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv("MONGODB_URI")
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_SPACE_DESK_SURVEYS)
+
+	filter := bson.D{
+		{Key: "type", Value: surveyType},
+		{Key: "answered", Value: true},
+	}
+	if seller != "" {
+		filter = append(filter, bson.E{Key: "user_id", Value: seller})
+	}
+	if from != "" || until != "" {
+		dateFilter := bson.D{}
+		if from != "" {
+			if fromTime, err := time.Parse(time.RFC3339, from); err == nil {
+				dateFilter = append(dateFilter, bson.E{Key: "$gte", Value: fromTime})
+			}
+		}
+		if until != "" {
+			if untilTime, err := time.Parse(time.RFC3339, until); err == nil {
+				dateFilter = append(dateFilter, bson.E{Key: "$lte", Value: untilTime})
+			}
+		}
+		if len(dateFilter) > 0 {
+			filter = append(filter, bson.E{Key: "answered_at", Value: dateFilter})
+		}
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	surveys := []schemas.SpaceDeskSurvey{}
+	if err := cursor.All(ctx, &surveys); err != nil {
+		return nil, err
+	}
+	return surveys, nil
+}
+
+func roundTwo(v float64) float64 {
+	return math.Round(v*100) / 100
+}
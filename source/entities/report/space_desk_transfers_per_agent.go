@@ -0,0 +1,105 @@
+package report
+
+import (
+	"api/database"
+	"api/schemas"
+	"context"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type SpaceDeskAgentTransfers struct {
+	Sent           int64 `json:"sent"`
+	Received       int64 `json:"received"`
+	Accepted       int64 `json:"accepted"`
+	Rejected       int64 `json:"rejected"`
+	ForcedSent     int64 `json:"forced_sent"`
+	ForcedReceived int64 `json:"forced_received"`
+}
+
+// GetSpaceDeskTransfersPerAgent conta as transferências de chats por agente (origem e destino) no período.
+func GetSpaceDeskTransfersPerAgent(from, until string) (map[string]SpaceDeskAgentTransfers, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoURI := os.Getenv("MONGODB_URI")
+	opts := options.Client().ApplyURI(mongoURI)
+	mongoClient, err := mongo.Connect(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	collection := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_SPACE_DESK_CHAT)
+
+	filter := bson.D{}
+	if from != "" || until != "" {
+		dateFilter := bson.D{}
+		if from != "" {
+			if fromTime, err := time.Parse(time.RFC3339, from); err == nil {
+				dateFilter = append(dateFilter, bson.E{Key: "$gte", Value: fromTime})
+			}
+		}
+		if until != "" {
+			if untilTime, err := time.Parse(time.RFC3339, until); err == nil {
+				dateFilter = append(dateFilter, bson.E{Key: "$lte", Value: untilTime})
+			}
+		}
+		if len(dateFilter) > 0 {
+			filter = append(filter, bson.E{Key: "requested_at", Value: dateFilter})
+		}
+	}
+
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "transfers.0", Value: bson.D{{Key: "$exists", Value: true}}}}}},
+		bson.D{{Key: "$unwind", Value: "$transfers"}},
+		bson.D{{Key: "$replaceRoot", Value: bson.D{{Key: "newRoot", Value: "$transfers"}}}},
+	}
+	if len(filter) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter}})
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	result := make(map[string]SpaceDeskAgentTransfers)
+	for cursor.Next(ctx) {
+		var transfer schemas.SpaceDeskChatTransfer
+		if err := cursor.Decode(&transfer); err != nil {
+			continue
+		}
+		forced := transfer.Mode == schemas.SPACE_DESK_TRANSFER_MODE_FORCED
+
+		if transfer.FromUserID != "" {
+			agent := result[transfer.FromUserID]
+			agent.Sent++
+			if forced {
+				agent.ForcedSent++
+			}
+			result[transfer.FromUserID] = agent
+		}
+
+		if transfer.ToUserID != "" {
+			agent := result[transfer.ToUserID]
+			agent.Received++
+			switch transfer.Status {
+			case schemas.SPACE_DESK_TRANSFER_STATUS_ACCEPTED:
+				agent.Accepted++
+			case schemas.SPACE_DESK_TRANSFER_STATUS_REJECTED:
+				agent.Rejected++
+			}
+			if forced {
+				agent.ForcedReceived++
+			}
+			result[transfer.ToUserID] = agent
+		}
+	}
+	return result, nil
+}
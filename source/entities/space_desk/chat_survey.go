@@ -0,0 +1,182 @@
+package spacedesk
+
+import (
+	"api/database"
+	"api/schemas"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var ErrInvalidSurveyType = errors.New("tipo de pesquisa inválido")
+
+// Prazo para o cliente responder o NPS digitando a nota
+const SURVEY_TEXT_REPLY_WINDOW = 24 * time.Hour
+
+// surveyRows monta as opções da pesquisa. A lista do WhatsApp aceita no máximo 10 linhas, por isso no NPS
+// a lista vai de 1 a 10 e a nota 0 é dada respondendo com o número (ver recordSurveyTextReply).
+func surveyRows(surveyType string) (string, []map[string]string, error) {
+	rows := []map[string]string{}
+	switch surveyType {
+	case schemas.SPACE_DESK_SURVEY_CSAT:
+		labels := []string{"Muito insatisfeito", "Insatisfeito", "Neutro", "Satisfeito", "Muito satisfeito"}
+		for i, label := range labels {
+			rows = append(rows, map[string]string{"id": fmt.Sprintf("csat_%d", i+1), "title": strconv.Itoa(i + 1), "description": label})
+		}
+		return "Como você avalia o nosso atendimento?", rows, nil
+	case schemas.SPACE_DESK_SURVEY_NPS:
+		for score := 10; score >= 1; score-- {
+			rows = append(rows, map[string]string{"id": fmt.Sprintf("nps_%d", score), "title": strconv.Itoa(score)})
+		}
+		return "De 0 a 10, quanto você recomendaria a Arte Arena para um amigo? Escolha uma nota na lista ou responda com o número.", rows, nil
+	}
+	return "", nil, ErrInvalidSurveyType
+}
+
+// sendChatSurvey envia a pesquisa de satisfação para o chat e registra o envio com o agente e grupo responsáveis.
+func sendChatSurvey(ctx context.Context, mongoClient *mongo.Client, chatID bson.ObjectID, surveyType string) (*schemas.SpaceDeskSurvey, error) {
+	body, rows, err := surveyRows(surveyType)
+	if err != nil {
+		return nil, err
+	}
+
+	db := mongoClient.Database(database.GetDB())
+
+	chat := schemas.SpaceDeskChat{}
+	if err := db.Collection(database.COLLECTION_SPACE_DESK_CHAT).FindOne(ctx, bson.M{"_id": chatID}).Decode(&chat); err != nil {
+		return nil, ErrChatNotFound
+	}
+
+	interactive := map[string]any{
+		"type": "list",
+		"body": map[string]string{"text": body},
+		"action": map[string]any{
+			"button":   "Avaliar",
+			"sections": []map[string]any{{"title": "Sua nota", "rows": rows}},
+		},
+	}
+
+	respMap, err := SendMessageToChat(ctx, mongoClient, OutgoingMessage{
+		ChatID:  chatID,
+		UserID:  chat.UserID,
+		Type:    "interactive",
+		Excerpt: body,
+		Payload: map[string]any{"interactive": interactive},
+		Extra:   bson.M{"survey_type": surveyType},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	survey := schemas.SpaceDeskSurvey{
+		Type:      surveyType,
+		ChatID:    chatID,
+		LeadID:    chat.LeadID,
+		UserID:    chat.UserID,
+		GroupID:   chat.GroupID,
+		MessageID: extractWamid(respMap),
+		SentAt:    time.Now(),
+	}
+	result, err := db.Collection(database.COLLECTION_SPACE_DESK_SURVEYS).InsertOne(ctx, survey)
+	if err != nil {
+		return nil, err
+	}
+	survey.ID = result.InsertedID.(bson.ObjectID)
+
+	return &survey, nil
+}
+
+// recordSurveyResponse grava a nota quando a resposta interativa pertence a uma pesquisa enviada.
+func recordSurveyResponse(ctx context.Context, db *mongo.Database, contextMessageID, replyID string) {
+	if contextMessageID == "" {
+		return
+	}
+
+	prefix, value, found := strings.Cut(replyID, "_")
+	if !found || (prefix != schemas.SPACE_DESK_SURVEY_CSAT && prefix != schemas.SPACE_DESK_SURVEY_NPS) {
+		return
+	}
+	score, err := strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+
+	// A última resposta do cliente prevalece
+	_, err = db.Collection(database.COLLECTION_SPACE_DESK_SURVEYS).UpdateOne(ctx,
+		bson.M{"message_id": contextMessageID, "type": prefix},
+		bson.M{"$set": bson.M{"answered": true, "score": score, "answered_at": time.Now()}},
+	)
+	if err != nil {
+		log.Printf("[recordSurveyResponse] Erro ao salvar nota da pesquisa: %v", err)
+	}
+}
+
+// recordSurveyTextReply grava a nota do NPS enviada por texto ("0" a "10") na última pesquisa NPS sem resposta
+// do chat, enviada há menos de SURVEY_TEXT_REPLY_WINDOW. Informa se a mensagem era a resposta da pesquisa.
+func recordSurveyTextReply(ctx context.Context, db *mongo.Database, chatFilter bson.M, message map[string]interface{}) bool {
+	if msgType, _ := message["type"].(string); msgType != "text" {
+		return false
+	}
+	textObj, _ := message["text"].(map[string]interface{})
+	body, _ := textObj["body"].(string)
+	score, err := strconv.Atoi(strings.TrimSpace(body))
+	if err != nil || score < 0 || score > 10 {
+		return false
+	}
+
+	var chat struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	if err := db.Collection(database.COLLECTION_SPACE_DESK_CHAT).FindOne(ctx, chatFilter).Decode(&chat); err != nil {
+		return false
+	}
+
+	now := time.Now()
+	err = db.Collection(database.COLLECTION_SPACE_DESK_SURVEYS).FindOneAndUpdate(ctx,
+		bson.M{
+			"chat_id":  chat.ID,
+			"type":     schemas.SPACE_DESK_SURVEY_NPS,
+			"answered": false,
+			"sent_at":  bson.M{"$gte": now.Add(-SURVEY_TEXT_REPLY_WINDOW)},
+		},
+		bson.M{"$set": bson.M{"answered": true, "score": score, "answered_at": now}},
+		options.FindOneAndUpdate().SetSort(bson.M{"sent_at": -1}),
+	).Err()
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("[recordSurveyTextReply] Erro ao salvar nota da pesquisa: %v", err)
+		}
+		return false
+	}
+	return true
+}
+
+// isSurveyReply informa se a mensagem recebida responde a uma pesquisa enviada, pelo id da mensagem
+// citada no contexto. A resposta da pesquisa não deve reabrir o chat que foi fechado para enviá-la.
+func isSurveyReply(ctx context.Context, db *mongo.Database, message map[string]interface{}) bool {
+	if msgType, _ := message["type"].(string); msgType != "interactive" {
+		return false
+	}
+	ctxObj, ok := message["context"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	contextMessageID, _ := ctxObj["id"].(string)
+	if contextMessageID == "" {
+		return false
+	}
+	count, err := db.Collection(database.COLLECTION_SPACE_DESK_SURVEYS).CountDocuments(ctx, bson.M{"message_id": contextMessageID})
+	if err != nil {
+		log.Printf("[isSurveyReply] Erro ao buscar pesquisa: %v", err)
+		return false
+	}
+	return count > 0
+}
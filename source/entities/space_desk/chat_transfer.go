@@ -0,0 +1,347 @@
+package spacedesk
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type CreateChatTransferRequest struct {
+	ChatID    string `json:"chat_id"`
+	ToUserID  string `json:"to_user_id"`
+	ToGroupID string `json:"to_group_id"`
+	Note      string `json:"note"`
+	Forced    bool   `json:"forced"`
+}
+
+type RespondChatTransferRequest struct {
+	ChatID string `json:"chat_id"`
+	Action string `json:"action"` // accept, reject ou cancel
+	Note   string `json:"note"`
+}
+
+// Perfis que podem forçar uma transferência sem aceite do destinatário
+var forcedTransferRoles = []string{schemas.USERS_ROLE_SUPER_ADMIN, schemas.USERS_ROLE_IT, schemas.USERS_ROLE_ADMIN, schemas.USERS_ROLE_LEADER}
+
+// userIdentifiers retorna os ids pelos quais o usuário pode aparecer em user_id dos chats e grupos.
+func userIdentifiers(user schemas.User) []string {
+	return []string{user.ID.Hex(), strconv.FormatUint(user.OldID, 10)}
+}
+
+// CreateChatTransfer solicita (ou força) a transferência de um chat para outro agente e/ou grupo.
+func CreateChatTransfer(w http.ResponseWriter, r *http.Request) {
+	var req CreateChatTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "JSON inválido: "+err.Error(), nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if req.ChatID == "" || (req.ToUserID == "" && req.ToGroupID == "") {
+		utils.SendResponse(w, http.StatusBadRequest, "Informe o chat e o agente ou grupo de destino", nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
+	if req.Note == "" {
+		utils.SendResponse(w, http.StatusBadRequest, "A nota de passagem é obrigatória", nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
+
+	chatID, err := bson.ObjectIDFromHex(req.ChatID)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "ID do chat inválido", nil, utils.INVALID_CHAT_ID_FORMAT)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	collection := db.Collection(database.COLLECTION_SPACE_DESK_CHAT)
+
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+	if req.Forced && !utils.HasAnyRole(user, forcedTransferRoles) {
+		utils.SendResponse(w, http.StatusForbidden, "Usuário sem permissão para forçar transferências", nil, utils.NOT_AUTHORIZED)
+		return
+	}
+
+	chat := schemas.SpaceDeskChat{}
+	if err := collection.FindOne(ctx, bson.M{"_id": chatID}).Decode(&chat); err != nil {
+		utils.SendResponse(w, http.StatusNotFound, "Chat não encontrado", nil, utils.CANNOT_FIND_SPACE_DESK_CHAT_ID)
+		return
+	}
+	if chat.PendingTransfer != nil && !req.Forced {
+		utils.SendResponse(w, http.StatusConflict, "O chat já possui uma transferência pendente", chat.PendingTransfer, utils.ALREADY_EXISTS)
+		return
+	}
+	if req.ToUserID != "" && req.ToUserID == chat.UserID {
+		utils.SendResponse(w, http.StatusBadRequest, "O chat já está com esse agente", nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
+
+	group := schemas.Group{}
+	if req.ToGroupID != "" {
+		groupID, err := bson.ObjectIDFromHex(req.ToGroupID)
+		if err != nil {
+			utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.CANNOT_FIND_SPACE_DESK_GROUP_ID_FORMAT)
+			return
+		}
+		if err := db.Collection(database.COLLECTION_SPACE_DESK_GROUPS).FindOne(ctx, bson.M{"_id": groupID}).Decode(&group); err != nil {
+			utils.SendResponse(w, http.StatusNotFound, "Grupo não encontrado", nil, utils.NOT_FOUND)
+			return
+		}
+	}
+
+	transfer := schemas.SpaceDeskChatTransfer{
+		ID:          bson.NewObjectID(),
+		FromUserID:  chat.UserID,
+		FromGroupID: chat.GroupID,
+		ToUserID:    req.ToUserID,
+		ToGroupID:   req.ToGroupID,
+		Note:        req.Note,
+		Mode:        schemas.SPACE_DESK_TRANSFER_MODE_REQUEST,
+		Status:      schemas.SPACE_DESK_TRANSFER_STATUS_PENDING,
+		RequestedBy: user.ID.Hex(),
+		RequestedAt: time.Now(),
+	}
+
+	var update bson.M
+	if req.Forced {
+		transfer.Mode = schemas.SPACE_DESK_TRANSFER_MODE_FORCED
+		transfer.Status = schemas.SPACE_DESK_TRANSFER_STATUS_ACCEPTED
+		transfer.RespondedBy = user.ID.Hex()
+		transfer.RespondedAt = transfer.RequestedAt
+		// Uma transferência forçada substitui a solicitação pendente, que fica no histórico como cancelada
+		history := []schemas.SpaceDeskChatTransfer{}
+		if chat.PendingTransfer != nil {
+			previous := *chat.PendingTransfer
+			previous.Status = schemas.SPACE_DESK_TRANSFER_STATUS_CANCELLED
+			previous.RespondedBy = user.ID.Hex()
+			previous.RespondedAt = transfer.RequestedAt
+			history = append(history, previous)
+		}
+		update = transferAppliedUpdate(transfer, history...)
+	} else {
+		update = bson.M{"$set": bson.M{"pending_transfer": transfer, "updated_at": transfer.RequestedAt}}
+	}
+
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": chatID}, update); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.ERROR_TO_UPDATE_IN_MONGODB)
+		return
+	}
+
+	recipients := group.UserIDs
+	if req.ToUserID != "" {
+		recipients = []string{req.ToUserID}
+	}
+	notifyChatTransfer(chatID, chat.Name, transfer, recipients)
+
+	utils.SendResponse(w, http.StatusCreated, "", transfer, 0)
+}
+
+// RespondChatTransfer aceita, recusa ou cancela a transferência pendente de um chat.
+func RespondChatTransfer(w http.ResponseWriter, r *http.Request) {
+	var req RespondChatTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "JSON inválido: "+err.Error(), nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
+
+	chatID, err := bson.ObjectIDFromHex(req.ChatID)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "ID do chat inválido", nil, utils.INVALID_CHAT_ID_FORMAT)
+		return
+	}
+
+	status := ""
+	switch req.Action {
+	case "accept":
+		status = schemas.SPACE_DESK_TRANSFER_STATUS_ACCEPTED
+	case "reject":
+		status = schemas.SPACE_DESK_TRANSFER_STATUS_REJECTED
+	case "cancel":
+		status = schemas.SPACE_DESK_TRANSFER_STATUS_CANCELLED
+	default:
+		utils.SendResponse(w, http.StatusBadRequest, "Ação inválida. Use 'accept', 'reject' ou 'cancel'", nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	collection := db.Collection(database.COLLECTION_SPACE_DESK_CHAT)
+
+	user, err := middlewares.CurrentUser(ctx, db, r)
+	if err != nil {
+		utils.SendResponse(w, http.StatusUnauthorized, "Usuário não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+
+	chat := schemas.SpaceDeskChat{}
+	if err := collection.FindOne(ctx, bson.M{"_id": chatID}).Decode(&chat); err != nil {
+		utils.SendResponse(w, http.StatusNotFound, "Chat não encontrado", nil, utils.CANNOT_FIND_SPACE_DESK_CHAT_ID)
+		return
+	}
+	if chat.PendingTransfer == nil {
+		utils.SendResponse(w, http.StatusNotFound, "O chat não possui transferência pendente", nil, utils.NOT_FOUND)
+		return
+	}
+	transfer := *chat.PendingTransfer
+
+	identifiers := userIdentifiers(user)
+	if req.Action == "cancel" {
+		if transfer.RequestedBy != user.ID.Hex() && !utils.HasAnyRole(user, forcedTransferRoles) {
+			utils.SendResponse(w, http.StatusForbidden, "Apenas quem solicitou pode cancelar a transferência", nil, utils.NOT_AUTHORIZED)
+			return
+		}
+	} else {
+		allowed := false
+		if transfer.ToUserID != "" {
+			allowed = slices.Contains(identifiers, transfer.ToUserID)
+		} else if groupID, err := bson.ObjectIDFromHex(transfer.ToGroupID); err == nil {
+			group := schemas.Group{}
+			if err := db.Collection(database.COLLECTION_SPACE_DESK_GROUPS).FindOne(ctx, bson.M{"_id": groupID}).Decode(&group); err == nil {
+				allowed = slices.ContainsFunc(group.UserIDs, func(id string) bool { return slices.Contains(identifiers, id) })
+			}
+		}
+		if !allowed {
+			utils.SendResponse(w, http.StatusForbidden, "A transferência não foi destinada a este usuário", nil, utils.NOT_AUTHORIZED)
+			return
+		}
+	}
+
+	transfer.Status = status
+	transfer.RespondedBy = user.ID.Hex()
+	transfer.RespondedAt = time.Now()
+	transfer.ResponseNote = strings.TrimSpace(req.Note)
+
+	// Se a transferência foi aceita sem agente definido (só grupo), quem aceitou assume o chat
+	if status == schemas.SPACE_DESK_TRANSFER_STATUS_ACCEPTED && transfer.ToUserID == "" {
+		transfer.ToUserID = user.ID.Hex()
+	}
+
+	var update bson.M
+	if status == schemas.SPACE_DESK_TRANSFER_STATUS_ACCEPTED {
+		update = transferAppliedUpdate(transfer)
+	} else {
+		update = bson.M{
+			"$unset": bson.M{"pending_transfer": ""},
+			"$push":  bson.M{"transfers": transfer},
+			"$set":   bson.M{"updated_at": transfer.RespondedAt},
+		}
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": chatID, "pending_transfer.id": transfer.ID}, update)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.ERROR_TO_UPDATE_IN_MONGODB)
+		return
+	}
+	if result.MatchedCount == 0 {
+		utils.SendResponse(w, http.StatusConflict, "A transferência já foi respondida", nil, 0)
+		return
+	}
+
+	notifyChatTransfer(chatID, chat.Name, transfer, []string{transfer.RequestedBy, transfer.FromUserID})
+
+	utils.SendResponse(w, http.StatusOK, "", transfer, 0)
+}
+
+// GetChatTransfers retorna o histórico de transferências e a transferência pendente de um chat.
+func GetChatTransfers(w http.ResponseWriter, r *http.Request) {
+	chatID, err := bson.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "ID do chat inválido", nil, utils.INVALID_CHAT_ID_FORMAT)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	chat := schemas.SpaceDeskChat{}
+	err = mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_SPACE_DESK_CHAT).FindOne(ctx, bson.M{"_id": chatID}).Decode(&chat)
+	if err != nil {
+		utils.SendResponse(w, http.StatusNotFound, "Chat não encontrado", nil, utils.CANNOT_FIND_SPACE_DESK_CHAT_ID)
+		return
+	}
+
+	if chat.Transfers == nil {
+		chat.Transfers = []schemas.SpaceDeskChatTransfer{}
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", bson.M{
+		"pending":   chat.PendingTransfer,
+		"transfers": chat.Transfers,
+	}, 0)
+}
+
+// transferAppliedUpdate monta o update que efetiva a transferência no chat e registra o histórico.
+func transferAppliedUpdate(transfer schemas.SpaceDeskChatTransfer, previous ...schemas.SpaceDeskChatTransfer) bson.M {
+	set := bson.M{"updated_at": transfer.RespondedAt}
+	if transfer.ToUserID != "" {
+		set["user_id"] = transfer.ToUserID
+	}
+	if transfer.ToGroupID != "" {
+		set["group_id"] = transfer.ToGroupID
+	}
+	return bson.M{
+		"$set":   set,
+		"$unset": bson.M{"pending_transfer": ""},
+		"$push":  bson.M{"transfers": bson.M{"$each": append(previous, transfer)}},
+	}
+}
+
+func notifyChatTransfer(chatID bson.ObjectID, chatName string, transfer schemas.SpaceDeskChatTransfer, recipients []string) {
+	msg := SpaceDeskWSMessage{
+		"type":      "chat_transfer",
+		"chat_id":   chatID,
+		"chat_name": chatName,
+		"transfer":  transfer,
+	}
+	notifySpaceDeskUsers(recipients, msg)
+
+	if transfer.Status == schemas.SPACE_DESK_TRANSFER_STATUS_ACCEPTED {
+		broadcastSpaceDeskMessage(SpaceDeskWSMessage{
+			"type":     "chat_updated",
+			"chat_id":  chatID,
+			"user_id":  transfer.ToUserID,
+			"group_id": transfer.ToGroupID,
+		})
+	}
+}
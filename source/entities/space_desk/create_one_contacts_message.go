@@ -0,0 +1,166 @@
+package spacedesk
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type CreateContactsMessageRequest struct {
+	To       string   `json:"to"`
+	UserId   string   `json:"userId"`
+	UsersIDs []string `json:"user_ids"`
+	LeadsIDs []string `json:"lead_ids"`
+}
+
+func CreateContactsMessage(w http.ResponseWriter, r *http.Request) {
+	var req CreateContactsMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "JSON inválido: "+err.Error(), nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
+	if req.To == "" || (len(req.UsersIDs) == 0 && len(req.LeadsIDs) == 0) {
+		utils.SendResponse(w, http.StatusBadRequest, "Informe o chat e ao menos um usuário ou lead", nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
+
+	chatID, err := bson.ObjectIDFromHex(req.To)
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "ID do chat inválido", nil, utils.INVALID_CHAT_ID_FORMAT)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	var chatDoc struct {
+		CompanyPhoneNumber string `bson:"company_phone_number"`
+	}
+	if err := db.Collection(database.COLLECTION_SPACE_DESK_CHAT).FindOne(ctx, bson.M{"_id": chatID}).Decode(&chatDoc); err != nil {
+		utils.SendResponse(w, http.StatusNotFound, "Chat não encontrado", nil, utils.CANNOT_FIND_SPACE_DESK_CHAT_ID)
+		return
+	}
+
+	contacts := []schemas.SpaceDeskContact{}
+
+	for _, idStr := range req.UsersIDs {
+		id, err := bson.ObjectIDFromHex(idStr)
+		if err != nil {
+			utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_USER_ID_FORMAT)
+			return
+		}
+		user := schemas.User{}
+		if err := db.Collection(database.COLLECTION_USERS).FindOne(ctx, bson.M{"_id": id}).Decode(&user); err != nil {
+			utils.SendResponse(w, http.StatusNotFound, "Usuário não encontrado", nil, utils.CANNOT_FIND_USER_BY_ID_IN_MONGODB)
+			return
+		}
+		contacts = append(contacts, contactFromUser(user, chatDoc.CompanyPhoneNumber))
+	}
+
+	for _, idStr := range req.LeadsIDs {
+		id, err := bson.ObjectIDFromHex(idStr)
+		if err != nil {
+			utils.SendResponse(w, http.StatusBadRequest, "", nil, utils.INVALID_LEAD_ID_FORMAT)
+			return
+		}
+		lead := schemas.Lead{}
+		if err := db.Collection(database.COLLECTION_LEADS).FindOne(ctx, bson.M{"_id": id}).Decode(&lead); err != nil {
+			utils.SendResponse(w, http.StatusNotFound, "Lead não encontrado", nil, utils.CANNOT_FIND_LEAD_BY_ID_IN_MONGODB)
+			return
+		}
+		if lead.Phone == "" {
+			utils.SendResponse(w, http.StatusBadRequest, "Lead sem telefone não pode ser enviado como contato", nil, 0)
+			return
+		}
+		contacts = append(contacts, contactFromLead(lead))
+	}
+
+	respMap, err := SendMessageToChat(ctx, mongoClient, OutgoingMessage{
+		ChatID:  chatID,
+		UserID:  req.UserId,
+		Type:    "contacts",
+		Excerpt: contactsExcerpt(contacts),
+		Payload: map[string]any{"contacts": contacts},
+		Extra:   bson.M{"contacts": contacts},
+	})
+	if err != nil {
+		sendMessageErrorResponse(w, err)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusCreated, "", respMap, 0)
+}
+
+func splitName(fullName string) (string, string) {
+	parts := strings.Fields(fullName)
+	if len(parts) == 0 {
+		return "", ""
+	}
+	return parts[0], strings.Join(parts[1:], " ")
+}
+
+func contactFromUser(user schemas.User, companyPhoneNumber string) schemas.SpaceDeskContact {
+	firstName, lastName := splitName(user.Name)
+	contact := schemas.SpaceDeskContact{
+		Name: schemas.SpaceDeskContactName{
+			FirstName:     firstName,
+			LastName:      lastName,
+			FormattedName: user.Name,
+		},
+		Phones: []schemas.SpaceDeskContactPhone{},
+		Org:    &schemas.SpaceDeskContactOrg{Company: "Arte Arena"},
+	}
+	if companyPhoneNumber != "" {
+		contact.Phones = append(contact.Phones, schemas.SpaceDeskContactPhone{
+			Phone: "+" + companyPhoneNumber,
+			WaID:  companyPhoneNumber,
+			Type:  "WORK",
+		})
+	}
+	if user.Email != "" {
+		contact.Emails = []schemas.SpaceDeskContactEmail{{Email: user.Email, Type: "WORK"}}
+	}
+	return contact
+}
+
+func contactFromLead(lead schemas.Lead) schemas.SpaceDeskContact {
+	name := lead.Name
+	if name == "" {
+		name = lead.Nickname
+	}
+	if name == "" {
+		name = lead.Phone
+	}
+	firstName, lastName := splitName(name)
+	phone := onlyDigits(lead.Phone)
+	return schemas.SpaceDeskContact{
+		Name: schemas.SpaceDeskContactName{
+			FirstName:     firstName,
+			LastName:      lastName,
+			FormattedName: name,
+		},
+		Phones: []schemas.SpaceDeskContactPhone{{
+			Phone: "+" + phone,
+			WaID:  phone,
+			Type:  "CELL",
+		}},
+	}
+}
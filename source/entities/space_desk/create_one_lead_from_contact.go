@@ -0,0 +1,140 @@
+package spacedesk
+
+import (
+	"api/database"
+	"api/entities/leads"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type CreateLeadFromContactRequest struct {
+	MessageID    string `json:"message_id"`
+	ContactIndex int    `json:"contact_index"`
+	Responsible  string `json:"responsible,omitempty"`
+}
+
+// CreateLeadFromContact cria um lead a partir de um cartão de contato compartilhado pelo cliente.
+func CreateLeadFromContact(w http.ResponseWriter, r *http.Request) {
+	var req CreateLeadFromContactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendResponse(w, http.StatusBadRequest, "JSON inválido: "+err.Error(), nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
+	if req.MessageID == "" || req.ContactIndex < 0 {
+		utils.SendResponse(w, http.StatusBadRequest, "O campo 'message_id' é obrigatório", nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+	colMessages := db.Collection(database.COLLECTION_SPACE_DESK_MESSAGE)
+	colLeads := db.Collection(database.COLLECTION_LEADS)
+
+	var messageDoc struct {
+		ChatID   bson.ObjectID              `bson:"chat_id"`
+		Type     string                     `bson:"type"`
+		By       string                     `bson:"by"`
+		Contacts []schemas.SpaceDeskContact `bson:"contacts"`
+	}
+	if err := colMessages.FindOne(ctx, bson.M{"message_id": req.MessageID}).Decode(&messageDoc); err != nil {
+		utils.SendResponse(w, http.StatusNotFound, "Mensagem não encontrada", nil, utils.NOT_FOUND)
+		return
+	}
+	if messageDoc.Type != "contacts" || req.ContactIndex >= len(messageDoc.Contacts) {
+		utils.SendResponse(w, http.StatusBadRequest, "A mensagem não possui o contato informado", nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
+
+	contact := messageDoc.Contacts[req.ContactIndex]
+	if !contact.LeadID.IsZero() {
+		utils.SendResponse(w, http.StatusConflict, "Contato já vinculado a um lead", bson.M{"lead_id": contact.LeadID}, 0)
+		return
+	}
+
+	phone := contactPhone(contact.Phones)
+	if phone == "" {
+		utils.SendResponse(w, http.StatusBadRequest, "Contato compartilhado sem telefone válido", nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
+
+	existing, err := leads.FindLeadByPhone(ctx, colLeads, phone, bson.ObjectID{})
+	if err == nil {
+		colMessages.UpdateOne(ctx, bson.M{"message_id": req.MessageID}, bson.M{"$set": bson.M{fmt.Sprintf("contacts.%d.lead_id", req.ContactIndex): existing.ID}})
+		utils.SendResponse(w, http.StatusConflict, "Já existe um lead com esse telefone", bson.M{"lead_id": existing.ID}, 0)
+		return
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_FIND_LEADS_IN_MONGODB)
+		return
+	}
+
+	name := contact.Name.FormattedName
+	if name == "" {
+		name = contact.Name.FirstName + " " + contact.Name.LastName
+	}
+
+	lead := schemas.Lead{
+		Name:      name,
+		Phone:     phone,
+		Source:    "Contato compartilhado",
+		Notes:     fmt.Sprintf("Contato compartilhado pelo cliente %s", messageDoc.By),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if req.Responsible != "" {
+		if responsibleID, err := bson.ObjectIDFromHex(req.Responsible); err == nil {
+			lead.Responsible = responsibleID
+		}
+	}
+
+	result, err := colLeads.InsertOne(ctx, lead)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.CANNOT_INSERT_LEAD_TO_MONGODB)
+		return
+	}
+	lead.ID = result.InsertedID.(bson.ObjectID)
+
+	_, err = colMessages.UpdateOne(ctx,
+		bson.M{"message_id": req.MessageID},
+		bson.M{"$set": bson.M{fmt.Sprintf("contacts.%d.lead_id", req.ContactIndex): lead.ID}},
+	)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.ERROR_TO_UPDATE_IN_MONGODB)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusCreated, "", lead, 0)
+}
+
+// contactPhone devolve, normalizado, o primeiro telefone válido do contato compartilhado; o wa_id tem
+// preferência sobre o número exibido.
+func contactPhone(phones []schemas.SpaceDeskContactPhone) string {
+	for _, p := range phones {
+		for _, number := range []string{p.WaID, p.Phone} {
+			if normalized, ok := utils.NormalizePhone(number); ok {
+				return normalized
+			}
+		}
+	}
+	return ""
+}
@@ -2,6 +2,7 @@ package spacedesk
 
 import (
 	"api/database"
+	"api/schemas"
 	"api/utils"
 	"bytes"
 	"context"
@@ -41,6 +42,9 @@ type CreateListMessageRequest struct {
 	To     string      `json:"to"`
 	UserId string      `json:"userId"`
 	List   ListPayload `json:"list"`
+	// Campo do lead preenchido com a linha escolhida (ex.: "segment"); LeadValues mapeia id da linha -> valor
+	LeadField  string            `json:"lead_field,omitempty"`
+	LeadValues map[string]string `json:"lead_values,omitempty"`
 }
 
 func CreateListMessage(w http.ResponseWriter, r *http.Request) {
@@ -60,6 +64,10 @@ func CreateListMessage(w http.ResponseWriter, r *http.Request) {
 		utils.SendResponse(w, http.StatusBadRequest, "Campos obrigatórios ausentes", nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
 		return
 	}
+	if req.LeadField != "" && !allowedLeadHookFields[req.LeadField] {
+		utils.SendResponse(w, http.StatusBadRequest, "Campo do lead não permitido: "+req.LeadField, nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), database.MONGO_TIMEOUT)
 	defer cancel()
@@ -159,6 +167,25 @@ func CreateListMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	listSend := schemas.SpaceDeskInteractiveSend{
+		MessageID:  wamid,
+		ChatID:     objID,
+		Kind:       "list",
+		Title:      req.List.Body,
+		Options:    []schemas.SpaceDeskInteractiveOption{},
+		LeadField:  req.LeadField,
+		LeadValues: req.LeadValues,
+		CreatedBy:  req.UserId,
+	}
+	for _, section := range req.List.Sections {
+		for _, row := range section.Rows {
+			listSend.Options = append(listSend.Options, schemas.SpaceDeskInteractiveOption{ID: row.ID, Title: row.Title, Description: row.Description})
+		}
+	}
+	if err := registerInteractiveSend(ctx, client.Database(database.GetDB()), listSend); err != nil {
+		log.Printf("[CreateListMessage] Erro ao registrar envio da lista: %v", err)
+	}
+
 	broadcastSpaceDeskMessage(respMap)
 	utils.SendResponse(w, http.StatusCreated, "", respMap, 0)
 }
@@ -2,6 +2,7 @@ package spacedesk
 
 import (
 	"api/database"
+	"api/schemas"
 	"api/utils"
 	"bytes"
 	"context"
@@ -201,6 +202,21 @@ func CreateOnePoll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	pollSend := schemas.SpaceDeskInteractiveSend{
+		MessageID: wamid,
+		ChatID:    objID,
+		Kind:      "poll",
+		Title:     reqBody.Poll.Name,
+		Options:   []schemas.SpaceDeskInteractiveOption{},
+		CreatedBy: reqBody.UserId,
+	}
+	for i, option := range reqBody.Poll.Options {
+		pollSend.Options = append(pollSend.Options, schemas.SpaceDeskInteractiveOption{ID: fmt.Sprintf("option_%d", i+1), Title: option})
+	}
+	if err := registerInteractiveSend(ctx, dbClient.Database(database.GetDB()), pollSend); err != nil {
+		log.Printf("[CreateOnePoll] Erro ao registrar envio da enquete: %v", err)
+	}
+
 	broadcastSpaceDeskMessage(respMap)
 	utils.SendResponse(w, http.StatusCreated, "", respMap, 0)
 }
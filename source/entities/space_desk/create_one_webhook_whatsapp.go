@@ -2,6 +2,8 @@ package spacedesk
 
 import (
 	"api/database"
+	"api/entities/leads"
+	"api/integrations/geocoding"
 	"api/schemas"
 	"api/utils"
 	"context"
@@ -10,6 +12,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -232,10 +235,13 @@ func CreateOneWebhookWhatsapp(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
+		if msgType == "contacts" {
+			lastMessageFromClient = contactsExcerpt(parseInboundContacts(messages["contacts"]))
+		}
 
 		// colocar nos chats o id do lead (caso não encotre ele cria um novo lead) e salva o id do lead no chat
 		collectionLeads := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_LEADS)
-		leadFilter := bson.M{"phone": clientPhoneNumber}
+		leadFilter := leads.PhoneFilter(clientPhoneNumber)
 
 		var leadDoc struct {
 			ID bson.ObjectID `bson:"_id"`
@@ -246,7 +252,7 @@ func CreateOneWebhookWhatsapp(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			// Criar novo lead se não existir
 			newLead := schemas.Lead{
-				Phone:     clientPhoneNumber,
+				Phone:     leadPhone(clientPhoneNumber),
 				Name:      name,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
@@ -261,21 +267,29 @@ func CreateOneWebhookWhatsapp(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		leads.QueueScoreRecompute(leadDoc.ID)
+
 		// 2. Atualizar chat COM leadID
 		filter := bson.M{"cliente_phone_number": clientPhoneNumber, "company_phone_number": companyPhoneNumber}
+		chatSet := bson.M{
+			"name":                               name,
+			"last_message_id":                    messageFromClientId,
+			"last_message_timestamp":             fmt.Sprint(now.Unix()),
+			"last_message_excerpt":               lastMessageFromClient,
+			"last_message_type":                  msgType,
+			"last_message_sender":                "client",
+			"last_message_from_client_timestamp": fmt.Sprint(now.Unix()),
+			"updated_at":                         updatedAt,
+			"closed":                             false,
+			"lead_id":                            leadDoc.ID, // Usar ID real
+		}
+		// A resposta da pesquisa de satisfação chega depois do fechamento e não reabre o chat
+		if isSurveyReply(ctx, mongoClient.Database(database.GetDB()), messages) ||
+			recordSurveyTextReply(ctx, mongoClient.Database(database.GetDB()), filter, messages) {
+			delete(chatSet, "closed")
+		}
 		update := bson.M{
-			"$set": bson.M{
-				"name":                               name,
-				"last_message_id":                    messageFromClientId,
-				"last_message_timestamp":             fmt.Sprint(now.Unix()),
-				"last_message_excerpt":               lastMessageFromClient,
-				"last_message_type":                  msgType,
-				"last_message_sender":                "client",
-				"last_message_from_client_timestamp": fmt.Sprint(now.Unix()),
-				"updated_at":                         updatedAt,
-				"closed":                             false,
-				"lead_id":                            leadDoc.ID, // Usar ID real
-			},
+			"$set": chatSet,
 			"$setOnInsert": bson.M{
 				"company_phone_number": companyPhoneNumber,
 				"cliente_phone_number": clientPhoneNumber,
@@ -411,28 +425,46 @@ func CreateOneWebhookWhatsapp(w http.ResponseWriter, r *http.Request) {
 					base["reply_id"] = lst["id"].(string)
 					base["reply_text"] = lst["title"].(string)
 				}
+				if ctxObj, ok := messages["context"].(map[string]interface{}); ok {
+					contextID, _ := ctxObj["id"].(string)
+					replyID, _ := base["reply_id"].(string)
+					replyText, _ := base["reply_text"].(string)
+					chatObjID, _ := chatId.(bson.ObjectID)
+					recordInteractiveResponse(ctx, mongoClient.Database(database.GetDB()), contextID, chatObjID, leadDoc.ID, messageFromClientId, replyID, replyText)
+					recordSurveyResponse(ctx, mongoClient.Database(database.GetDB()), contextID, replyID)
+				}
 				update = bson.M{"$set": base, "$setOnInsert": bson.M{"created_at": updatedAt}}
 
 			case "location":
 				loc := messages["location"].(map[string]interface{})
+				locName, _ := loc["name"].(string)
+				locAddress, _ := loc["address"].(string)
+				locSet := bson.M{
+					"chat_id":   chatId,
+					"type":      "location",
+					"latitude":  loc["latitude"],
+					"longitude": loc["longitude"],
+					"name":      locName,
+					"address":   locAddress,
+					"lead_id":   leadDoc.ID,
+				}
+				db := mongoClient.Database(database.GetDB())
+				if geocoded := applyInboundLocation(ctx, db, geocoding.Default(), leadDoc.ID, messageFromClientId, loc); geocoded != nil {
+					locSet["geocoded_address"] = geocoded
+				}
 				update = bson.M{
-					"$set": bson.M{
-						"chat_id":   chatId,
-						"type":      "location",
-						"latitude":  loc["latitude"],
-						"longitude": loc["longitude"],
-						"name":      loc["name"].(string),
-						"address":   loc["address"].(string),
-					},
+					"$set":         locSet,
 					"$setOnInsert": bson.M{"created_at": updatedAt},
 				}
 
 			case "contacts":
+				sharedContacts := parseInboundContacts(messages["contacts"])
+				linkContactsToLeads(ctx, collectionLeads, sharedContacts)
 				update = bson.M{
 					"$set": bson.M{
 						"chat_id":  chatId,
 						"type":     "contacts",
-						"contacts": messages["contacts"],
+						"contacts": sharedContacts,
 					},
 					"$setOnInsert": bson.M{"created_at": updatedAt},
 				}
@@ -505,7 +537,7 @@ func CreateOneWebhookWhatsapp(w http.ResponseWriter, r *http.Request) {
 				defer rdb.Close()
 			}
 
-			redisKey := "spacedesk:lead:phone:" + clientPhoneNumber
+			redisKey := "spacedesk:lead:phone:" + leadPhone(clientPhoneNumber)
 			cached := false
 			if rdb != nil {
 				if err := rdb.Get(ctx, redisKey).Err(); err == nil {
@@ -515,7 +547,7 @@ func CreateOneWebhookWhatsapp(w http.ResponseWriter, r *http.Request) {
 
 			if !cached {
 				collectionLeads := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_LEADS)
-				leadFilter := bson.M{"phone": clientPhoneNumber}
+				leadFilter := leads.PhoneFilter(clientPhoneNumber)
 				count, err := collectionLeads.CountDocuments(ctx, leadFilter)
 
 				if err == nil {
@@ -523,7 +555,7 @@ func CreateOneWebhookWhatsapp(w http.ResponseWriter, r *http.Request) {
 					if count == 0 {
 						newLead := schemas.Lead{
 							Name:       name,
-							Phone:      clientPhoneNumber,
+							Phone:      leadPhone(clientPhoneNumber),
 							Source:     "SpaceDesk",
 							PlatformId: chatID.Hex(),
 							CreatedAt:  time.Now(),
@@ -547,3 +579,57 @@ func CreateOneWebhookWhatsapp(w http.ResponseWriter, r *http.Request) {
 	}
 
 }
+
+// parseInboundContacts converte o array "contacts" recebido no webhook para o schema interno.
+func parseInboundContacts(raw any) []schemas.SpaceDeskContact {
+	contacts := []schemas.SpaceDeskContact{}
+	rawBytes, err := json.Marshal(raw)
+	if err != nil {
+		return contacts
+	}
+	if err := json.Unmarshal(rawBytes, &contacts); err != nil {
+		log.Printf("[CreateOneWebhookWhatsapp] Erro ao interpretar contatos recebidos: %v", err)
+	}
+	return contacts
+}
+
+// linkContactsToLeads marca em cada contato compartilhado o lead que já possui o mesmo telefone. O wa_id já
+// vem com o código do país; o telefone digitado no cartão é normalizado antes da busca.
+func linkContactsToLeads(ctx context.Context, collectionLeads *mongo.Collection, contacts []schemas.SpaceDeskContact) {
+	for i, contact := range contacts {
+		for _, phone := range contact.Phones {
+			number := phone.Phone
+			if phone.WaID != "" {
+				number = "+" + phone.WaID
+			}
+			normalized, ok := utils.NormalizePhone(number)
+			if !ok {
+				continue
+			}
+			var leadDoc struct {
+				ID bson.ObjectID `bson:"_id"`
+			}
+			if err := collectionLeads.FindOne(ctx, leads.PhoneFilter(normalized)).Decode(&leadDoc); err == nil {
+				contacts[i].LeadID = leadDoc.ID
+				break
+			}
+		}
+	}
+}
+
+// leadPhone devolve o telefone do lead em E.164. O wa_id de números antigos pode vir sem o nono dígito;
+// o chat continua com o wa_id, que é o endereço de envio, mas o lead fica com o número padronizado.
+func leadPhone(phone string) string {
+	if normalized, ok := utils.NormalizePhone(phone); ok {
+		return normalized
+	}
+	return phone
+}
+
+func contactsExcerpt(contacts []schemas.SpaceDeskContact) string {
+	names := []string{}
+	for _, contact := range contacts {
+		names = append(names, contact.Name.FormattedName)
+	}
+	return "Contato: " + strings.Join(names, ", ")
+}
@@ -0,0 +1,88 @@
+package spacedesk
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"math"
+	"net/http"
+	"os"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type InteractiveOptionResult struct {
+	schemas.SpaceDeskInteractiveOption
+	Percentage float64 `json:"percentage"`
+}
+
+type InteractiveResults struct {
+	Send      schemas.SpaceDeskInteractiveSend       `json:"send"`
+	Options   []InteractiveOptionResult              `json:"options"`
+	Responses []schemas.SpaceDeskInteractiveResponse `json:"responses"`
+}
+
+// GetInteractiveResults retorna a apuração de uma enquete/lista. O {id} pode ser o _id do envio ou o wamid da mensagem.
+func GetInteractiveResults(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		utils.SendResponse(w, http.StatusBadRequest, "ID do envio é obrigatório", nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.CANNOT_CONNECT_TO_MONGODB)
+		return
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(database.GetDB())
+
+	filter := bson.M{"message_id": id}
+	if objID, err := bson.ObjectIDFromHex(id); err == nil {
+		filter = bson.M{"_id": objID}
+	}
+
+	send := schemas.SpaceDeskInteractiveSend{}
+	err = db.Collection(database.COLLECTION_SPACE_DESK_INTERACTIVE).FindOne(ctx, filter).Decode(&send)
+	if err == mongo.ErrNoDocuments {
+		utils.SendResponse(w, http.StatusNotFound, "Envio não encontrado", nil, utils.NOT_FOUND)
+		return
+	}
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.ERROR_TO_FIND_IN_MONGODB)
+		return
+	}
+
+	result := InteractiveResults{Send: send, Options: []InteractiveOptionResult{}, Responses: []schemas.SpaceDeskInteractiveResponse{}}
+	for _, option := range send.Options {
+		percentage := 0.0
+		if send.TotalResponses > 0 {
+			percentage = math.Round(float64(option.Count)/float64(send.TotalResponses)*10000) / 100
+		}
+		result.Options = append(result.Options, InteractiveOptionResult{SpaceDeskInteractiveOption: option, Percentage: percentage})
+	}
+
+	cursor, err := db.Collection(database.COLLECTION_SPACE_DESK_INTERACTIVE_RES).Find(ctx,
+		bson.M{"send_id": send.ID},
+		options.Find().SetSort(bson.D{{Key: "responded_at", Value: -1}}),
+	)
+	if err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.ERROR_TO_FIND_IN_MONGODB)
+		return
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &result.Responses); err != nil {
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.ERROR_TO_FIND_IN_MONGODB)
+		return
+	}
+
+	utils.SendResponse(w, http.StatusOK, "", result, 0)
+}
@@ -0,0 +1,97 @@
+package spacedesk
+
+import (
+	"api/database"
+	"api/integrations/geocoding"
+	"api/schemas"
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const LOCATION_ADDRESS_SOURCE = "whatsapp_location"
+
+// applyInboundLocation geocodifica a localização recebida e a propaga para o lead, o cliente vinculado
+// e os orçamentos ainda não aprovados. Retorna o endereço encontrado (ou nil) para ser salvo na mensagem.
+func applyInboundLocation(ctx context.Context, db *mongo.Database, geocoder geocoding.Geocoder, leadID bson.ObjectID, messageID string, loc map[string]any) *geocoding.Address {
+	latitude, okLat := loc["latitude"].(float64)
+	longitude, okLng := loc["longitude"].(float64)
+	if !okLat || !okLng || leadID.IsZero() {
+		return nil
+	}
+
+	address, err := geocoder.Reverse(ctx, latitude, longitude)
+	if err != nil {
+		log.Printf("[applyInboundLocation] Erro ao geocodificar (%f, %f): %v", latitude, longitude, err)
+		return nil
+	}
+
+	name, _ := loc["name"].(string)
+	now := time.Now()
+
+	lastLocation := schemas.LeadLocation{
+		Latitude:     latitude,
+		Longitude:    longitude,
+		Name:         name,
+		CEP:          address.CEP,
+		Street:       address.Street,
+		Number:       address.Number,
+		Neighborhood: address.Neighborhood,
+		City:         address.City,
+		State:        address.State,
+		Formatted:    address.Formatted,
+		MessageID:    messageID,
+		ReceivedAt:   now,
+	}
+
+	collectionLeads := db.Collection(database.COLLECTION_LEADS)
+	if _, err := collectionLeads.UpdateByID(ctx, leadID, bson.M{"$set": bson.M{"last_location": lastLocation, "updated_at": now}}); err != nil {
+		log.Printf("[applyInboundLocation] Erro ao salvar localização no lead: %v", err)
+	}
+
+	var leadDoc struct {
+		RelatedClient bson.ObjectID `bson:"related_client"`
+	}
+	if err := collectionLeads.FindOne(ctx, bson.M{"_id": leadID}).Decode(&leadDoc); err == nil && !leadDoc.RelatedClient.IsZero() {
+		// Só preenche o endereço do cliente quando ele ainda não foi cadastrado
+		_, err := db.Collection(database.COLLECTION_CLIENTS).UpdateOne(ctx,
+			bson.M{"_id": leadDoc.RelatedClient, "contact.zip_code": bson.M{"$in": bson.A{nil, ""}}},
+			bson.M{"$set": bson.M{
+				"contact.zip_code":     address.CEP,
+				"contact.address":      address.Street,
+				"contact.number":       address.Number,
+				"contact.neighborhood": address.Neighborhood,
+				"contact.city":         address.City,
+				"contact.state":        address.State,
+				"updated_at":           now,
+			}},
+		)
+		if err != nil {
+			log.Printf("[applyInboundLocation] Erro ao atualizar endereço do cliente: %v", err)
+		}
+	}
+
+	if address.CEP != "" {
+		proposed := schemas.ProposedAddress{
+			CEP:        address.CEP,
+			Details:    geocoding.FormatAddress(*address),
+			Source:     LOCATION_ADDRESS_SOURCE,
+			MessageID:  messageID,
+			Latitude:   latitude,
+			Longitude:  longitude,
+			ProposedAt: now,
+		}
+		_, err := db.Collection(database.COLLECTION_BUDGETS).UpdateMany(ctx,
+			bson.M{"related_lead": leadID, "approved": bson.M{"$ne": true}},
+			bson.M{"$set": bson.M{"proposed_address": proposed}},
+		)
+		if err != nil {
+			log.Printf("[applyInboundLocation] Erro ao propor endereço nos orçamentos: %v", err)
+		}
+	}
+
+	return address
+}
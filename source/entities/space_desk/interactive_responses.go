@@ -0,0 +1,116 @@
+package spacedesk
+
+import (
+	"api/database"
+	"api/schemas"
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Campos do lead que podem ser preenchidos automaticamente pela resposta de uma lista
+var allowedLeadHookFields = map[string]bool{
+	"segment": true,
+	"type":    true,
+	"rating":  true,
+	"status":  true,
+	"source":  true,
+}
+
+// registerInteractiveSend salva o envio de uma enquete/lista para que as respostas possam ser correlacionadas pelo wamid.
+func registerInteractiveSend(ctx context.Context, db *mongo.Database, send schemas.SpaceDeskInteractiveSend) error {
+	var chatDoc struct {
+		LeadID bson.ObjectID `bson:"lead_id"`
+	}
+	if err := db.Collection(database.COLLECTION_SPACE_DESK_CHAT).FindOne(ctx, bson.M{"_id": send.ChatID}).Decode(&chatDoc); err == nil {
+		send.LeadID = chatDoc.LeadID
+	}
+
+	now := time.Now()
+	send.CreatedAt = now
+	send.UpdatedAt = now
+	_, err := db.Collection(database.COLLECTION_SPACE_DESK_INTERACTIVE).InsertOne(ctx, send)
+	return err
+}
+
+// recordInteractiveResponse correlaciona uma resposta (button_reply/list_reply) com o envio original e atualiza a contagem.
+// Cada chat conta uma vez por envio: se o cliente responder de novo, o voto anterior é substituído.
+func recordInteractiveResponse(ctx context.Context, db *mongo.Database, contextMessageID string, chatID, leadID bson.ObjectID, replyMessageID, optionID, optionTitle string) {
+	if contextMessageID == "" || optionID == "" {
+		return
+	}
+
+	colSends := db.Collection(database.COLLECTION_SPACE_DESK_INTERACTIVE)
+	colResponses := db.Collection(database.COLLECTION_SPACE_DESK_INTERACTIVE_RES)
+
+	send := schemas.SpaceDeskInteractiveSend{}
+	if err := colSends.FindOne(ctx, bson.M{"message_id": contextMessageID}).Decode(&send); err != nil {
+		return
+	}
+
+	// Upsert atômico por envio e chat (com índice único): reentregas do webhook não duplicam a resposta
+	now := time.Now()
+	set := bson.M{
+		"message_id":   replyMessageID,
+		"option_id":    optionID,
+		"option_title": optionTitle,
+		"responded_at": now,
+	}
+	if !leadID.IsZero() {
+		set["lead_id"] = leadID
+	}
+	previous := schemas.SpaceDeskInteractiveResponse{}
+	err := colResponses.FindOneAndUpdate(ctx,
+		bson.M{"send_id": send.ID, "chat_id": chatID},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+	).Decode(&previous)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		colSends.UpdateOne(ctx, bson.M{"_id": send.ID}, bson.M{"$inc": bson.M{"total_responses": 1}})
+	case err != nil:
+		log.Printf("[recordInteractiveResponse] Erro ao salvar resposta: %v", err)
+		return
+	case previous.MessageID == replyMessageID || previous.OptionID == optionID:
+		return
+	default:
+		colSends.UpdateOne(ctx,
+			bson.M{"_id": send.ID, "options.id": previous.OptionID},
+			bson.M{"$inc": bson.M{"options.$.count": -1}},
+		)
+	}
+
+	_, err = colSends.UpdateOne(ctx,
+		bson.M{"_id": send.ID, "options.id": optionID},
+		bson.M{"$inc": bson.M{"options.$.count": 1}, "$set": bson.M{"updated_at": now}},
+	)
+	if err != nil {
+		log.Printf("[recordInteractiveResponse] Erro ao atualizar contagem: %v", err)
+	}
+
+	if send.LeadField != "" && allowedLeadHookFields[send.LeadField] && !leadID.IsZero() {
+		value := optionTitle
+		if v, ok := send.LeadValues[optionID]; ok && v != "" {
+			value = v
+		}
+		_, err := db.Collection(database.COLLECTION_LEADS).UpdateByID(ctx, leadID, bson.M{"$set": bson.M{
+			send.LeadField: value,
+			"updated_at":   now,
+		}})
+		if err != nil {
+			log.Printf("[recordInteractiveResponse] Erro ao atualizar lead: %v", err)
+		}
+	}
+
+	broadcastSpaceDeskMessage(SpaceDeskWSMessage{
+		"type":       "interactive_response",
+		"send_id":    send.ID,
+		"message_id": send.MessageID,
+		"chat_id":    chatID,
+		"option_id":  optionID,
+	})
+}
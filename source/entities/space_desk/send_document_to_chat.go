@@ -0,0 +1,113 @@
+package spacedesk
+
+import (
+	"api/database"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// OutgoingDocument é um arquivo gerado pelo sistema (ex.: PDF do orçamento) a ser enviado ao chat.
+type OutgoingDocument struct {
+	ChatID      bson.ObjectID
+	UserID      string
+	FileName    string
+	ContentType string
+	Caption     string
+	Data        []byte
+	Extra       bson.M
+}
+
+// SendDocumentToChat sobe o arquivo na 360dialog com a chave do número da empresa do chat
+// e envia como mensagem de documento.
+func SendDocumentToChat(ctx context.Context, mongoClient *mongo.Client, doc OutgoingDocument) (map[string]any, error) {
+	var chatDoc struct {
+		CompanyPhoneNumber string `bson:"company_phone_number"`
+	}
+	err := mongoClient.Database(database.GetDB()).Collection(database.COLLECTION_SPACE_DESK_CHAT).FindOne(ctx, bson.M{"_id": doc.ChatID}).Decode(&chatDoc)
+	if err != nil {
+		return nil, ErrChatNotFound
+	}
+
+	apiKey := apiKeyForCompanyPhone(chatDoc.CompanyPhoneNumber)
+	if apiKey == "" {
+		return nil, ErrApiKeyNotConfigured
+	}
+
+	mediaID, err := uploadDocumentTo360(ctx, apiKey, doc)
+	if err != nil {
+		log.Printf("[SendDocumentToChat] Erro no upload do documento: %v", err)
+		return nil, ErrCannotReachSpaceDesk
+	}
+
+	document := map[string]any{"id": mediaID, "filename": doc.FileName}
+	if doc.Caption != "" {
+		document["caption"] = doc.Caption
+	}
+
+	excerpt := doc.Caption
+	if excerpt == "" {
+		excerpt = doc.FileName
+	}
+
+	return SendMessageToChat(ctx, mongoClient, OutgoingMessage{
+		ChatID:  doc.ChatID,
+		UserID:  doc.UserID,
+		Type:    "document",
+		Excerpt: excerpt,
+		Payload: map[string]any{"document": document},
+		Extra:   doc.Extra,
+	})
+}
+
+func uploadDocumentTo360(ctx context.Context, apiKey string, doc OutgoingDocument) (string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	_ = writer.WriteField("messaging_product", "whatsapp")
+
+	partHeader := textproto.MIMEHeader{}
+	partHeader.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, doc.FileName))
+	partHeader.Set("Content-Type", doc.ContentType)
+	part, err := writer.CreatePart(partHeader)
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(doc.Data); err != nil {
+		return "", err
+	}
+	writer.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://waba-v2.360dialog.io/media", body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("D360-API-KEY", apiKey)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	var data struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(respBody, &data)
+	if data.ID == "" {
+		return "", fmt.Errorf("ID da mídia vazio: %s", respBody)
+	}
+	return data.ID, nil
+}
@@ -0,0 +1,190 @@
+package spacedesk
+
+import (
+	"api/database"
+	"api/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	ErrChatNotFound         = errors.New("chat não encontrado")
+	ErrApiKeyNotConfigured  = errors.New("api key não configurada")
+	ErrWamidNotReturned     = errors.New("a API não retornou o wamid")
+	ErrCannotReachSpaceDesk = errors.New("falha ao enviar mensagem para a 360dialog")
+)
+
+// OutgoingMessage descreve uma mensagem enviada pela empresa para um chat já existente.
+type OutgoingMessage struct {
+	ChatID  bson.ObjectID
+	UserID  string
+	Type    string
+	Excerpt string
+	Payload map[string]any
+	Extra   bson.M
+}
+
+func apiKeyForCompanyPhone(companyPhoneNumber string) string {
+	switch companyPhoneNumber {
+	case "5511958339942":
+		return os.Getenv(utils.SPACE_DESK_API_KEY_2)
+	case "551123371548":
+		return os.Getenv(utils.SPACE_DESK_API_KEY)
+	}
+	return ""
+}
+
+// SendMessageToChat envia o payload para a 360dialog usando o número da empresa do chat,
+// registra o evento, a mensagem e atualiza o resumo do chat.
+func SendMessageToChat(ctx context.Context, mongoClient *mongo.Client, msg OutgoingMessage) (map[string]any, error) {
+	db := mongoClient.Database(database.GetDB())
+
+	var chatDoc struct {
+		ClientePhoneNumber string `bson:"cliente_phone_number"`
+		CompanyPhoneNumber string `bson:"company_phone_number"`
+	}
+	if err := db.Collection(database.COLLECTION_SPACE_DESK_CHAT).FindOne(ctx, bson.M{"_id": msg.ChatID}).Decode(&chatDoc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrChatNotFound
+		}
+		return nil, err
+	}
+
+	apiKey := apiKeyForCompanyPhone(chatDoc.CompanyPhoneNumber)
+	if apiKey == "" {
+		return nil, ErrApiKeyNotConfigured
+	}
+
+	payload := map[string]any{
+		"messaging_product": "whatsapp",
+		"recipient_type":    "individual",
+		"to":                chatDoc.ClientePhoneNumber,
+		"type":              msg.Type,
+	}
+	for k, v := range msg.Payload {
+		payload[k] = v
+	}
+
+	bodyBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req360, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://waba-v2.360dialog.io/messages", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	req360.Header.Set("Content-Type", "application/json")
+	req360.Header.Set("Accept", "application/json")
+	req360.Header.Set("D360-API-KEY", apiKey)
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req360)
+	if err != nil {
+		log.Printf("[SendMessageToChat] Erro ao enviar mensagem: %v", err)
+		return nil, ErrCannotReachSpaceDesk
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Printf("[SendMessageToChat] Resposta da 360dialog: %s", respBody)
+
+	respMap := map[string]any{}
+	if err := json.Unmarshal(respBody, &respMap); err != nil {
+		return nil, err
+	}
+
+	wamid := extractWamid(respMap)
+	if wamid == "not_returned" {
+		return nil, ErrWamidNotReturned
+	}
+
+	now := time.Now().UTC()
+
+	eventMessage := bson.M{
+		"type":      msg.Type,
+		"from":      "space-erp-backend",
+		"to":        msg.ChatID.Hex(),
+		"id":        wamid,
+		"timestamp": fmt.Sprint(now.Unix()),
+		"user":      msg.UserID,
+	}
+	if content, ok := msg.Payload[msg.Type]; ok {
+		eventMessage[msg.Type] = content
+	}
+	event := bson.M{
+		"entry": []any{bson.M{"changes": []any{bson.M{"field": "messages", "value": bson.M{"messages": []any{eventMessage}}}}}},
+	}
+	if _, err := db.Collection(database.COLLECTION_SPACE_DESK_EVENTS_WHATSAPP).InsertOne(ctx, event); err != nil {
+		return nil, err
+	}
+
+	message := bson.M{
+		"body":              msg.Excerpt,
+		"chat_id":           msg.ChatID,
+		"by":                msg.UserID,
+		"from":              "company",
+		"created_at":        now,
+		"message_id":        wamid,
+		"message_timestamp": fmt.Sprint(now.Unix()),
+		"type":              msg.Type,
+		"status":            "",
+		"updated_at":        now.Format(time.RFC3339),
+	}
+	for k, v := range msg.Extra {
+		message[k] = v
+	}
+	if _, err := db.Collection(database.COLLECTION_SPACE_DESK_MESSAGE).InsertOne(ctx, message); err != nil {
+		return nil, err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"last_message_id":        wamid,
+			"last_message_timestamp": fmt.Sprint(now.Unix()),
+			"last_message_excerpt":   msg.Excerpt,
+			"last_message_type":      msg.Type,
+			"last_message_sender":    "company",
+			"updated_at":             now.Format(time.RFC3339),
+		},
+	}
+	if _, err := db.Collection(database.COLLECTION_SPACE_DESK_CHAT).UpdateOne(ctx, bson.M{"_id": msg.ChatID}, update, options.UpdateOne().SetUpsert(false)); err != nil {
+		return nil, err
+	}
+
+	respMap["from"] = "company"
+	respMap["to"] = msg.ChatID.Hex()
+	respMap["type"] = msg.Type
+	respMap["messages"] = []any{msg.Excerpt}
+	broadcastSpaceDeskMessage(respMap)
+
+	return respMap, nil
+}
+
+// sendMessageErrorResponse traduz os erros de SendMessageToChat para a resposta HTTP.
+func sendMessageErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrChatNotFound):
+		utils.SendResponse(w, http.StatusNotFound, "Chat não encontrado", nil, 0)
+	case errors.Is(err, ErrApiKeyNotConfigured):
+		utils.SendResponse(w, http.StatusInternalServerError, "API key não configurada", nil, 0)
+	case errors.Is(err, ErrWamidNotReturned):
+		utils.SendResponse(w, http.StatusBadGateway, "A API não retornou o wamid, mensagem não salva.", nil, 0)
+	case errors.Is(err, ErrCannotReachSpaceDesk):
+		utils.SendResponse(w, http.StatusBadGateway, "", nil, utils.ERROR_TO_SEND_MESSAGE)
+	default:
+		utils.SendResponse(w, http.StatusInternalServerError, "", nil, utils.ERROR_TO_INSERT_IN_MONGODB)
+	}
+}
@@ -21,6 +21,8 @@ type UpdateChatStatusBody struct {
 	Closed       bool   `json:"closed" bson:"closed"`
 	NeedTemplate bool   `json:"need_template" bson:"need_template"`
 	Blocked      *bool  `json:"blocked,omitempty"`
+	// Pesquisa enviada ao encerrar o chat: "csat" ou "nps" (opcional)
+	Survey string `json:"survey,omitempty"`
 }
 
 func UpdateChatStatus(w http.ResponseWriter, r *http.Request) {
@@ -43,6 +45,12 @@ func UpdateChatStatus(w http.ResponseWriter, r *http.Request) {
 		utils.SendResponse(w, http.StatusBadRequest, "Campo 'id' obrigatório ausente", nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
 		return
 	}
+	if body.Survey != "" {
+		if _, _, err := surveyRows(body.Survey); err != nil || !body.Closed {
+			utils.SendResponse(w, http.StatusBadRequest, "Pesquisa inválida. Use 'csat' ou 'nps' ao encerrar o chat", nil, utils.SPACE_DESK_INVALID_REQUEST_DATA)
+			return
+		}
+	}
 	var raw map[string]any
 	_ = json.Unmarshal(bodyBytes, &raw)
 
@@ -94,5 +102,16 @@ func UpdateChatStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if body.Survey != "" {
+		survey, err := sendChatSurvey(ctx, mongoClient, objectID, body.Survey)
+		if err != nil {
+			log.Println("Erro ao enviar pesquisa de satisfação:", err)
+			utils.SendResponse(w, http.StatusOK, "Status do chat atualizado, mas a pesquisa não foi enviada", nil, 0)
+			return
+		}
+		utils.SendResponse(w, http.StatusOK, "Status do chat atualizado com sucesso", survey, 0)
+		return
+	}
+
 	utils.SendResponse(w, http.StatusOK, "Status do chat atualizado com sucesso", nil, 0)
 }
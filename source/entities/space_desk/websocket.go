@@ -1,10 +1,18 @@
 package spacedesk
 
 import (
+	"api/database"
+	"api/middlewares"
+	"api/utils"
+	"context"
 	"net/http"
+	"os"
+	"slices"
 	"sync"
 
 	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type SpaceDeskWSMessage map[string]any
@@ -14,6 +22,7 @@ var upgrader = websocket.Upgrader{
 }
 
 var wsClients = make(map[*websocket.Conn]bool)
+var wsClientUsers = make(map[*websocket.Conn][]string)
 var wsMutex sync.Mutex
 
 func broadcastSpaceDeskMessage(msg SpaceDeskWSMessage) {
@@ -24,10 +33,46 @@ func broadcastSpaceDeskMessage(msg SpaceDeskWSMessage) {
 		if err != nil {
 			client.Close()
 			delete(wsClients, client)
+			delete(wsClientUsers, client)
 		}
 	}
 }
 
+// notifySpaceDeskUsers envia a mensagem apenas para as conexões autenticadas de um dos usuários informados.
+func notifySpaceDeskUsers(userIDs []string, msg SpaceDeskWSMessage) {
+	wsMutex.Lock()
+	defer wsMutex.Unlock()
+	for client, identifiers := range wsClientUsers {
+		if !slices.ContainsFunc(userIDs, func(id string) bool { return id != "" && slices.Contains(identifiers, id) }) {
+			continue
+		}
+		if err := client.WriteJSON(msg); err != nil {
+			client.Close()
+			delete(wsClients, client)
+			delete(wsClientUsers, client)
+		}
+	}
+}
+
+// webSocketUserIdentifiers identifica o usuário autenticado pelo LaravelAuth que abriu a conexão. Conexões
+// sem token continuam recebendo as mensagens gerais, mas não os avisos direcionados.
+func webSocketUserIdentifiers(r *http.Request) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGO_TIMEOUT)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(os.Getenv(utils.MONGODB_URI)))
+	if err != nil {
+		return nil
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	user, err := middlewares.CurrentUser(ctx, mongoClient.Database(database.GetDB()), r)
+	if err != nil {
+		return nil
+	}
+	return userIdentifiers(user)
+}
+
 func SpaceDeskWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -36,8 +81,13 @@ func SpaceDeskWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
+	identifiers := webSocketUserIdentifiers(r)
+
 	wsMutex.Lock()
 	wsClients[conn] = true
+	if len(identifiers) > 0 {
+		wsClientUsers[conn] = identifiers
+	}
 	wsMutex.Unlock()
 
 	for {
@@ -52,5 +102,6 @@ func SpaceDeskWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 
 	wsMutex.Lock()
 	delete(wsClients, conn)
+	delete(wsClientUsers, conn)
 	wsMutex.Unlock()
 }
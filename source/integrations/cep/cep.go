@@ -0,0 +1,215 @@
+package cep
+
+import (
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	VIACEP_BASE_URL = "https://viacep.com.br"
+
+	// CEPs mudam raramente; os não encontrados ficam menos tempo para não fixar um erro momentâneo do provedor
+	CACHE_TTL           = 30 * 24 * time.Hour
+	NOT_FOUND_CACHE_TTL = 24 * time.Hour
+	CACHE_MAX_ENTRIES   = 20000
+)
+
+var (
+	ErrInvalid  = errors.New("CEP inválido")
+	ErrNotFound = errors.New("CEP não encontrado")
+)
+
+// Address é o endereço de um CEP, com o CEP já formatado (00000-000) e a UF em State.
+type Address struct {
+	CEP          string `json:"cep"`
+	Street       string `json:"street"`
+	Complement   string `json:"complement,omitempty"`
+	Neighborhood string `json:"neighborhood"`
+	City         string `json:"city"`
+	State        string `json:"state"`
+	IBGE         string `json:"ibge,omitempty"`
+}
+
+// Provider busca o endereço de um CEP já normalizado (8 dígitos). CEP inexistente volta como ErrNotFound.
+type Provider interface {
+	Lookup(ctx context.Context, cep string) (*Address, error)
+}
+
+// Default retorna o provedor do ambiente atual: o stub local em desenvolvimento e o ViaCEP nos demais.
+func Default() Provider {
+	if os.Getenv(utils.ENV) == utils.ENV_DEVELOPMENT {
+		return StubProvider{}
+	}
+	return ViaCEPProvider{BaseURL: VIACEP_BASE_URL, HTTP: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// Normalize deixa só os dígitos do CEP e confere se sobraram 8, sem serem todos iguais (ex.: 00000-000).
+func Normalize(cep string) (string, error) {
+	digits := utils.OnlyDigits(cep)
+	if len(digits) != 8 || strings.Count(digits, digits[:1]) == 8 {
+		return "", ErrInvalid
+	}
+	return digits, nil
+}
+
+// Format devolve o CEP no formato 00000-000, ou o valor original quando ele não é um CEP válido.
+func Format(cep string) string {
+	digits, err := Normalize(cep)
+	if err != nil {
+		return cep
+	}
+	return digits[:5] + "-" + digits[5:]
+}
+
+type cacheEntry struct {
+	address   *Address
+	expiresAt time.Time
+}
+
+var (
+	cacheMutex sync.Mutex
+	cache      = map[string]cacheEntry{}
+)
+
+// Lookup valida o CEP e busca o endereço no provedor padrão, com cache local em memória. Falhas do provedor
+// voltam como erro comum, para que quem chama possa seguir sem o endereço; ErrInvalid e ErrNotFound indicam
+// que o CEP de fato não serve.
+func Lookup(ctx context.Context, cep string) (*Address, error) {
+	return LookupWith(ctx, Default(), cep)
+}
+
+func LookupWith(ctx context.Context, provider Provider, cep string) (*Address, error) {
+	digits, err := Normalize(cep)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cacheMutex.Lock()
+	entry, ok := cache[digits]
+	cacheMutex.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		if entry.address == nil {
+			return nil, ErrNotFound
+		}
+		address := *entry.address
+		return &address, nil
+	}
+
+	address, err := provider.Lookup(ctx, digits)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	ttl := CACHE_TTL
+	if address == nil {
+		ttl = NOT_FOUND_CACHE_TTL
+	}
+	cacheMutex.Lock()
+	if len(cache) >= CACHE_MAX_ENTRIES {
+		for key, e := range cache {
+			if now.After(e.expiresAt) || len(cache) >= CACHE_MAX_ENTRIES {
+				delete(cache, key)
+			}
+		}
+	}
+	cache[digits] = cacheEntry{address: address, expiresAt: now.Add(ttl)}
+	cacheMutex.Unlock()
+
+	if address == nil {
+		return nil, ErrNotFound
+	}
+	result := *address
+	return &result, nil
+}
+
+// ViaCEPProvider usa a API pública do ViaCEP.
+type ViaCEPProvider struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+type viaCEPResponse struct {
+	CEP         string `json:"cep"`
+	Logradouro  string `json:"logradouro"`
+	Complemento string `json:"complemento"`
+	Bairro      string `json:"bairro"`
+	Localidade  string `json:"localidade"`
+	UF          string `json:"uf"`
+	IBGE        string `json:"ibge"`
+	// O ViaCEP devolve "erro": true ou "erro": "true" para CEP inexistente
+	Erro any `json:"erro"`
+}
+
+func (v ViaCEPProvider) Lookup(ctx context.Context, cep string) (*Address, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/ws/%s/json/", strings.TrimRight(v.BaseURL, "/"), cep), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := v.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// O ViaCEP responde 400 para CEP mal formatado
+	if resp.StatusCode == http.StatusBadRequest {
+		return nil, ErrInvalid
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("viacep retornou status %d", resp.StatusCode)
+	}
+
+	body := viaCEPResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Erro != nil && fmt.Sprint(body.Erro) != "false" {
+		return nil, ErrNotFound
+	}
+
+	return &Address{
+		CEP:          Format(body.CEP),
+		Street:       body.Logradouro,
+		Complement:   body.Complemento,
+		Neighborhood: body.Bairro,
+		City:         body.Localidade,
+		State:        body.UF,
+		IBGE:         body.IBGE,
+	}, nil
+}
+
+// StubProvider responde com alguns CEPs gravados do ViaCEP e, para os demais, um endereço genérico em São
+// Paulo. CEPs começando em 97 não existem, para testar a recusa.
+type StubProvider struct {
+	Addresses map[string]Address
+}
+
+var stubAddresses = map[string]Address{
+	"01001000": {CEP: "01001-000", Street: "Praça da Sé", Complement: "lado ímpar", Neighborhood: "Sé", City: "São Paulo", State: "SP", IBGE: "3550308"},
+	"20040002": {CEP: "20040-002", Street: "Rua da Assembleia", Complement: "até 60 - lado par", Neighborhood: "Centro", City: "Rio de Janeiro", State: "RJ", IBGE: "3304557"},
+	"30130010": {CEP: "30130-010", Street: "Praça Sete de Setembro", Neighborhood: "Centro", City: "Belo Horizonte", State: "MG", IBGE: "3106200"},
+}
+
+func (s StubProvider) Lookup(ctx context.Context, cep string) (*Address, error) {
+	if address, ok := s.Addresses[cep]; ok {
+		return &address, nil
+	}
+	if address, ok := stubAddresses[cep]; ok {
+		return &address, nil
+	}
+	if strings.HasPrefix(cep, "97") {
+		return nil, ErrNotFound
+	}
+	return &Address{CEP: Format(cep), Street: "Rua de Teste", Neighborhood: "Centro", City: "São Paulo", State: "SP", IBGE: "3550308"}, nil
+}
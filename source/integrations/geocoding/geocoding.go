@@ -0,0 +1,243 @@
+package geocoding
+
+import (
+	"api/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Address struct {
+	Street       string  `json:"street,omitempty" bson:"street,omitempty"`
+	Number       string  `json:"number,omitempty" bson:"number,omitempty"`
+	Neighborhood string  `json:"neighborhood,omitempty" bson:"neighborhood,omitempty"`
+	City         string  `json:"city,omitempty" bson:"city,omitempty"`
+	State        string  `json:"state,omitempty" bson:"state,omitempty"`
+	CEP          string  `json:"cep,omitempty" bson:"cep,omitempty"`
+	Country      string  `json:"country,omitempty" bson:"country,omitempty"`
+	Formatted    string  `json:"formatted,omitempty" bson:"formatted,omitempty"`
+	Latitude     float64 `json:"latitude" bson:"latitude"`
+	Longitude    float64 `json:"longitude" bson:"longitude"`
+}
+
+// Geocoder converte coordenadas em um endereço.
+type Geocoder interface {
+	Reverse(ctx context.Context, latitude, longitude float64) (*Address, error)
+}
+
+// Default retorna o geocoder do ambiente atual: o stub local em desenvolvimento e o Nominatim nos demais.
+func Default() Geocoder {
+	if os.Getenv(utils.ENV) == utils.ENV_DEVELOPMENT {
+		return StubGeocoder{}
+	}
+	return NominatimGeocoder{BaseURL: "https://nominatim.openstreetmap.org"}
+}
+
+// Locator encontra o endereço e as coordenadas de um CEP.
+type Locator interface {
+	Locate(ctx context.Context, cep string) (*Address, error)
+}
+
+// DefaultLocator segue a mesma regra de Default: stub em desenvolvimento e Nominatim nos demais.
+func DefaultLocator() Locator {
+	if os.Getenv(utils.ENV) == utils.ENV_DEVELOPMENT {
+		return StubGeocoder{}
+	}
+	return NominatimGeocoder{BaseURL: "https://nominatim.openstreetmap.org"}
+}
+
+// StubGeocoder devolve um endereço fixo, usado em desenvolvimento e testes manuais.
+type StubGeocoder struct {
+	Address *Address
+}
+
+func (s StubGeocoder) Reverse(ctx context.Context, latitude, longitude float64) (*Address, error) {
+	address := Address{
+		Street:       "Rua de Teste",
+		Number:       "100",
+		Neighborhood: "Centro",
+		City:         "São Paulo",
+		State:        "SP",
+		CEP:          "01001000",
+		Country:      "BR",
+	}
+	if s.Address != nil {
+		address = *s.Address
+	}
+	address.Latitude = latitude
+	address.Longitude = longitude
+	if address.Formatted == "" {
+		address.Formatted = FormatAddress(address)
+	}
+	return &address, nil
+}
+
+// Locate devolve o endereço fixo com o CEP informado, deslocando as coordenadas pelos dígitos do CEP para
+// que CEPs diferentes fiquem em pontos diferentes.
+func (s StubGeocoder) Locate(ctx context.Context, cep string) (*Address, error) {
+	offset := 0.0
+	for i, r := range cep {
+		if r >= '0' && r <= '9' && i < 5 {
+			offset = offset*10 + float64(r-'0')
+		}
+	}
+	latitude := -23.5505 + float64(int(offset)%100)/1000
+	longitude := -46.6333 + float64(int(offset)/100%100)/1000
+	address, err := s.Reverse(ctx, latitude, longitude)
+	if err != nil {
+		return nil, err
+	}
+	address.CEP = cep
+	return address, nil
+}
+
+// NominatimGeocoder usa a API pública de reverse geocoding do OpenStreetMap.
+type NominatimGeocoder struct {
+	BaseURL string
+}
+
+type nominatimResponse struct {
+	DisplayName string `json:"display_name"`
+	Address     struct {
+		Road          string `json:"road"`
+		HouseNumber   string `json:"house_number"`
+		Suburb        string `json:"suburb"`
+		Neighbourhood string `json:"neighbourhood"`
+		City          string `json:"city"`
+		Town          string `json:"town"`
+		Village       string `json:"village"`
+		StateCode     string `json:"ISO3166-2-lvl4"`
+		Postcode      string `json:"postcode"`
+		CountryCode   string `json:"country_code"`
+	} `json:"address"`
+	Error string `json:"error"`
+}
+
+func (n NominatimGeocoder) Reverse(ctx context.Context, latitude, longitude float64) (*Address, error) {
+	url := fmt.Sprintf("%s/reverse?format=jsonv2&addressdetails=1&lat=%f&lon=%f", n.BaseURL, latitude, longitude)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Language", "pt-BR")
+	req.Header.Set("User-Agent", "space-erp-backend")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nominatim retornou status %d", resp.StatusCode)
+	}
+
+	body := nominatimResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Error != "" {
+		return nil, fmt.Errorf("nominatim: %s", body.Error)
+	}
+
+	city := body.Address.City
+	if city == "" {
+		city = body.Address.Town
+	}
+	if city == "" {
+		city = body.Address.Village
+	}
+	neighborhood := body.Address.Suburb
+	if neighborhood == "" {
+		neighborhood = body.Address.Neighbourhood
+	}
+
+	return &Address{
+		Street:       body.Address.Road,
+		Number:       body.Address.HouseNumber,
+		Neighborhood: neighborhood,
+		City:         city,
+		State:        strings.TrimPrefix(body.Address.StateCode, "BR-"),
+		CEP:          strings.ReplaceAll(body.Address.Postcode, "-", ""),
+		Country:      strings.ToUpper(body.Address.CountryCode),
+		Formatted:    body.DisplayName,
+		Latitude:     latitude,
+		Longitude:    longitude,
+	}, nil
+}
+
+// Locate busca o CEP no Nominatim e devolve o primeiro resultado no Brasil.
+func (n NominatimGeocoder) Locate(ctx context.Context, cep string) (*Address, error) {
+	params := url.Values{
+		"format":         {"jsonv2"},
+		"addressdetails": {"1"},
+		"postalcode":     {cep},
+		"countrycodes":   {"br"},
+		"limit":          {"1"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.BaseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Language", "pt-BR")
+	req.Header.Set("User-Agent", "space-erp-backend")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nominatim retornou status %d", resp.StatusCode)
+	}
+
+	results := []struct {
+		Lat         string `json:"lat"`
+		Lon         string `json:"lon"`
+		DisplayName string `json:"display_name"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("nominatim: CEP %s não encontrado", cep)
+	}
+
+	latitude, errLat := strconv.ParseFloat(results[0].Lat, 64)
+	longitude, errLon := strconv.ParseFloat(results[0].Lon, 64)
+	if errLat != nil || errLon != nil {
+		return nil, fmt.Errorf("nominatim: coordenadas inválidas para o CEP %s", cep)
+	}
+	return &Address{CEP: cep, Country: "BR", Formatted: results[0].DisplayName, Latitude: latitude, Longitude: longitude}, nil
+}
+
+// FormatAddress monta o endereço em uma linha no formato usado nos orçamentos.
+func FormatAddress(a Address) string {
+	parts := []string{}
+	street := a.Street
+	if a.Number != "" {
+		street += ", " + a.Number
+	}
+	for _, part := range []string{street, a.Neighborhood, a.City} {
+		if strings.TrimSpace(part) != "" {
+			parts = append(parts, part)
+		}
+	}
+	line := strings.Join(parts, " - ")
+	if a.State != "" {
+		line += "/" + a.State
+	}
+	return line
+}
@@ -0,0 +1,248 @@
+package shipping
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const CORREIOS_BASE_URL = "https://api.correios.com.br"
+
+// correiosServices são os serviços do contrato, com os mesmos códigos usados pela Frenet.
+var correiosServices = []struct {
+	Code        string
+	Description string
+}{
+	{Code: "03220", Description: "SEDEX"},
+	{Code: "03298", Description: "PAC"},
+}
+
+// CorreiosProvider cota direto na API do contrato dos Correios. O token é obtido com usuário, código de
+// acesso e cartão de postagem e reaproveitado até perto de expirar; contrato e DR vêm junto com o token.
+type CorreiosProvider struct {
+	BaseURL     string
+	User        string
+	AccessCode  string
+	PostingCard string
+	HTTP        *http.Client
+
+	mutex     sync.Mutex
+	token     string
+	contract  string
+	dr        int
+	expiresAt time.Time
+}
+
+type correiosTokenResponse struct {
+	Token          string `json:"token"`
+	ExpiraEm       string `json:"expiraEm"`
+	CartaoPostagem struct {
+		Contrato string `json:"contrato"`
+		Dr       int    `json:"dr"`
+	} `json:"cartaoPostagem"`
+}
+
+type correiosPriceResponse struct {
+	PcFinal string `json:"pcFinal"`
+	TxErro  string `json:"txErro"`
+}
+
+type correiosDeadlineResponse struct {
+	PrazoEntrega int    `json:"prazoEntrega"`
+	TxErro       string `json:"txErro"`
+}
+
+type correiosErrorResponse struct {
+	Msgs []string `json:"msgs"`
+}
+
+func (c *CorreiosProvider) Name() string {
+	return PROVIDER_CORREIOS
+}
+
+func (c *CorreiosProvider) Quote(ctx context.Context, req QuoteRequest) ([]Service, error) {
+	if err := c.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	volumes := 0
+	for _, p := range req.Packages {
+		volumes += max(p.Quantity, 1)
+	}
+	// A API cota um objeto por vez; o valor declarado é dividido entre os volumes
+	declaredValue := 0.0
+	if volumes > 0 {
+		declaredValue = req.InvoiceValue / float64(volumes)
+	}
+
+	services := []Service{}
+	for _, svc := range correiosServices {
+		service := Service{ServiceCode: svc.Code, ServiceDescription: svc.Description, Carrier: "Correios", CarrierCode: "COR"}
+
+		total := 0.0
+		var quoteErr error
+		for _, p := range req.Packages {
+			price, err := c.price(ctx, svc.Code, req, p, declaredValue)
+			if err != nil {
+				quoteErr = err
+				break
+			}
+			total += price * float64(max(p.Quantity, 1))
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if quoteErr == nil {
+			var deadline uint
+			deadline, quoteErr = c.deadline(ctx, svc.Code, req)
+			service.DeliveryTime = deadline
+		}
+		if quoteErr != nil {
+			var refusal correiosRefusal
+			if !errors.As(quoteErr, &refusal) {
+				return nil, quoteErr
+			}
+			service.Error, service.Message = true, quoteErr.Error()
+		} else {
+			service.Price = float64(int(total*100+0.5)) / 100
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+// correiosRefusal é a recusa do serviço pelos Correios (CEP não atendido, peso acima do limite etc.),
+// que vira um serviço indisponível em vez de falha do provedor.
+type correiosRefusal string
+
+func (e correiosRefusal) Error() string {
+	return string(e)
+}
+
+func (c *CorreiosProvider) authenticate(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.token != "" && time.Now().Before(c.expiresAt) {
+		return nil
+	}
+
+	body, _ := json.Marshal(map[string]string{"numero": c.PostingCard})
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.BaseURL, "/")+"/token/v1/autentica/cartaopostagem", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.SetBasicAuth(c.User, c.AccessCode)
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTP.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("correios recusou a autenticação (status %d)", resp.StatusCode)
+	}
+	tokenResp := correiosTokenResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil || tokenResp.Token == "" {
+		return fmt.Errorf("resposta inválida na autenticação dos correios")
+	}
+
+	expiresAt, err := time.Parse("2006-01-02T15:04:05", tokenResp.ExpiraEm)
+	if err != nil {
+		expiresAt = time.Now().Add(time.Hour)
+	}
+	c.token = tokenResp.Token
+	c.contract = tokenResp.CartaoPostagem.Contrato
+	c.dr = tokenResp.CartaoPostagem.Dr
+	// Renova um pouco antes do vencimento para não usar um token expirado no meio da cotação
+	c.expiresAt = expiresAt.Add(-5 * time.Minute)
+	return nil
+}
+
+func (c *CorreiosProvider) price(ctx context.Context, code string, req QuoteRequest, p Package, declaredValue float64) (float64, error) {
+	params := url.Values{
+		"cepOrigem":   {req.SellerCEP},
+		"cepDestino":  {req.RecipientCEP},
+		"psObjeto":    {strconv.Itoa(weightInGrams(p.Weight))},
+		"tpObjeto":    {"2"},
+		"comprimento": {strconv.Itoa(int(p.Length + 0.5))},
+		"largura":     {strconv.Itoa(int(p.Width + 0.5))},
+		"altura":      {strconv.Itoa(int(p.Height + 0.5))},
+		"nuContrato":  {c.contract},
+		"nuDR":        {strconv.Itoa(c.dr)},
+	}
+	if declaredValue > 0 {
+		params.Set("vlDeclarado", strconv.FormatFloat(declaredValue, 'f', 2, 64))
+	}
+
+	priceResp := correiosPriceResponse{}
+	if err := c.get(ctx, "/preco/v1/nacional/"+code, params, &priceResp); err != nil {
+		return 0, err
+	}
+	if priceResp.TxErro != "" {
+		return 0, correiosRefusal(priceResp.TxErro)
+	}
+	return parseDecimal(priceResp.PcFinal), nil
+}
+
+func (c *CorreiosProvider) deadline(ctx context.Context, code string, req QuoteRequest) (uint, error) {
+	params := url.Values{"cepOrigem": {req.SellerCEP}, "cepDestino": {req.RecipientCEP}}
+
+	deadlineResp := correiosDeadlineResponse{}
+	if err := c.get(ctx, "/prazo/v1/nacional/"+code, params, &deadlineResp); err != nil {
+		return 0, err
+	}
+	if deadlineResp.TxErro != "" {
+		return 0, correiosRefusal(deadlineResp.TxErro)
+	}
+	return uint(max(deadlineResp.PrazoEntrega, 0)), nil
+}
+
+func (c *CorreiosProvider) get(ctx context.Context, path string, params url.Values, out any) error {
+	c.mutex.Lock()
+	token := c.token
+	c.mutex.Unlock()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(c.BaseURL, "/")+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.HTTP.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 4xx com mensagens é recusa do serviço; os demais status são falha do provedor
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusUnauthorized {
+		errResp := correiosErrorResponse{}
+		if json.NewDecoder(resp.Body).Decode(&errResp) == nil && len(errResp.Msgs) > 0 {
+			return correiosRefusal(strings.Join(errResp.Msgs, "; "))
+		}
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		c.mutex.Lock()
+		c.token = ""
+		c.mutex.Unlock()
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("correios retornou status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("resposta inválida dos correios: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package shipping
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// correiosTestServer responde a autenticação com recordedCorreiosToken e as demais rotas com routes.
+func correiosTestServer(t *testing.T, authentications *int32, routes map[string]func(w http.ResponseWriter, r *http.Request)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token/v1/autentica/cartaopostagem" {
+			if user, code, ok := r.BasicAuth(); !ok || user != "user" || code != "code" {
+				t.Errorf("autenticação sem as credenciais do contrato")
+			}
+			atomic.AddInt32(authentications, 1)
+			writeRecorded(w, http.StatusCreated, recordedCorreiosToken)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer stub-token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		route, ok := routes[r.URL.Path]
+		if !ok {
+			t.Errorf("rota inesperada: %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		route(w, r)
+	}))
+}
+
+func recorded(body string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeRecorded(w, http.StatusOK, body)
+	}
+}
+
+func TestCorreiosQuoteParsesServices(t *testing.T) {
+	var authentications int32
+	server := correiosTestServer(t, &authentications, map[string]func(w http.ResponseWriter, r *http.Request){
+		"/preco/v1/nacional/03220": func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			if query.Get("nuContrato") != "9912345678" || query.Get("nuDR") != "72" || query.Get("psObjeto") != "1500" || query.Get("vlDeclarado") != "50.00" {
+				t.Errorf("parâmetros do preço = %s", r.URL.RawQuery)
+			}
+			writeRecorded(w, http.StatusOK, recordedCorreiosPriceSedex)
+		},
+		"/prazo/v1/nacional/03220": recorded(recordedCorreiosDeadlineSedex),
+		"/preco/v1/nacional/03298": recorded(recordedCorreiosPricePac),
+		"/prazo/v1/nacional/03298": recorded(`{"coProduto":"03298","txErro":"CEP de destino não atendido"}`),
+	})
+	defer server.Close()
+
+	provider := &CorreiosProvider{BaseURL: server.URL, User: "user", AccessCode: "code", PostingCard: "0000000000", HTTP: server.Client()}
+	req := QuoteRequest{
+		SellerCEP:    "01001000",
+		RecipientCEP: "20040020",
+		InvoiceValue: 150,
+		Packages:     []Package{{Height: 10, Length: 20, Width: 15, Weight: 1.5, Quantity: 3}},
+	}
+	services, err := provider.Quote(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Quote: %v", err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("len(services) = %d, esperado 2", len(services))
+	}
+
+	// 36,40 por volume, três volumes
+	sedex := services[0]
+	if sedex.ServiceCode != "03220" || sedex.Price != 109.20 || sedex.DeliveryTime != 1 || !sedex.Available() {
+		t.Errorf("sedex = %+v", sedex)
+	}
+	pac := services[1]
+	if !pac.Error || pac.Message != "CEP de destino não atendido" || pac.Price != 0 {
+		t.Errorf("pac = %+v", pac)
+	}
+
+	// O token ainda válido é reaproveitado na próxima cotação
+	if _, err := provider.Quote(context.Background(), req); err != nil {
+		t.Fatalf("segunda Quote: %v", err)
+	}
+	if authentications != 1 {
+		t.Errorf("autenticações = %d, esperado 1", authentications)
+	}
+}
+
+func TestCorreiosQuoteRefusalMessages(t *testing.T) {
+	var authentications int32
+	refusal := func(w http.ResponseWriter, r *http.Request) {
+		writeRecorded(w, http.StatusBadRequest, `{"msgs":["Peso acima do limite","Consulte o contrato"]}`)
+	}
+	server := correiosTestServer(t, &authentications, map[string]func(w http.ResponseWriter, r *http.Request){
+		"/preco/v1/nacional/03220": refusal,
+		"/preco/v1/nacional/03298": refusal,
+	})
+	defer server.Close()
+
+	provider := &CorreiosProvider{BaseURL: server.URL, User: "user", AccessCode: "code", PostingCard: "0000000000", HTTP: server.Client()}
+	services, err := provider.Quote(context.Background(), QuoteRequest{Packages: []Package{{Weight: 50, Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("Quote: %v", err)
+	}
+	for _, svc := range services {
+		if !svc.Error || svc.Message != "Peso acima do limite; Consulte o contrato" {
+			t.Errorf("serviço = %+v", svc)
+		}
+	}
+}
+
+func TestCorreiosQuoteFailures(t *testing.T) {
+	t.Run("autenticação recusada", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeRecorded(w, http.StatusUnauthorized, `{"msgs":["Usuário ou código de acesso inválido"]}`)
+		}))
+		defer server.Close()
+
+		provider := &CorreiosProvider{BaseURL: server.URL, User: "user", AccessCode: "code", PostingCard: "0000000000", HTTP: server.Client()}
+		if _, err := provider.Quote(context.Background(), QuoteRequest{}); err == nil || !strings.Contains(err.Error(), "autenticação") {
+			t.Errorf("err = %v", err)
+		}
+	})
+
+	t.Run("status de erro", func(t *testing.T) {
+		var authentications int32
+		server := correiosTestServer(t, &authentications, map[string]func(w http.ResponseWriter, r *http.Request){
+			"/preco/v1/nacional/03220": func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		})
+		defer server.Close()
+
+		provider := &CorreiosProvider{BaseURL: server.URL, User: "user", AccessCode: "code", PostingCard: "0000000000", HTTP: server.Client()}
+		if _, err := provider.Quote(context.Background(), QuoteRequest{Packages: []Package{{Weight: 1, Quantity: 1}}}); err == nil {
+			t.Error("Quote deveria falhar")
+		}
+	})
+
+	t.Run("token expirado", func(t *testing.T) {
+		var authentications int32
+		server := correiosTestServer(t, &authentications, map[string]func(w http.ResponseWriter, r *http.Request){
+			"/preco/v1/nacional/03220": func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+		})
+		defer server.Close()
+
+		provider := &CorreiosProvider{BaseURL: server.URL, User: "user", AccessCode: "code", PostingCard: "0000000000", HTTP: server.Client()}
+		if _, err := provider.Quote(context.Background(), QuoteRequest{Packages: []Package{{Weight: 1, Quantity: 1}}}); err == nil {
+			t.Fatal("Quote deveria falhar")
+		}
+		if provider.token != "" {
+			t.Error("o token recusado deveria ser descartado")
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package shipping
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	FRENET_BASE_URL   = "https://api.frenet.com.br"
+	RECIPIENT_COUNTRY = "BR"
+)
+
+// FrenetProvider cota na Frenet, que agrega Correios, transportadoras e Lalamove em uma única chamada.
+type FrenetProvider struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+type frenetItem struct {
+	Height   float64 `json:"Height"`
+	Length   float64 `json:"Length"`
+	Quantity int     `json:"Quantity"`
+	Weight   float64 `json:"Weight"`
+	Width    float64 `json:"Width"`
+}
+
+// frenetRequest vai sem ShippingServiceCode para que a Frenet devolva todos os serviços disponíveis.
+type frenetRequest struct {
+	SellerCEP            string       `json:"SellerCEP"`
+	RecipientCEP         string       `json:"RecipientCEP"`
+	ShipmentInvoiceValue float64      `json:"ShipmentInvoiceValue"`
+	ShippingItemArray    []frenetItem `json:"ShippingItemArray"`
+	RecipientCountry     string       `json:"RecipientCountry"`
+}
+
+type frenetResponse struct {
+	ShippingSevicesArray []struct {
+		Carrier               string `json:"Carrier"`
+		CarrierCode           string `json:"CarrierCode"`
+		DeliveryTime          string `json:"DeliveryTime"`
+		Msg                   string `json:"Msg"`
+		ServiceCode           string `json:"ServiceCode"`
+		ServiceDescription    string `json:"ServiceDescription"`
+		ShippingPrice         string `json:"ShippingPrice"`
+		OriginalShippingPrice string `json:"OriginalShippingPrice"`
+		Error                 bool   `json:"Error"`
+	} `json:"ShippingSevicesArray"`
+}
+
+func (f *FrenetProvider) Name() string {
+	return PROVIDER_FRENET
+}
+
+func (f *FrenetProvider) Quote(ctx context.Context, req QuoteRequest) ([]Service, error) {
+	body := frenetRequest{
+		SellerCEP:            req.SellerCEP,
+		RecipientCEP:         req.RecipientCEP,
+		ShipmentInvoiceValue: req.InvoiceValue,
+		RecipientCountry:     RECIPIENT_COUNTRY,
+	}
+	for _, p := range req.Packages {
+		body.ShippingItemArray = append(body.ShippingItemArray, frenetItem{
+			Height: p.Height, Length: p.Length, Quantity: p.Quantity, Weight: p.Weight, Width: p.Width,
+		})
+	}
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(f.BaseURL, "/")+"/shipping/quote", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("token", f.Token)
+
+	resp, err := f.HTTP.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("frenet retornou status %d", resp.StatusCode)
+	}
+
+	frenetResp := frenetResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&frenetResp); err != nil {
+		return nil, fmt.Errorf("resposta inválida da frenet: %w", err)
+	}
+
+	services := []Service{}
+	for _, svc := range frenetResp.ShippingSevicesArray {
+		deliveryTime, _ := strconv.ParseUint(strings.TrimSpace(svc.DeliveryTime), 10, 64)
+		services = append(services, Service{
+			ServiceCode:        svc.ServiceCode,
+			ServiceDescription: svc.ServiceDescription,
+			Carrier:            svc.Carrier,
+			CarrierCode:        svc.CarrierCode,
+			Price:              parseDecimal(svc.ShippingPrice),
+			OriginalPrice:      parseDecimal(svc.OriginalShippingPrice),
+			DeliveryTime:       uint(deliveryTime),
+			Error:              svc.Error,
+			Message:            svc.Msg,
+		})
+	}
+	return services, nil
+}
+
+// parseDecimal lê valores com vírgula ou ponto decimal, como os devolvidos pela Frenet e pelos Correios.
+func parseDecimal(value string) float64 {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, ",") {
+		value = strings.ReplaceAll(strings.ReplaceAll(value, ".", ""), ",", ".")
+	}
+	number, _ := strconv.ParseFloat(value, 64)
+	return number
+}
@@ -0,0 +1,94 @@
+package shipping
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFrenetQuoteParsesServices(t *testing.T) {
+	var received frenetRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/shipping/quote" {
+			t.Errorf("requisição inesperada: %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("token") != "secret" {
+			t.Errorf("token = %q", r.Header.Get("token"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("corpo inválido: %v", err)
+		}
+		writeRecorded(w, http.StatusOK, `{"ShippingSevicesArray":[
+			{"Carrier":"Correios","CarrierCode":"COR","DeliveryTime":" 2 ","Msg":"","ServiceCode":"03220","ServiceDescription":"SEDEX","ShippingPrice":"1.234,56","OriginalShippingPrice":"1300.10","Error":false},
+			{"Carrier":"Loggi","CarrierCode":"LOG","DeliveryTime":"","Msg":"CEP não atendido","ServiceCode":"LOG","ServiceDescription":"Loggi","ShippingPrice":"","OriginalShippingPrice":"","Error":true}
+		]}`)
+	}))
+	defer server.Close()
+
+	provider := &FrenetProvider{BaseURL: server.URL + "/", Token: "secret", HTTP: server.Client()}
+	services, err := provider.Quote(context.Background(), QuoteRequest{
+		SellerCEP:    "01001000",
+		RecipientCEP: "20040020",
+		InvoiceValue: 150,
+		Packages:     []Package{{Height: 10, Length: 20, Width: 15, Weight: 1.5, Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatalf("Quote: %v", err)
+	}
+
+	if received.RecipientCountry != RECIPIENT_COUNTRY || received.ShipmentInvoiceValue != 150 || len(received.ShippingItemArray) != 1 || received.ShippingItemArray[0].Quantity != 2 {
+		t.Errorf("requisição enviada = %+v", received)
+	}
+	if len(services) != 2 {
+		t.Fatalf("len(services) = %d, esperado 2", len(services))
+	}
+	sedex := services[0]
+	if sedex.ServiceCode != "03220" || sedex.Carrier != "Correios" || sedex.Price != 1234.56 || sedex.OriginalPrice != 1300.10 || sedex.DeliveryTime != 2 || !sedex.Available() {
+		t.Errorf("sedex = %+v", sedex)
+	}
+	loggi := services[1]
+	if !loggi.Error || loggi.Message != "CEP não atendido" || loggi.Price != 0 || loggi.Available() {
+		t.Errorf("loggi = %+v", loggi)
+	}
+}
+
+func TestFrenetQuoteFailures(t *testing.T) {
+	cases := map[string]struct {
+		status int
+		body   string
+	}{
+		"status de erro":    {http.StatusInternalServerError, `{}`},
+		"resposta inválida": {http.StatusOK, `não é json`},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeRecorded(w, c.status, c.body)
+			}))
+			defer server.Close()
+
+			provider := &FrenetProvider{BaseURL: server.URL, Token: "secret", HTTP: server.Client()}
+			if _, err := provider.Quote(context.Background(), QuoteRequest{}); err == nil {
+				t.Error("Quote deveria falhar")
+			}
+		})
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	cases := map[string]float64{
+		"38.90":    38.90,
+		"36,40":    36.40,
+		"1.234,56": 1234.56,
+		" 12 ":     12,
+		"":         0,
+		"abc":      0,
+	}
+	for value, expected := range cases {
+		if got := parseDecimal(value); got != expected {
+			t.Errorf("parseDecimal(%q) = %v, esperado %v", value, got, expected)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package shipping
+
+import (
+	"api/integrations/geocoding"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	LALAMOVE_BASE_URL = "https://rest.lalamove.com"
+	LALAMOVE_MARKET   = "BR"
+)
+
+// lalamoveServices são os veículos cotados, com os códigos da Frenet para o mesmo serviço e o peso máximo
+// total (kg) que cada um leva.
+var lalamoveServices = []struct {
+	Type        string
+	Code        string
+	Description string
+	MaxWeight   float64
+}{
+	{Type: "LALAGO", Code: "683", Description: "Lalamove LalaGo", MaxWeight: 20},
+	{Type: "LALAPRO", Code: "727", Description: "Lalamove LalaPro", MaxWeight: 20},
+	{Type: "CAR", Code: "1085", Description: "Lalamove Carro", MaxWeight: 200},
+	{Type: "UV_FIORINO", Code: "755", Description: "Lalamove Fiorino", MaxWeight: 600},
+}
+
+// LalamoveProvider cota entregas no mesmo dia direto na API v3 da Lalamove. A Lalamove trabalha com
+// coordenadas, então os CEPs de origem e destino passam antes pelo Locator.
+type LalamoveProvider struct {
+	BaseURL string
+	APIKey  string
+	Secret  string
+	Locator geocoding.Locator
+	HTTP    *http.Client
+}
+
+type lalamoveStop struct {
+	Coordinates struct {
+		Lat string `json:"lat"`
+		Lng string `json:"lng"`
+	} `json:"coordinates"`
+	Address string `json:"address"`
+}
+
+type lalamoveQuotationResponse struct {
+	Data struct {
+		QuotationID    string `json:"quotationId"`
+		ServiceType    string `json:"serviceType"`
+		PriceBreakdown struct {
+			Total    string `json:"total"`
+			Currency string `json:"currency"`
+		} `json:"priceBreakdown"`
+	} `json:"data"`
+	Errors []struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (l *LalamoveProvider) Name() string {
+	return PROVIDER_LALAMOVE
+}
+
+func (l *LalamoveProvider) Quote(ctx context.Context, req QuoteRequest) ([]Service, error) {
+	origin, err := l.stop(ctx, req.SellerCEP)
+	if err != nil {
+		return nil, fmt.Errorf("não foi possível localizar o CEP de origem: %w", err)
+	}
+	destination, err := l.stop(ctx, req.RecipientCEP)
+	if err != nil {
+		return nil, fmt.Errorf("não foi possível localizar o CEP de destino: %w", err)
+	}
+
+	totalWeight := 0.0
+	for _, p := range req.Packages {
+		totalWeight += p.Weight * float64(max(p.Quantity, 1))
+	}
+
+	services := []Service{}
+	for _, svc := range lalamoveServices {
+		service := Service{ServiceCode: svc.Code, ServiceDescription: svc.Description, Carrier: "Lalamove", CarrierCode: "LLM"}
+		if totalWeight > svc.MaxWeight {
+			service.Error, service.Message = true, fmt.Sprintf("Peso total acima do limite de %.0f kg do veículo", svc.MaxWeight)
+			services = append(services, service)
+			continue
+		}
+
+		price, message, err := l.quotation(ctx, svc.Type, origin, destination)
+		if err != nil {
+			return nil, err
+		}
+		if message != "" {
+			service.Error, service.Message = true, message
+		} else {
+			// Entrega no mesmo dia, por isso o prazo fica zerado
+			service.Price = price
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+func (l *LalamoveProvider) stop(ctx context.Context, cep string) (lalamoveStop, error) {
+	stop := lalamoveStop{}
+	address, err := l.Locator.Locate(ctx, cep)
+	if err != nil {
+		return stop, err
+	}
+	stop.Coordinates.Lat = strconv.FormatFloat(address.Latitude, 'f', 6, 64)
+	stop.Coordinates.Lng = strconv.FormatFloat(address.Longitude, 'f', 6, 64)
+	stop.Address = address.Formatted
+	if stop.Address == "" {
+		stop.Address = "CEP " + cep
+	}
+	return stop, nil
+}
+
+// quotation cota um veículo. Recusas da Lalamove (fora da área atendida etc.) voltam na mensagem;
+// o erro fica para falhas do provedor.
+func (l *LalamoveProvider) quotation(ctx context.Context, serviceType string, origin, destination lalamoveStop) (float64, string, error) {
+	body, err := json.Marshal(map[string]any{"data": map[string]any{
+		"serviceType": serviceType,
+		"language":    "pt_BR",
+		"stops":       []lalamoveStop{origin, destination},
+	}})
+	if err != nil {
+		return 0, "", err
+	}
+
+	path := "/v3/quotations"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(l.BaseURL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Market", LALAMOVE_MARKET)
+	httpReq.Header.Set("Authorization", l.authorization(http.MethodPost, path, body, time.Now()))
+
+	resp, err := l.HTTP.Do(httpReq)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	quotation := lalamoveQuotationResponse{}
+	decodeErr := json.NewDecoder(resp.Body).Decode(&quotation)
+	if resp.StatusCode == http.StatusUnprocessableEntity && decodeErr == nil && len(quotation.Errors) > 0 {
+		return 0, quotation.Errors[0].Message, nil
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return 0, "", fmt.Errorf("lalamove retornou status %d", resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return 0, "", fmt.Errorf("resposta inválida da lalamove: %w", decodeErr)
+	}
+	return parseDecimal(quotation.Data.PriceBreakdown.Total), "", nil
+}
+
+// authorization assina a requisição no formato da Lalamove: HMAC-SHA256 de
+// "timestamp\r\nMÉTODO\r\ncaminho\r\n\r\ncorpo" com o secret.
+func (l *LalamoveProvider) authorization(method, path string, body []byte, now time.Time) string {
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(l.Secret))
+	mac.Write([]byte(timestamp + "\r\n" + method + "\r\n" + path + "\r\n\r\n" + string(body)))
+	return fmt.Sprintf("hmac %s:%s:%s", l.APIKey, timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
@@ -0,0 +1,133 @@
+package shipping
+
+import (
+	"api/integrations/geocoding"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeLocator map[string]*geocoding.Address
+
+func (f fakeLocator) Locate(ctx context.Context, cep string) (*geocoding.Address, error) {
+	address, ok := f[cep]
+	if !ok {
+		return nil, errors.New("CEP não encontrado")
+	}
+	return address, nil
+}
+
+var testLocator = fakeLocator{
+	"01001000": {Latitude: -23.55, Longitude: -46.63, Formatted: "Praça da Sé, São Paulo"},
+	"01310100": {Latitude: -23.56, Longitude: -46.65},
+}
+
+func TestLalamoveQuoteParsesServices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v3/quotations" {
+			t.Errorf("requisição inesperada: %s %s", r.Method, r.URL.Path)
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "hmac key:") || r.Header.Get("Market") != LALAMOVE_MARKET {
+			t.Errorf("cabeçalhos = %v", r.Header)
+		}
+		body := struct {
+			Data struct {
+				ServiceType string         `json:"serviceType"`
+				Stops       []lalamoveStop `json:"stops"`
+			} `json:"data"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("corpo inválido: %v", err)
+		}
+		if len(body.Data.Stops) != 2 || body.Data.Stops[0].Coordinates.Lat != "-23.550000" || body.Data.Stops[1].Address != "CEP 01310100" {
+			t.Errorf("paradas = %+v", body.Data.Stops)
+		}
+
+		switch body.Data.ServiceType {
+		case "LALAGO":
+			writeRecorded(w, http.StatusCreated, recordedLalamoveQuotation)
+		case "LALAPRO":
+			writeRecorded(w, http.StatusOK, `{"data":{"priceBreakdown":{"total":"35,50","currency":"BRL"}}}`)
+		default:
+			writeRecorded(w, http.StatusUnprocessableEntity, `{"errors":[{"id":"ERR_OUT_OF_SERVICE_AREA","message":"Fora da área atendida"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	provider := &LalamoveProvider{BaseURL: server.URL, APIKey: "key", Secret: "secret", Locator: testLocator, HTTP: server.Client()}
+	// 25 kg passam do limite do LalaGo e do LalaPro
+	services, err := provider.Quote(context.Background(), QuoteRequest{
+		SellerCEP:    "01001000",
+		RecipientCEP: "01310100",
+		Packages:     []Package{{Weight: 5, Quantity: 3}},
+	})
+	if err != nil {
+		t.Fatalf("Quote: %v", err)
+	}
+	if len(services) != len(lalamoveServices) {
+		t.Fatalf("len(services) = %d, esperado %d", len(services), len(lalamoveServices))
+	}
+	if lalago := services[0]; lalago.ServiceCode != "683" || lalago.Price != 21.90 || !lalago.Available() {
+		t.Errorf("lalago = %+v", lalago)
+	}
+	if lalapro := services[1]; lalapro.Price != 35.50 || !lalapro.Available() {
+		t.Errorf("lalapro = %+v", lalapro)
+	}
+	if car := services[2]; !car.Error || car.Message != "Fora da área atendida" {
+		t.Errorf("carro = %+v", car)
+	}
+
+	services, err = provider.Quote(context.Background(), QuoteRequest{
+		SellerCEP:    "01001000",
+		RecipientCEP: "01310100",
+		Packages:     []Package{{Weight: 25, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Quote: %v", err)
+	}
+	if lalago := services[0]; !lalago.Error || !strings.Contains(lalago.Message, "20 kg") {
+		t.Errorf("lalago acima do peso = %+v", lalago)
+	}
+}
+
+func TestLalamoveQuoteFailures(t *testing.T) {
+	t.Run("CEP sem coordenadas", func(t *testing.T) {
+		provider := &LalamoveProvider{APIKey: "key", Secret: "secret", Locator: testLocator, HTTP: http.DefaultClient}
+		_, err := provider.Quote(context.Background(), QuoteRequest{SellerCEP: "01001000", RecipientCEP: "99999999"})
+		if err == nil || !strings.Contains(err.Error(), "destino") {
+			t.Errorf("err = %v", err)
+		}
+	})
+
+	t.Run("status de erro", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeRecorded(w, http.StatusUnauthorized, `{"errors":[{"id":"ERR_UNAUTHORIZED","message":"Unauthorized"}]}`)
+		}))
+		defer server.Close()
+
+		provider := &LalamoveProvider{BaseURL: server.URL, APIKey: "key", Secret: "secret", Locator: testLocator, HTTP: server.Client()}
+		if _, err := provider.Quote(context.Background(), QuoteRequest{SellerCEP: "01001000", RecipientCEP: "01310100"}); err == nil {
+			t.Error("Quote deveria falhar")
+		}
+	})
+}
+
+func TestLalamoveAuthorization(t *testing.T) {
+	provider := &LalamoveProvider{APIKey: "key", Secret: "secret"}
+	now := time.UnixMilli(1700000000000)
+	authorization := provider.authorization(http.MethodPost, "/v3/quotations", []byte(`{}`), now)
+	if !strings.HasPrefix(authorization, "hmac key:1700000000000:") || len(authorization) != len("hmac key:1700000000000:")+64 {
+		t.Errorf("authorization = %q", authorization)
+	}
+	if authorization != provider.authorization(http.MethodPost, "/v3/quotations", []byte(`{}`), now) {
+		t.Error("a assinatura deveria ser determinística")
+	}
+	if authorization == provider.authorization(http.MethodPost, "/v3/quotations", []byte(`{"a":1}`), now) {
+		t.Error("a assinatura deveria depender do corpo")
+	}
+}
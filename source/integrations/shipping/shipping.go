@@ -0,0 +1,245 @@
+package shipping
+
+import (
+	"api/integrations/geocoding"
+	"api/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	PROVIDER_FRENET   = "frenet"
+	PROVIDER_CORREIOS = "correios"
+	PROVIDER_LALAMOVE = "lalamove"
+
+	// Ordem padrão da cotação: a Frenet agrega as transportadoras e os contratos diretos entram se ela falhar
+	DEFAULT_PROVIDERS = PROVIDER_FRENET + "," + PROVIDER_CORREIOS + "," + PROVIDER_LALAMOVE
+
+	// Prazo de cada provedor antes de passar para o próximo
+	PROVIDER_TIMEOUT = 8 * time.Second
+
+	STUB_PATH = "/dev/stubs/shipping"
+)
+
+var (
+	ErrNotConfigured      = errors.New("credenciais do provedor de frete não configuradas")
+	ErrNoProviders        = errors.New("nenhum provedor de frete configurado")
+	ErrAllProvidersFailed = errors.New("nenhum provedor de frete respondeu")
+)
+
+// O token dos Correios vale por horas; o provedor é reaproveitado entre cotações para não autenticar a cada uma
+var (
+	correiosMutex     sync.Mutex
+	correiosProviders = map[string]*CorreiosProvider{}
+)
+
+// Package é um volume da cotação, com medidas em cm e peso em kg, e quantos volumes iguais existem.
+type Package struct {
+	Height   float64
+	Length   float64
+	Width    float64
+	Weight   float64
+	Quantity int
+}
+
+type QuoteRequest struct {
+	SellerCEP    string
+	RecipientCEP string
+	InvoiceValue float64
+	Packages     []Package
+}
+
+// Service é um serviço cotado, já normalizado entre provedores. Os códigos seguem os da Frenet
+// (ex.: 03220 para SEDEX e 683 para Lalamove LalaGo), para que a escolha não dependa de quem cotou.
+type Service struct {
+	Provider           string  `json:"provider"`
+	ServiceCode        string  `json:"service_code"`
+	ServiceDescription string  `json:"service_description"`
+	Carrier            string  `json:"carrier"`
+	CarrierCode        string  `json:"carrier_code,omitempty"`
+	Price              float64 `json:"price"`
+	OriginalPrice      float64 `json:"original_price,omitempty"`
+	DeliveryTime       uint    `json:"delivery_time"`
+	Error              bool    `json:"error,omitempty"`
+	Message            string  `json:"message,omitempty"`
+}
+
+// Available indica se o serviço pode ser contratado para o envio.
+func (s Service) Available() bool {
+	return !s.Error && s.Price > 0
+}
+
+// Provider cota todos os serviços que atende para o envio. Serviços recusados voltam com Error;
+// o erro da função fica para falhas do provedor (credenciais, conexão, resposta inválida).
+type Provider interface {
+	Name() string
+	Quote(ctx context.Context, req QuoteRequest) ([]Service, error)
+}
+
+// Attempt registra o resultado de cada provedor consultado na cotação.
+type Attempt struct {
+	Provider string `json:"provider"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration_ms"`
+}
+
+type Quote struct {
+	Provider string    `json:"provider"`
+	Services []Service `json:"services"`
+	Attempts []Attempt `json:"attempts"`
+}
+
+// Fallback consulta os provedores em ordem, cada um com seu prazo, e fica com o primeiro que devolve algum
+// serviço disponível. Se nenhum tiver serviço disponível, devolve a última resposta válida para que os
+// motivos das recusas apareçam; só há erro quando todos os provedores falharam.
+type Fallback struct {
+	Providers []Provider
+	Timeout   time.Duration
+}
+
+func (f Fallback) Quote(ctx context.Context, req QuoteRequest) (*Quote, error) {
+	if len(f.Providers) == 0 {
+		return nil, ErrNoProviders
+	}
+	timeout := f.Timeout
+	if timeout == 0 {
+		timeout = PROVIDER_TIMEOUT
+	}
+
+	attempts := []Attempt{}
+	var answered *Quote
+	for _, provider := range f.Providers {
+		if ctx.Err() != nil {
+			break
+		}
+		started := time.Now()
+		providerCtx, cancel := context.WithTimeout(ctx, timeout)
+		services, err := provider.Quote(providerCtx, req)
+		cancel()
+
+		attempt := Attempt{Provider: provider.Name(), Duration: time.Since(started).Milliseconds()}
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("sem resposta em %s", timeout)
+			}
+			attempt.Error = err.Error()
+			attempts = append(attempts, attempt)
+			continue
+		}
+		attempts = append(attempts, attempt)
+
+		for i := range services {
+			services[i].Provider = provider.Name()
+		}
+		answered = &Quote{Provider: provider.Name(), Services: services}
+		for _, svc := range services {
+			if svc.Available() {
+				answered.Attempts = attempts
+				return answered, nil
+			}
+		}
+	}
+
+	if answered != nil {
+		answered.Attempts = attempts
+		return answered, nil
+	}
+	messages := []string{}
+	for _, a := range attempts {
+		messages = append(messages, a.Provider+": "+a.Error)
+	}
+	return &Quote{Attempts: attempts}, fmt.Errorf("%w (%s)", ErrAllProvidersFailed, strings.Join(messages, "; "))
+}
+
+// Default monta a cadeia de provedores configurada em SHIPPING_PROVIDERS (padrão: frenet, correios e
+// lalamove), ignorando os que não têm credenciais. Em desenvolvimento, Correios e Lalamove sem credenciais
+// usam o stub montado no próprio servidor.
+func Default() (Fallback, error) {
+	names := os.Getenv(utils.SHIPPING_PROVIDERS)
+	if names == "" {
+		names = DEFAULT_PROVIDERS
+	}
+
+	providers := []Provider{}
+	for _, name := range strings.Split(names, ",") {
+		provider, err := newProvider(strings.ToLower(strings.TrimSpace(name)))
+		if errors.Is(err, ErrNotConfigured) {
+			continue
+		}
+		if err != nil {
+			return Fallback{}, err
+		}
+		providers = append(providers, provider)
+	}
+	if len(providers) == 0 {
+		return Fallback{}, ErrNoProviders
+	}
+	return Fallback{Providers: providers, Timeout: PROVIDER_TIMEOUT}, nil
+}
+
+func newProvider(name string) (Provider, error) {
+	development := os.Getenv(utils.ENV) == utils.ENV_DEVELOPMENT
+	stubURL := fmt.Sprintf("http://localhost:%s%s", os.Getenv(utils.PORT), STUB_PATH)
+	client := &http.Client{Timeout: PROVIDER_TIMEOUT}
+
+	switch name {
+	case PROVIDER_FRENET:
+		token := os.Getenv(utils.FRENET_API_KEY)
+		if token == "" {
+			return nil, ErrNotConfigured
+		}
+		baseURL := os.Getenv(utils.FRENET_API_URL)
+		if baseURL == "" {
+			baseURL = FRENET_BASE_URL
+		}
+		return &FrenetProvider{BaseURL: baseURL, Token: token, HTTP: client}, nil
+
+	case PROVIDER_CORREIOS:
+		baseURL := CORREIOS_BASE_URL
+		user, accessCode, postingCard := os.Getenv(utils.CORREIOS_API_USER), os.Getenv(utils.CORREIOS_API_CODE), os.Getenv(utils.CORREIOS_POSTING_CARD)
+		if user == "" || accessCode == "" || postingCard == "" {
+			if !development {
+				return nil, ErrNotConfigured
+			}
+			baseURL, user, accessCode, postingCard = stubURL+"/correios", "stub", "stub", "0000000000"
+		}
+
+		correiosMutex.Lock()
+		defer correiosMutex.Unlock()
+		key := baseURL + "|" + user + "|" + accessCode + "|" + postingCard
+		if provider, ok := correiosProviders[key]; ok {
+			return provider, nil
+		}
+		provider := &CorreiosProvider{BaseURL: baseURL, User: user, AccessCode: accessCode, PostingCard: postingCard, HTTP: client}
+		correiosProviders[key] = provider
+		return provider, nil
+
+	case PROVIDER_LALAMOVE:
+		provider := &LalamoveProvider{
+			BaseURL: LALAMOVE_BASE_URL,
+			APIKey:  os.Getenv(utils.LALAMOVE_API_KEY),
+			Secret:  os.Getenv(utils.LALAMOVE_API_SECRET),
+			Locator: geocoding.DefaultLocator(),
+			HTTP:    client,
+		}
+		if provider.APIKey == "" || provider.Secret == "" {
+			if !development {
+				return nil, ErrNotConfigured
+			}
+			provider.BaseURL, provider.APIKey, provider.Secret = stubURL+"/lalamove", "stub", "stub"
+		}
+		return provider, nil
+	}
+	return nil, fmt.Errorf("provedor de frete desconhecido em %s: %q", utils.SHIPPING_PROVIDERS, name)
+}
+
+// weightInGrams converte o peso em kg para gramas, sem deixar volume com peso zero.
+func weightInGrams(weight float64) int {
+	return max(1, int(weight*1000+0.5))
+}
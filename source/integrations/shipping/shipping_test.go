@@ -0,0 +1,108 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProvider devolve services/err, ou espera o prazo acabar quando block é verdadeiro.
+type fakeProvider struct {
+	name     string
+	services []Service
+	err      error
+	block    bool
+	calls    int
+}
+
+func (f *fakeProvider) Name() string {
+	return f.name
+}
+
+func (f *fakeProvider) Quote(ctx context.Context, req QuoteRequest) ([]Service, error) {
+	f.calls++
+	if f.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return f.services, f.err
+}
+
+func TestFallbackUsesFirstAvailableProvider(t *testing.T) {
+	first := &fakeProvider{name: "first", services: []Service{{ServiceCode: "1", Price: 10}}}
+	second := &fakeProvider{name: "second", services: []Service{{ServiceCode: "2", Price: 20}}}
+
+	quote, err := Fallback{Providers: []Provider{first, second}}.Quote(context.Background(), QuoteRequest{})
+	if err != nil {
+		t.Fatalf("Quote: %v", err)
+	}
+	if quote.Provider != "first" || quote.Services[0].Provider != "first" || len(quote.Attempts) != 1 {
+		t.Errorf("quote = %+v", quote)
+	}
+	if second.calls != 0 {
+		t.Error("o segundo provedor não deveria ser consultado")
+	}
+}
+
+func TestFallbackSkipsFailedProvider(t *testing.T) {
+	failing := &fakeProvider{name: "failing", err: errors.New("status 503")}
+	working := &fakeProvider{name: "working", services: []Service{{ServiceCode: "2", Price: 20}}}
+
+	quote, err := Fallback{Providers: []Provider{failing, working}}.Quote(context.Background(), QuoteRequest{})
+	if err != nil {
+		t.Fatalf("Quote: %v", err)
+	}
+	if quote.Provider != "working" || len(quote.Attempts) != 2 || quote.Attempts[0].Error != "status 503" || quote.Attempts[1].Error != "" {
+		t.Errorf("quote = %+v", quote)
+	}
+}
+
+func TestFallbackSkipsProviderOnTimeout(t *testing.T) {
+	slow := &fakeProvider{name: "slow", block: true}
+	working := &fakeProvider{name: "working", services: []Service{{ServiceCode: "2", Price: 20}}}
+
+	quote, err := Fallback{Providers: []Provider{slow, working}, Timeout: 20 * time.Millisecond}.Quote(context.Background(), QuoteRequest{})
+	if err != nil {
+		t.Fatalf("Quote: %v", err)
+	}
+	if quote.Provider != "working" || !strings.HasPrefix(quote.Attempts[0].Error, "sem resposta em") {
+		t.Errorf("quote = %+v", quote)
+	}
+}
+
+func TestFallbackKeepsLastAnswerWithoutAvailableServices(t *testing.T) {
+	refused := &fakeProvider{name: "refused", services: []Service{{ServiceCode: "1", Error: true, Message: "CEP não atendido"}}}
+	failing := &fakeProvider{name: "failing", err: errors.New("status 500")}
+
+	quote, err := Fallback{Providers: []Provider{refused, failing}}.Quote(context.Background(), QuoteRequest{})
+	if err != nil {
+		t.Fatalf("Quote: %v", err)
+	}
+	if quote.Provider != "refused" || quote.Services[0].Message != "CEP não atendido" || len(quote.Attempts) != 2 {
+		t.Errorf("quote = %+v", quote)
+	}
+}
+
+func TestFallbackAllProvidersFailed(t *testing.T) {
+	first := &fakeProvider{name: "first", err: errors.New("status 503")}
+	second := &fakeProvider{name: "second", block: true}
+
+	quote, err := Fallback{Providers: []Provider{first, second}, Timeout: 20 * time.Millisecond}.Quote(context.Background(), QuoteRequest{})
+	if !errors.Is(err, ErrAllProvidersFailed) {
+		t.Fatalf("err = %v, esperado ErrAllProvidersFailed", err)
+	}
+	if !strings.Contains(err.Error(), "first: status 503") || !strings.Contains(err.Error(), "second: sem resposta") {
+		t.Errorf("err = %v", err)
+	}
+	if quote == nil || len(quote.Attempts) != 2 || len(quote.Services) != 0 {
+		t.Errorf("quote = %+v", quote)
+	}
+}
+
+func TestFallbackWithoutProviders(t *testing.T) {
+	if _, err := (Fallback{}).Quote(context.Background(), QuoteRequest{}); !errors.Is(err, ErrNoProviders) {
+		t.Errorf("err = %v, esperado ErrNoProviders", err)
+	}
+}
@@ -0,0 +1,127 @@
+package shipping
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Respostas gravadas das APIs reais, usadas pelo StubServer.
+const (
+	recordedFrenetQuote = `{"ShippingSevicesArray":[
+		{"Carrier":"Correios","CarrierCode":"COR","DeliveryTime":"1","Msg":"","ServiceCode":"03220","ServiceDescription":"SEDEX","ShippingPrice":"38.90","OriginalDeliveryTime":"1","OriginalShippingPrice":"41.20","Error":false},
+		{"Carrier":"Correios","CarrierCode":"COR","DeliveryTime":"5","Msg":"","ServiceCode":"03298","ServiceDescription":"PAC","ShippingPrice":"22.45","OriginalDeliveryTime":"5","OriginalShippingPrice":"23.80","Error":false},
+		{"Carrier":"Jadlog","CarrierCode":"JAD","DeliveryTime":"3","Msg":"","ServiceCode":"F_3","ServiceDescription":".Package","ShippingPrice":"27.10","OriginalDeliveryTime":"3","OriginalShippingPrice":"27.10","Error":false},
+		{"Carrier":"Lalamove","CarrierCode":"LLM","DeliveryTime":"0","Msg":"","ServiceCode":"683","ServiceDescription":"Lalamove LalaGo","ShippingPrice":"19.90","OriginalDeliveryTime":"0","OriginalShippingPrice":"19.90","Error":false},
+		{"Carrier":"Loggi","CarrierCode":"LOG","DeliveryTime":"","Msg":"CEP de destino não atendido","ServiceCode":"LOG_DRPOFF","ServiceDescription":"Loggi Ponto","ShippingPrice":"","OriginalDeliveryTime":"","OriginalShippingPrice":"","Error":true}
+	],"Timeout":0}`
+
+	recordedCorreiosToken = `{"ambiente":"PRODUCAO","id":"stub","perfil":"PJ","cartaoPostagem":{"numero":"0000000000","contrato":"9912345678","dr":72,"api":[27,34,35,36,37,41,76,78,80,83,87,93,566,586,587,623,621,620]},"emissao":"2024-01-01T08:00:00","expiraEm":"2099-01-01T08:00:00","token":"stub-token"}`
+
+	recordedCorreiosPriceSedex    = `{"coProduto":"03220","pcBase":"35,10","pcBaseGeral":"35,10","peVariacao":"0,0000","pcReferencia":"35,10","vlBaseCalculoImposto":"35,10","inPesoCubico":"N","psCobrado":"1000","peAdValorem":"0,0000","vlSeguroAutomatico":"24,50","pcFinal":"36,40"}`
+	recordedCorreiosPricePac      = `{"coProduto":"03298","pcBase":"21,30","pcBaseGeral":"21,30","peVariacao":"0,0000","pcReferencia":"21,30","vlBaseCalculoImposto":"21,30","inPesoCubico":"N","psCobrado":"1000","peAdValorem":"0,0000","vlSeguroAutomatico":"24,50","pcFinal":"21,30"}`
+	recordedCorreiosDeadlineSedex = `{"coProduto":"03220","prazoEntrega":1,"dataMaxima":"2024-01-02T23:59:59","entregaDomiciliar":"S","entregaSabado":"S"}`
+	recordedCorreiosDeadlinePac   = `{"coProduto":"03298","prazoEntrega":5,"dataMaxima":"2024-01-08T23:59:59","entregaDomiciliar":"S","entregaSabado":"N"}`
+
+	recordedLalamoveQuotation = `{"data":{"quotationId":"1514140994227007571","scheduleAt":"2024-01-01T10:00:00.00Z","expiresAt":"2024-01-01T10:05:00.00Z","serviceType":"","language":"PT_BR","isRouteOptimized":false,"priceBreakdown":{"base":"15.90","extraMileage":"6.00","totalExcludePriorityFee":"21.90","total":"21.90","currency":"BRL"},"distance":{"value":"8420","unit":"m"}}}`
+)
+
+// StubServer imita as APIs de frete com respostas gravadas e é montado em STUB_PATH quando o servidor roda
+// em desenvolvimento (Frenet em /frenet, Correios em /correios e Lalamove em /lalamove). CEPs de destino
+// começando em 99 fazem Frenet e Correios responderem erro e em 98 não responderem, para exercitar o fallback.
+type StubServer struct{}
+
+func NewStubServer() *StubServer {
+	return &StubServer{}
+}
+
+func (s *StubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, STUB_PATH)
+
+	switch {
+	case r.Method == http.MethodPost && path == "/frenet/shipping/quote":
+		body := frenetRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || r.Header.Get("token") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !s.simulateFailure(w, r, body.RecipientCEP) {
+			writeRecorded(w, http.StatusOK, recordedFrenetQuote)
+		}
+
+	case r.Method == http.MethodPost && path == "/correios/token/v1/autentica/cartaopostagem":
+		if _, _, ok := r.BasicAuth(); !ok {
+			writeRecorded(w, http.StatusUnauthorized, `{"msgs":["Usuário ou código de acesso inválido"]}`)
+			return
+		}
+		writeRecorded(w, http.StatusCreated, recordedCorreiosToken)
+
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/correios/"):
+		if r.Header.Get("Authorization") != "Bearer stub-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if s.simulateFailure(w, r, r.URL.Query().Get("cepDestino")) {
+			return
+		}
+		recorded := map[string]string{
+			"/correios/preco/v1/nacional/03220": recordedCorreiosPriceSedex,
+			"/correios/preco/v1/nacional/03298": recordedCorreiosPricePac,
+			"/correios/prazo/v1/nacional/03220": recordedCorreiosDeadlineSedex,
+			"/correios/prazo/v1/nacional/03298": recordedCorreiosDeadlinePac,
+		}
+		response, ok := recorded[path]
+		if !ok {
+			writeRecorded(w, http.StatusBadRequest, `{"msgs":["Produto não contratado"]}`)
+			return
+		}
+		writeRecorded(w, http.StatusOK, response)
+
+	case r.Method == http.MethodPost && path == "/lalamove/v3/quotations":
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "hmac ") || r.Header.Get("Market") == "" {
+			writeRecorded(w, http.StatusUnauthorized, `{"errors":[{"id":"ERR_UNAUTHORIZED","message":"Unauthorized"}]}`)
+			return
+		}
+		body := struct {
+			Data struct {
+				ServiceType string `json:"serviceType"`
+			} `json:"data"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeRecorded(w, http.StatusBadRequest, `{"errors":[{"id":"ERR_INVALID_FIELD","message":"Invalid body"}]}`)
+			return
+		}
+		if body.Data.ServiceType == "UV_FIORINO" {
+			writeRecorded(w, http.StatusUnprocessableEntity, `{"errors":[{"id":"ERR_INVALID_SERVICE_TYPE","message":"Veículo indisponível na região"}]}`)
+			return
+		}
+		writeRecorded(w, http.StatusCreated, strings.Replace(recordedLalamoveQuotation, `"serviceType":""`, `"serviceType":"`+body.Data.ServiceType+`"`, 1))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// simulateFailure responde erro para CEPs iniciados em 99 e segura a resposta para os iniciados em 98.
+func (s *StubServer) simulateFailure(w http.ResponseWriter, r *http.Request, recipientCEP string) bool {
+	switch {
+	case strings.HasPrefix(recipientCEP, "99"):
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	case strings.HasPrefix(recipientCEP, "98"):
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * PROVIDER_TIMEOUT):
+		}
+		w.WriteHeader(http.StatusGatewayTimeout)
+		return true
+	}
+	return false
+}
+
+func writeRecorded(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
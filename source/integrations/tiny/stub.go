@@ -0,0 +1,118 @@
+package tiny
+
+import (
+	"api/schemas"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StubServer imita os endpoints da API v2 do Tiny usados pela sincronização, guardando os pedidos
+// em memória. É montado em STUB_PATH quando o servidor roda em desenvolvimento.
+type StubServer struct {
+	mutex    sync.Mutex
+	orders   []schemas.TinyOrder
+	invoices map[string]Invoice
+	nextID   int
+}
+
+func NewStubServer() *StubServer {
+	today := time.Now().Format("02/01/2006")
+	return &StubServer{
+		nextID: 1003,
+		orders: []schemas.TinyOrder{
+			{
+				ID: "1001", Number: "5001", DataPedido: today, Situacao: "Aprovado", TotalPedido: "350.00",
+				Cliente: &schemas.TinyCliente{Nome: "Cliente Stub", CpfCnpj: "000.000.000-00", Cep: "01001-000", Cidade: "São Paulo", Uf: "SP"},
+				Itens:   []schemas.TinyItem{{Item: schemas.TinyItemDetail{Codigo: "CAM-01", Descricao: "Camisa personalizada", Quantidade: "10.00", ValorUnitario: "35.00"}}},
+			},
+			{
+				ID: "1002", Number: "5002", DataPedido: today, Situacao: "Enviado", TotalPedido: "120.00",
+				CodigoRastreamento: "AA123456789BR", IdNotaFiscal: "9001",
+				Cliente: &schemas.TinyCliente{Nome: "Outro Cliente Stub", Cep: "20040-002", Cidade: "Rio de Janeiro", Uf: "RJ"},
+				Itens:   []schemas.TinyItem{{Item: schemas.TinyItemDetail{Codigo: "BON-01", Descricao: "Boné bordado", Quantidade: "4.00", ValorUnitario: "30.00"}}},
+			},
+		},
+		invoices: map[string]Invoice{
+			"9001": {ID: "9001", Numero: "000123", Serie: "1", ChaveAcesso: "35240100000000000000550010000001231000000000", DataEmissao: today, ValorNota: "120.00"},
+		},
+	}
+}
+
+func (s *StubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("token") == "" {
+		writeStub(w, map[string]any{"status": "Erro", "codigo_erro": 2, "erros": []tinyError{{Erro: "token inválido"}}})
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch path.Base(r.URL.Path) {
+	case "pedidos.pesquisa.php":
+		pedidos := []map[string]any{}
+		for _, order := range s.orders {
+			pedidos = append(pedidos, map[string]any{"pedido": stubOrder(order)})
+		}
+		writeStub(w, map[string]any{"status": "OK", "pagina": 1, "numero_paginas": 1, "pedidos": pedidos})
+	case "pedido.obter.php":
+		for _, order := range s.orders {
+			if order.ID == r.Form.Get("id") {
+				writeStub(w, map[string]any{"status": "OK", "pedido": stubOrder(order)})
+				return
+			}
+		}
+		writeStub(w, map[string]any{"status": "Erro", "codigo_erro": 20, "erros": []tinyError{{Erro: "pedido não encontrado"}}})
+	case "pedido.incluir.php":
+		payload := struct {
+			Pedido OrderInput `json:"pedido"`
+		}{}
+		if err := json.Unmarshal([]byte(r.Form.Get("pedido")), &payload); err != nil {
+			writeStub(w, map[string]any{"status": "Erro", "codigo_erro": 31, "erros": []tinyError{{Erro: "JSON do pedido inválido"}}})
+			return
+		}
+		id := strconv.Itoa(s.nextID)
+		number := strconv.Itoa(s.nextID + 4000)
+		s.nextID++
+		cliente := payload.Pedido.Cliente
+		ecommerce := payload.Pedido.NumeroPedidoEcommerce
+		s.orders = append(s.orders, schemas.TinyOrder{
+			ID: id, Number: number, DataPedido: payload.Pedido.DataPedido, DataPrevista: payload.Pedido.DataPrevista,
+			Situacao: "Em aberto", Cliente: &cliente, Itens: payload.Pedido.Itens, NumeroEcommerce: &ecommerce,
+		})
+		writeStub(w, map[string]any{"status": "OK", "registros": []map[string]any{
+			{"registro": map[string]any{"sequencia": "1", "status": "OK", "id": id, "numero": number}},
+		}})
+	case "nota.fiscal.obter.php":
+		invoice, ok := s.invoices[r.Form.Get("id")]
+		if !ok {
+			writeStub(w, map[string]any{"status": "Erro", "codigo_erro": 20, "erros": []tinyError{{Erro: "nota fiscal não encontrada"}}})
+			return
+		}
+		writeStub(w, map[string]any{"status": "OK", "nota_fiscal": invoice})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// stubOrder devolve o pedido no formato da API, com o número em "numero".
+func stubOrder(order schemas.TinyOrder) map[string]any {
+	raw, _ := json.Marshal(order)
+	result := map[string]any{}
+	_ = json.Unmarshal(raw, &result)
+	delete(result, "number")
+	result["numero"] = order.Number
+	return result
+}
+
+func writeStub(w http.ResponseWriter, retorno map[string]any) {
+	retorno["status_processamento"] = "2"
+	if retorno["status"] == "OK" {
+		retorno["status_processamento"] = "3"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"retorno": retorno})
+}
@@ -0,0 +1,312 @@
+package tiny
+
+import (
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_BASE_URL = "https://api.tiny.com.br/api2"
+
+	// Caminho em que o stub é montado no servidor em desenvolvimento
+	STUB_PATH = "/dev/stubs/tiny"
+)
+
+var (
+	ErrNotConfigured = errors.New("token do Tiny não configurado")
+	ErrNotFound      = errors.New("registro não encontrado no Tiny")
+)
+
+// Invoice é a nota fiscal retornada por nota.fiscal.obter.
+type Invoice struct {
+	ID          string `json:"id"`
+	Numero      string `json:"numero"`
+	Serie       string `json:"serie"`
+	ChaveAcesso string `json:"chave_acesso"`
+	DataEmissao string `json:"data_emissao"`
+	ValorNota   string `json:"valor_nota"`
+}
+
+// OrderInput é o pedido enviado para pedido.incluir.
+type OrderInput struct {
+	DataPedido            string              `json:"data_pedido"`
+	DataPrevista          string              `json:"data_prevista,omitempty"`
+	Cliente               schemas.TinyCliente `json:"cliente"`
+	Itens                 []schemas.TinyItem  `json:"itens"`
+	ValorFrete            string              `json:"valor_frete,omitempty"`
+	ValorDesconto         string              `json:"valor_desconto,omitempty"`
+	FormaPagamento        string              `json:"forma_pagamento,omitempty"`
+	NumeroPedidoEcommerce string              `json:"numero_pedido_ecommerce,omitempty"`
+	NomeVendedor          string              `json:"nome_vendedor,omitempty"`
+	Obs                   string              `json:"obs,omitempty"`
+}
+
+// CreatedOrder identifica o pedido criado no Tiny.
+type CreatedOrder struct {
+	ID     string
+	Number string
+}
+
+// Client é o que a sincronização usa do Tiny; o HTTPClient fala com a API real ou com o stub.
+type Client interface {
+	SearchOrders(ctx context.Context, updatedSince time.Time, page int) ([]schemas.TinyOrder, int, error)
+	GetOrder(ctx context.Context, id string) (*schemas.TinyOrder, error)
+	CreateOrder(ctx context.Context, order OrderInput) (*CreatedOrder, error)
+	GetInvoice(ctx context.Context, id string) (*Invoice, error)
+}
+
+// Default retorna o cliente configurado pelo ambiente. Sem TINY_API_URL, em desenvolvimento usa o
+// stub montado no próprio servidor e nos demais ambientes a API do Tiny.
+func Default() (Client, error) {
+	baseURL := os.Getenv(utils.TINY_API_URL)
+	token := os.Getenv(utils.TINY_API_TOKEN)
+	if baseURL == "" && os.Getenv(utils.ENV) == utils.ENV_DEVELOPMENT {
+		baseURL = fmt.Sprintf("http://localhost:%s%s", os.Getenv(utils.PORT), STUB_PATH)
+		if token == "" {
+			token = "stub"
+		}
+	}
+	if baseURL == "" {
+		baseURL = DEFAULT_BASE_URL
+	}
+	if token == "" {
+		return nil, ErrNotConfigured
+	}
+	return &HTTPClient{BaseURL: baseURL, Token: token, HTTP: &http.Client{Timeout: 15 * time.Second}}, nil
+}
+
+type HTTPClient struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+type tinyError struct {
+	Erro string `json:"erro"`
+}
+
+type tinyEnvelope struct {
+	Retorno struct {
+		Status        string          `json:"status"`
+		CodigoErro    json.Number     `json:"codigo_erro"`
+		Erros         []tinyError     `json:"erros"`
+		NumeroPaginas json.Number     `json:"numero_paginas"`
+		Pedidos       json.RawMessage `json:"pedidos"`
+		Pedido        json.RawMessage `json:"pedido"`
+		NotaFiscal    json.RawMessage `json:"nota_fiscal"`
+		Registros     json.RawMessage `json:"registros"`
+	} `json:"retorno"`
+}
+
+// call faz o POST no endpoint do Tiny (ex.: "pedidos.pesquisa.php") e devolve o retorno já validado.
+func (c *HTTPClient) call(ctx context.Context, endpoint string, params url.Values) (*tinyEnvelope, error) {
+	params.Set("token", c.Token)
+	params.Set("formato", "json")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.BaseURL, "/")+"/"+endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tiny retornou status %d", resp.StatusCode)
+	}
+
+	envelope := &tinyEnvelope{}
+	if err := json.NewDecoder(resp.Body).Decode(envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Retorno.Status != "OK" {
+		// Código 20: a consulta não retornou registros
+		if envelope.Retorno.CodigoErro.String() == "20" {
+			return nil, ErrNotFound
+		}
+		messages := []string{}
+		for _, e := range envelope.Retorno.Erros {
+			messages = append(messages, e.Erro)
+		}
+		return nil, fmt.Errorf("tiny: %s", strings.Join(messages, "; "))
+	}
+	return envelope, nil
+}
+
+func (c *HTTPClient) SearchOrders(ctx context.Context, updatedSince time.Time, page int) ([]schemas.TinyOrder, int, error) {
+	params := url.Values{"pagina": {strconv.Itoa(max(page, 1))}}
+	if !updatedSince.IsZero() {
+		params.Set("dataAtualizacao", updatedSince.Format("02/01/2006 15:04:05"))
+	}
+	envelope, err := c.call(ctx, "pedidos.pesquisa.php", params)
+	if errors.Is(err, ErrNotFound) {
+		return []schemas.TinyOrder{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var wrapped []struct {
+		Pedido json.RawMessage `json:"pedido"`
+	}
+	if err := json.Unmarshal(envelope.Retorno.Pedidos, &wrapped); err != nil {
+		return nil, 0, err
+	}
+	orders := make([]schemas.TinyOrder, 0, len(wrapped))
+	for _, w := range wrapped {
+		order, err := decodeOrder(w.Pedido)
+		if err != nil {
+			return nil, 0, err
+		}
+		orders = append(orders, order)
+	}
+	pages, _ := envelope.Retorno.NumeroPaginas.Int64()
+	return orders, int(pages), nil
+}
+
+func (c *HTTPClient) GetOrder(ctx context.Context, id string) (*schemas.TinyOrder, error) {
+	envelope, err := c.call(ctx, "pedido.obter.php", url.Values{"id": {id}})
+	if err != nil {
+		return nil, err
+	}
+	order, err := decodeOrder(envelope.Retorno.Pedido)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (c *HTTPClient) CreateOrder(ctx context.Context, order OrderInput) (*CreatedOrder, error) {
+	payload, err := json.Marshal(map[string]any{"pedido": order})
+	if err != nil {
+		return nil, err
+	}
+	envelope, err := c.call(ctx, "pedido.incluir.php", url.Values{"pedido": {string(payload)}})
+	if err != nil {
+		return nil, err
+	}
+
+	var registros []struct {
+		Registro struct {
+			Status string      `json:"status"`
+			ID     json.Number `json:"id"`
+			Numero json.Number `json:"numero"`
+			Erros  []tinyError `json:"erros"`
+		} `json:"registro"`
+	}
+	if err := json.Unmarshal(envelope.Retorno.Registros, &registros); err != nil {
+		return nil, err
+	}
+	if len(registros) == 0 {
+		return nil, errors.New("tiny não retornou o pedido criado")
+	}
+	registro := registros[0].Registro
+	if registro.Status != "OK" {
+		messages := []string{}
+		for _, e := range registro.Erros {
+			messages = append(messages, e.Erro)
+		}
+		return nil, fmt.Errorf("tiny: %s", strings.Join(messages, "; "))
+	}
+	return &CreatedOrder{ID: registro.ID.String(), Number: registro.Numero.String()}, nil
+}
+
+func (c *HTTPClient) GetInvoice(ctx context.Context, id string) (*Invoice, error) {
+	envelope, err := c.call(ctx, "nota.fiscal.obter.php", url.Values{"id": {id}})
+	if err != nil {
+		return nil, err
+	}
+	invoice := Invoice{}
+	if err := json.Unmarshal(envelope.Retorno.NotaFiscal, &invoice); err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// decodeOrder lê o pedido da API; o número vem em "numero" e no snapshot fica em number.
+func decodeOrder(raw json.RawMessage) (schemas.TinyOrder, error) {
+	order := schemas.TinyOrder{}
+	if err := json.Unmarshal(raw, &order); err != nil {
+		return order, err
+	}
+	var extra struct {
+		Numero any `json:"numero"`
+	}
+	if err := json.Unmarshal(raw, &extra); err != nil {
+		return order, err
+	}
+	if order.Number == "" && extra.Numero != nil {
+		order.Number = fmt.Sprint(extra.Numero)
+	}
+	return order, nil
+}
+
+// StatusFromSituacao converte a situação do pedido no Tiny no status local. O Tiny só acompanha o
+// faturamento e o envio, então situações anteriores (em aberto, aprovado) não mudam o status local.
+func StatusFromSituacao(situacao string) (schemas.OrderStatus, bool) {
+	switch normalizeSituacao(situacao) {
+	case "preparando envio", "preparando_envio":
+		return schemas.StatusEmSeparacao, true
+	case "faturado", "pronto para envio", "pronto_envio":
+		return schemas.StatusSeparacao, true
+	case "enviado":
+		return schemas.StatusEmEntrega, true
+	case "entregue":
+		return schemas.StatusEntregue, true
+	case "nao entregue", "nao_entregue":
+		return schemas.StatusDevolucao, true
+	}
+	return "", false
+}
+
+// IsCancelled indica pedidos cancelados no Tiny, que não têm status equivalente aqui.
+func IsCancelled(situacao string) bool {
+	return normalizeSituacao(situacao) == "cancelado"
+}
+
+func normalizeSituacao(situacao string) string {
+	replacer := strings.NewReplacer("ã", "a", "á", "a", "â", "a", "é", "e", "ê", "e", "í", "i", "ó", "o", "õ", "o", "ú", "u", "ç", "c")
+	return replacer.Replace(strings.ToLower(strings.TrimSpace(situacao)))
+}
+
+// ParseDate lê as datas do Tiny (dd/mm/aaaa, com ou sem hora).
+func ParseDate(value string) (time.Time, error) {
+	location, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		location = time.Local
+	}
+	for _, layout := range []string{"02/01/2006 15:04:05", "02/01/2006"} {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(value), location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("data inválida do Tiny: %q", value)
+}
+
+// ParseNumber lê valores numéricos do Tiny, que podem vir com vírgula decimal.
+func ParseNumber(value string) float64 {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, ",") {
+		value = strings.ReplaceAll(strings.ReplaceAll(value, ".", ""), ",", ".")
+	}
+	number, _ := strconv.ParseFloat(value, 64)
+	return number
+}
+
+// FormatNumber escreve valores no formato aceito pelo Tiny.
+func FormatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
@@ -0,0 +1,165 @@
+package tracking
+
+import (
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const LINKETRACK_BASE_URL = "https://api.linketrack.com"
+
+var (
+	ErrNotConfigured = errors.New("credenciais do rastreio não configuradas")
+	ErrNotFound      = errors.New("código de rastreio não encontrado na transportadora")
+)
+
+// Provider consulta os eventos de um código de rastreio. Os eventos voltam em ordem cronológica e com o
+// status já normalizado (schemas.TRACKING_STATUS_*), para que o restante do sistema não dependa da transportadora.
+type Provider interface {
+	Track(ctx context.Context, code string) ([]schemas.OrderTrackingEvent, error)
+}
+
+// Default retorna o provedor do ambiente atual: o stub local em desenvolvimento e o Linketrack nos demais.
+func Default() (Provider, error) {
+	if os.Getenv(utils.ENV) == utils.ENV_DEVELOPMENT {
+		return StubProvider{}, nil
+	}
+	user := os.Getenv(utils.TRACKING_API_USER)
+	token := os.Getenv(utils.TRACKING_API_TOKEN)
+	if user == "" || token == "" {
+		return nil, ErrNotConfigured
+	}
+	return LinketrackProvider{BaseURL: LINKETRACK_BASE_URL, User: user, Token: token, HTTP: &http.Client{Timeout: 15 * time.Second}}, nil
+}
+
+// StubProvider simula o rastreio dos Correios a partir do código: todo objeto é postado, segue em
+// trânsito e sai para entrega; códigos cujo último dígito é ímpar já aparecem como entregues.
+type StubProvider struct {
+	Events map[string][]schemas.OrderTrackingEvent
+}
+
+func (s StubProvider) Track(ctx context.Context, code string) ([]schemas.OrderTrackingEvent, error) {
+	code = NormalizeCode(code)
+	if events, ok := s.Events[code]; ok {
+		return events, nil
+	}
+	digits := strings.TrimFunc(code, func(r rune) bool { return r < '0' || r > '9' })
+	if digits == "" {
+		return nil, ErrNotFound
+	}
+
+	today := time.Now().Truncate(24 * time.Hour)
+	events := []schemas.OrderTrackingEvent{
+		{Status: schemas.TRACKING_STATUS_POSTED, Description: "Objeto postado", Location: "São Paulo - SP", At: today.AddDate(0, 0, -3).Add(10 * time.Hour)},
+		{Status: schemas.TRACKING_STATUS_IN_TRANSIT, Description: "Objeto em transferência - por favor aguarde", Location: "Cajamar - SP", At: today.AddDate(0, 0, -2).Add(18 * time.Hour)},
+		{Status: schemas.TRACKING_STATUS_OUT_FOR_DELIVERY, Description: "Objeto saiu para entrega ao destinatário", Location: "São Paulo - SP", At: today.AddDate(0, 0, -1).Add(8 * time.Hour)},
+	}
+	if (digits[len(digits)-1]-'0')%2 == 1 {
+		events = append(events, schemas.OrderTrackingEvent{
+			Status: schemas.TRACKING_STATUS_DELIVERED, Description: "Objeto entregue ao destinatário", Location: "São Paulo - SP", At: today.AddDate(0, 0, -1).Add(15 * time.Hour),
+		})
+	}
+	return events, nil
+}
+
+// LinketrackProvider usa a API do Linketrack, que agrega o rastreio dos Correios.
+type LinketrackProvider struct {
+	BaseURL string
+	User    string
+	Token   string
+	HTTP    *http.Client
+}
+
+type linketrackResponse struct {
+	Codigo  string `json:"codigo"`
+	Eventos []struct {
+		Data   string `json:"data"`
+		Hora   string `json:"hora"`
+		Local  string `json:"local"`
+		Status string `json:"status"`
+	} `json:"eventos"`
+}
+
+func (l LinketrackProvider) Track(ctx context.Context, code string) ([]schemas.OrderTrackingEvent, error) {
+	params := url.Values{"user": {l.User}, "token": {l.Token}, "codigo": {NormalizeCode(code)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(l.BaseURL, "/")+"/track/json?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := l.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("linketrack retornou status %d", resp.StatusCode)
+	}
+
+	body := linketrackResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if len(body.Eventos) == 0 {
+		return nil, ErrNotFound
+	}
+
+	location, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		location = time.Local
+	}
+	events := []schemas.OrderTrackingEvent{}
+	for _, e := range body.Eventos {
+		at, err := time.ParseInLocation("02/01/2006 15:04", strings.TrimSpace(e.Data+" "+e.Hora), location)
+		if err != nil {
+			continue
+		}
+		events = append(events, schemas.OrderTrackingEvent{
+			Status:      StatusFromDescription(e.Status),
+			Description: e.Status,
+			Location:    e.Local,
+			At:          at,
+		})
+	}
+	// O Linketrack devolve do mais recente para o mais antigo
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At.Before(events[j].At) })
+	return events, nil
+}
+
+// StatusFromDescription classifica a descrição do evento dos Correios em um status normalizado.
+func StatusFromDescription(description string) string {
+	d := strings.ToLower(description)
+	switch {
+	case strings.Contains(d, "entregue ao destinatário"), strings.Contains(d, "entregue ao destinatario"):
+		return schemas.TRACKING_STATUS_DELIVERED
+	case strings.Contains(d, "saiu para entrega"):
+		return schemas.TRACKING_STATUS_OUT_FOR_DELIVERY
+	case strings.Contains(d, "devolvido"), strings.Contains(d, "devolução"), strings.Contains(d, "devolucao"):
+		return schemas.TRACKING_STATUS_RETURNED
+	case strings.Contains(d, "não entregue"), strings.Contains(d, "nao entregue"), strings.Contains(d, "não foi possível"),
+		strings.Contains(d, "nao foi possivel"), strings.Contains(d, "extraviado"), strings.Contains(d, "roubado"):
+		return schemas.TRACKING_STATUS_EXCEPTION
+	case strings.Contains(d, "postado"):
+		return schemas.TRACKING_STATUS_POSTED
+	}
+	return schemas.TRACKING_STATUS_IN_TRANSIT
+}
+
+// NormalizeCode remove espaços e padroniza o código de rastreio em maiúsculas.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+}
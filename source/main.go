@@ -1,15 +1,21 @@
 package main
 
 import (
+	"api/database"
+	"api/entities/addresses"
 	"api/entities/budgets"
 	"api/entities/clients"
+	"api/entities/exports"
 	"api/entities/funnels"
 	funnelshistory "api/entities/funnels_history"
 	"api/entities/leads"
 	"api/entities/orders"
+	"api/entities/products"
 	"api/entities/report"
 	spacedesk "api/entities/space_desk"
 	users "api/entities/users"
+	"api/integrations/shipping"
+	"api/integrations/tiny"
 	"api/middlewares"
 	"api/utils"
 	"fmt"
@@ -39,19 +45,39 @@ func main() {
 	mux.Handle("PATCH /v1/funnels/{id}", middlewares.LaravelAuth(http.HandlerFunc(funnels.UpdateOne)))
 	mux.Handle("DELETE /v1/funnels/{id}", middlewares.LaravelAuth(http.HandlerFunc(funnels.DeleteOne)))
 	mux.HandleFunc("/v1/ws/funnels", funnels.FunnelWebSocketHandler)
+	mux.Handle("/v1/ws/orders", middlewares.LaravelAuth(http.HandlerFunc(orders.OrdersWebSocketHandler)))
 
 	mux.Handle("GET /v1/leads", middlewares.LaravelAuth(http.HandlerFunc(leads.GetAll)))
 	mux.Handle("GET /v1/leads/{id}", middlewares.LaravelAuth(http.HandlerFunc(leads.GetOne)))
 	mux.Handle("GET /v1/leads-by-number/{number}", middlewares.LaravelAuth(http.HandlerFunc(leads.GetOneByNumber)))
 	mux.Handle("POST /v1/leads", middlewares.LaravelAuth(http.HandlerFunc(leads.CreateOne)))
 	mux.Handle("PATCH /v1/leads/{id}", middlewares.LaravelAuth(http.HandlerFunc(leads.UpdateOne)))
+	mux.Handle("GET /v1/leads/vcard", middlewares.LaravelAuth(http.HandlerFunc(leads.ExportVCard)))
+	mux.Handle("GET /v1/leads/export", middlewares.LaravelAuth(http.HandlerFunc(leads.Export)))
+	mux.Handle("POST /v1/leads/vcard", middlewares.LaravelAuth(http.HandlerFunc(leads.ImportVCard)))
+	mux.Handle("GET /v1/leads/duplicates", middlewares.LaravelAuth(http.HandlerFunc(leads.GetDuplicates)))
+	mux.Handle("GET /v1/leads/duplicates/{id}", middlewares.LaravelAuth(http.HandlerFunc(leads.GetOneDuplicates)))
+	mux.Handle("GET /v1/leads/timeline/{id}", middlewares.LaravelAuth(http.HandlerFunc(leads.GetTimeline)))
+	mux.Handle("POST /v1/leads/{id}/merge", middlewares.LaravelAuth(http.HandlerFunc(leads.Merge)))
+	mux.Handle("POST /v1/leads/phones/normalization", middlewares.LaravelAuth(http.HandlerFunc(leads.NormalizePhones)))
+	mux.Handle("GET /v1/leads/imports", middlewares.LaravelAuth(http.HandlerFunc(leads.GetAllImports)))
+	mux.Handle("GET /v1/leads/imports/{id}", middlewares.LaravelAuth(http.HandlerFunc(leads.GetImport)))
+	mux.Handle("POST /v1/leads/imports", middlewares.LaravelAuth(http.HandlerFunc(leads.CreateImport)))
+	mux.Handle("GET /v1/leads/scoring", middlewares.LaravelAuth(http.HandlerFunc(leads.GetScoringModel)))
+	mux.Handle("PUT /v1/leads/scoring", middlewares.LaravelAuth(http.HandlerFunc(leads.UpdateScoringModel)))
+	mux.Handle("POST /v1/leads/{id}/score", middlewares.LaravelAuth(http.HandlerFunc(leads.RecomputeScore)))
 	mux.Handle("GET /v1/leads/tiers", middlewares.LaravelAuth(http.HandlerFunc(leads.GetAllTiers)))
+	mux.Handle("GET /v1/leads/tiers/changes", middlewares.LaravelAuth(http.HandlerFunc(leads.GetTierChanges)))
 	mux.Handle("GET /v1/leads/tiers/{id}", middlewares.LaravelAuth(http.HandlerFunc(leads.GetOneTier)))
 	mux.Handle("POST /v1/leads/tiers", middlewares.LaravelAuth(http.HandlerFunc(leads.CreateOneTier)))
 	mux.Handle("PATCH /v1/leads/tiers/{id}", middlewares.LaravelAuth(http.HandlerFunc(leads.UpdateOneTier)))
 
 	mux.Handle("GET /v1/clients", middlewares.LaravelAuth(http.HandlerFunc(clients.GetAll)))
+	mux.Handle("GET /v1/clients/export", middlewares.LaravelAuth(http.HandlerFunc(clients.Export)))
 	mux.Handle("GET /v1/clients/{id}", middlewares.LaravelAuth(http.HandlerFunc(clients.GetOne)))
+	mux.Handle("POST /v1/clients/{id}/address/normalization", middlewares.LaravelAuth(http.HandlerFunc(clients.NormalizeAddress)))
+
+	mux.Handle("GET /v1/cep/{cep}", middlewares.LaravelAuth(http.HandlerFunc(addresses.GetCEP)))
 
 	mux.Handle("GET /v1/users", middlewares.LaravelAuth(http.HandlerFunc(users.GetAll)))
 	mux.Handle("GET /v1/users/{id}", middlewares.LaravelAuth(http.HandlerFunc(users.GetOne)))
@@ -62,11 +88,51 @@ func main() {
 	mux.Handle("GET /v1/users/superadmin/reports/commercial", middlewares.LaravelAuth(http.HandlerFunc(users.GetSuperadminSellersPerformanceReport)))
 
 	mux.Handle("GET /v1/budgets", middlewares.LaravelAuth(http.HandlerFunc(budgets.GetAll)))
+	mux.Handle("GET /v1/budgets/export", middlewares.LaravelAuth(http.HandlerFunc(budgets.Export)))
+	mux.Handle("POST /v1/budgets/shipping", middlewares.LaravelAuth(http.HandlerFunc(budgets.CompareShippingQuotes)))
 	mux.Handle("POST /v1/budgets/shipping/{service}", middlewares.LaravelAuth(http.HandlerFunc(budgets.CreateShippingQuote)))
 	mux.Handle("GET /v1/budgets/{id}", middlewares.LaravelAuth(http.HandlerFunc(budgets.GetOne)))
+	mux.Handle("POST /v1/budgets", middlewares.LaravelAuth(http.HandlerFunc(budgets.CreateOne)))
+	mux.Handle("PATCH /v1/budgets/{id}", middlewares.LaravelAuth(http.HandlerFunc(budgets.UpdateOne)))
+	mux.Handle("POST /v1/budgets/line-items/migration", middlewares.LaravelAuth(http.HandlerFunc(budgets.MigrateLineItems)))
+	mux.Handle("POST /v1/budgets/{id}/order/conversion", middlewares.LaravelAuth(http.HandlerFunc(budgets.ConvertToOrder)))
+	mux.Handle("PATCH /v1/budgets/{id}/approval", middlewares.LaravelAuth(http.HandlerFunc(budgets.UpdateApproval)))
+	mux.Handle("POST /v1/budgets/{id}/pdf/generation", middlewares.LaravelAuth(http.HandlerFunc(budgets.CreateBudgetPDF)))
+	mux.Handle("GET /v1/budgets/{id}/pdf", middlewares.LaravelAuth(http.HandlerFunc(budgets.DownloadBudgetPDF)))
+	mux.Handle("POST /v1/budgets/{id}/pdf/send", middlewares.LaravelAuth(http.HandlerFunc(budgets.SendBudgetPDF)))
+	mux.Handle("GET /v1/budgets/{id}/packages", middlewares.LaravelAuth(http.HandlerFunc(budgets.GetBudgetPackages)))
+	mux.Handle("GET /v1/budgets/{id}/shipping", middlewares.LaravelAuth(http.HandlerFunc(budgets.QuoteBudgetShipping)))
+	mux.Handle("PATCH /v1/budgets/{id}/delivery", middlewares.LaravelAuth(http.HandlerFunc(budgets.UpdateBudgetDelivery)))
+	mux.Handle("POST /v1/budgets/{id}/proposed-address/acceptance", middlewares.LaravelAuth(http.HandlerFunc(budgets.AcceptProposedAddress)))
+	mux.Handle("DELETE /v1/budgets/{id}/proposed-address", middlewares.LaravelAuth(http.HandlerFunc(budgets.DiscardProposedAddress)))
 
 	mux.Handle("GET /v1/orders", middlewares.LaravelAuth(http.HandlerFunc(orders.GetAll)))
+	mux.Handle("GET /v1/orders/export", middlewares.LaravelAuth(http.HandlerFunc(orders.Export)))
+	mux.Handle("GET /v1/orders/board", middlewares.LaravelAuth(http.HandlerFunc(orders.GetBoard)))
+	mux.Handle("POST /v1/orders/board/move", middlewares.LaravelAuth(http.HandlerFunc(orders.MoveOnBoard)))
+	mux.Handle("GET /v1/orders/workflow", middlewares.LaravelAuth(http.HandlerFunc(orders.GetWorkflow)))
+	mux.Handle("GET /v1/orders/metrics/stages", middlewares.LaravelAuth(http.HandlerFunc(orders.GetStageMetrics)))
+	mux.Handle("POST /v1/orders/tiny/sync", middlewares.LaravelAuth(http.HandlerFunc(orders.SyncTiny)))
+	mux.Handle("GET /v1/orders/tiny/sync-logs", middlewares.LaravelAuth(http.HandlerFunc(orders.GetTinySyncLogs)))
+	mux.Handle("POST /v1/orders/tracking/poll", middlewares.LaravelAuth(http.HandlerFunc(orders.PollTracking)))
+	mux.Handle("GET /v1/orders/deadlines", middlewares.LaravelAuth(http.HandlerFunc(orders.GetDeadlines)))
+	mux.Handle("POST /v1/orders/deadlines/check", middlewares.LaravelAuth(http.HandlerFunc(orders.CheckDeadlines)))
 	mux.Handle("GET /v1/orders/{id}", middlewares.LaravelAuth(http.HandlerFunc(orders.GetOne)))
+	mux.Handle("PATCH /v1/orders/{id}/transition", middlewares.LaravelAuth(http.HandlerFunc(orders.UpdateTransition)))
+	mux.Handle("POST /v1/orders/{id}/tiny", middlewares.LaravelAuth(http.HandlerFunc(orders.PushOrderToTiny)))
+	mux.Handle("GET /v1/orders/{id}/tracking", middlewares.LaravelAuth(http.HandlerFunc(orders.GetOrderTracking)))
+	mux.Handle("POST /v1/orders/{id}/tracking", middlewares.LaravelAuth(http.HandlerFunc(orders.RefreshOrderTracking)))
+
+	mux.Handle("GET /v1/exports", middlewares.LaravelAuth(http.HandlerFunc(exports.GetAll)))
+	mux.Handle("GET /v1/exports/{id}", middlewares.LaravelAuth(http.HandlerFunc(exports.GetOne)))
+	mux.Handle("GET /v1/exports/{id}/download", middlewares.LaravelAuth(http.HandlerFunc(exports.Download)))
+
+	mux.Handle("GET /v1/products", middlewares.LaravelAuth(http.HandlerFunc(products.GetAll)))
+	mux.Handle("GET /v1/products/{id}", middlewares.LaravelAuth(http.HandlerFunc(products.GetOne)))
+	mux.Handle("POST /v1/products", middlewares.LaravelAuth(http.HandlerFunc(products.CreateOne)))
+	mux.Handle("PATCH /v1/products/{id}", middlewares.LaravelAuth(http.HandlerFunc(products.UpdateOne)))
+	mux.Handle("DELETE /v1/products/{id}", middlewares.LaravelAuth(http.HandlerFunc(products.DeleteOne)))
+	mux.Handle("POST /v1/products/prices", middlewares.LaravelAuth(http.HandlerFunc(products.LookupPrices)))
 
 	mux.Handle("GET /v1/reports", middlewares.LaravelAuth(http.HandlerFunc(report.GetByQuery)))
 	mux.Handle("GET /v2/reports/clients", middlewares.LaravelAuth(http.HandlerFunc(report.GetByQueryV2)))
@@ -116,6 +182,9 @@ func main() {
 
 	mux.Handle("PATCH /v1/space-desk/chats/status", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.UpdateChatStatus)))
 	mux.Handle("PATCH /v1/space-desk/chats/user", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.UpdateChatUser)))
+	mux.Handle("POST /v1/space-desk/chats/transfer", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.CreateChatTransfer)))
+	mux.Handle("PATCH /v1/space-desk/chats/transfer", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.RespondChatTransfer)))
+	mux.Handle("GET /v1/space-desk/chats/{id}/transfers", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.GetChatTransfers)))
 
 	mux.Handle("POST /v1/space-desk/ready-messages", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.CreateOneReadyMessage)))
 	mux.Handle("PUT /v1/space-desk/ready-messages", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.UpdateOneReadyMessage)))
@@ -128,7 +197,10 @@ func main() {
 
 	mux.Handle("POST /v1/space-desk/poll", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.CreateOnePoll)))
 	mux.Handle("POST /v1/space-desk/list", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.CreateListMessage)))
+	mux.Handle("GET /v1/space-desk/interactive/{id}/results", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.GetInteractiveResults)))
 	mux.Handle("POST /v1/space-desk/location", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.CreateLocationRequestMessage)))
+	mux.Handle("POST /v1/space-desk/contacts", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.CreateContactsMessage)))
+	mux.Handle("POST /v1/space-desk/contacts/lead", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.CreateLeadFromContact)))
 
 	mux.Handle("POST /v1/space-desk/phone-config", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.CreatePhoneConfig)))
 	mux.Handle("PATCH /v1/space-desk/phone-config", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.UpdatePhoneConfig)))
@@ -139,7 +211,21 @@ func main() {
 	mux.Handle("GET /v1/space-desk/pix-config", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.GetAllPixConfig)))
 	mux.Handle("DELETE /v1/space-desk/pix-config", middlewares.LaravelAuth(http.HandlerFunc(spacedesk.DeletePixConfig)))
 
-	mux.HandleFunc("/v1/ws/space-desk", spacedesk.SpaceDeskWebSocketHandler)
+	mux.Handle("/v1/ws/space-desk", middlewares.OptionalLaravelAuth(http.HandlerFunc(spacedesk.SpaceDeskWebSocketHandler)))
+
+	if env == utils.ENV_DEVELOPMENT {
+		mux.Handle(tiny.STUB_PATH+"/", tiny.NewStubServer())
+		mux.Handle(shipping.STUB_PATH+"/", shipping.NewStubServer())
+	}
+
+	orders.StartTrackingPoller()
+	orders.StartDeadlineMonitor()
+	leads.StartLeadDuplicates()
+	leads.StartLeadScoring()
+	leads.StartLeadTiers()
+	leads.StartLeadImports()
+	exports.StartExportsCleanup()
+	go database.EnsureIndexes()
 
 	fmt.Printf("Servidor iniciado na porta %s às %s\n", os.Getenv(utils.PORT), time.Now().Format("2006-01-02 15:04:05"))
 	http.ListenAndServe(fmt.Sprintf(":%s", os.Getenv(utils.PORT)), middlewares.SecurityHeaders(middlewares.Cors(mux)))
@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"api/database"
+	"api/schemas"
+	"context"
+	"errors"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var ErrUserNotAuthenticated = errors.New("usuário não autenticado")
+
+// CurrentUser carrega, pelo old_id, o usuário autenticado pelo LaravelAuth.
+func CurrentUser(ctx context.Context, db *mongo.Database, r *http.Request) (schemas.User, error) {
+	user := schemas.User{}
+	laravelUser, ok := r.Context().Value(UserContextKey).(LaravelUser)
+	if !ok {
+		return user, ErrUserNotAuthenticated
+	}
+	err := db.Collection(database.COLLECTION_USERS).FindOne(ctx, bson.D{{Key: "old_id", Value: laravelUser.ID}}).Decode(&user)
+	return user, err
+}
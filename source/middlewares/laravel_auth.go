@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 )
 
 type contextKey string
@@ -21,58 +22,83 @@ type LaravelUser struct {
 
 func LaravelAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if os.Getenv(utils.ENV) == utils.ENV_DEVELOPMENT {
-			// Usuário fictício para ambiente de desenvolvimento
-			user := LaravelUser{
-				ID:    2,
-				Name:  "Dev User",
-				Email: "devuser@example.com",
-			}
-			ctx := context.WithValue(r.Context(), UserContextKey, user)
-			next.ServeHTTP(w, r.WithContext(ctx))
+		user, status, message := authenticateLaravelUser(r)
+		if status != http.StatusOK {
+			utils.SendResponse(w, status, message, nil, 0)
 			return
 		}
 
-		token := r.Header.Get("Authorization")
-		if token == "" {
-			utils.SendResponse(w, http.StatusUnauthorized, "Token não informado", nil, 0)
-			return
-		}
+		ctx := context.WithValue(r.Context(), UserContextKey, user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
-		laravelURL := os.Getenv("LARAVEL_API_URL")
-		if laravelURL == "" {
-			laravelURL = "http://localhost:8000"
+// OptionalLaravelAuth identifica o usuário quando a requisição traz um token válido e segue sem usuário no
+// contexto caso contrário. É usado nas rotas que já eram abertas, como o websocket do Space Desk.
+func OptionalLaravelAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, status, _ := authenticateLaravelUser(r); status == http.StatusOK {
+			r = r.WithContext(context.WithValue(r.Context(), UserContextKey, user))
 		}
-		userURL := fmt.Sprintf("%s/api/user", laravelURL)
+		next.ServeHTTP(w, r)
+	})
+}
 
-		req, err := http.NewRequest("GET", userURL, nil)
-		if err != nil {
-			utils.SendResponse(w, http.StatusInternalServerError, "Erro ao criar requisição de autenticação", nil, 0)
-			return
+// laravelToken lê o token do cabeçalho Authorization. O navegador não envia cabeçalhos ao abrir um websocket,
+// então nessas requisições o token também é aceito no parâmetro ?token=.
+func laravelToken(r *http.Request) string {
+	token := r.Header.Get("Authorization")
+	if token == "" && strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		if queryToken := r.URL.Query().Get("token"); queryToken != "" {
+			token = "Bearer " + strings.TrimPrefix(queryToken, "Bearer ")
 		}
-		req.Header.Set("Authorization", token)
+	}
+	return token
+}
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			utils.SendResponse(w, http.StatusBadGateway, "Erro ao conectar na API de autenticação", nil, 0)
-			return
-		}
-		defer resp.Body.Close()
+// authenticateLaravelUser valida o token na API do Laravel e devolve o usuário, ou o status e a mensagem da falha.
+func authenticateLaravelUser(r *http.Request) (LaravelUser, int, string) {
+	if os.Getenv(utils.ENV) == utils.ENV_DEVELOPMENT {
+		// Usuário fictício para ambiente de desenvolvimento
+		return LaravelUser{
+			ID:    2,
+			Name:  "Dev User",
+			Email: "devuser@example.com",
+		}, http.StatusOK, ""
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			utils.SendResponse(w, http.StatusUnauthorized, "Token inválido ou usuário não autenticado", nil, 0)
-			return
-		}
+	token := laravelToken(r)
+	if token == "" {
+		return LaravelUser{}, http.StatusUnauthorized, "Token não informado"
+	}
 
-		user := LaravelUser{}
-		err = json.NewDecoder(resp.Body).Decode(&user)
-		if err != nil || user.ID == 0 || user.Name == "" || user.Email == "" {
-			utils.SendResponse(w, http.StatusUnauthorized, "Usuário inválido retornado pela autenticação", nil, 0)
-			return
-		}
+	laravelURL := os.Getenv("LARAVEL_API_URL")
+	if laravelURL == "" {
+		laravelURL = "http://localhost:8000"
+	}
+	userURL := fmt.Sprintf("%s/api/user", laravelURL)
 
-		ctx := context.WithValue(r.Context(), UserContextKey, user)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	req, err := http.NewRequest("GET", userURL, nil)
+	if err != nil {
+		return LaravelUser{}, http.StatusInternalServerError, "Erro ao criar requisição de autenticação"
+	}
+	req.Header.Set("Authorization", token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return LaravelUser{}, http.StatusBadGateway, "Erro ao conectar na API de autenticação"
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return LaravelUser{}, http.StatusUnauthorized, "Token inválido ou usuário não autenticado"
+	}
+
+	user := LaravelUser{}
+	err = json.NewDecoder(resp.Body).Decode(&user)
+	if err != nil || user.ID == 0 || user.Name == "" || user.Email == "" {
+		return LaravelUser{}, http.StatusUnauthorized, "Usuário inválido retornado pela autenticação"
+	}
+	return user, http.StatusOK, ""
 }
@@ -7,10 +7,25 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+const (
+	BUDGETS_APPROVAL_STATUS_PENDING  = "pending"
+	BUDGETS_APPROVAL_STATUS_APPROVED = "approved"
+	BUDGETS_APPROVAL_STATUS_REJECTED = "rejected"
+
+	BUDGETS_DISCOUNT_TYPE_VALUE      = "value"
+	BUDGETS_DISCOUNT_TYPE_PERCENTAGE = "percentage"
+)
+
+// Delivery é a entrega escolhida no orçamento. Quando vem de uma cotação, guarda também o serviço,
+// a transportadora e o provedor de frete que fez a cotação.
 type Delivery struct {
-	Option   string  `json:"option" bson:"option"`
-	Deadline uint    `json:"deadline" bson:"deadline"`
-	Price    float64 `json:"price" bson:"price"`
+	Option      string    `json:"option" bson:"option"`
+	Deadline    uint      `json:"deadline" bson:"deadline"`
+	Price       float64   `json:"price" bson:"price"`
+	ServiceCode string    `json:"service_code,omitempty" bson:"service_code,omitempty"`
+	Carrier     string    `json:"carrier,omitempty" bson:"carrier,omitempty"`
+	Provider    string    `json:"provider,omitempty" bson:"provider,omitempty"`
+	QuotedAt    time.Time `json:"quoted_at,omitzero" bson:"quoted_at,omitempty"`
 }
 
 type EarlyMode struct {
@@ -34,33 +49,70 @@ type Billing struct {
 	Installments []Installments `json:"installments" bson:"installments"`
 }
 
+// Address é o endereço de entrega do orçamento. Logradouro, bairro, cidade e UF são preenchidos pela
+// consulta do CEP; Details guarda o endereço como o vendedor escreveu (número, complemento etc.).
 type Address struct {
-	CEP     string `json:"cep" bson:"cep"`
-	Details string `json:"details" bson:"details"`
+	CEP          string `json:"cep" bson:"cep"`
+	Details      string `json:"details" bson:"details"`
+	Street       string `json:"street,omitempty" bson:"street,omitempty"`
+	Neighborhood string `json:"neighborhood,omitempty" bson:"neighborhood,omitempty"`
+	City         string `json:"city,omitempty" bson:"city,omitempty"`
+	State        string `json:"state,omitempty" bson:"state,omitempty"`
+}
+
+// ProposedAddress é um endereço de entrega sugerido (ex.: localização enviada no WhatsApp) aguardando confirmação do vendedor.
+type ProposedAddress struct {
+	CEP        string    `json:"cep" bson:"cep"`
+	Details    string    `json:"details" bson:"details"`
+	Source     string    `json:"source" bson:"source"`
+	MessageID  string    `json:"message_id,omitempty" bson:"message_id,omitempty"`
+	Latitude   float64   `json:"latitude,omitempty" bson:"latitude,omitempty"`
+	Longitude  float64   `json:"longitude,omitempty" bson:"longitude,omitempty"`
+	ProposedAt time.Time `json:"proposed_at" bson:"proposed_at"`
+}
+
+// BudgetPDF aponta para o último PDF gerado do orçamento, guardado no GridFS.
+type BudgetPDF struct {
+	FileID      bson.ObjectID `json:"file_id" bson:"file_id"`
+	FileName    string        `json:"file_name" bson:"file_name"`
+	Size        int           `json:"size" bson:"size"`
+	GeneratedBy bson.ObjectID `json:"generated_by,omitzero" bson:"generated_by,omitempty"`
+	GeneratedAt time.Time     `json:"generated_at" bson:"generated_at"`
 }
 
 type Budget struct {
-	ID                 bson.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	OldID              uint64        `json:"old_id" bson:"old_id"`
-	CreatedBy          bson.ObjectID `json:"created_by" bson:"created_by"`
-	Seller             bson.ObjectID `json:"seller" bson:"seller"`
-	RelatedLead        bson.ObjectID `json:"related_lead" bson:"related_lead"`
-	RelatedClient      bson.ObjectID `json:"related_client" bson:"related_client"`
-	OldProductsList    string        `json:"old_products_list" bson:"old_products_list"`
-	Address            Address       `json:"address" bson:"address"`
-	Delivery           Delivery      `json:"delivery" bson:"delivery"`
-	EarlyMode          EarlyMode     `json:"early_mode" bson:"early_mode"`
-	Discount           Discount      `json:"discount" bson:"discount"`
-	OldGifts           string        `json:"old_gifts" bson:"old_gifts"`
-	ProductionDeadline uint          `json:"production_deadline" bson:"production_deadline"`
-	PaymentMethod      string        `json:"payment_method" bson:"payment_method"`
-	Billing            Billing       `json:"billing" bson:"billing"`
-	Trello_uri         string        `json:"trello_uri" bson:"trello_uri"`
-	Notes              string        `json:"notes" bson:"notes"`
-	DeliveryForecast   time.Time     `json:"delivery_forecast" bson:"delivery_forecast"`
-	CreatedAt          time.Time     `json:"created_at" bson:"created_at,omitempty"`
-	UpdatedAt          time.Time     `json:"updated_at" bson:"updated_at,omitempty"`
-	Approved           bool          `json:"approved" bson:"approved"`
+	ID                 bson.ObjectID    `json:"id,omitempty" bson:"_id,omitempty"`
+	OldID              uint64           `json:"old_id" bson:"old_id"`
+	CreatedBy          bson.ObjectID    `json:"created_by" bson:"created_by"`
+	Seller             bson.ObjectID    `json:"seller" bson:"seller"`
+	RelatedLead        bson.ObjectID    `json:"related_lead" bson:"related_lead"`
+	RelatedClient      bson.ObjectID    `json:"related_client" bson:"related_client"`
+	OldProductsList    string           `json:"old_products_list" bson:"old_products_list"`
+	Items              []LineItem       `json:"items,omitempty" bson:"items"`
+	Totals             *BudgetTotals    `json:"totals,omitempty" bson:"totals,omitempty"`
+	Address            Address          `json:"address" bson:"address"`
+	Delivery           Delivery         `json:"delivery" bson:"delivery"`
+	EarlyMode          EarlyMode        `json:"early_mode" bson:"early_mode"`
+	Discount           Discount         `json:"discount" bson:"discount"`
+	OldGifts           string           `json:"old_gifts" bson:"old_gifts"`
+	ProductionDeadline uint             `json:"production_deadline" bson:"production_deadline"`
+	PaymentMethod      string           `json:"payment_method" bson:"payment_method"`
+	Billing            Billing          `json:"billing" bson:"billing"`
+	Trello_uri         string           `json:"trello_uri" bson:"trello_uri"`
+	Notes              string           `json:"notes" bson:"notes"`
+	DeliveryForecast   time.Time        `json:"delivery_forecast" bson:"delivery_forecast"`
+	CreatedAt          time.Time        `json:"created_at" bson:"created_at,omitempty"`
+	UpdatedAt          time.Time        `json:"updated_at" bson:"updated_at,omitempty"`
+	Approved           bool             `json:"approved" bson:"approved"`
+	ProposedAddress    *ProposedAddress `json:"proposed_address,omitempty" bson:"proposed_address,omitempty"`
+	ApprovalStatus     string           `json:"approval_status,omitempty" bson:"approval_status,omitempty"`
+	ApprovedBy         bson.ObjectID    `json:"approved_by,omitzero" bson:"approved_by,omitempty"`
+	ApprovedAt         time.Time        `json:"approved_at,omitzero" bson:"approved_at,omitempty"`
+	RejectedBy         bson.ObjectID    `json:"rejected_by,omitzero" bson:"rejected_by,omitempty"`
+	RejectedAt         time.Time        `json:"rejected_at,omitzero" bson:"rejected_at,omitempty"`
+	RejectionReason    string           `json:"rejection_reason,omitempty" bson:"rejection_reason,omitempty"`
+	PDF                *BudgetPDF       `json:"pdf,omitempty" bson:"pdf,omitempty"`
+	RelatedOrder       bson.ObjectID    `json:"related_order,omitzero" bson:"related_order,omitempty"`
 }
 
 type BudgetOld struct {
@@ -0,0 +1,30 @@
+package schemas
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Export é uma exportação em segundo plano: os filtros usados, o progresso e o arquivo gerado no GridFS,
+// disponível para download até ExpiresAt.
+type Export struct {
+	ID        bson.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Entity    string        `json:"entity" bson:"entity"`
+	Format    string        `json:"format" bson:"format"`
+	Columns   []string      `json:"columns" bson:"columns"`
+	Query     string        `json:"query,omitempty" bson:"query,omitempty"`
+	Status    string        `json:"status" bson:"status"`
+	TotalRows int64         `json:"total_rows" bson:"total_rows"`
+	Rows      int64         `json:"rows" bson:"rows"`
+	FileID    bson.ObjectID `json:"file_id,omitzero" bson:"file_id,omitempty"`
+	FileName  string        `json:"file_name,omitempty" bson:"file_name,omitempty"`
+	Error     string        `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedBy string        `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	// Só quem criou a exportação e os administradores podem acompanhá-la e baixar o arquivo
+	CreatedByID bson.ObjectID `json:"created_by_id,omitzero" bson:"created_by_id,omitempty"`
+	CreatedAt   time.Time     `json:"created_at" bson:"created_at"`
+	// Preenchidos quando a exportação termina
+	FinishedAt time.Time `json:"finished_at,omitzero" bson:"finished_at,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at,omitzero" bson:"expires_at,omitempty"`
+}
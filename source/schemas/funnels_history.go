@@ -10,6 +10,7 @@ type FunnelsHistory struct {
 	ID              bson.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
 	RelatedUserName string        `json:"related_user,omitempty" bson:"related_user,omitempty"`
 	RelatedFunnel   bson.ObjectID `json:"related_funnel,omitempty" bson:"related_funnel,omitempty"`
+	RelatedLead     bson.ObjectID `json:"related_lead,omitzero" bson:"related_lead,omitempty"`
 	Action          string        `json:"action,omitempty" bson:"action,omitempty"`
 	CreatedAt       time.Time     `json:"created_at" bson:"created_at,omitempty"`
 }
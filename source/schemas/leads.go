@@ -7,23 +7,70 @@ import (
 )
 
 type Lead struct {
-	ID             bson.ObjectID   `json:"id,omitempty" bson:"_id,omitempty"`
-	Name           string          `json:"name,omitempty" bson:"name,omitempty"`
-	Nickname       string          `json:"nickname,omitempty" bson:"nickname,omitempty"`
-	Phone          string          `json:"phone,omitempty" bson:"phone,omitempty"`
-	Type           string          `json:"type,omitempty" bson:"type,omitempty"`
-	Segment        string          `json:"segment,omitempty" bson:"segment,omitempty"`
-	CreatedAt      time.Time       `json:"created_at" bson:"created_at,omitempty"`
-	UpdatedAt      time.Time       `json:"updated_at" bson:"updated_at,omitempty"`
-	Status         string          `json:"status,omitempty" bson:"status,omitempty"`
-	Source         string          `json:"source,omitempty" bson:"source,omitempty"`
-	PlatformId     string          `json:"platform_id,omitempty" bson:"platform_id,omitempty"`
-	RelatedBudgets []bson.ObjectID `json:"related_budgets,omitempty" bson:"related_budgets,omitempty"`
-	RelatedOrders  []bson.ObjectID `json:"related_orders,omitempty" bson:"related_orders,omitempty"`
-	RelatedClient  bson.ObjectID   `json:"related_client,omitempty" bson:"related_client,omitempty"`
-	Rating         string          `json:"rating,omitempty" bson:"rating,omitempty"`
-	Notes          string          `json:"notes,omitempty" bson:"notes,omitempty"`
-	Responsible    bson.ObjectID   `json:"responsible,omitempty" bson:"responsible,omitempty"`
-	UnlinkClient   bool            `json:"unlink_client,omitempty" bson:"-"`
-	Blocked        bool            `json:"blocked,omitempty" bson:"blocked,omitempty"`
+	ID       bson.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name     string        `json:"name,omitempty" bson:"name,omitempty"`
+	Nickname string        `json:"nickname,omitempty" bson:"nickname,omitempty"`
+	Phone    string        `json:"phone,omitempty" bson:"phone,omitempty"`
+	// Outros telefones do lead, herdados de leads mesclados a ele
+	AlternatePhones []string            `json:"alternate_phones,omitempty" bson:"alternate_phones,omitempty"`
+	Type            string              `json:"type,omitempty" bson:"type,omitempty"`
+	Segment         string              `json:"segment,omitempty" bson:"segment,omitempty"`
+	CreatedAt       time.Time           `json:"created_at" bson:"created_at,omitempty"`
+	UpdatedAt       time.Time           `json:"updated_at" bson:"updated_at,omitempty"`
+	Status          string              `json:"status,omitempty" bson:"status,omitempty"`
+	Source          string              `json:"source,omitempty" bson:"source,omitempty"`
+	PlatformId      string              `json:"platform_id,omitempty" bson:"platform_id,omitempty"`
+	RelatedBudgets  []bson.ObjectID     `json:"related_budgets,omitempty" bson:"related_budgets,omitempty"`
+	RelatedOrders   []bson.ObjectID     `json:"related_orders,omitempty" bson:"related_orders,omitempty"`
+	RelatedClient   bson.ObjectID       `json:"related_client,omitempty" bson:"related_client,omitempty"`
+	Rating          string              `json:"rating,omitempty" bson:"rating,omitempty"`
+	Notes           string              `json:"notes,omitempty" bson:"notes,omitempty"`
+	Responsible     bson.ObjectID       `json:"responsible,omitempty" bson:"responsible,omitempty"`
+	UnlinkClient    bool                `json:"unlink_client,omitempty" bson:"-"`
+	Blocked         bool                `json:"blocked,omitempty" bson:"blocked,omitempty"`
+	LastLocation    *LeadLocation       `json:"last_location,omitempty" bson:"last_location,omitempty"`
+	Score           *LeadScore          `json:"score,omitempty" bson:"score,omitempty"`
+	Tier            *LeadTierAssignment `json:"tier,omitempty" bson:"tier,omitempty"`
+}
+
+// LeadLocation guarda a última localização enviada pelo lead no WhatsApp, já geocodificada.
+type LeadLocation struct {
+	Latitude     float64   `json:"latitude" bson:"latitude"`
+	Longitude    float64   `json:"longitude" bson:"longitude"`
+	Name         string    `json:"name,omitempty" bson:"name,omitempty"`
+	CEP          string    `json:"cep,omitempty" bson:"cep,omitempty"`
+	Street       string    `json:"street,omitempty" bson:"street,omitempty"`
+	Number       string    `json:"number,omitempty" bson:"number,omitempty"`
+	Neighborhood string    `json:"neighborhood,omitempty" bson:"neighborhood,omitempty"`
+	City         string    `json:"city,omitempty" bson:"city,omitempty"`
+	State        string    `json:"state,omitempty" bson:"state,omitempty"`
+	Formatted    string    `json:"formatted,omitempty" bson:"formatted,omitempty"`
+	MessageID    string    `json:"message_id,omitempty" bson:"message_id,omitempty"`
+	ReceivedAt   time.Time `json:"received_at" bson:"received_at"`
+}
+
+// LeadMerge registra a mesclagem de leads duplicados: os leads de origem como estavam antes de serem
+// removidos e quantos registros de cada coleção passaram a apontar para o lead mantido.
+type LeadMerge struct {
+	ID        bson.ObjectID    `json:"id,omitempty" bson:"_id,omitempty"`
+	Target    bson.ObjectID    `json:"target" bson:"target"`
+	Sources   []Lead           `json:"sources" bson:"sources"`
+	Moved     map[string]int64 `json:"moved" bson:"moved"`
+	MergedBy  string           `json:"merged_by,omitempty" bson:"merged_by,omitempty"`
+	CreatedAt time.Time        `json:"created_at" bson:"created_at"`
+}
+
+// LeadHistory registra uma alteração feita no cadastro do lead, com o valor anterior e o novo de cada campo.
+type LeadHistory struct {
+	ID        bson.ObjectID     `json:"id,omitempty" bson:"_id,omitempty"`
+	LeadID    bson.ObjectID     `json:"lead_id" bson:"lead_id"`
+	UserName  string            `json:"user,omitempty" bson:"user,omitempty"`
+	Changes   []LeadFieldChange `json:"changes" bson:"changes"`
+	CreatedAt time.Time         `json:"created_at" bson:"created_at"`
+}
+
+type LeadFieldChange struct {
+	Field string `json:"field" bson:"field"`
+	From  any    `json:"from,omitempty" bson:"from,omitempty"`
+	To    any    `json:"to,omitempty" bson:"to,omitempty"`
 }
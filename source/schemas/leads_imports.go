@@ -0,0 +1,41 @@
+package schemas
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// LeadImport acompanha a importação de uma planilha de leads: o mapeamento das colunas, o progresso e o
+// relatório das linhas que não viraram lead.
+type LeadImport struct {
+	ID       bson.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	FileName string        `json:"file_name" bson:"file_name"`
+	// Planilha enviada, guardada no GridFS para retomar a importação se o servidor reiniciar
+	FileID bson.ObjectID `json:"file_id,omitzero" bson:"file_id,omitempty"`
+	// Campo do lead (name, phone, segment, source, responsible) -> coluna da planilha
+	Mapping       map[string]string    `json:"mapping" bson:"mapping"`
+	DefaultSource string               `json:"default_source,omitempty" bson:"default_source,omitempty"`
+	Status        string               `json:"status" bson:"status"`
+	TotalRows     int                  `json:"total_rows" bson:"total_rows"`
+	ProcessedRows int                  `json:"processed_rows" bson:"processed_rows"`
+	Created       int                  `json:"created" bson:"created"`
+	Duplicates    int                  `json:"duplicates" bson:"duplicates"`
+	Failed        int                  `json:"failed" bson:"failed"`
+	Errors        []LeadImportRowError `json:"errors" bson:"errors"`
+	Error         string               `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedBy     string               `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedAt     time.Time            `json:"created_at" bson:"created_at"`
+	StartedAt     time.Time            `json:"started_at,omitzero" bson:"started_at,omitempty"`
+	FinishedAt    time.Time            `json:"finished_at,omitzero" bson:"finished_at,omitempty"`
+}
+
+// LeadImportRowError é uma linha da planilha que não virou lead. Row é o número da linha na planilha,
+// contando o cabeçalho; nas duplicadas, LeadID é o lead que já tem o telefone.
+type LeadImportRowError struct {
+	Row     int           `json:"row" bson:"row"`
+	Field   string        `json:"field,omitempty" bson:"field,omitempty"`
+	Value   string        `json:"value,omitempty" bson:"value,omitempty"`
+	Message string        `json:"message" bson:"message"`
+	LeadID  bson.ObjectID `json:"lead_id,omitzero" bson:"lead_id,omitempty"`
+}
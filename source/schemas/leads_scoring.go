@@ -0,0 +1,57 @@
+package schemas
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// LeadScore é a pontuação do lead, de 0 a 100, com a nota de cada componente (de 0 a 1) usada no cálculo.
+type LeadScore struct {
+	Value      float64             `json:"value" bson:"value"`
+	Components LeadScoreComponents `json:"components" bson:"components"`
+	Signals    LeadScoreSignals    `json:"signals" bson:"signals"`
+	ComputedAt time.Time           `json:"computed_at" bson:"computed_at"`
+}
+
+type LeadScoreComponents struct {
+	Tier       float64 `json:"tier" bson:"tier"`
+	Engagement float64 `json:"engagement" bson:"engagement"`
+	Conversion float64 `json:"conversion" bson:"conversion"`
+	Recency    float64 `json:"recency" bson:"recency"`
+}
+
+// LeadScoreSignals são os dados do lead que alimentam a pontuação, guardados para explicar o resultado.
+type LeadScoreSignals struct {
+	Tier             string    `json:"tier,omitempty" bson:"tier,omitempty"`
+	MessagesReceived int       `json:"messages_received" bson:"messages_received"`
+	MessagesSent     int       `json:"messages_sent" bson:"messages_sent"`
+	AvgReplyMinutes  float64   `json:"avg_reply_minutes,omitempty" bson:"avg_reply_minutes,omitempty"`
+	Budgets          int       `json:"budgets" bson:"budgets"`
+	ConvertedBudgets int       `json:"converted_budgets" bson:"converted_budgets"`
+	LastActivityAt   time.Time `json:"last_activity_at,omitzero" bson:"last_activity_at,omitempty"`
+}
+
+// LeadScoringModel configura a pontuação dos leads. Os pesos são relativos entre si; os demais campos
+// definem o que vale nota máxima em cada componente.
+type LeadScoringModel struct {
+	ID      bson.ObjectID      `json:"id,omitempty" bson:"_id,omitempty"`
+	Weights LeadScoringWeights `json:"weights" bson:"weights"`
+	// Mensagens recebidas do lead na janela de engajamento que valem nota máxima
+	TargetMessages int `json:"target_messages" bson:"target_messages"`
+	// Tempo médio de resposta do lead, em minutos, que vale nota máxima
+	TargetReplyMinutes float64 `json:"target_reply_minutes" bson:"target_reply_minutes"`
+	// Janela, em dias, das mensagens consideradas no engajamento
+	EngagementWindowDays int `json:"engagement_window_days" bson:"engagement_window_days"`
+	// Dias sem atividade para a nota de recência cair pela metade
+	RecencyHalfLifeDays float64   `json:"recency_half_life_days" bson:"recency_half_life_days"`
+	UpdatedBy           string    `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+	UpdatedAt           time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+type LeadScoringWeights struct {
+	Tier       float64 `json:"tier" bson:"tier"`
+	Engagement float64 `json:"engagement" bson:"engagement"`
+	Conversion float64 `json:"conversion" bson:"conversion"`
+	Recency    float64 `json:"recency" bson:"recency"`
+}
@@ -16,3 +16,29 @@ type LeadTier struct {
 	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time     `json:"updated_at" bson:"updated_at"`
 }
+
+// LeadTierAssignment é o tier gravado no lead pelo recálculo em segundo plano, com os valores dos pedidos
+// usados. Sem TierID quando os pedidos do lead não se encaixam em nenhum tier.
+type LeadTierAssignment struct {
+	TierID        bson.ObjectID `json:"tier_id,omitzero" bson:"tier_id,omitempty"`
+	Label         string        `json:"label" bson:"label"`
+	Icon          string        `json:"icon,omitempty" bson:"icon,omitempty"`
+	SumType       string        `json:"sum_type,omitempty" bson:"sum_type,omitempty"`
+	MinValue      float64       `json:"min_value" bson:"min_value"`
+	MaxValue      float64       `json:"max_value" bson:"max_value"`
+	TotalValue    float64       `json:"total_value" bson:"total_value"`
+	MaxOrderValue float64       `json:"max_order_value" bson:"max_order_value"`
+	SkippedOrders int           `json:"skipped_orders,omitempty" bson:"skipped_orders,omitempty"`
+	ComputedAt    time.Time     `json:"computed_at" bson:"computed_at"`
+}
+
+// LeadTierChange registra a troca de tier de um lead para os relatórios de upgrade e downgrade.
+type LeadTierChange struct {
+	ID        bson.ObjectID       `json:"id,omitempty" bson:"_id,omitempty"`
+	LeadID    bson.ObjectID       `json:"lead_id" bson:"lead_id"`
+	From      *LeadTierAssignment `json:"from" bson:"from"`
+	To        *LeadTierAssignment `json:"to" bson:"to"`
+	Direction string              `json:"direction" bson:"direction"`
+	Reason    string              `json:"reason" bson:"reason"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,33 @@
+package schemas
+
+import "go.mongodb.org/mongo-driver/v2/bson"
+
+// LineItemCustomization guarda a personalização de um item (ex.: uniforme com nome e número).
+type LineItemCustomization struct {
+	Size   string `json:"size,omitempty" bson:"size,omitempty"`
+	Color  string `json:"color,omitempty" bson:"color,omitempty"`
+	Name   string `json:"name,omitempty" bson:"name,omitempty"`
+	Number string `json:"number,omitempty" bson:"number,omitempty"`
+	Notes  string `json:"notes,omitempty" bson:"notes,omitempty"`
+}
+
+// LineItem é um item tipado de orçamento ou pedido, substituindo as listas legadas em texto.
+// UnitPrice nulo indica que o preço deve vir da tabela do catálogo; zero é um preço informado.
+type LineItem struct {
+	ProductID     bson.ObjectID          `json:"product_id,omitzero" bson:"product_id,omitempty"`
+	SKU           string                 `json:"sku,omitempty" bson:"sku,omitempty"`
+	Description   string                 `json:"description" bson:"description"`
+	Quantity      float64                `json:"quantity" bson:"quantity"`
+	UnitPrice     *float64               `json:"unit_price" bson:"unit_price"`
+	Customization *LineItemCustomization `json:"customization,omitempty" bson:"customization,omitempty"`
+	Total         float64                `json:"total" bson:"total"`
+}
+
+// BudgetTotals é calculado no servidor a partir dos itens, desconto, antecipação e entrega.
+type BudgetTotals struct {
+	Subtotal float64 `json:"subtotal" bson:"subtotal"`
+	Discount float64 `json:"discount" bson:"discount"`
+	EarlyTax float64 `json:"early_tax" bson:"early_tax"`
+	Shipping float64 `json:"shipping" bson:"shipping"`
+	Total    float64 `json:"total" bson:"total"`
+}
@@ -55,6 +55,54 @@ const (
 	TypeReposicao    OrderType = "Reposição"
 )
 
+// OrderTransition registra uma mudança de etapa/status do pedido. Duration é o tempo, em segundos,
+// que o pedido ficou no estado anterior.
+type OrderTransition struct {
+	FromStage  OrderStage    `json:"from_stage,omitempty" bson:"from_stage,omitempty"`
+	FromStatus OrderStatus   `json:"from_status,omitempty" bson:"from_status,omitempty"`
+	ToStage    OrderStage    `json:"to_stage" bson:"to_stage"`
+	ToStatus   OrderStatus   `json:"to_status" bson:"to_status"`
+	UserID     bson.ObjectID `json:"user_id,omitzero" bson:"user_id,omitempty"`
+	Reason     string        `json:"reason,omitempty" bson:"reason,omitempty"`
+	Forced     bool          `json:"forced,omitempty" bson:"forced,omitempty"`
+	Duration   int64         `json:"duration" bson:"duration"`
+	At         time.Time     `json:"at" bson:"at"`
+}
+
+// OrderInvoice guarda os dados da nota fiscal emitida no Tiny para o pedido.
+type OrderInvoice struct {
+	ID        string    `json:"id" bson:"id"`
+	Number    string    `json:"number,omitempty" bson:"number,omitempty"`
+	Series    string    `json:"series,omitempty" bson:"series,omitempty"`
+	AccessKey string    `json:"access_key,omitempty" bson:"access_key,omitempty"`
+	Total     float64   `json:"total,omitempty" bson:"total,omitempty"`
+	IssuedAt  time.Time `json:"issued_at,omitzero" bson:"issued_at,omitempty"`
+}
+
+// Status normalizados dos eventos de rastreio, independentes da transportadora.
+const (
+	TRACKING_STATUS_POSTED           = "posted"
+	TRACKING_STATUS_IN_TRANSIT       = "in_transit"
+	TRACKING_STATUS_OUT_FOR_DELIVERY = "out_for_delivery"
+	TRACKING_STATUS_DELIVERED        = "delivered"
+	TRACKING_STATUS_EXCEPTION        = "exception"
+	TRACKING_STATUS_RETURNED         = "returned"
+)
+
+// Risco de o pedido não cumprir a data prevista, calculado pela etapa atual e os dias úteis restantes.
+const (
+	ORDER_DEADLINE_RISK_AT_RISK = "at_risk"
+	ORDER_DEADLINE_RISK_LATE    = "late"
+)
+
+// OrderTrackingEvent é um evento do rastreio do pedido na transportadora.
+type OrderTrackingEvent struct {
+	Status      string    `json:"status" bson:"status"`
+	Description string    `json:"description" bson:"description"`
+	Location    string    `json:"location,omitempty" bson:"location,omitempty"`
+	At          time.Time `json:"at" bson:"at"`
+}
+
 type TinyOrder struct {
 	ID     string `json:"id,omitempty" bson:"id,omitempty"`
 	Number string `json:"number,omitempty" bson:"number,omitempty"`
@@ -154,23 +202,38 @@ type TinyParcelaDetail struct {
 }
 
 type Order struct {
-	ID                 bson.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
-	OldID              uint64        `json:"old_id" bson:"old_id"`
-	CreatedBy          bson.ObjectID `json:"created_by,omitempty" bson:"created_by,omitempty"`
-	RelatedSeller      bson.ObjectID `json:"related_seller,omitempty" bson:"related_seller,omitempty"`
-	RelatedDesigner    bson.ObjectID `json:"related_designer,omitempty" bson:"related_designer,omitempty"`
-	TrackingCode       string        `json:"tracking_code,omitempty" bson:"tracking_code,omitempty"`
-	Status             OrderStatus   `json:"status,omitempty" bson:"status,omitempty"`
-	Stage              OrderStage    `json:"stage,omitempty" bson:"stage,omitempty"`
-	Type               OrderType     `json:"type,omitempty" bson:"type,omitempty"`
-	UrlTrello          string        `json:"url_trello,omitempty" bson:"url_trello,omitempty"`
-	ProductsListLegacy string        `json:"products_list_legacy,omitempty" bson:"products_list_legacy,omitempty"`
-	RelatedBudget      bson.ObjectID `json:"related_budget,omitempty" bson:"related_budget,omitempty"`
-	ExpectedDate       time.Time     `json:"expected_date,omitempty" bson:"expected_date,omitempty"`
-	CustomProperties   any           `json:"custom_properties,omitempty" bson:"custom_properties,omitempty"`
-	Tiny               TinyOrder     `json:"tiny,omitempty" bson:"tiny,omitempty"`
-	Notes              string        `json:"notes,omitempty" bson:"notes,omitempty"`
-	CreatedAt          time.Time     `json:"created_at" bson:"created_at"`
-	UpdatedAt          time.Time     `json:"updated_at" bson:"updated_at"`
-	PaymentDate        *time.Time    `json:"payment_date,omitempty" bson:"payment_date,omitempty"`
+	ID                 bson.ObjectID        `json:"_id,omitempty" bson:"_id,omitempty"`
+	OldID              uint64               `json:"old_id" bson:"old_id"`
+	CreatedBy          bson.ObjectID        `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	RelatedSeller      bson.ObjectID        `json:"related_seller,omitempty" bson:"related_seller,omitempty"`
+	RelatedDesigner    bson.ObjectID        `json:"related_designer,omitempty" bson:"related_designer,omitempty"`
+	TrackingCode       string               `json:"tracking_code,omitempty" bson:"tracking_code,omitempty"`
+	Status             OrderStatus          `json:"status,omitempty" bson:"status,omitempty"`
+	Stage              OrderStage           `json:"stage,omitempty" bson:"stage,omitempty"`
+	Type               OrderType            `json:"type,omitempty" bson:"type,omitempty"`
+	UrlTrello          string               `json:"url_trello,omitempty" bson:"url_trello,omitempty"`
+	ProductsListLegacy string               `json:"products_list_legacy,omitempty" bson:"products_list_legacy,omitempty"`
+	Items              []LineItem           `json:"items,omitempty" bson:"items,omitempty"`
+	RelatedBudget      bson.ObjectID        `json:"related_budget,omitempty" bson:"related_budget,omitempty"`
+	ExpectedDate       time.Time            `json:"expected_date,omitempty" bson:"expected_date,omitempty"`
+	CustomProperties   any                  `json:"custom_properties,omitempty" bson:"custom_properties,omitempty"`
+	Tiny               TinyOrder            `json:"tiny,omitempty" bson:"tiny,omitempty"`
+	Notes              string               `json:"notes,omitempty" bson:"notes,omitempty"`
+	CreatedAt          time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt          time.Time            `json:"updated_at" bson:"updated_at"`
+	PaymentDate        *time.Time           `json:"payment_date,omitempty" bson:"payment_date,omitempty"`
+	StageEnteredAt     time.Time            `json:"stage_entered_at,omitzero" bson:"stage_entered_at,omitempty"`
+	StatusChangedAt    time.Time            `json:"status_changed_at,omitzero" bson:"status_changed_at,omitempty"`
+	History            []OrderTransition    `json:"history,omitempty" bson:"history,omitempty"`
+	Invoice            *OrderInvoice        `json:"invoice,omitempty" bson:"invoice,omitempty"`
+	TinySyncedAt       time.Time            `json:"tiny_synced_at,omitzero" bson:"tiny_synced_at,omitempty"`
+	TinySyncError      string               `json:"tiny_sync_error,omitempty" bson:"tiny_sync_error,omitempty"`
+	TrackingStatus     string               `json:"tracking_status,omitempty" bson:"tracking_status,omitempty"`
+	TrackingEvents     []OrderTrackingEvent `json:"tracking_events,omitempty" bson:"tracking_events,omitempty"`
+	TrackingCheckedAt  time.Time            `json:"tracking_checked_at,omitzero" bson:"tracking_checked_at,omitempty"`
+	TrackingError      string               `json:"tracking_error,omitempty" bson:"tracking_error,omitempty"`
+	TrackingNotified   []string             `json:"tracking_notified,omitempty" bson:"tracking_notified,omitempty"`
+	DeadlineRisk       string               `json:"deadline_risk,omitempty" bson:"deadline_risk,omitempty"`
+	DeadlineRiskSince  time.Time            `json:"deadline_risk_since,omitzero" bson:"deadline_risk_since,omitempty"`
+	DeadlineAlerts     []string             `json:"deadline_alerts,omitempty" bson:"deadline_alerts,omitempty"`
 }
@@ -0,0 +1,43 @@
+package schemas
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ProductPriceBreak define o preço unitário a partir de uma quantidade mínima.
+type ProductPriceBreak struct {
+	MinQuantity float64 `json:"min_quantity" bson:"min_quantity"`
+	UnitPrice   float64 `json:"unit_price" bson:"unit_price"`
+}
+
+// ProductDimensions são as medidas da unidade embalada, usadas na cotação de frete (cm e kg).
+type ProductDimensions struct {
+	Height float64 `json:"height" bson:"height"`
+	Width  float64 `json:"width" bson:"width"`
+	Length float64 `json:"length" bson:"length"`
+	Weight float64 `json:"weight" bson:"weight"`
+}
+
+// ProductPacking é a regra de embalagem para o frete: as unidades vão em caixas de até UnitsPerBox, com as
+// medidas da caixa e, em Box.Weight, o peso da caixa vazia. O peso da unidade continua em Dimensions.
+type ProductPacking struct {
+	UnitsPerBox int               `json:"units_per_box" bson:"units_per_box"`
+	Box         ProductDimensions `json:"box" bson:"box"`
+}
+
+type Product struct {
+	ID          bson.ObjectID       `json:"id,omitempty" bson:"_id,omitempty"`
+	SKU         string              `json:"sku" bson:"sku"`
+	Name        string              `json:"name" bson:"name"`
+	Category    string              `json:"category,omitempty" bson:"category,omitempty"`
+	BasePrice   float64             `json:"base_price" bson:"base_price"`
+	PriceBreaks []ProductPriceBreak `json:"price_breaks,omitempty" bson:"price_breaks,omitempty"`
+	Dimensions  ProductDimensions   `json:"dimensions" bson:"dimensions"`
+	Packing     *ProductPacking     `json:"packing,omitempty" bson:"packing,omitempty"`
+	TinyID      string              `json:"tiny_id,omitempty" bson:"tiny_id,omitempty"`
+	Active      bool                `json:"active" bson:"active"`
+	CreatedAt   time.Time           `json:"created_at" bson:"created_at,omitempty"`
+	UpdatedAt   time.Time           `json:"updated_at" bson:"updated_at,omitempty"`
+}
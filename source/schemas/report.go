@@ -7,10 +7,11 @@ import (
 )
 
 const (
-	REPORT_TYPE_CLIENTS = "clients"
-	REPORT_TYPE_BUDGETS = "budgets"
-	REPORT_TYPE_LEADS   = "leads"
-	REPORT_TYPE_ORDERS  = "orders"
+	REPORT_TYPE_CLIENTS    = "clients"
+	REPORT_TYPE_BUDGETS    = "budgets"
+	REPORT_TYPE_LEADS      = "leads"
+	REPORT_TYPE_ORDERS     = "orders"
+	REPORT_TYPE_SPACE_DESK = "space_desk"
 
 	REPORT_GOAL_TYPE_MONTHLY = "monthly"
 	REPORT_GOAL_TYPE_YEARLY  = "yearly"
@@ -107,9 +107,22 @@ type SpaceDeskContactName struct {
 	FormattedName string `json:"formatted_name" bson:"formatted_name"`
 }
 
+type SpaceDeskContactEmail struct {
+	Email string `json:"email" bson:"email"`
+	Type  string `json:"type,omitempty" bson:"type,omitempty"`
+}
+
+type SpaceDeskContactOrg struct {
+	Company string `json:"company,omitempty" bson:"company,omitempty"`
+	Title   string `json:"title,omitempty" bson:"title,omitempty"`
+}
+
 type SpaceDeskContact struct {
 	Name   SpaceDeskContactName    `json:"name" bson:"name"`
 	Phones []SpaceDeskContactPhone `json:"phones" bson:"phones"`
+	Emails []SpaceDeskContactEmail `json:"emails,omitempty" bson:"emails,omitempty"`
+	Org    *SpaceDeskContactOrg    `json:"org,omitempty" bson:"org,omitempty"`
+	LeadID bson.ObjectID           `json:"lead_id,omitzero" bson:"lead_id,omitempty"`
 }
 
 type SpaceDeskList struct {
@@ -136,6 +149,42 @@ type SpaceDeskPoll struct {
 	SelectableOptionsCount int      `json:"selectable_options_count,omitempty" bson:"selectable_options_count,omitempty"`
 }
 
+// SpaceDeskInteractiveSend registra cada envio de enquete/lista para correlacionar as respostas.
+type SpaceDeskInteractiveSend struct {
+	ID             bson.ObjectID                `json:"id" bson:"_id,omitempty"`
+	MessageID      string                       `json:"message_id" bson:"message_id"`
+	ChatID         bson.ObjectID                `json:"chat_id" bson:"chat_id"`
+	LeadID         bson.ObjectID                `json:"lead_id,omitzero" bson:"lead_id,omitempty"`
+	Kind           string                       `json:"kind" bson:"kind"`
+	Title          string                       `json:"title" bson:"title"`
+	Options        []SpaceDeskInteractiveOption `json:"options" bson:"options"`
+	TotalResponses int                          `json:"total_responses" bson:"total_responses"`
+	LeadField      string                       `json:"lead_field,omitempty" bson:"lead_field,omitempty"`
+	LeadValues     map[string]string            `json:"lead_values,omitempty" bson:"lead_values,omitempty"`
+	CreatedBy      string                       `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedAt      time.Time                    `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time                    `json:"updated_at" bson:"updated_at"`
+}
+
+type SpaceDeskInteractiveOption struct {
+	ID          string `json:"id" bson:"id"`
+	Title       string `json:"title" bson:"title"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+	Count       int    `json:"count" bson:"count"`
+}
+
+// SpaceDeskInteractiveResponse é a resposta (atual) de um chat para um envio; responder de novo substitui a anterior.
+type SpaceDeskInteractiveResponse struct {
+	ID          bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	SendID      bson.ObjectID `json:"send_id" bson:"send_id"`
+	ChatID      bson.ObjectID `json:"chat_id" bson:"chat_id"`
+	LeadID      bson.ObjectID `json:"lead_id,omitzero" bson:"lead_id,omitempty"`
+	MessageID   string        `json:"message_id" bson:"message_id"`
+	OptionID    string        `json:"option_id" bson:"option_id"`
+	OptionTitle string        `json:"option_title" bson:"option_title"`
+	RespondedAt time.Time     `json:"responded_at" bson:"responded_at"`
+}
+
 type SpaceDeskMessageContext struct {
 	From string `json:"from,omitempty" bson:"from,omitempty"`
 	ID   string `json:"id,omitempty" bson:"id,omitempty"` // ID da mensagem original que foi respondida
@@ -197,10 +246,60 @@ type SpaceDeskChat struct {
 		Timestamp any    `bson:"timestamp" json:"timestamp"`
 		Value     string `bson:"value" json:"value"`
 	} `bson:"last_status_from_company_related_to_message_id" json:"last_status_from_company_related_to_message_id"`
-	LastMessage          string `bson:"last_message" json:"last_message"`
-	LastMessageTimestamp any    `bson:"last_message_timestamp" json:"last_message_timestamp"`
-	Closed               bool   `bson:"closed" json:"closed"`
-	Blocked              bool   `bson:"blocked,omitempty" json:"blocked,omitempty"`
+	LastMessage          string                  `bson:"last_message" json:"last_message"`
+	LastMessageTimestamp any                     `bson:"last_message_timestamp" json:"last_message_timestamp"`
+	Closed               bool                    `bson:"closed" json:"closed"`
+	Blocked              bool                    `bson:"blocked,omitempty" json:"blocked,omitempty"`
+	GroupID              string                  `bson:"group_id,omitempty" json:"group_id,omitempty"`
+	PendingTransfer      *SpaceDeskChatTransfer  `bson:"pending_transfer,omitempty" json:"pending_transfer,omitempty"`
+	Transfers            []SpaceDeskChatTransfer `bson:"transfers,omitempty" json:"transfers,omitempty"`
+}
+
+const (
+	SPACE_DESK_TRANSFER_MODE_REQUEST = "request"
+	SPACE_DESK_TRANSFER_MODE_FORCED  = "forced"
+
+	SPACE_DESK_TRANSFER_STATUS_PENDING   = "pending"
+	SPACE_DESK_TRANSFER_STATUS_ACCEPTED  = "accepted"
+	SPACE_DESK_TRANSFER_STATUS_REJECTED  = "rejected"
+	SPACE_DESK_TRANSFER_STATUS_CANCELLED = "cancelled"
+)
+
+// SpaceDeskChatTransfer é uma transferência de atendimento entre agentes/grupos, com a nota de passagem obrigatória.
+type SpaceDeskChatTransfer struct {
+	ID           bson.ObjectID `bson:"id" json:"id"`
+	FromUserID   string        `bson:"from_user_id" json:"from_user_id"`
+	FromGroupID  string        `bson:"from_group_id,omitempty" json:"from_group_id,omitempty"`
+	ToUserID     string        `bson:"to_user_id,omitempty" json:"to_user_id,omitempty"`
+	ToGroupID    string        `bson:"to_group_id,omitempty" json:"to_group_id,omitempty"`
+	Note         string        `bson:"note" json:"note"`
+	Mode         string        `bson:"mode" json:"mode"`
+	Status       string        `bson:"status" json:"status"`
+	RequestedBy  string        `bson:"requested_by" json:"requested_by"`
+	RequestedAt  time.Time     `bson:"requested_at" json:"requested_at"`
+	RespondedBy  string        `bson:"responded_by,omitempty" json:"responded_by,omitempty"`
+	RespondedAt  time.Time     `bson:"responded_at,omitempty" json:"responded_at,omitzero"`
+	ResponseNote string        `bson:"response_note,omitempty" json:"response_note,omitempty"`
+}
+
+const (
+	SPACE_DESK_SURVEY_CSAT = "csat"
+	SPACE_DESK_SURVEY_NPS  = "nps"
+)
+
+// SpaceDeskSurvey é a pesquisa de satisfação enviada ao encerrar um chat.
+type SpaceDeskSurvey struct {
+	ID         bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type       string        `bson:"type" json:"type"`
+	ChatID     bson.ObjectID `bson:"chat_id" json:"chat_id"`
+	LeadID     bson.ObjectID `bson:"lead_id,omitempty" json:"lead_id,omitzero"`
+	UserID     string        `bson:"user_id" json:"user_id"`
+	GroupID    string        `bson:"group_id,omitempty" json:"group_id,omitempty"`
+	MessageID  string        `bson:"message_id" json:"message_id"`
+	Answered   bool          `bson:"answered" json:"answered"`
+	Score      int           `bson:"score" json:"score"`
+	SentAt     time.Time     `bson:"sent_at" json:"sent_at"`
+	AnsweredAt time.Time     `bson:"answered_at,omitempty" json:"answered_at,omitzero"`
 }
 
 type Group struct {
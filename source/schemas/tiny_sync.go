@@ -0,0 +1,46 @@
+package schemas
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	TINY_SYNC_DIRECTION_IMPORT = "import"
+	TINY_SYNC_DIRECTION_EXPORT = "export"
+	TINY_SYNC_DIRECTION_BOTH   = "both"
+)
+
+// TinySyncConflict é uma divergência entre o pedido local e o Tiny que a sincronização não resolveu sozinha.
+type TinySyncConflict struct {
+	OrderID bson.ObjectID `json:"order_id,omitzero" bson:"order_id,omitempty"`
+	TinyID  string        `json:"tiny_id" bson:"tiny_id"`
+	Field   string        `json:"field" bson:"field"`
+	Local   string        `json:"local" bson:"local"`
+	Remote  string        `json:"remote" bson:"remote"`
+	Message string        `json:"message" bson:"message"`
+}
+
+type TinySyncError struct {
+	OrderID bson.ObjectID `json:"order_id,omitzero" bson:"order_id,omitempty"`
+	TinyID  string        `json:"tiny_id,omitempty" bson:"tiny_id,omitempty"`
+	Error   string        `json:"error" bson:"error"`
+}
+
+// TinySyncLog registra uma execução da sincronização de pedidos com o Tiny.
+type TinySyncLog struct {
+	ID         bson.ObjectID      `json:"id,omitempty" bson:"_id,omitempty"`
+	Direction  string             `json:"direction" bson:"direction"`
+	StartedBy  bson.ObjectID      `json:"started_by,omitzero" bson:"started_by,omitempty"`
+	Since      time.Time          `json:"since,omitzero" bson:"since,omitempty"`
+	Imported   int                `json:"imported" bson:"imported"`
+	Updated    int                `json:"updated" bson:"updated"`
+	Unchanged  int                `json:"unchanged" bson:"unchanged"`
+	Pushed     int                `json:"pushed" bson:"pushed"`
+	Conflicts  []TinySyncConflict `json:"conflicts" bson:"conflicts"`
+	Errors     []TinySyncError    `json:"errors" bson:"errors"`
+	Success    bool               `json:"success" bson:"success"`
+	StartedAt  time.Time          `json:"started_at" bson:"started_at"`
+	FinishedAt time.Time          `json:"finished_at" bson:"finished_at"`
+}
@@ -20,6 +20,18 @@ const (
 	SPACE_DESK_API_KEY_2         = "SPACE_DESK_API_KEY_2"
 	FRENET_API_KEY               = "FRENET_API_KEY"
 	REDIS_URI                    = "REDIS_URI"
+	TINY_API_TOKEN               = "TINY_API_TOKEN"
+	TINY_API_URL                 = "TINY_API_URL"
+	TRACKING_API_USER            = "TRACKING_API_USER"
+	TRACKING_API_TOKEN           = "TRACKING_API_TOKEN"
+	SHIPPING_ORIGIN_CEP          = "SHIPPING_ORIGIN_CEP"
+	SHIPPING_PROVIDERS           = "SHIPPING_PROVIDERS"
+	FRENET_API_URL               = "FRENET_API_URL"
+	CORREIOS_API_USER            = "CORREIOS_API_USER"
+	CORREIOS_API_CODE            = "CORREIOS_API_CODE"
+	CORREIOS_POSTING_CARD        = "CORREIOS_POSTING_CARD"
+	LALAMOVE_API_KEY             = "LALAMOVE_API_KEY"
+	LALAMOVE_API_SECRET          = "LALAMOVE_API_SECRET"
 
 	ENV_DEVELOPMENT = "development"
 	ENV_HOMOLOG     = "homolog"
@@ -28,6 +40,12 @@ const (
 
 var allowedKeys = []string{ENV, PORT, MONGODB_URI, MYSQL_URI, LARAVEL_API_URL, SPACE_DESK_WEBHOOK_X_API_KEY, SPACE_DESK_API_KEY, FRENET_API_KEY, REDIS_URI, SPACE_DESK_API_KEY_2}
 
+// optionalKeys podem aparecer no .env mas não são obrigatórias (integrações que o ambiente pode não usar)
+var optionalKeys = []string{
+	TINY_API_TOKEN, TINY_API_URL, TRACKING_API_USER, TRACKING_API_TOKEN, SHIPPING_ORIGIN_CEP, SHIPPING_PROVIDERS,
+	FRENET_API_URL, CORREIOS_API_USER, CORREIOS_API_CODE, CORREIOS_POSTING_CARD, LALAMOVE_API_KEY, LALAMOVE_API_SECRET,
+}
+
 var allowedEnvValues = []string{ENV_DEVELOPMENT, ENV_HOMOLOG, ENV_RELEASE}
 
 func LoadEnvVariables() {
@@ -94,11 +112,11 @@ func LoadEnvVariables() {
 			}
 		}
 
-		isAllowed := slices.Contains(allowedKeys, key)
+		isAllowed := slices.Contains(allowedKeys, key) || slices.Contains(optionalKeys, key)
 
 		if !isAllowed {
 			panic(fmt.Sprintf("[ENV] Chave '%s' não é permitida. Chaves permitidas: %s",
-				key, strings.Join(allowedKeys[:], ", ")))
+				key, strings.Join(slices.Concat(allowedKeys, optionalKeys), ", ")))
 		}
 
 		if err := os.Setenv(key, value); err != nil {
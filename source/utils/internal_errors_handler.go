@@ -60,6 +60,23 @@ const (
 	METHOD_NOT_ALLOWED
 	ERROR_TO_QUERY_MONGODB
 	INVALID_CHAT_ID
+	BUDGETS_INVALID_REQUEST_DATA
+	CANNOT_INSERT_BUDGET_TO_MONGODB
+	CANNOT_UPDATE_BUDGET_IN_MONGODB
+	PRODUCTS_INVALID_REQUEST_DATA
+	INVALID_PRODUCT_ID_FORMAT
+	CANNOT_FIND_PRODUCTS_IN_MONGODB
+	CANNOT_INSERT_PRODUCT_TO_MONGODB
+	CANNOT_UPDATE_PRODUCT_IN_MONGODB
+	CANNOT_INSERT_ORDER_TO_MONGODB
+	ORDERS_INVALID_REQUEST_DATA
+	CANNOT_UPDATE_ORDER_IN_MONGODB
+	INVALID_CEP
+	CANNOT_UPDATE_CLIENT_IN_MONGODB
+	CANNOT_MERGE_LEADS_IN_MONGODB
+	INVALID_EXPORT_REQUEST
+	CANNOT_FIND_EXPORTS_IN_MONGODB
+	CANNOT_INSERT_EXPORT_TO_MONGODB
 )
 
 func SendInternalError(internalErrorCode int) string {
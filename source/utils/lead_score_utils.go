@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"api/schemas"
+	"math"
+	"time"
+)
+
+// DefaultLeadScoringModel é usado enquanto nenhum modelo foi salvo em leads_scoring.
+func DefaultLeadScoringModel() schemas.LeadScoringModel {
+	return schemas.LeadScoringModel{
+		Weights:              schemas.LeadScoringWeights{Tier: 35, Engagement: 25, Conversion: 25, Recency: 15},
+		TargetMessages:       20,
+		TargetReplyMinutes:   30,
+		EngagementWindowDays: 90,
+		RecencyHalfLifeDays:  30,
+	}
+}
+
+// CalculateLeadScore combina as notas de tier, engajamento, conversão e recência (de 0 a 1) na pontuação de
+// 0 a 100, ponderada pelos pesos do modelo. tierScore é a posição do tier do lead entre os tiers cadastrados.
+func CalculateLeadScore(signals schemas.LeadScoreSignals, tierScore float64, model schemas.LeadScoringModel, now time.Time) schemas.LeadScore {
+	components := schemas.LeadScoreComponents{Tier: clamp01(tierScore)}
+
+	// Engajamento: volume de mensagens do lead e rapidez com que ele responde
+	if model.TargetMessages > 0 {
+		components.Engagement = 0.6 * clamp01(float64(signals.MessagesReceived)/float64(model.TargetMessages))
+	}
+	if signals.AvgReplyMinutes > 0 && model.TargetReplyMinutes > 0 {
+		components.Engagement += 0.4 * clamp01(model.TargetReplyMinutes/signals.AvgReplyMinutes)
+	}
+
+	// Conversão: orçamentos que viraram pedido, com um orçamento fictício não convertido para que um único
+	// orçamento convertido não valha nota máxima
+	if signals.Budgets > 0 {
+		components.Conversion = float64(signals.ConvertedBudgets) / float64(signals.Budgets+1)
+	}
+
+	// Recência: cai pela metade a cada RecencyHalfLifeDays sem atividade
+	if !signals.LastActivityAt.IsZero() && model.RecencyHalfLifeDays > 0 {
+		days := max(0, now.Sub(signals.LastActivityAt).Hours()/24)
+		components.Recency = math.Pow(0.5, days/model.RecencyHalfLifeDays)
+	}
+
+	weights := model.Weights
+	total := weights.Tier + weights.Engagement + weights.Conversion + weights.Recency
+	value := 0.0
+	if total > 0 {
+		value = 100 * (weights.Tier*components.Tier +
+			weights.Engagement*components.Engagement +
+			weights.Conversion*components.Conversion +
+			weights.Recency*components.Recency) / total
+	}
+
+	components.Tier = round2(components.Tier)
+	components.Engagement = round2(components.Engagement)
+	components.Conversion = round2(components.Conversion)
+	components.Recency = round2(components.Recency)
+	return schemas.LeadScore{Value: math.Round(value*10) / 10, Components: components, Signals: signals, ComputedAt: now}
+}
+
+func clamp01(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"api/schemas"
+	"testing"
+	"time"
+)
+
+func TestCalculateLeadScore(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	model := DefaultLeadScoringModel()
+
+	tests := []struct {
+		name       string
+		signals    schemas.LeadScoreSignals
+		tierScore  float64
+		model      schemas.LeadScoringModel
+		value      float64
+		components schemas.LeadScoreComponents
+	}{
+		{
+			name:  "lead sem sinais",
+			model: model,
+		},
+		{
+			name: "nota máxima em tudo menos conversão",
+			signals: schemas.LeadScoreSignals{
+				MessagesReceived: 20,
+				AvgReplyMinutes:  15,
+				Budgets:          1,
+				ConvertedBudgets: 1,
+				LastActivityAt:   now,
+			},
+			tierScore:  1,
+			model:      model,
+			value:      87.5,
+			components: schemas.LeadScoreComponents{Tier: 1, Engagement: 1, Conversion: 0.5, Recency: 1},
+		},
+		{
+			name: "notas parciais",
+			signals: schemas.LeadScoreSignals{
+				MessagesReceived: 10,
+				AvgReplyMinutes:  60,
+				Budgets:          3,
+				ConvertedBudgets: 1,
+				LastActivityAt:   now.AddDate(0, 0, -30),
+			},
+			tierScore:  0.5,
+			model:      model,
+			value:      43.8,
+			components: schemas.LeadScoreComponents{Tier: 0.5, Engagement: 0.5, Conversion: 0.25, Recency: 0.5},
+		},
+		{
+			name:       "atividade no futuro conta como agora",
+			signals:    schemas.LeadScoreSignals{LastActivityAt: now.Add(time.Hour)},
+			model:      model,
+			value:      15,
+			components: schemas.LeadScoreComponents{Recency: 1},
+		},
+		{
+			name:       "nota do tier limitada a 1",
+			tierScore:  1.5,
+			model:      schemas.LeadScoringModel{Weights: schemas.LeadScoringWeights{Tier: 1}},
+			value:      100,
+			components: schemas.LeadScoreComponents{Tier: 1},
+		},
+		{
+			name:       "modelo sem pesos",
+			signals:    schemas.LeadScoreSignals{MessagesReceived: 20, LastActivityAt: now},
+			tierScore:  1,
+			model:      schemas.LeadScoringModel{TargetMessages: 20, RecencyHalfLifeDays: 30},
+			components: schemas.LeadScoreComponents{Tier: 1, Engagement: 0.6, Recency: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := CalculateLeadScore(tt.signals, tt.tierScore, tt.model, now)
+			if score.Value != tt.value {
+				t.Fatalf("Value = %v, esperado %v", score.Value, tt.value)
+			}
+			if score.Components != tt.components {
+				t.Fatalf("Components = %+v, esperado %+v", score.Components, tt.components)
+			}
+			if score.Signals != tt.signals || !score.ComputedAt.Equal(now) {
+				t.Fatalf("sinais ou data do cálculo não foram guardados: %+v", score)
+			}
+		})
+	}
+}
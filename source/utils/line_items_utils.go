@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"api/schemas"
+	"encoding/json"
+	"math"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type legacyLineItem struct {
+	Nome       string  `json:"nome"`
+	Preco      float64 `json:"preco"`
+	Quantidade float64 `json:"quantidade"`
+}
+
+// LegacyLineItems converte a lista de produtos legada (JSON em texto com preco/quantidade) para itens tipados.
+// Os valores são mantidos como estão; quem chama decide como tratar quantidade zero.
+func LegacyLineItems(list string) ([]schemas.LineItem, error) {
+	products := []legacyLineItem{}
+	if list == "" {
+		return []schemas.LineItem{}, nil
+	}
+	if err := json.Unmarshal([]byte(list), &products); err != nil {
+		return nil, err
+	}
+
+	items := make([]schemas.LineItem, 0, len(products))
+	for _, p := range products {
+		price := p.Preco
+		items = append(items, schemas.LineItem{
+			Description: p.Nome,
+			Quantity:    p.Quantidade,
+			UnitPrice:   &price,
+			Total:       roundCents(p.Preco * p.Quantidade),
+		})
+	}
+	return items, nil
+}
+
+// LineItemPrice retorna o preço unitário do item, considerando zero quando ele não foi informado.
+func LineItemPrice(item schemas.LineItem) float64 {
+	if item.UnitPrice == nil {
+		return 0
+	}
+	return *item.UnitPrice
+}
+
+// SumLineItems recalcula o total de cada item e retorna a soma. Itens sem preço passam a ter preço zero.
+func SumLineItems(items []schemas.LineItem) float64 {
+	subtotal := 0.0
+	for i := range items {
+		price := LineItemPrice(items[i])
+		items[i].UnitPrice = &price
+		items[i].Total = roundCents(price * items[i].Quantity)
+		subtotal += items[i].Total
+	}
+	return roundCents(subtotal)
+}
+
+// CalculateBudgetTotals calcula os totais do orçamento: itens - desconto + taxa de antecipação + entrega.
+// O desconto nunca ultrapassa o subtotal.
+func CalculateBudgetTotals(budget *schemas.Budget) schemas.BudgetTotals {
+	totals := schemas.BudgetTotals{
+		Subtotal: SumLineItems(budget.Items),
+		EarlyTax: roundCents(budget.EarlyMode.Tax),
+		Shipping: roundCents(budget.Delivery.Price),
+	}
+
+	switch budget.Discount.Type {
+	case schemas.BUDGETS_DISCOUNT_TYPE_VALUE:
+		totals.Discount = budget.Discount.Value
+	case schemas.BUDGETS_DISCOUNT_TYPE_PERCENTAGE:
+		totals.Discount = totals.Subtotal * budget.Discount.Percentage / 100
+	}
+	totals.Discount = roundCents(math.Min(totals.Discount, totals.Subtotal))
+
+	totals.Total = roundCents(totals.Subtotal - totals.Discount + totals.EarlyTax + totals.Shipping)
+	return totals
+}
+
+// LineItemsFromDocument lê os itens tipados de um documento cru (bson.M); ok é falso quando não há itens.
+func LineItemsFromDocument(doc map[string]any) ([]schemas.LineItem, bool) {
+	wrapper := struct {
+		Items []schemas.LineItem `bson:"items"`
+	}{}
+	if !decodeDocumentField(doc, "items", &wrapper) || len(wrapper.Items) == 0 {
+		return nil, false
+	}
+	return wrapper.Items, true
+}
+
+// BudgetTotalsFromDocument lê os totais calculados no servidor de um documento cru de orçamento.
+func BudgetTotalsFromDocument(doc map[string]any) (schemas.BudgetTotals, bool) {
+	wrapper := struct {
+		Totals *schemas.BudgetTotals `bson:"totals"`
+	}{}
+	if !decodeDocumentField(doc, "totals", &wrapper) || wrapper.Totals == nil {
+		return schemas.BudgetTotals{}, false
+	}
+	return *wrapper.Totals, true
+}
+
+// LegacyBudgetTotals calcula os totais de um orçamento ainda não migrado (documento cru) a partir da lista
+// legada, com a mesma regra de CalculateBudgetTotals.
+func LegacyBudgetTotals(doc map[string]any) schemas.BudgetTotals {
+	budget := schemas.Budget{}
+	budget.OldProductsList, _ = doc["old_products_list"].(string)
+	budget.Items, _ = LegacyLineItems(budget.OldProductsList)
+
+	fields := struct {
+		Delivery  schemas.Delivery  `bson:"delivery"`
+		Discount  schemas.Discount  `bson:"discount"`
+		EarlyMode schemas.EarlyMode `bson:"early_mode"`
+	}{}
+	if decodeDocumentField(doc, "delivery", &fields) {
+		budget.Delivery = fields.Delivery
+	}
+	if decodeDocumentField(doc, "discount", &fields) {
+		budget.Discount = fields.Discount
+	}
+	if decodeDocumentField(doc, "early_mode", &fields) {
+		budget.EarlyMode = fields.EarlyMode
+	}
+	return CalculateBudgetTotals(&budget)
+}
+
+func decodeDocumentField(doc map[string]any, key string, out any) bool {
+	value, ok := doc[key]
+	if !ok || value == nil {
+		return false
+	}
+	data, err := bson.Marshal(bson.M{key: value})
+	if err != nil {
+		return false
+	}
+	return bson.Unmarshal(data, out) == nil
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
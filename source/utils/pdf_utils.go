@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+)
+
+const (
+	PDF_PAGE_WIDTH  = 595.28 // A4 em pontos
+	PDF_PAGE_HEIGHT = 841.89
+)
+
+// Larguras das fontes padrão Helvetica e Helvetica-Bold (caracteres 32 a 126, em milésimos do tamanho da fonte)
+var (
+	helveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+	winAnsiExtras = map[rune]byte{
+		'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	}
+)
+
+// PDFDocument é um gerador mínimo de PDF (A4, Helvetica, texto, linhas e retângulos) sem dependências externas.
+// As coordenadas são em pontos a partir do canto superior esquerdo da página.
+type PDFDocument struct {
+	pages   []*bytes.Buffer
+	current *bytes.Buffer
+}
+
+func NewPDFDocument() *PDFDocument {
+	doc := &PDFDocument{}
+	doc.AddPage()
+	return doc
+}
+
+func (d *PDFDocument) AddPage() {
+	d.current = &bytes.Buffer{}
+	d.pages = append(d.pages, d.current)
+}
+
+func (d *PDFDocument) PageCount() int {
+	return len(d.pages)
+}
+
+// SetPage volta a escrever em uma página já criada (índice a partir de zero), útil para rodapés.
+func (d *PDFDocument) SetPage(index int) {
+	if index >= 0 && index < len(d.pages) {
+		d.current = d.pages[index]
+	}
+}
+
+// Text escreve o texto com a linha de base em y. Cores em RGB de 0 a 255.
+func (d *PDFDocument) Text(x, y, size float64, bold bool, color [3]uint8, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.current, "BT %s rg /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		pdfColor(color), font, size, x, PDF_PAGE_HEIGHT-y, pdfEscape(text))
+}
+
+// TextRight escreve o texto alinhado à direita em x.
+func (d *PDFDocument) TextRight(x, y, size float64, bold bool, color [3]uint8, text string) {
+	d.Text(x-PDFTextWidth(text, size, bold), y, size, bold, color, text)
+}
+
+func (d *PDFDocument) Rect(x, y, width, height float64, color [3]uint8) {
+	fmt.Fprintf(d.current, "%s rg %.2f %.2f %.2f %.2f re f\n", pdfColor(color), x, PDF_PAGE_HEIGHT-y-height, width, height)
+}
+
+func (d *PDFDocument) Line(x1, y1, x2, y2, width float64, color [3]uint8) {
+	fmt.Fprintf(d.current, "%s RG %.2f w %.2f %.2f m %.2f %.2f l S\n", pdfColor(color), width, x1, PDF_PAGE_HEIGHT-y1, x2, PDF_PAGE_HEIGHT-y2)
+}
+
+// Bytes monta o arquivo PDF com os conteúdos das páginas comprimidos.
+func (d *PDFDocument) Bytes() ([]byte, error) {
+	out := &bytes.Buffer{}
+	offsets := []int{}
+	writeObject := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// 1: catálogo, 2: árvore de páginas, 3 e 4: fontes, depois pares página/conteúdo
+	kids := []string{}
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+i*2))
+	}
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		writeObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			PDF_PAGE_WIDTH, PDF_PAGE_HEIGHT, 6+i*2))
+
+		compressed := &bytes.Buffer{}
+		zw := zlib.NewWriter(compressed)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		writeObject(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes(), nil
+}
+
+// PDFTextWidth calcula a largura do texto em pontos. Acentuados usam a largura média das letras.
+func PDFTextWidth(text string, size float64, bold bool) float64 {
+	widths := helveticaWidths
+	if bold {
+		widths = helveticaBoldWidths
+	}
+	total := 0
+	for _, r := range text {
+		if r >= 32 && r <= 126 {
+			total += widths[r-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// PDFWrapText quebra o texto em linhas que cabem na largura informada.
+func PDFWrapText(text string, size float64, bold bool, maxWidth float64) []string {
+	lines := []string{}
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if line != "" && PDFTextWidth(candidate, size, bold) > maxWidth {
+				lines = append(lines, line)
+				candidate = word
+			}
+			line = candidate
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func pdfEscape(text string) string {
+	b := strings.Builder{}
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r >= 32 && r <= 126:
+			b.WriteByte(byte(r))
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			if c, ok := winAnsiExtras[r]; ok {
+				fmt.Fprintf(&b, "\\%03o", c)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+	}
+	return b.String()
+}
+
+func pdfColor(c [3]uint8) string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c[0])/255, float64(c[1])/255, float64(c[2])/255)
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	pdfStartXrefPattern = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	pdfStreamPattern    = regexp.MustCompile(`(?s)<< /Length (\d+) /Filter /FlateDecode >>\nstream\n`)
+)
+
+// parsePDFObjects confere a tabela xref do arquivo e devolve o corpo de cada objeto, indexado pelo número.
+func parsePDFObjects(t *testing.T, pdf []byte) map[int]string {
+	t.Helper()
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) {
+		t.Fatalf("cabeçalho inválido: %q", pdf[:min(len(pdf), 16)])
+	}
+	match := pdfStartXrefPattern.FindSubmatch(pdf)
+	if match == nil {
+		t.Fatal("startxref não encontrado no fim do arquivo")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d não aponta para a tabela xref", xref)
+	}
+
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	var first, size int
+	if _, err := fmt.Sscanf(lines[1], "%d %d", &first, &size); err != nil || first != 0 {
+		t.Fatalf("subseção xref inválida: %q", lines[1])
+	}
+	if lines[2] != "0000000000 65535 f " {
+		t.Fatalf("entrada livre inválida: %q", lines[2])
+	}
+	if len(lines) < 4+size || lines[2+size] != "trailer" {
+		t.Fatalf("a tabela xref não tem %d entradas seguidas do trailer", size)
+	}
+	if trailer := fmt.Sprintf("<< /Size %d /Root 1 0 R >>", size); lines[3+size] != trailer {
+		t.Fatalf("trailer = %q, esperado %q", lines[3+size], trailer)
+	}
+
+	objects := map[int]string{}
+	for number := 1; number < size; number++ {
+		entry := lines[2+number]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("entrada xref %d inválida: %q", number, entry)
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		header := fmt.Sprintf("%d 0 obj\n", number)
+		if !bytes.HasPrefix(pdf[offset:], []byte(header)) {
+			t.Fatalf("offset %d do objeto %d aponta para %q", offset, number, pdf[offset:min(len(pdf), offset+16)])
+		}
+		body := pdf[offset+len(header):]
+		end := bytes.Index(body, []byte("\nendobj\n"))
+		if stream := pdfStreamPattern.FindSubmatchIndex(body); stream != nil && stream[0] == 0 {
+			length, _ := strconv.Atoi(string(body[stream[2]:stream[3]]))
+			end = stream[1] + length + len("\nendstream")
+			if !bytes.HasPrefix(body[stream[1]+length:], []byte("\nendstream\nendobj\n")) {
+				t.Fatalf("/Length do objeto %d não corresponde ao stream", number)
+			}
+		}
+		if end < 0 {
+			t.Fatalf("objeto %d sem endobj", number)
+		}
+		objects[number] = string(body[:end])
+	}
+	return objects
+}
+
+// pdfStreamContent descomprime o conteúdo de um objeto de stream.
+func pdfStreamContent(t *testing.T, object string) string {
+	t.Helper()
+
+	start := strings.Index(object, "stream\n") + len("stream\n")
+	stop := strings.LastIndex(object, "\nendstream")
+	reader, err := zlib.NewReader(strings.NewReader(object[start:stop]))
+	if err != nil {
+		t.Fatalf("stream inválido: %v", err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("stream inválido: %v", err)
+	}
+	return string(content)
+}
+
+func TestPDFDocumentBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages int
+	}{
+		{"uma página", 1},
+		{"três páginas", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := NewPDFDocument()
+			for i := 0; i < tt.pages; i++ {
+				if i > 0 {
+					doc.AddPage()
+				}
+				doc.Text(40, 60, 12, i%2 == 0, [3]uint8{0, 0, 0}, fmt.Sprintf("Página %d (orçamento)", i+1))
+				doc.Rect(40, 80, 100, 20, [3]uint8{240, 240, 240})
+				doc.Line(40, 110, 200, 110, 1, [3]uint8{0, 0, 0})
+			}
+			if doc.PageCount() != tt.pages {
+				t.Fatalf("PageCount() = %d, esperado %d", doc.PageCount(), tt.pages)
+			}
+
+			pdf, err := doc.Bytes()
+			if err != nil {
+				t.Fatalf("Bytes: %v", err)
+			}
+			objects := parsePDFObjects(t, pdf)
+
+			if len(objects) != 4+tt.pages*2 {
+				t.Fatalf("%d objetos, esperado %d", len(objects), 4+tt.pages*2)
+			}
+			if objects[1] != "<< /Type /Catalog /Pages 2 0 R >>" {
+				t.Fatalf("catálogo inválido: %q", objects[1])
+			}
+			if want := fmt.Sprintf("/Count %d >>", tt.pages); !strings.HasSuffix(objects[2], want) {
+				t.Fatalf("árvore de páginas %q sem %q", objects[2], want)
+			}
+
+			pages := 0
+			for number := 5; number < 5+tt.pages*2; number += 2 {
+				if !strings.HasPrefix(objects[number], "<< /Type /Page /Parent 2 0 R ") {
+					t.Fatalf("objeto %d não é uma página: %q", number, objects[number])
+				}
+				if !strings.HasSuffix(objects[number], fmt.Sprintf("/Contents %d 0 R >>", number+1)) {
+					t.Fatalf("página %d não aponta para o conteúdo %d", number, number+1)
+				}
+				content := pdfStreamContent(t, objects[number+1])
+				if want := fmt.Sprintf(`(P\341gina %d \(or\347amento\)) Tj`, pages+1); !strings.Contains(content, want) {
+					t.Fatalf("conteúdo da página %d sem o texto %q: %q", pages+1, want, content)
+				}
+				pages++
+			}
+			if pages != tt.pages {
+				t.Fatalf("%d páginas, esperado %d", pages, tt.pages)
+			}
+		})
+	}
+}
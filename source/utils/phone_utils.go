@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"strings"
+)
+
+const BRAZIL_COUNTRY_CODE = "55"
+
+// NormalizePhone converte o telefone para E.164 sem o "+" (ex.: 5511987654321), o mesmo formato do wa_id do
+// WhatsApp. Números brasileiros ganham o 55 quando vêm só com DDD e o nono dígito quando são celulares de 8
+// dígitos; o 0 de discagem e o 00 internacional são removidos. Devolve false quando não é um telefone válido.
+func NormalizePhone(phone string) (string, bool) {
+	digits := OnlyDigits(phone)
+	// Com "+" ou "00" o número já traz o código do país
+	international := strings.HasPrefix(strings.TrimSpace(phone), "+") || strings.HasPrefix(digits, "00")
+	digits = strings.TrimPrefix(digits, "00")
+	if !international && strings.HasPrefix(digits, "0") && (len(digits) == 11 || len(digits) == 12) {
+		digits = digits[1:]
+	}
+
+	national := ""
+	switch {
+	case !international && (len(digits) == 10 || len(digits) == 11) && validDDD(digits[:2]):
+		national = digits
+	case strings.HasPrefix(digits, BRAZIL_COUNTRY_CODE) && (len(digits) == 12 || len(digits) == 13) && validDDD(digits[2:4]):
+		national = digits[2:]
+	case (international && len(digits) >= 8 || len(digits) >= 12) && len(digits) <= 15 && !strings.HasPrefix(digits, BRAZIL_COUNTRY_CODE):
+		// Número estrangeiro: só é aceito com o código do país explícito ou longo demais para ser nacional
+		return digits, true
+	default:
+		return "", false
+	}
+
+	ddd, number := national[:2], national[2:]
+	if len(number) == 8 && number[0] >= '6' {
+		number = "9" + number
+	}
+	if len(number) == 9 && number[0] != '9' {
+		return "", false
+	}
+	return BRAZIL_COUNTRY_CODE + ddd + number, true
+}
+
+// FormatE164 devolve o telefone normalizado com o "+" do E.164, ou vazio se ele não for válido.
+func FormatE164(phone string) string {
+	normalized, ok := NormalizePhone(phone)
+	if !ok {
+		return ""
+	}
+	return "+" + normalized
+}
+
+// PhoneVariants lista as formas em que o mesmo telefone pode estar gravado (normalizado, sem o nono dígito,
+// sem o 55 e como veio), para buscar registros antigos que ainda não foram normalizados.
+func PhoneVariants(phone string) []string {
+	variants := []string{}
+	add := func(value string) {
+		if value == "" {
+			return
+		}
+		for _, v := range variants {
+			if v == value {
+				return
+			}
+		}
+		variants = append(variants, value)
+	}
+
+	add(strings.TrimSpace(phone))
+	add(OnlyDigits(phone))
+	normalized, ok := NormalizePhone(phone)
+	if !ok {
+		return variants
+	}
+	add(normalized)
+	add("+" + normalized)
+	if strings.HasPrefix(normalized, BRAZIL_COUNTRY_CODE) && len(normalized) == 13 {
+		national := normalized[2:]
+		withoutNinth := national[:2] + national[3:]
+		add(national)
+		add(BRAZIL_COUNTRY_CODE + withoutNinth)
+		add(withoutNinth)
+	}
+	return variants
+}
+
+// PhoneKey identifica o telefone para achar duplicados: números brasileiros viram 55 + DDD + os últimos 8
+// dígitos, de forma que a presença ou não do nono dígito não diferencia dois leads.
+func PhoneKey(phone string) string {
+	normalized, ok := NormalizePhone(phone)
+	if !ok {
+		return ""
+	}
+	if strings.HasPrefix(normalized, BRAZIL_COUNTRY_CODE) && len(normalized) == 13 {
+		return normalized[:4] + normalized[5:]
+	}
+	return normalized
+}
+
+// validDDD aceita os DDDs brasileiros, que vão de 11 a 99 sem zero no segundo dígito.
+func validDDD(ddd string) bool {
+	return len(ddd) == 2 && ddd[0] >= '1' && ddd[0] <= '9' && ddd[1] >= '1' && ddd[1] <= '9'
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  string
+		ok    bool
+	}{
+		{"(11) 98765-4321", "5511987654321", true},
+		{"+55 11 98765-4321", "5511987654321", true},
+		{"5511987654321", "5511987654321", true},
+		{"011987654321", "5511987654321", true},
+		// Celular antigo, sem o nono dígito
+		{"11 8765-4321", "5511987654321", true},
+		{"551187654321", "5511987654321", true},
+		// Fixo não ganha o nono dígito
+		{"11 3456-7890", "551134567890", true},
+		{"+1 415 555 2671", "14155552671", true},
+		{"00 44 20 7946 0958", "442079460958", true},
+		{"351912345678", "351912345678", true},
+		{"(11) 9876-543", "", false},
+		{"(10) 98765-4321", "", false},
+		{"11 88765-4321", "", false},
+		{"12345", "", false},
+		{"abc", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phone, func(t *testing.T) {
+			got, ok := NormalizePhone(tt.phone)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("NormalizePhone(%q) = %q, %v, esperado %q, %v", tt.phone, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPhoneKey(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  string
+	}{
+		{"(11) 98765-4321", "551187654321"},
+		{"11 8765-4321", "551187654321"},
+		{"+55 11 98765-4321", "551187654321"},
+		{"11 3456-7890", "551134567890"},
+		{"+1 415 555 2671", "14155552671"},
+		{"12345", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phone, func(t *testing.T) {
+			if got := PhoneKey(tt.phone); got != tt.want {
+				t.Fatalf("PhoneKey(%q) = %q, esperado %q", tt.phone, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPhoneVariants(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  []string
+	}{
+		{"(11) 98765-4321", []string{"(11) 98765-4321", "11987654321", "5511987654321", "+5511987654321", "551187654321", "1187654321"}},
+		{"5511987654321", []string{"5511987654321", "+5511987654321", "11987654321", "551187654321", "1187654321"}},
+		{"11 3456-7890", []string{"11 3456-7890", "1134567890", "551134567890", "+551134567890"}},
+		{"+1 415 555 2671", []string{"+1 415 555 2671", "14155552671", "+14155552671"}},
+		{"12345", []string{"12345"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phone, func(t *testing.T) {
+			if got := PhoneVariants(tt.phone); !slices.Equal(got, tt.want) {
+				t.Fatalf("PhoneVariants(%q) = %q, esperado %q", tt.phone, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunEvery executa o job em segundo plano a cada intervalo, a partir do primeiro intervalo após a subida do
+// servidor. Cada execução recebe um contexto com o prazo informado; erros e panics são apenas registrados
+// no log para não derrubar o servidor nem interromper as próximas execuções.
+func RunEvery(name string, interval, timeout time.Duration, job func(ctx context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			runJob(name, timeout, job)
+		}
+	}()
+}
+
+func runJob(name string, timeout time.Duration, job func(ctx context.Context) error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[%s] Panic na execução agendada: %v", name, r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	started := time.Now()
+	if err := job(ctx); err != nil {
+		log.Printf("[%s] Erro na execução agendada: %v", name, err)
+		return
+	}
+	log.Printf("[%s] Execução agendada concluída em %s", name, time.Since(started).Round(time.Millisecond))
+}
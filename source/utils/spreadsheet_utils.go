@@ -0,0 +1,390 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	SPREADSHEET_CSV  = "csv"
+	SPREADSHEET_XLSX = "xlsx"
+)
+
+var errInvalidSpreadsheet = errors.New("arquivo não é um CSV ou XLSX válido")
+
+// ReadSpreadsheet lê um CSV ou a primeira planilha de um XLSX e devolve as linhas como texto, com a
+// primeira linha sendo o cabeçalho. O formato vem da extensão do arquivo ou, sem ela, do conteúdo.
+func ReadSpreadsheet(fileName string, data []byte) ([][]string, error) {
+	isZip := bytes.HasPrefix(data, []byte("PK\x03\x04"))
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".xlsx":
+		return readXLSX(data)
+	case ".csv", ".txt":
+		if isZip {
+			return nil, errInvalidSpreadsheet
+		}
+		return readCSV(data)
+	}
+	if isZip {
+		return readXLSX(data)
+	}
+	return readCSV(data)
+}
+
+// readCSV aceita os separadores vírgula, ponto e vírgula (padrão do Excel em português) e tabulação.
+// Arquivos que não estão em UTF-8 são tratados como Latin-1.
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		data = []byte(string(runes))
+	}
+
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	separator := ','
+	best := bytes.Count(firstLine, []byte(","))
+	for _, candidate := range []rune{';', '\t'} {
+		if count := bytes.Count(firstLine, []byte(string(candidate))); count > best {
+			separator, best = candidate, count
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = separator
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidSpreadsheet, err)
+	}
+	return rows, nil
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				Text string `xml:"t"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errInvalidSpreadsheet
+	}
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	shared := xlsxSharedStrings{}
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeZipXML(file, &shared); err != nil {
+			return nil, err
+		}
+	}
+	sharedTexts := make([]string, len(shared.Items))
+	for i, item := range shared.Items {
+		text := item.Text
+		for _, run := range item.Runs {
+			text += run.Text
+		}
+		sharedTexts[i] = text
+	}
+
+	sheetFile, ok := files[firstSheetPath(files)]
+	if !ok {
+		return nil, errInvalidSpreadsheet
+	}
+	sheet := xlsxSheet{}
+	if err := decodeZipXML(sheetFile, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{}
+	for _, row := range sheet.Rows {
+		// Linhas vazias não aparecem no XML; o número da linha mantém as posições da planilha
+		for row.Number > len(rows)+1 {
+			rows = append(rows, []string{})
+		}
+		values := []string{}
+		for i, cell := range row.Cells {
+			column := xlsxColumnIndex(cell.Ref)
+			if column < 0 {
+				column = i
+			}
+			for len(values) <= column {
+				values = append(values, "")
+			}
+			values[column] = xlsxCellValue(cell.Type, cell.Value, cell.Inline.Text, sharedTexts)
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// firstSheetPath segue o workbook até o arquivo da primeira aba; sem os relacionamentos, usa o nome padrão.
+func firstSheetPath(files map[string]*zip.File) string {
+	workbook := xlsxWorkbook{}
+	relationships := xlsxRelationships{}
+	workbookFile, okWorkbook := files["xl/workbook.xml"]
+	relsFile, okRels := files["xl/_rels/workbook.xml.rels"]
+	if okWorkbook && okRels && decodeZipXML(workbookFile, &workbook) == nil && decodeZipXML(relsFile, &relationships) == nil && len(workbook.Sheets) > 0 {
+		for _, rel := range relationships.Items {
+			if rel.ID != workbook.Sheets[0].ID {
+				continue
+			}
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/")
+			}
+			return path.Join("xl", rel.Target)
+		}
+	}
+	return "xl/worksheets/sheet1.xml"
+}
+
+func decodeZipXML(file *zip.File, out any) error {
+	reader, err := file.Open()
+	if err != nil {
+		return errInvalidSpreadsheet
+	}
+	defer reader.Close()
+	if err := xml.NewDecoder(io.LimitReader(reader, 200<<20)).Decode(out); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidSpreadsheet, err)
+	}
+	return nil
+}
+
+// xlsxColumnIndex converte a referência da célula (ex.: "AB12") no índice da coluna a partir de zero.
+func xlsxColumnIndex(ref string) int {
+	index := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		index = index*26 + int(c-'A'+1)
+	}
+	return index - 1
+}
+
+func xlsxCellValue(cellType, value, inline string, shared []string) string {
+	switch cellType {
+	case "s":
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 || i >= len(shared) {
+			return ""
+		}
+		return shared[i]
+	case "inlineStr":
+		return inline
+	case "b":
+		if value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	case "", "n":
+		// Telefones e documentos digitados como número chegam em notação científica
+		if strings.ContainsAny(value, "eE") {
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				return strconv.FormatFloat(number, 'f', -1, 64)
+			}
+		}
+	}
+	return value
+}
+
+// SpreadsheetWriter grava uma planilha linha a linha, sem manter as linhas em memória. Close finaliza o
+// arquivo, mas não fecha o io.Writer de destino.
+type SpreadsheetWriter interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+// SpreadsheetContentType devolve o Content-Type do formato.
+func SpreadsheetContentType(format string) string {
+	if format == SPREADSHEET_XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// NewSpreadsheetWriter cria o escritor do formato pedido (csv ou xlsx).
+func NewSpreadsheetWriter(w io.Writer, format string) (SpreadsheetWriter, error) {
+	switch format {
+	case SPREADSHEET_CSV:
+		return newCSVWriter(w)
+	case SPREADSHEET_XLSX:
+		return newXLSXWriter(w)
+	}
+	return nil, fmt.Errorf("formato de planilha desconhecido: %s", format)
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+// newCSVWriter grava com BOM e ponto e vírgula, o que o Excel em português abre direto com os acentos.
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	if _, err := w.Write([]byte("\xef\xbb\xbf")); err != nil {
+		return nil, err
+	}
+	writer := csv.NewWriter(w)
+	writer.Comma = ';'
+	return &csvWriter{writer: writer}, nil
+}
+
+func (c *csvWriter) WriteRow(values []any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case float64:
+			// Vírgula decimal, como o Excel em português espera
+			record[i] = strings.Replace(strconv.FormatFloat(v, 'f', -1, 64), ".", ",", 1)
+		case nil:
+			record[i] = ""
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return c.writer.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheet   io.Writer
+	row     int
+}
+
+var xlsxStaticFiles = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Planilha1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+// newXLSXWriter grava os arquivos fixos do pacote e deixa a planilha aberta por último, para as linhas
+// irem direto para o zip. Os textos vão como inlineStr, sem tabela de strings compartilhadas.
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	archive := zip.NewWriter(w)
+	for _, file := range xlsxStaticFiles {
+		entry, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(entry, file.content); err != nil {
+			return nil, err
+		}
+	}
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	header := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	if _, err := io.WriteString(sheet, header); err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{archive: archive, sheet: sheet}, nil
+}
+
+func (x *xlsxWriter) WriteRow(values []any) error {
+	x.row++
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, x.row)
+	for i, value := range values {
+		ref := xlsxColumnName(i) + strconv.Itoa(x.row)
+		switch v := value.(type) {
+		case nil:
+			continue
+		case float64:
+			fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'f', -1, 64))
+		case int, int32, int64, uint64:
+			fmt.Fprintf(&b, `<c r="%s"><v>%d</v></c>`, ref, v)
+		default:
+			b.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(&b, []byte(xlsxSafeText(fmt.Sprint(v)))); err != nil {
+				return err
+			}
+			b.WriteString(`</t></is></c>`)
+		}
+	}
+	b.WriteString(`</row>`)
+	_, err := io.WriteString(x.sheet, b.String())
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return x.archive.Close()
+}
+
+// xlsxColumnName converte o índice da coluna (a partir de zero) na letra da planilha.
+func xlsxColumnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxSafeText remove os caracteres de controle que o XML não aceita e que o Excel recusa ao abrir.
+func xlsxSafeText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(text, ""))
+}
@@ -0,0 +1,253 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// xlsxFixture monta um XLSX mínimo com os arquivos informados (caminho dentro do zip -> conteúdo).
+func xlsxFixture(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	buffer := bytes.Buffer{}
+	archive := zip.NewWriter(&buffer)
+	for name, content := range files {
+		file, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("zip: %v", err)
+		}
+		if _, err := file.Write([]byte(content)); err != nil {
+			t.Fatalf("zip: %v", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+const xlsxFixtureSheet = `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="inlineStr"><is><t>Ativo</t></is></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>5.5119876543210E12</v></c><c r="C2" t="b"><v>1</v></c></row>
+<row r="4"><c r="B4"><v>42</v></c><c r="D4" t="s"><v>9</v></c></row>
+</sheetData></worksheet>`
+
+const xlsxFixtureSharedStrings = `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Nome</t></si><si><t>Telefone</t></si><si><r><t>João </t></r><r><t>Silva</t></r></si>
+</sst>`
+
+func TestReadSpreadsheet(t *testing.T) {
+	xlsxRows := [][]string{
+		{"Nome", "Telefone", "Ativo"},
+		{"João Silva", "5511987654321", "TRUE"},
+		{},
+		{"", "42", "", ""},
+	}
+
+	tests := []struct {
+		name     string
+		fileName string
+		data     []byte
+		want     [][]string
+		err      bool
+	}{
+		{
+			name:     "CSV com vírgula",
+			fileName: "leads.csv",
+			data:     []byte("nome,telefone\nAna,11987654321\n"),
+			want:     [][]string{{"nome", "telefone"}, {"Ana", "11987654321"}},
+		},
+		{
+			name:     "CSV do Excel com ponto e vírgula e BOM",
+			fileName: "leads.csv",
+			data:     []byte("\xef\xbb\xbfnome;telefone;obs\nAna;11987654321;\"a, b\"\n"),
+			want:     [][]string{{"nome", "telefone", "obs"}, {"Ana", "11987654321", "a, b"}},
+		},
+		{
+			name:     "CSV com tabulação e linhas de tamanhos diferentes",
+			fileName: "leads.txt",
+			data:     []byte("nome\ttelefone\nAna\n"),
+			want:     [][]string{{"nome", "telefone"}, {"Ana"}},
+		},
+		{
+			name:     "CSV em Latin-1",
+			fileName: "leads.csv",
+			data:     []byte("nome;cidade\nJo\xe3o;S\xe3o Paulo\n"),
+			want:     [][]string{{"nome", "cidade"}, {"João", "São Paulo"}},
+		},
+		{
+			name:     "CSV sem extensão",
+			fileName: "leads",
+			data:     []byte("nome\nAna\n"),
+			want:     [][]string{{"nome"}, {"Ana"}},
+		},
+		{
+			name:     "XLSX com o caminho padrão da aba",
+			fileName: "leads.xlsx",
+			data: xlsxFixture(t, map[string]string{
+				"xl/worksheets/sheet1.xml": xlsxFixtureSheet,
+				"xl/sharedStrings.xml":     xlsxFixtureSharedStrings,
+			}),
+			want: xlsxRows,
+		},
+		{
+			name:     "XLSX seguindo o workbook até a primeira aba",
+			fileName: "leads.XLSX",
+			data: xlsxFixture(t, map[string]string{
+				"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+					`<sheets><sheet name="Leads" sheetId="1" r:id="rId3"/><sheet name="Outra" sheetId="2" r:id="rId1"/></sheets></workbook>`,
+				"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+					`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId3" Target="worksheets/leads.xml"/></Relationships>`,
+				"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>Outra</t></is></c></row></sheetData></worksheet>`,
+				"xl/worksheets/leads.xml":  xlsxFixtureSheet,
+				"xl/sharedStrings.xml":     xlsxFixtureSharedStrings,
+			}),
+			want: xlsxRows,
+		},
+		{
+			name:     "XLSX sem extensão",
+			fileName: "leads",
+			data: xlsxFixture(t, map[string]string{
+				"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c t="inlineStr"><is><t>nome</t></is></c><c><v>1</v></c></row></sheetData></worksheet>`,
+			}),
+			want: [][]string{{"nome", "1"}},
+		},
+		{
+			name:     "XLSX sem aba",
+			fileName: "leads.xlsx",
+			data:     xlsxFixture(t, map[string]string{"xl/sharedStrings.xml": xlsxFixtureSharedStrings}),
+			err:      true,
+		},
+		{
+			name:     "XLSX com a aba ilegível",
+			fileName: "leads.xlsx",
+			data:     xlsxFixture(t, map[string]string{"xl/worksheets/sheet1.xml": "<worksheet><sheetData>"}),
+			err:      true,
+		},
+		{
+			name:     "arquivo que não é zip com extensão xlsx",
+			fileName: "leads.xlsx",
+			data:     []byte("nome,telefone\n"),
+			err:      true,
+		},
+		{
+			name:     "zip com extensão csv",
+			fileName: "leads.csv",
+			data:     xlsxFixture(t, map[string]string{"xl/worksheets/sheet1.xml": xlsxFixtureSheet}),
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ReadSpreadsheet(tt.fileName, tt.data)
+			if tt.err {
+				if !errors.Is(err, errInvalidSpreadsheet) {
+					t.Fatalf("ReadSpreadsheet() erro = %v, esperado %v", err, errInvalidSpreadsheet)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadSpreadsheet() erro = %v", err)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Fatalf("ReadSpreadsheet() = %q, esperado %q", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpreadsheetWriter(t *testing.T) {
+	rows := [][]any{
+		{"Nome", "Valor", "Quantidade", "Observação"},
+		{`João "Zé" <Silva> & Cia`, 1234.5, int64(3), nil},
+		{"linha\x01 com controle", 0.1, 42, "a;b"},
+	}
+
+	tests := []struct {
+		format string
+		raw    string
+		want   [][]string
+	}{
+		{
+			format: SPREADSHEET_CSV,
+			raw: "\xef\xbb\xbfNome;Valor;Quantidade;Observação\n" +
+				"\"João \"\"Zé\"\" <Silva> & Cia\";1234,5;3;\n" +
+				"linha\x01 com controle;0,1;42;\"a;b\"\n",
+			want: [][]string{
+				{"Nome", "Valor", "Quantidade", "Observação"},
+				{`João "Zé" <Silva> & Cia`, "1234,5", "3", ""},
+				{"linha\x01 com controle", "0,1", "42", "a;b"},
+			},
+		},
+		{
+			format: SPREADSHEET_XLSX,
+			want: [][]string{
+				{"Nome", "Valor", "Quantidade", "Observação"},
+				{`João "Zé" <Silva> & Cia`, "1234.5", "3"},
+				{"linha com controle", "0.1", "42", "a;b"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			buffer := bytes.Buffer{}
+			writer, err := NewSpreadsheetWriter(&buffer, tt.format)
+			if err != nil {
+				t.Fatalf("NewSpreadsheetWriter: %v", err)
+			}
+			for _, row := range rows {
+				if err := writer.WriteRow(row); err != nil {
+					t.Fatalf("WriteRow: %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			if tt.raw != "" && buffer.String() != tt.raw {
+				t.Fatalf("arquivo = %q, esperado %q", buffer.String(), tt.raw)
+			}
+			got, err := ReadSpreadsheet("planilha."+tt.format, buffer.Bytes())
+			if err != nil {
+				t.Fatalf("ReadSpreadsheet: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("planilha lida = %q, esperado %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewSpreadsheetWriter(&bytes.Buffer{}, "ods"); err == nil {
+		t.Fatal("NewSpreadsheetWriter aceitou um formato desconhecido")
+	}
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{51, "AZ"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+
+	for _, tt := range tests {
+		if got := xlsxColumnName(tt.index); got != tt.want {
+			t.Fatalf("xlsxColumnName(%d) = %q, esperado %q", tt.index, got, tt.want)
+		}
+		if back := xlsxColumnIndex(tt.want + "1"); back != tt.index {
+			t.Fatalf("xlsxColumnIndex(%q) = %d, esperado %d", tt.want+"1", back, tt.index)
+		}
+	}
+}
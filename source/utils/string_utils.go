@@ -0,0 +1,13 @@
+package utils
+
+import "strings"
+
+// OnlyDigits remove tudo o que não for dígito (telefones, CEPs, CPF/CNPJ).
+func OnlyDigits(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, value)
+}
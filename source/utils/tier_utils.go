@@ -3,21 +3,32 @@ package utils
 import (
 	"api/schemas"
 	"encoding/json"
-	"fmt"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+const (
+	TIER_SUM_TOTAL      = "total"
+	TIER_SUM_INDIVIDUAL = "individual"
+)
+
 type ProductInLegacyList struct {
 	Preco      float64 `json:"preco"`
 	Quantidade float64 `json:"quantidade"`
 }
 
-func CalculateLeadTier(relatedOrders bson.A, tiers []schemas.LeadTier) (any, error) {
-	totalValue := 0.0
-	maxValue := 0.0
+// LeadOrderValues resume os pedidos de um lead para o cálculo do tier. Skipped conta os pedidos sem itens e
+// sem lista legada (ou com a lista legada ilegível), que entram com valor zero em vez de impedir o cálculo.
+type LeadOrderValues struct {
+	Total   float64
+	Max     float64
+	Skipped int
+}
 
-	for i, orderDoc := range relatedOrders {
+// SumLeadOrders soma o valor dos pedidos do lead e guarda o maior pedido individual.
+func SumLeadOrders(relatedOrders bson.A) LeadOrderValues {
+	values := LeadOrderValues{}
+	for _, orderDoc := range relatedOrders {
 		var orderMap map[string]any
 		switch v := orderDoc.(type) {
 		case bson.M:
@@ -30,39 +41,78 @@ func CalculateLeadTier(relatedOrders bson.A, tiers []schemas.LeadTier) (any, err
 				orderMap[elem.Key] = elem.Value
 			}
 		default:
-			return nil, fmt.Errorf("orderDoc at index %d is not a map", i)
-		}
-		productsListLegacy, ok := orderMap["products_list_legacy"].(string)
-		if !ok || productsListLegacy == "" {
-			return nil, fmt.Errorf("order at index %d missing or empty products_list_legacy", i)
-		}
-		products := []ProductInLegacyList{}
-		if err := json.Unmarshal([]byte(productsListLegacy), &products); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal products_list_legacy at index %d: %w", i, err)
+			values.Skipped++
+			continue
 		}
-		currentOrderValue := 0.0
-		for _, p := range products {
-			currentOrderValue += p.Preco * p.Quantidade
+
+		currentOrderValue, ok := OrderValue(orderMap)
+		if !ok {
+			values.Skipped++
+			continue
 		}
-		totalValue += currentOrderValue
-		if currentOrderValue > maxValue {
-			maxValue = currentOrderValue
+		values.Total += currentOrderValue
+		if currentOrderValue > values.Max {
+			values.Max = currentOrderValue
 		}
 	}
+	return values
+}
 
-	for _, tier := range tiers {
+// OrderValue soma os itens do pedido ou, nos pedidos antigos, a lista legada. ok é false quando o pedido não
+// tem nenhum dos dois ou a lista legada está ilegível.
+func OrderValue(orderMap map[string]any) (float64, bool) {
+	if items, ok := LineItemsFromDocument(orderMap); ok {
+		return SumLineItems(items), true
+	}
+	productsListLegacy, ok := orderMap["products_list_legacy"].(string)
+	if !ok || productsListLegacy == "" {
+		return 0, false
+	}
+	products := []ProductInLegacyList{}
+	if err := json.Unmarshal([]byte(productsListLegacy), &products); err != nil {
+		return 0, false
+	}
+	value := 0.0
+	for _, p := range products {
+		value += p.Preco * p.Quantidade
+	}
+	return value, true
+}
+
+// CalculateLeadTier devolve o tier em que os valores do lead se encaixam, ou nil quando nenhum se encaixa.
+// Tiers de sum_type diferentes podem casar ao mesmo tempo; nesse caso vale o de maior min_value.
+func CalculateLeadTier(values LeadOrderValues, tiers []schemas.LeadTier) *schemas.LeadTier {
+	var matched *schemas.LeadTier
+	for i, tier := range tiers {
 		var valueToCompare float64
 		switch tier.SumType {
-		case "total":
-			valueToCompare = totalValue
-		case "individual":
-			valueToCompare = maxValue
+		case TIER_SUM_TOTAL:
+			valueToCompare = values.Total
+		case TIER_SUM_INDIVIDUAL:
+			valueToCompare = values.Max
 		default:
-			return nil, fmt.Errorf("invalid SumType in tier: %s", tier.SumType)
+			continue
+		}
+		if valueToCompare < tier.MinValue || valueToCompare > tier.MaxValue {
+			continue
+		}
+		if matched == nil || tier.MinValue > matched.MinValue {
+			matched = &tiers[i]
+		}
+	}
+	return matched
+}
+
+// FindOverlappingTier procura, entre os tiers do mesmo sum_type, um cuja faixa (com os limites inclusos)
+// tenha algum valor em comum com a do tier informado. O próprio tier, pelo ID, é ignorado.
+func FindOverlappingTier(tier schemas.LeadTier, tiers []schemas.LeadTier) (schemas.LeadTier, bool) {
+	for _, other := range tiers {
+		if other.ID == tier.ID || other.SumType != tier.SumType {
+			continue
 		}
-		if valueToCompare >= tier.MinValue && valueToCompare <= tier.MaxValue {
-			return tier, nil
+		if tier.MinValue <= other.MaxValue && other.MinValue <= tier.MaxValue {
+			return other, true
 		}
 	}
-	return nil, fmt.Errorf("no matching tier found for the calculated values")
+	return schemas.LeadTier{}, false
 }
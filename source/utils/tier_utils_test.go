@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"api/schemas"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestFindOverlappingTier(t *testing.T) {
+	bronze := schemas.LeadTier{ID: bson.NewObjectID(), Label: "Bronze", SumType: TIER_SUM_TOTAL, MinValue: 0, MaxValue: 999.99}
+	prata := schemas.LeadTier{ID: bson.NewObjectID(), Label: "Prata", SumType: TIER_SUM_TOTAL, MinValue: 1000, MaxValue: 4999.99}
+	grande := schemas.LeadTier{ID: bson.NewObjectID(), Label: "Pedido grande", SumType: TIER_SUM_INDIVIDUAL, MinValue: 0, MaxValue: 10000}
+	tiers := []schemas.LeadTier{bronze, prata, grande}
+
+	tests := []struct {
+		name string
+		tier schemas.LeadTier
+		want string
+	}{
+		{
+			name: "faixa livre acima das demais",
+			tier: schemas.LeadTier{SumType: TIER_SUM_TOTAL, MinValue: 5000, MaxValue: 9999.99},
+		},
+		{
+			name: "faixa dentro de outra",
+			tier: schemas.LeadTier{SumType: TIER_SUM_TOTAL, MinValue: 2000, MaxValue: 3000},
+			want: "Prata",
+		},
+		{
+			name: "limite igual ao de outra",
+			tier: schemas.LeadTier{SumType: TIER_SUM_TOTAL, MinValue: 4999.99, MaxValue: 8000},
+			want: "Prata",
+		},
+		{
+			name: "faixa que cobre outra",
+			tier: schemas.LeadTier{SumType: TIER_SUM_TOTAL, MinValue: 500, MaxValue: 20000},
+			want: "Bronze",
+		},
+		{
+			name: "outro sum_type",
+			tier: schemas.LeadTier{SumType: TIER_SUM_INDIVIDUAL, MinValue: 10000.01, MaxValue: 50000},
+		},
+		{
+			name: "mesma faixa de um tier de outro sum_type",
+			tier: schemas.LeadTier{SumType: TIER_SUM_TOTAL, MinValue: 5000, MaxValue: 10000},
+		},
+		{
+			name: "o próprio tier alterado",
+			tier: schemas.LeadTier{ID: prata.ID, SumType: TIER_SUM_TOTAL, MinValue: 1000, MaxValue: 6000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other, ok := FindOverlappingTier(tt.tier, tiers)
+			if ok != (tt.want != "") || other.Label != tt.want {
+				t.Fatalf("FindOverlappingTier() = %q, %v, esperado %q", other.Label, ok, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package utils
+
+import (
+	"api/schemas"
+	"slices"
+)
+
+// HasAnyRole indica se o usuário tem ao menos um dos perfis informados.
+func HasAnyRole(user schemas.User, roles []string) bool {
+	return slices.ContainsFunc(user.Role, func(role string) bool { return slices.Contains(roles, role) })
+}
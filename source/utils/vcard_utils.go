@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type VCardPhone struct {
+	Number string
+	Type   string
+	WaID   string
+}
+
+type VCard struct {
+	FormattedName string
+	FirstName     string
+	LastName      string
+	Phones        []VCardPhone
+	Emails        []string
+	Org           string
+	Note          string
+}
+
+func escapeVCardValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "\n", `\n`, ",", `\,`, ";", `\;`)
+	return replacer.Replace(value)
+}
+
+func unescapeVCardValue(value string) string {
+	replacer := strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";")
+	return replacer.Replace(value)
+}
+
+// WriteVCard escreve o cartão no formato vCard 3.0.
+func WriteVCard(w io.Writer, card VCard) error {
+	lines := []string{"BEGIN:VCARD", "VERSION:3.0"}
+
+	formattedName := card.FormattedName
+	if formattedName == "" {
+		formattedName = strings.TrimSpace(card.FirstName + " " + card.LastName)
+	}
+	lines = append(lines, "FN:"+escapeVCardValue(formattedName))
+	lines = append(lines, fmt.Sprintf("N:%s;%s;;;", escapeVCardValue(card.LastName), escapeVCardValue(card.FirstName)))
+
+	for _, phone := range card.Phones {
+		phoneType := phone.Type
+		if phoneType == "" {
+			phoneType = "CELL"
+		}
+		params := "TYPE=" + strings.ToUpper(phoneType)
+		if phone.WaID != "" {
+			params += ";waid=" + phone.WaID
+		}
+		lines = append(lines, fmt.Sprintf("TEL;%s:%s", params, phone.Number))
+	}
+	for _, email := range card.Emails {
+		lines = append(lines, "EMAIL;TYPE=INTERNET:"+escapeVCardValue(email))
+	}
+	if card.Org != "" {
+		lines = append(lines, "ORG:"+escapeVCardValue(card.Org))
+	}
+	if card.Note != "" {
+		lines = append(lines, "NOTE:"+escapeVCardValue(card.Note))
+	}
+	lines = append(lines, "END:VCARD")
+
+	_, err := io.WriteString(w, strings.Join(lines, "\r\n")+"\r\n")
+	return err
+}
+
+// ParseVCards lê um ou mais cartões de um arquivo .vcf (versões 2.1, 3.0 e 4.0).
+func ParseVCards(r io.Reader) ([]VCard, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	unfolded := []string{}
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(unfolded) > 0 {
+			unfolded[len(unfolded)-1] += line[1:]
+			continue
+		}
+		unfolded = append(unfolded, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	cards := []VCard{}
+	var current *VCard
+	for i, line := range unfolded {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		upper := strings.ToUpper(line)
+		if upper == "BEGIN:VCARD" {
+			current = &VCard{}
+			continue
+		}
+		if upper == "END:VCARD" {
+			if current != nil {
+				cards = append(cards, *current)
+			}
+			current = nil
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("linha %d fora de um bloco BEGIN:VCARD", i+1)
+		}
+
+		sep := strings.Index(line, ":")
+		if sep < 0 {
+			continue
+		}
+		nameAndParams := strings.Split(line[:sep], ";")
+		value := line[sep+1:]
+		name := strings.ToUpper(nameAndParams[0])
+		if dot := strings.LastIndex(name, "."); dot >= 0 {
+			name = name[dot+1:]
+		}
+
+		params := map[string]string{}
+		for _, param := range nameAndParams[1:] {
+			key, val, found := strings.Cut(param, "=")
+			if !found {
+				params["TYPE"] = strings.ToUpper(key)
+				continue
+			}
+			params[strings.ToUpper(key)] = val
+		}
+
+		switch name {
+		case "FN":
+			current.FormattedName = unescapeVCardValue(value)
+		case "N":
+			parts := strings.Split(value, ";")
+			current.LastName = unescapeVCardValue(parts[0])
+			if len(parts) > 1 {
+				current.FirstName = unescapeVCardValue(parts[1])
+			}
+		case "TEL":
+			current.Phones = append(current.Phones, VCardPhone{
+				Number: strings.TrimPrefix(value, "tel:"),
+				Type:   strings.ToUpper(params["TYPE"]),
+				WaID:   params["WAID"],
+			})
+		case "EMAIL":
+			current.Emails = append(current.Emails, unescapeVCardValue(value))
+		case "ORG":
+			current.Org = unescapeVCardValue(strings.TrimRight(value, ";"))
+		case "NOTE":
+			current.Note = unescapeVCardValue(value)
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("arquivo vCard sem END:VCARD")
+	}
+
+	return cards, nil
+}